@@ -67,3 +67,11 @@ openssl verify -verbose -CAfile client_ca_cert.pem  client_cert.pem
 
 
 rm *_csr.pem
+
+# Generate the token signing keys (RS256, ES256 and EdDSA).
+openssl genpkey -algorithm RSA -pkeyopt rsa_keygen_bits:2048 -out jwt_rsa_key.pem
+openssl pkey -in jwt_rsa_key.pem -pubout -out jwt_rsa_pub.pem
+openssl genpkey -algorithm EC -pkeyopt ec_paramgen_curve:P-256 -out jwt_ec_key.pem
+openssl pkey -in jwt_ec_key.pem -pubout -out jwt_ec_pub.pem
+openssl genpkey -algorithm ed25519 -out jwt_ed25519_key.pem
+openssl pkey -in jwt_ed25519_key.pem -pubout -out jwt_ed25519_pub.pem
@@ -2,14 +2,17 @@ package main
 
 import (
 	"auth/pkg/config"
+	"auth/pkg/hashers"
 	"auth/pkg/jwt"
+	"auth/pkg/models"
+	"auth/pkg/notifiers"
+	"auth/pkg/secrets"
 	"auth/pkg/server"
 	"auth/pkg/services"
 	"auth/pkg/stores"
-	"auth/pkg/stores/pg"
-	"auth/pkg/stores/sqlite"
 	"auth/pkg/validators"
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/pflag"
@@ -17,10 +20,18 @@ import (
 	"go.uber.org/zap"
 	"log"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 var Version = "v0.1-dev"
 
+// shutdownTimeout is the time given to the pending work when the service stops.
+const shutdownTimeout = 10 * time.Second
+
 var (
 	configFile = pflag.StringP("config_file", "c", "config", "The name of the config file")
 	configPath = pflag.StringP("config_path", "p", "./config", "The path to the config file")
@@ -49,36 +60,131 @@ func main() {
 		logger.Fatal("error reading configuration", zap.Error(err))
 	}
 
-	var db *sql.DB
-	switch configuration.Database.Type {
-	case "sqlite":
-		db, err = sqlite.Open(configuration.Database.Path)
-	case "postgres":
-		db, err = pg.Open(configuration.Database)
-	default:
-		logger.Fatal("unknown database type")
+	// authService migrate up|down|status manages the schema instead of starting the service
+	if pflag.Arg(0) == "migrate" {
+		if err := migrate(configuration.Database, pflag.Args()[1:]); err != nil {
+			logger.Fatal("migration error", zap.Error(err))
+		}
+		return
+	}
+
+	if err := run(configuration); err != nil {
+		logger.Fatal("service error", zap.Error(err))
 	}
+}
+
+// run opens the stores and serves the realms until the service is stopped or fails.
+// The errors are returned rather than fatal, so the stores are closed and the memory database saves its snapshot.
+func run(configuration *config.AppSettings) error {
+	logger := zap.L()
 
+	// The pending migrations are applied, the service refuses an unknown or newer schema
+	dbStores, closer, err := stores.Open(configuration.Database)
 	if err != nil {
-		logger.Fatal("error opening database", zap.Error(err))
+		return fmt.Errorf("error opening database: %w", err)
 	}
-	defer db.Close()
+	defer func() {
+		if err := closer.Close(); err != nil {
+			logger.Error("error closing the database", zap.Error(err))
+		}
+	}()
 
-	// Set all the dependencies
-	userValidator := validators.NewUserValidator(validator.New(), validators.NewPasswordValidator(configuration.Password))
-	jwtGenerator := jwt.NewTokenGenerator(configuration.Token)
-	userStore := stores.NewPgUserStore(pg.New(db))
-	userService := services.NewUserService(userStore, userValidator, 10)
-	authService := services.NewJwtAuthService(userStore, jwtGenerator)
+	// Set all the dependencies, the stores, keys and services of each realm are separate
+	realmSettings, err := configuration.AllRealms()
+	if err != nil {
+		return fmt.Errorf("error reading the realms: %w", err)
+	}
+	passwordHasher, err := hashers.NewPasswordHasher(configuration.Hasher)
+	if err != nil {
+		return fmt.Errorf("error creating the password hasher: %w", err)
+	}
+	var cipher *secrets.Cipher
+	if configuration.MFA.EncryptionKey != "" {
+		cipher, err = secrets.NewCipherFromBase64(configuration.MFA.EncryptionKey)
+		if err != nil {
+			return fmt.Errorf("error creating the MFA cipher: %w", err)
+		}
+	}
+	notifier, err := notifiers.NewNotifier(configuration.Notifier)
+	if err != nil {
+		return fmt.Errorf("error creating the notifier: %w", err)
+	}
+	revocationStore := dbStores.RevocationStore()
 
-	srv, err := server.NewGrpcServer(configuration.TLSConfig, userService, authService)
+	realms := make(map[string]server.Realm, len(realmSettings))
+	defer shutdownRealms(realms)
+	keyRings := make(map[string]*jwt.KeyRing, len(realmSettings))
+	for _, realm := range realmSettings {
+		keyRing, err := jwt.NewKeyRing(realm.Token)
+		if err != nil {
+			return fmt.Errorf("error loading the token keys of the realm %s: %w", realm.Name, err)
+		}
+		keyRings[realm.Name] = keyRing
+		realms[realm.Name] = newRealm(configuration, realm, dbStores, keyRing, passwordHasher, revocationStore, cipher, notifier)
+	}
+
+	// The roles are shared by the realms, the admins are users of the default realm
+	roleService := realms[config.DefaultRealm].RoleService
+	roles := make([]models.Role, 0, len(configuration.Roles))
+	for _, r := range configuration.Roles {
+		roles = append(roles, models.Role{Name: r.Name, Permissions: r.Permissions})
+	}
+	if err := roleService.DefineRoles(context.Background(), roles); err != nil {
+		return fmt.Errorf("error defining the roles: %w", err)
+	}
+	for _, admin := range configuration.Authorization.Admins {
+		if err := roleService.AssignRole(context.Background(), admin, configuration.Authorization.AdminRole); err != nil {
+			return fmt.Errorf("error assigning the admin role to %s: %w", admin, err)
+		}
+	}
+
+	if configuration.Token.RevocationGCInterval > 0 {
+		go services.CollectRevokedTokens(
+			context.Background(),
+			revocationStore,
+			time.Minute*time.Duration(configuration.Token.RevocationGCInterval),
+			time.Second*time.Duration(configuration.Token.ClockSkew),
+		)
+	}
+
+	// Rotate the token keys without restarting the service
+	config.WatchConfiguration(func(c *config.AppSettings, err error) {
+		if err != nil {
+			logger.Error("error reloading configuration", zap.Error(err))
+			return
+		}
+		realmSettings, err := c.AllRealms()
+		if err != nil {
+			logger.Error("error reloading the realms", zap.Error(err))
+			return
+		}
+		for _, realm := range realmSettings {
+			keyRing, ok := keyRings[realm.Name]
+			if !ok {
+				logger.Warn("new realm ignored until restart", zap.String("realm", realm.Name))
+				continue
+			}
+			if err := keyRing.Load(realm.Token); err != nil {
+				logger.Error("error reloading the token keys", zap.String("realm", realm.Name), zap.Error(err))
+				continue
+			}
+			logger.Info("token keys reloaded", zap.String("realm", realm.Name), zap.String("ActiveKey", realm.Token.ActiveKey))
+		}
+	})
+
+	srv, err := server.NewGrpcServer(
+		configuration.TLSConfig,
+		realms,
+		server.DefaultPolicies(configuration.Authorization.AdminRole),
+	)
 
 	if err != nil {
-		logger.Fatal("error creating the grpc server", zap.Error(err))
+		return fmt.Errorf("error creating the grpc server: %w", err)
 	}
 
 	logger.Info(
 		"service started",
+		zap.Int("Realms", len(realms)),
 		zap.String("Network", configuration.Network),
 		zap.String("Address", configuration.Address),
 		zap.Int("Port", configuration.GRPCPort),
@@ -87,14 +193,221 @@ func main() {
 
 	lis, err := net.Listen(configuration.Network, fmt.Sprintf("%s:%v", configuration.Address, configuration.GRPCPort))
 	if err != nil {
-		logger.Fatal(
-			"could not start listener",
-			zap.String("address", configuration.Address),
-			zap.Int("port", configuration.GRPCPort),
-			zap.Error(err))
+		return fmt.Errorf("could not start listener on %s:%v: %w", configuration.Address, configuration.GRPCPort, err)
+	}
+
+	// A failure of the HTTP server stops the service like a signal
+	var httpServer *http.Server
+	var httpErr error
+	httpFailed := make(chan struct{})
+	if configuration.HTTPPort != 0 {
+		httpServer = newHTTPServer(configuration, server.NewHTTPHandler(realms))
+		go func() {
+			if err := serveHTTP(httpServer, configuration.TLSConfig); err != nil {
+				httpErr = err
+				close(httpFailed)
+			}
+		}()
+	}
+
+	// The stores are closed once the servers stop, the memory database saves its snapshot
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+		case <-httpFailed:
+		}
+		logger.Info("stopping the service")
+		srv.GracefulStop()
+	}()
+
+	err = srv.Serve(lis)
+	if httpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			logger.Error("error stopping the http server", zap.Error(err))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("grpc server error: %w", err)
+	}
+	select {
+	case <-httpFailed:
+		return fmt.Errorf("http server error: %w", httpErr)
+	default:
+	}
+
+	return nil
+}
+
+// shutdownRealms waits until the pending password reset tokens of the realms are sent, at most shutdownTimeout.
+func shutdownRealms(realms map[string]server.Realm) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for name, realm := range realms {
+		if err := realm.PasswordResetService.Shutdown(ctx); err != nil {
+			zap.L().Error("error sending the pending password reset tokens", zap.String("realm", name), zap.Error(err))
+		}
 	}
+}
+
+// newRealm creates the services of the realm, with its password policy and its tokens signed by the keyRing.
+// The stores of s only see the users of the realm.
+func newRealm(
+	configuration *config.AppSettings,
+	realm config.Realm,
+	s stores.Stores,
+	keyRing *jwt.KeyRing,
+	passwordHasher hashers.PasswordHasher,
+	revocationStore stores.RevocationStore,
+	cipher *secrets.Cipher,
+	notifier notifiers.Notifier,
+) server.Realm {
+	passwordValidator := validators.NewPasswordValidator(realm.Password)
+	userValidator := validators.NewUserValidator(validator.New(), passwordValidator)
+	jwtGenerator := jwt.NewTokenGenerator(realm.Token, keyRing)
+	jwtVerifier := jwt.NewTokenVerifier(realm.Token, keyRing)
+	userStore := s.UserStore(realm.Name)
+	refreshTokenStore := s.RefreshTokenStore(realm.Name)
+	passwordResetStore := s.PasswordResetStore(realm.Name)
+	roleStore := s.RoleStore(realm.Name)
+	apiKeyStore := s.APIKeyStore(realm.Name)
+	oauthClientStore := s.OAuthClientStore(realm.Name)
+	loginThrottler := services.NewLoginThrottler(s.LoginFailureStore(realm.Name), configuration.Throttle)
+	var mfaService services.MFAService
+	if cipher != nil {
+		mfaService = services.NewMFAService(
+			s.TOTPStore(realm.Name),
+			s.MFAChallengeStore(realm.Name),
+			cipher,
+			configuration.MFA,
+		)
+	}
+
+	authService := services.NewJwtAuthService(
+		userStore,
+		refreshTokenStore,
+		revocationStore,
+		jwtGenerator,
+		jwtVerifier,
+		keyRing,
+		passwordValidator,
+		passwordHasher,
+		loginThrottler,
+		mfaService,
+		s.RecoveryCodeStore(realm.Name),
+		roleStore,
+		apiKeyStore,
+		oauthClientStore,
+		time.Minute*time.Duration(realm.Token.RefreshExpDuration),
+	)
+
+	return server.Realm{
+		UserService: services.NewUserService(userStore, userValidator, passwordHasher),
+		AuthService: authService,
+		PasswordResetService: services.NewPasswordResetService(
+			userStore,
+			passwordResetStore,
+			refreshTokenStore,
+			services.NewPasswordResetThrottler(s.LoginFailureStore(realm.Name), configuration.Throttle),
+			notifier,
+			passwordValidator,
+			passwordHasher,
+			time.Minute*time.Duration(configuration.PasswordReset.ExpDuration),
+		),
+		RoleService:   services.NewRoleService(userStore, roleStore),
+		APIKeyService: services.NewAPIKeyService(apiKeyStore),
+		OAuthService:  services.NewOAuthService(oauthClientStore, authService, jwtGenerator, realm.Token),
+		OIDCService: services.NewOIDCService(
+			oauthClientStore,
+			s.AuthorizationCodeStore(realm.Name),
+			userStore,
+			authService,
+			jwtGenerator,
+			realm.Token,
+		),
+	}
+}
 
-	if err := srv.Serve(lis); err != nil {
-		logger.Fatal("grpc server error", zap.Error(err))
+// migrate applies the pending migrations with up, reverts the last migration with down,
+// or prints the version of the schema and the migrations with status.
+func migrate(configuration config.Database, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected migrate up|down|status")
+	}
+	migrator, closer, err := stores.OpenMigrator(configuration)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := closer.Close(); err != nil {
+			zap.L().Error("error closing the database", zap.Error(err))
+		}
+	}()
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range applied {
+			fmt.Printf("applied %d_%s\n", m.Version, m.Name)
+		}
+		fmt.Printf("%d migrations applied\n", len(applied))
+	case "down":
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Println("no migration to revert")
+			return nil
+		}
+		fmt.Printf("reverted %d_%s\n", reverted.Version, reverted.Name)
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("schema version %d, latest %d\n", status.Version, len(migrator.Migrations()))
+		for _, m := range status.Applied {
+			fmt.Printf("%4d %-30s applied %s\n", m.Version, m.Name, m.AppliedAt.Format(time.RFC3339))
+		}
+		for _, m := range status.Pending {
+			fmt.Printf("%4d %-30s pending\n", m.Version, m.Name)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+
+	return nil
+}
+
+// newHTTPServer creates the HTTP server of the handler on the HTTPPort.
+func newHTTPServer(configuration *config.AppSettings, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf("%s:%v", configuration.Address, configuration.HTTPPort),
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// serveHTTP serves the httpServer until it is shut down, it returns nil once the server is shut down.
+func serveHTTP(httpServer *http.Server, configuration config.TLS) error {
+	zap.L().Info("http server started", zap.String("Address", httpServer.Addr))
+
+	var err error
+	if configuration.UseTLS {
+		err = httpServer.ListenAndServeTLS(configuration.CertFile, configuration.KeyFile)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
 	}
+	return err
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"auth/pkg/config"
 	"auth/pkg/pb"
+	"auth/pkg/server"
 	"context"
 	ctls "crypto/tls"
 	"crypto/x509"
@@ -12,6 +13,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"log"
 	"os"
 	"time"
@@ -22,6 +24,26 @@ func main() {
 	tls := defaults.Bool("tls", false, "Connection uses TLS if true, else plain TCP")
 	username := defaults.StringP("username", "u", "", "the username")
 	password := defaults.StringP("password", "p", "", "the password")
+	email := defaults.StringP("email", "e", "", "the email")
+	token := defaults.StringP("token", "t", "", "the token")
+	refreshToken := defaults.StringP("refresh_token", "r", "", "the refresh token revoked on logout")
+	newUsername := defaults.String("new_username", "", "the new username of the updated user")
+	newPassword := defaults.String("new_password", "", "the new password")
+	prefix := defaults.String("prefix", "", "the username prefix of the listed users")
+	pageSize := defaults.Int32("page_size", 0, "the number of listed users")
+	pageToken := defaults.String("page_token", "", "the token of the page of listed users")
+	code := defaults.String("code", "", "the TOTP or recovery code")
+	role := defaults.String("role", "", "the role assigned or revoked")
+	realm := defaults.String("realm", "", "the realm of the users, the default realm if empty")
+	serviceAccount := defaults.String("service_account", "", "the service account of the API keys")
+	permissions := defaults.StringSlice("permissions", nil, "the comma-separated permissions of the created API key")
+	expiresAt := defaults.Int64("expires_at", 0, "the unix time the created API key expires at, never if 0")
+	apiKey := defaults.StringP("api_key", "k", "", "the API key exchanged for a token")
+	keyPrefix := defaults.String("key_prefix", "", "the prefix of the revoked API key")
+	clientID := defaults.String("client_id", "", "the id of the OAuth client")
+	scopes := defaults.StringSlice("scopes", nil, "the comma-separated scopes allowed to the created OAuth client")
+	audiences := defaults.StringSlice("audiences", nil, "the comma-separated audiences allowed to the created OAuth client")
+	redirectURIs := defaults.StringSlice("redirect_uris", nil, "the comma-separated OpenID Connect redirect URIs of the created OAuth client")
 
 	caFile := defaults.String("ca_file", "cert/ca_cert.pem", "The file containing the CA root cert file")
 	certFile := defaults.String("cert_file", "cert/client_cert.pem", "The file containing the client cert file")
@@ -31,7 +53,7 @@ func main() {
 	defaults.Parse(os.Args)
 
 	if len(os.Args) < 2 {
-		fmt.Println("subcommand expected: 'create' or 'auth'")
+		fmt.Println("subcommand expected: 'create', 'get', 'update', 'delete', 'list', 'auth', 'refresh', 'logout', 'password', 'forgot', 'reset', 'unlock', 'enroll', 'confirm', 'mfa', 'codes', 'count_codes', 'recover', 'assign', 'revoke', 'roles', 'create_key', 'list_keys', 'revoke_key', 'exchange', 'create_client', 'delete_client' or 'introspect'")
 		pflag.PrintDefaults()
 		os.Exit(1)
 	}
@@ -40,7 +62,48 @@ func main() {
 	switch os.Args[1] {
 	case "create":
 		cmd = func(ctx context.Context, client pb.AuthClient) error {
-			response, err := client.CreateUser(ctx, &pb.CreateUserRequest{Username: *username, Password: *password})
+			response, err := client.CreateUser(ctx, &pb.CreateUserRequest{Username: *username, Password: *password, Email: *email})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "get":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.GetUser(ctx, &pb.GetUserRequest{Username: *username})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "update":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.UpdateUser(ctx, &pb.UpdateUserRequest{
+				Username:    *username,
+				NewUsername: *newUsername,
+				Password:    *password,
+				Email:       *email,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "delete":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.DeleteUser(ctx, &pb.DeleteUserRequest{Username: *username})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "list":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.ListUsers(ctx, &pb.ListUsersRequest{Prefix: *prefix, PageSize: *pageSize, PageToken: *pageToken})
 			if err != nil {
 				return err
 			}
@@ -56,8 +119,206 @@ func main() {
 			fmt.Println(response)
 			return nil
 		}
+	case "refresh":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: *token})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "logout":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.Logout(ctx, &pb.LogoutRequest{Token: *token, RefreshToken: *refreshToken})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "password":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.ChangePassword(ctx, &pb.ChangePasswordRequest{Token: *token, CurrentPassword: *password, NewPassword: *newPassword})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "forgot":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.RequestPasswordReset(ctx, &pb.RequestPasswordResetRequest{Username: *username})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "reset":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.ResetPassword(ctx, &pb.ResetPasswordRequest{Token: *token, NewPassword: *newPassword})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "unlock":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.UnlockUser(ctx, &pb.UnlockUserRequest{Username: *username})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "enroll":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.EnrollTOTP(ctx, &pb.EnrollTOTPRequest{Token: *token})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "confirm":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.ConfirmTOTP(ctx, &pb.ConfirmTOTPRequest{Token: *token, Code: *code})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "mfa":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.VerifyMFA(ctx, &pb.VerifyMFARequest{MfaToken: *token, Code: *code})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "codes":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.GenerateRecoveryCodes(ctx, &pb.GenerateRecoveryCodesRequest{Token: *token})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "count_codes":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.CountRecoveryCodes(ctx, &pb.CountRecoveryCodesRequest{Token: *token})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "recover":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.AuthenticateWithRecoveryCode(ctx, &pb.AuthenticateWithRecoveryCodeRequest{Username: *username, Code: *code})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "assign":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.AssignRole(ctx, &pb.AssignRoleRequest{Username: *username, Role: *role})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "revoke":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.RevokeRole(ctx, &pb.RevokeRoleRequest{Username: *username, Role: *role})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "roles":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.ListRoles(ctx, &pb.ListRolesRequest{Username: *username})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "create_key":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.CreateAPIKey(ctx, &pb.CreateAPIKeyRequest{ServiceAccount: *serviceAccount, Permissions: *permissions, ExpiresAt: *expiresAt})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "list_keys":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.ListAPIKeys(ctx, &pb.ListAPIKeysRequest{ServiceAccount: *serviceAccount})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "revoke_key":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.RevokeAPIKey(ctx, &pb.RevokeAPIKeyRequest{Prefix: *keyPrefix})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "exchange":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.ExchangeAPIKey(ctx, &pb.ExchangeAPIKeyRequest{Key: *apiKey})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "create_client":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.CreateOAuthClient(ctx, &pb.CreateOAuthClientRequest{ClientId: *clientID, Scopes: *scopes, Audiences: *audiences, RedirectUris: *redirectURIs})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "delete_client":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.DeleteOAuthClient(ctx, &pb.DeleteOAuthClientRequest{ClientId: *clientID})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
+	case "introspect":
+		cmd = func(ctx context.Context, client pb.AuthClient) error {
+			response, err := client.Introspect(ctx, &pb.IntrospectRequest{Token: *token})
+			if err != nil {
+				return err
+			}
+			fmt.Println(response)
+			return nil
+		}
 	default:
-		fmt.Println("subcommand expected: 'create' or 'auth'")
+		fmt.Println("subcommand expected: 'create', 'get', 'update', 'delete', 'list', 'auth', 'refresh', 'logout', 'password', 'forgot', 'reset', 'unlock', 'enroll', 'confirm', 'mfa', 'codes', 'count_codes', 'recover', 'assign', 'revoke', 'roles', 'create_key', 'list_keys', 'revoke_key', 'exchange', 'create_client', 'delete_client' or 'introspect'")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
@@ -88,6 +349,13 @@ func main() {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	// The token also authorizes the admin commands.
+	if *token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+*token)
+	}
+	if *realm != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, server.RealmMetadataKey, *realm)
+	}
 	err = cmd(ctx, client)
 
 	if err != nil {
@@ -2,19 +2,29 @@ package config
 
 import (
 	"fmt"
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 	"strings"
 )
 
 // AppSettings represent the settings for the application.
 type AppSettings struct {
-	Network   string
-	Address   string
-	GRPCPort  int
-	TLSConfig TLS
-	Database  Database
-	Password  Password
-	Token     Token
+	Network       string
+	Address       string
+	GRPCPort      int
+	HTTPPort      int
+	TLSConfig     TLS
+	Database      Database
+	Password      Password
+	Hasher        Hasher
+	Token         Token
+	Throttle      Throttle
+	MFA           MFA
+	PasswordReset PasswordReset
+	Notifier      Notifier
+	Roles         []Role
+	Authorization Authorization
+	Realms        []Realm
 }
 
 // TLS settings
@@ -50,13 +60,163 @@ type Password struct {
 	MinSpecial   int
 }
 
+// Hasher settings
+// The passwords are hashed with Algorithm: bcrypt, argon2id or scrypt. The hashes of the other algorithms,
+// or of other parameters, remain valid and are replaced on the next successful authentication.
+type Hasher struct {
+	Algorithm string
+	Bcrypt    Bcrypt
+	Argon2id  Argon2id
+	Scrypt    Scrypt
+}
+
+// Bcrypt settings
+type Bcrypt struct {
+	Cost int
+}
+
+// Argon2id settings
+// Memory is in KiB, SaltLength and KeyLength in bytes.
+type Argon2id struct {
+	Memory      int
+	Iterations  int
+	Parallelism int
+	SaltLength  int
+	KeyLength   int
+}
+
+// Scrypt settings
+// N is the CPU/memory cost, a power of 2. SaltLength and KeyLength are in bytes.
+type Scrypt struct {
+	N          int
+	R          int
+	P          int
+	SaltLength int
+	KeyLength  int
+}
+
 // Token settings
+// The tokens are signed with the key ActiveKey of Keys, the other keys are only used to verify the tokens
+// until they are removed from the list. If Keys is empty, the single key SigningMethod, SignedKey, PrivateKeyFile
+// and PublicKeyFile is used.
 type Token struct {
-	SigningMethod string
-	SignedKey     string
-	Audience      string
-	Issuer        string
-	ExpDuration   int
+	SigningMethod        string
+	SignedKey            string
+	PrivateKeyFile       string
+	PublicKeyFile        string
+	Keys                 []TokenKey
+	ActiveKey            string
+	Audience             string
+	Issuer               string
+	ExpDuration          int
+	RefreshExpDuration   int
+	ClockSkew            int
+	RevocationGCInterval int
+}
+
+// PasswordReset settings
+// The password reset tokens expire after ExpDuration minutes.
+type PasswordReset struct {
+	ExpDuration int
+}
+
+// Notifier settings
+// Type is log, file or smtp. The notifications include the link ResetURL followed by the reset token if it is set.
+type Notifier struct {
+	Type     string
+	File     string
+	ResetURL string
+	SMTP     SMTP
+}
+
+// SMTP settings
+type SMTP struct {
+	Host     string
+	Port     int
+	UserName string
+	Password string
+	From     string
+}
+
+// Throttle settings
+// The failed logins are counted per username and per IP address during Window minutes after the last failure.
+// Each failure delays the next login BaseDelay seconds, doubled on each failure up to MaxDelay seconds.
+// After MaxFailures failures of a username, or IPMaxFailures failures from an address, the logins are locked
+// for Lockout minutes. A zero Window disables the throttling, a zero MaxFailures or IPMaxFailures the lockout.
+type Throttle struct {
+	Window        int
+	MaxFailures   int
+	IPMaxFailures int
+	Lockout       int
+	BaseDelay     int
+	MaxDelay      int
+}
+
+// MFA settings
+// The TOTP secrets are encrypted at rest with EncryptionKey, a base64 encoded 32 bytes key. An empty key disables
+// the multi-factor authentication. The authenticator apps display the accounts under the Issuer name.
+// The MFA tokens expire after ChallengeExpDuration minutes and accept at most MaxAttempts codes.
+// The codes of Skew time steps before or after the current one are accepted.
+type MFA struct {
+	Issuer               string
+	EncryptionKey        string
+	ChallengeExpDuration int
+	MaxAttempts          int
+	Skew                 int
+}
+
+// Role settings
+// The roles are created with their Permissions on startup, the permissions of an existing role are replaced.
+type Role struct {
+	Name        string
+	Permissions []string
+}
+
+// Authorization settings
+// The RPCs administering the users and the roles require the AdminRole, assigned on startup to the existing users
+// listed in Admins.
+type Authorization struct {
+	AdminRole string
+	Admins    []string
+}
+
+// DefaultRealm is the realm of the requests without realm metadata.
+const DefaultRealm = "default"
+
+// Realm settings
+// A realm is a tenant with its own users, password policy and tokens: the usernames are unique per realm and the
+// tokens of a realm carry its Name in the tenant claim. The DefaultRealm uses the top level Password and Token settings,
+// the Realms are additional ones.
+type Realm struct {
+	Name     string
+	Password Password
+	Token    Token
+}
+
+// TokenKey settings
+type TokenKey struct {
+	ID             string
+	SigningMethod  string
+	SignedKey      string
+	PrivateKeyFile string
+	PublicKeyFile  string
+}
+
+// AllRealms returns the DefaultRealm followed by the Realms, it returns an error if a realm has no name
+// or if two realms have the same name.
+func (c *AppSettings) AllRealms() ([]Realm, error) {
+	realms := append([]Realm{{Name: DefaultRealm, Password: c.Password, Token: c.Token}}, c.Realms...)
+	names := make(map[string]bool, len(realms))
+	for _, realm := range realms {
+		if realm.Name == "" {
+			return nil, fmt.Errorf("realm without name")
+		}
+		if names[realm.Name] {
+			return nil, fmt.Errorf("duplicate realm %s", realm.Name)
+		}
+		names[realm.Name] = true
+	}
+	return realms, nil
 }
 
 // LoadConfiguration parses a file (configName) Json or Yaml in the path configPath and returns an AppSettings struct.
@@ -78,3 +238,17 @@ func LoadConfiguration(configName, configPath string) (*AppSettings, error) {
 
 	return configuration, nil
 }
+
+// WatchConfiguration watches the config file read by LoadConfiguration and calls onChange with the new AppSettings
+// each time the file is modified.
+func WatchConfiguration(onChange func(*AppSettings, error)) {
+	viper.OnConfigChange(func(fsnotify.Event) {
+		configuration := &AppSettings{}
+		if err := viper.Unmarshal(configuration); err != nil {
+			onChange(configuration, fmt.Errorf("error parsing config file: %w", err))
+			return
+		}
+		onChange(configuration, nil)
+	})
+	viper.WatchConfig()
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"time"
 )
 
 type UsernameAlreadyExistErr struct {
@@ -18,6 +19,18 @@ func (e UsernameAlreadyExistErr) GRPCStatus() *status.Status {
 	return status.Newf(codes.AlreadyExists, "username %s already exists", e.Name)
 }
 
+type UserNotFoundErr struct {
+	Name string
+}
+
+func (e UserNotFoundErr) Error() string {
+	return fmt.Sprintf("user %s not found", e.Name)
+}
+
+func (e UserNotFoundErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.NotFound, "user %s not found", e.Name)
+}
+
 type AuthenticationFailErr string
 
 func (e AuthenticationFailErr) Error() string {
@@ -42,3 +55,383 @@ func (e ValidationErr) GRPCStatus() *status.Status {
 func NewValidationErr(err error) ValidationErr {
 	return ValidationErr{err: err}
 }
+
+type TokenExpiredErr struct {
+	ExpiredAt time.Time
+}
+
+func (e TokenExpiredErr) Error() string {
+	return fmt.Sprintf("token expired at %s", e.ExpiredAt.Format(time.RFC3339))
+}
+
+func (TokenExpiredErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "token expired")
+}
+
+type TokenSignatureErr struct {
+	err error
+}
+
+func (e TokenSignatureErr) Error() string {
+	return fmt.Sprintf("invalid token signature: %v", e.err)
+}
+
+func (e TokenSignatureErr) Unwrap() error {
+	return e.err
+}
+
+func (TokenSignatureErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "invalid token")
+}
+
+func NewTokenSignatureErr(err error) TokenSignatureErr {
+	return TokenSignatureErr{err: err}
+}
+
+type TokenAudienceErr struct {
+	Audience []string
+}
+
+func (e TokenAudienceErr) Error() string {
+	return fmt.Sprintf("token audience %v is not accepted", e.Audience)
+}
+
+func (TokenAudienceErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "invalid token")
+}
+
+type InvalidTokenErr struct {
+	err error
+}
+
+func (e InvalidTokenErr) Error() string {
+	return fmt.Sprintf("invalid token: %v", e.err)
+}
+
+func (e InvalidTokenErr) Unwrap() error {
+	return e.err
+}
+
+func (InvalidTokenErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "invalid token")
+}
+
+func NewInvalidTokenErr(err error) InvalidTokenErr {
+	return InvalidTokenErr{err: err}
+}
+
+type TokenRevokedErr struct {
+	ID string
+}
+
+func (e TokenRevokedErr) Error() string {
+	return fmt.Sprintf("token %s revoked", e.ID)
+}
+
+func (TokenRevokedErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "token revoked")
+}
+
+type InvalidRefreshTokenErr struct{}
+
+func (InvalidRefreshTokenErr) Error() string {
+	return "invalid refresh token"
+}
+
+func (InvalidRefreshTokenErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "invalid refresh token")
+}
+
+type InvalidPasswordResetTokenErr struct{}
+
+func (InvalidPasswordResetTokenErr) Error() string {
+	return "invalid password reset token"
+}
+
+func (InvalidPasswordResetTokenErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "invalid password reset token")
+}
+
+type LoginThrottledErr struct {
+	RetryAfter time.Duration
+}
+
+func (e LoginThrottledErr) Error() string {
+	return fmt.Sprintf("too many login attempts, retry in %s", e.retryAfter())
+}
+
+func (e LoginThrottledErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.ResourceExhausted, "too many login attempts, retry in %s", e.retryAfter())
+}
+
+func (e LoginThrottledErr) retryAfter() time.Duration {
+	return (e.RetryAfter + time.Second - 1).Truncate(time.Second)
+}
+
+type PasswordResetThrottledErr struct {
+	RetryAfter time.Duration
+}
+
+func (e PasswordResetThrottledErr) Error() string {
+	return fmt.Sprintf("too many password reset requests, retry in %s", e.retryAfter())
+}
+
+func (e PasswordResetThrottledErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.ResourceExhausted, "too many password reset requests, retry in %s", e.retryAfter())
+}
+
+func (e PasswordResetThrottledErr) retryAfter() time.Duration {
+	return (e.RetryAfter + time.Second - 1).Truncate(time.Second)
+}
+
+type AccountLockedErr struct {
+	Until time.Time
+}
+
+func (e AccountLockedErr) Error() string {
+	return fmt.Sprintf("account locked until %s", e.Until.Format(time.RFC3339))
+}
+
+func (e AccountLockedErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.PermissionDenied, "account temporarily locked until %s", e.Until.UTC().Format(time.RFC3339))
+}
+
+type InvalidMFATokenErr struct{}
+
+func (InvalidMFATokenErr) Error() string {
+	return "invalid MFA token"
+}
+
+func (InvalidMFATokenErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "invalid MFA token")
+}
+
+type InvalidMFACodeErr struct{}
+
+func (InvalidMFACodeErr) Error() string {
+	return "invalid MFA code"
+}
+
+func (InvalidMFACodeErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "invalid MFA code")
+}
+
+type TOTPAlreadyEnabledErr struct {
+	Name string
+}
+
+func (e TOTPAlreadyEnabledErr) Error() string {
+	return fmt.Sprintf("TOTP already enabled for user %s", e.Name)
+}
+
+func (TOTPAlreadyEnabledErr) GRPCStatus() *status.Status {
+	return status.New(codes.FailedPrecondition, "TOTP already enabled")
+}
+
+type TOTPNotEnrolledErr struct {
+	Name string
+}
+
+func (e TOTPNotEnrolledErr) Error() string {
+	return fmt.Sprintf("TOTP not enrolled for user %s", e.Name)
+}
+
+func (TOTPNotEnrolledErr) GRPCStatus() *status.Status {
+	return status.New(codes.FailedPrecondition, "TOTP not enrolled")
+}
+
+type MFADisabledErr struct{}
+
+func (MFADisabledErr) Error() string {
+	return "multi-factor authentication disabled"
+}
+
+func (MFADisabledErr) GRPCStatus() *status.Status {
+	return status.New(codes.FailedPrecondition, "multi-factor authentication disabled")
+}
+
+type PasswordChangeRequiredErr struct {
+	Name string
+}
+
+func (e PasswordChangeRequiredErr) Error() string {
+	return fmt.Sprintf("user %s must change the password", e.Name)
+}
+
+func (PasswordChangeRequiredErr) GRPCStatus() *status.Status {
+	return status.New(codes.PermissionDenied, "password change required")
+}
+
+type RoleNotFoundErr struct {
+	Name string
+}
+
+func (e RoleNotFoundErr) Error() string {
+	return fmt.Sprintf("role %s not found", e.Name)
+}
+
+func (e RoleNotFoundErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.NotFound, "role %s not found", e.Name)
+}
+
+type RoleNotAssignedErr struct {
+	Name string
+	Role string
+}
+
+func (e RoleNotAssignedErr) Error() string {
+	return fmt.Sprintf("role %s not assigned to user %s", e.Role, e.Name)
+}
+
+func (e RoleNotAssignedErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.NotFound, "role %s not assigned to user %s", e.Role, e.Name)
+}
+
+type MissingTokenErr struct{}
+
+func (MissingTokenErr) Error() string {
+	return "missing bearer token"
+}
+
+func (MissingTokenErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "missing bearer token")
+}
+
+type PermissionDeniedErr struct {
+	Name   string
+	Method string
+}
+
+func (e PermissionDeniedErr) Error() string {
+	return fmt.Sprintf("user %s is not allowed to call %s", e.Name, e.Method)
+}
+
+func (PermissionDeniedErr) GRPCStatus() *status.Status {
+	return status.New(codes.PermissionDenied, "permission denied")
+}
+
+type RealmNotFoundErr struct {
+	Name string
+}
+
+func (e RealmNotFoundErr) Error() string {
+	return fmt.Sprintf("realm %s not found", e.Name)
+}
+
+func (e RealmNotFoundErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.InvalidArgument, "realm %s not found", e.Name)
+}
+
+type InvalidAPIKeyErr struct{}
+
+func (InvalidAPIKeyErr) Error() string {
+	return "invalid API key"
+}
+
+func (InvalidAPIKeyErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "invalid API key")
+}
+
+type APIKeyNotFoundErr struct {
+	Prefix string
+}
+
+func (e APIKeyNotFoundErr) Error() string {
+	return fmt.Sprintf("API key %s not found", e.Prefix)
+}
+
+func (e APIKeyNotFoundErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.NotFound, "API key %s not found", e.Prefix)
+}
+
+type InvalidClientErr struct{}
+
+func (InvalidClientErr) Error() string {
+	return "invalid client"
+}
+
+func (InvalidClientErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "invalid client")
+}
+
+type InvalidScopeErr struct {
+	Scope string
+}
+
+func (e InvalidScopeErr) Error() string {
+	return fmt.Sprintf("scope %s not allowed", e.Scope)
+}
+
+func (e InvalidScopeErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.PermissionDenied, "scope %s not allowed", e.Scope)
+}
+
+type InvalidAudienceErr struct {
+	Audience string
+}
+
+func (e InvalidAudienceErr) Error() string {
+	return fmt.Sprintf("audience %s not allowed", e.Audience)
+}
+
+func (e InvalidAudienceErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.PermissionDenied, "audience %s not allowed", e.Audience)
+}
+
+type MFARequiredErr struct {
+	Name string
+}
+
+func (e MFARequiredErr) Error() string {
+	return fmt.Sprintf("user %s requires multi-factor authentication", e.Name)
+}
+
+func (MFARequiredErr) GRPCStatus() *status.Status {
+	return status.New(codes.FailedPrecondition, "multi-factor authentication required")
+}
+
+type OAuthClientAlreadyExistErr struct {
+	ID string
+}
+
+func (e OAuthClientAlreadyExistErr) Error() string {
+	return fmt.Sprintf("OAuth client %s already exists", e.ID)
+}
+
+func (e OAuthClientAlreadyExistErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.AlreadyExists, "OAuth client %s already exists", e.ID)
+}
+
+type OAuthClientNotFoundErr struct {
+	ID string
+}
+
+func (e OAuthClientNotFoundErr) Error() string {
+	return fmt.Sprintf("OAuth client %s not found", e.ID)
+}
+
+func (e OAuthClientNotFoundErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.NotFound, "OAuth client %s not found", e.ID)
+}
+
+type InvalidRedirectURIErr struct {
+	RedirectURI string
+}
+
+func (e InvalidRedirectURIErr) Error() string {
+	return fmt.Sprintf("redirect URI %s not registered", e.RedirectURI)
+}
+
+func (e InvalidRedirectURIErr) GRPCStatus() *status.Status {
+	return status.Newf(codes.InvalidArgument, "redirect URI %s not registered", e.RedirectURI)
+}
+
+type InvalidAuthorizationCodeErr struct{}
+
+func (InvalidAuthorizationCodeErr) Error() string {
+	return "invalid authorization code"
+}
+
+func (InvalidAuthorizationCodeErr) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, "invalid authorization code")
+}
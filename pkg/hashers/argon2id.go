@@ -0,0 +1,105 @@
+package hashers
+
+import (
+	"auth/pkg/config"
+	"crypto/subtle"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2idHasher is a PasswordHasher using argon2id. Its hashes are in the PHC format $argon2id$v=19$m=,t=,p=$salt$hash.
+type Argon2idHasher struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  int
+	keyLength   uint32
+}
+
+// NewArgon2idHasher creates a new instance of an Argon2idHasher with the config.Argon2id.
+// The parameters not set default to the OWASP recommendation: 19 MiB of memory, 2 iterations and 1 thread.
+func NewArgon2idHasher(configuration config.Argon2id) *Argon2idHasher {
+	h := &Argon2idHasher{
+		memory:      uint32(configuration.Memory),
+		iterations:  uint32(configuration.Iterations),
+		parallelism: uint8(configuration.Parallelism),
+		saltLength:  configuration.SaltLength,
+		keyLength:   uint32(configuration.KeyLength),
+	}
+	if h.memory == 0 {
+		h.memory = 19 * 1024
+	}
+	if h.iterations == 0 {
+		h.iterations = 2
+	}
+	if h.parallelism == 0 {
+		h.parallelism = 1
+	}
+	if h.saltLength == 0 {
+		h.saltLength = 16
+	}
+	if h.keyLength == 0 {
+		h.keyLength = 32
+	}
+	return h
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt, err := newSalt(h.saltLength)
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.iterations, h.memory, h.parallelism, h.keyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.memory, h.iterations, h.parallelism, b64.EncodeToString(salt), b64.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(hash, password string) (bool, error) {
+	p, err := parseArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+	key := argon2.IDKey([]byte(password), p.salt, p.iterations, p.memory, p.parallelism, uint32(len(p.key)))
+
+	return subtle.ConstantTimeCompare(key, p.key) == 1, nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	p, err := parseArgon2id(hash)
+	return err != nil ||
+		p.memory != h.memory ||
+		p.iterations != h.iterations ||
+		p.parallelism != h.parallelism ||
+		len(p.salt) != h.saltLength ||
+		len(p.key) != int(h.keyLength)
+}
+
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+// parseArgon2id returns the parameters of the argon2id PHC string.
+func parseArgon2id(hash string) (*argon2idParams, error) {
+	var version int
+	var saltAndKey string
+	p := &argon2idParams{}
+	_, err := fmt.Sscanf(hash, "$argon2id$v=%d$m=%d,t=%d,p=%d$%s", &version, &p.memory, &p.iterations, &p.parallelism, &saltAndKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid argon2id hash: %w", err)
+	}
+	if version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+	p.salt, p.key, err = decodeSaltAndKey(saltAndKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid argon2id hash: %w", err)
+	}
+	return p, nil
+}
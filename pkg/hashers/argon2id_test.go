@@ -0,0 +1,36 @@
+package hashers
+
+import (
+	"auth/pkg/config"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestArgon2idHasher_NeedsRehash(t *testing.T) {
+	h := NewArgon2idHasher(config.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1})
+	hash, err := h.Hash("password")
+	require.NoError(t, err)
+	require.False(t, h.NeedsRehash(hash))
+
+	tests := []struct {
+		name          string
+		configuration config.Argon2id
+	}{
+		{name: "memory", configuration: config.Argon2id{Memory: 128, Iterations: 1, Parallelism: 1}},
+		{name: "iterations", configuration: config.Argon2id{Memory: 64, Iterations: 2, Parallelism: 1}},
+		{name: "parallelism", configuration: config.Argon2id{Memory: 64, Iterations: 1, Parallelism: 2}},
+		{name: "salt length", configuration: config.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 32}},
+		{name: "key length", configuration: config.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, KeyLength: 64}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgraded := NewArgon2idHasher(tt.configuration)
+			require.True(t, upgraded.NeedsRehash(hash))
+
+			// The hash stays valid with the new parameters.
+			ok, err := upgraded.Verify(hash, "password")
+			require.NoError(t, err)
+			require.True(t, ok)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package hashers
+
+import (
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// defaultBcryptCost is the cost used until the hasher was configurable.
+const defaultBcryptCost = 10
+
+// BcryptHasher is a PasswordHasher using bcrypt. Its hashes are in the modular crypt format $2a$cost$saltandhash.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher creates a new instance of a BcryptHasher with the cost, 10 if it is not set.
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost == 0 {
+		cost = defaultBcryptCost
+	}
+	return &BcryptHasher{cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Verify(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.cost
+}
@@ -0,0 +1,21 @@
+package hashers
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBcryptHasher_NeedsRehash(t *testing.T) {
+	h := NewBcryptHasher(4)
+	hash, err := h.Hash("password")
+	require.NoError(t, err)
+	require.False(t, h.NeedsRehash(hash))
+
+	upgraded := NewBcryptHasher(5)
+	require.True(t, upgraded.NeedsRehash(hash))
+	ok, err := upgraded.Verify(hash, "password")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Equal(t, defaultBcryptCost, NewBcryptHasher(0).cost)
+}
@@ -0,0 +1,113 @@
+package hashers
+
+import (
+	"auth/pkg/config"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// PasswordHasher hashes the passwords into PHC strings ($id$params$salt$hash) and verifies them.
+type PasswordHasher interface {
+	//Hash returns the hash of the password.
+	Hash(password string) (string, error)
+	//Verify returns true if the password matches the hash, an error if the hash can't be verified.
+	Verify(hash, password string) (bool, error)
+	//NeedsRehash returns true if the hash was not produced with the algorithm and the parameters of the hasher.
+	NeedsRehash(hash string) bool
+}
+
+// b64 encodes the salts and the keys of the PHC strings.
+var b64 = base64.RawStdEncoding
+
+// hasher hashes the passwords with its current PasswordHasher and verifies the hashes of all the supported algorithms,
+// so the hashes of several algorithms can coexist until they are upgraded.
+type hasher struct {
+	current    PasswordHasher
+	algorithms map[string]PasswordHasher
+}
+
+// NewPasswordHasher creates a PasswordHasher hashing the passwords with the algorithm of the config.Hasher:
+// bcrypt (the default), argon2id or scrypt. It verifies the hashes of the three algorithms.
+func NewPasswordHasher(configuration config.Hasher) (PasswordHasher, error) {
+	bcryptHasher := NewBcryptHasher(configuration.Bcrypt.Cost)
+	argon2idHasher := NewArgon2idHasher(configuration.Argon2id)
+	scryptHasher := NewScryptHasher(configuration.Scrypt)
+
+	h := &hasher{
+		algorithms: map[string]PasswordHasher{
+			"2a":       bcryptHasher,
+			"2b":       bcryptHasher,
+			"2y":       bcryptHasher,
+			"argon2id": argon2idHasher,
+			"scrypt":   scryptHasher,
+		},
+	}
+	switch strings.ToLower(configuration.Algorithm) {
+	case "", "bcrypt":
+		h.current = bcryptHasher
+	case "argon2id":
+		h.current = argon2idHasher
+	case "scrypt":
+		h.current = scryptHasher
+	default:
+		return nil, fmt.Errorf("unsupported password hashing algorithm %q", configuration.Algorithm)
+	}
+
+	return h, nil
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+func (h *hasher) Verify(hash, password string) (bool, error) {
+	algorithm, ok := h.algorithms[identifier(hash)]
+	if !ok {
+		return false, fmt.Errorf("unsupported password hash algorithm %q", identifier(hash))
+	}
+	return algorithm.Verify(hash, password)
+}
+
+func (h *hasher) NeedsRehash(hash string) bool {
+	return h.current.NeedsRehash(hash)
+}
+
+// identifier returns the id of the algorithm of the PHC string.
+func identifier(hash string) string {
+	parts := strings.SplitN(hash, "$", 3)
+	if len(parts) < 3 || parts[0] != "" {
+		return ""
+	}
+	return parts[1]
+}
+
+// newSalt returns a random salt of length bytes.
+func newSalt(length int) ([]byte, error) {
+	salt := make([]byte, length)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("error generating the salt: %w", err)
+	}
+	return salt, nil
+}
+
+// decodeSaltAndKey decodes the last two fields salt$key of a PHC string.
+func decodeSaltAndKey(saltAndKey string) ([]byte, []byte, error) {
+	salt, key, found := strings.Cut(saltAndKey, "$")
+	if !found {
+		return nil, nil, fmt.Errorf("missing hash")
+	}
+	decodedSalt, err := b64.DecodeString(salt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	decodedKey, err := b64.DecodeString(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid hash: %w", err)
+	}
+	if len(decodedKey) == 0 {
+		return nil, nil, fmt.Errorf("missing hash")
+	}
+	return decodedSalt, decodedKey, nil
+}
@@ -0,0 +1,93 @@
+package hashers
+
+import (
+	"auth/pkg/config"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// testHasherConfig returns a config.Hasher with cheap parameters to keep the tests fast.
+func testHasherConfig(algorithm string) config.Hasher {
+	return config.Hasher{
+		Algorithm: algorithm,
+		Bcrypt:    config.Bcrypt{Cost: 4},
+		Argon2id:  config.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1},
+		Scrypt:    config.Scrypt{N: 16, R: 1, P: 1},
+	}
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		prefix    string
+		wantErr   bool
+	}{
+		{algorithm: "", prefix: "$2a$04$"},
+		{algorithm: "bcrypt", prefix: "$2a$04$"},
+		{algorithm: "argon2id", prefix: "$argon2id$v=19$m=64,t=1,p=1$"},
+		{algorithm: "scrypt", prefix: "$scrypt$ln=4,r=1,p=1$"},
+		{algorithm: "md5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			h, err := NewPasswordHasher(testHasherConfig(tt.algorithm))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			hash, err := h.Hash("password")
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(hash, tt.prefix), hash)
+			require.False(t, h.NeedsRehash(hash))
+
+			ok, err := h.Verify(hash, "password")
+			require.NoError(t, err)
+			require.True(t, ok)
+			ok, err = h.Verify(hash, "wrong")
+			require.NoError(t, err)
+			require.False(t, ok)
+		})
+	}
+}
+
+func TestPasswordHasher_coexisting_algorithms(t *testing.T) {
+	hashes := make(map[string]string)
+	for _, algorithm := range []string{"bcrypt", "argon2id", "scrypt"} {
+		h, err := NewPasswordHasher(testHasherConfig(algorithm))
+		require.NoError(t, err)
+		hashes[algorithm], err = h.Hash("password")
+		require.NoError(t, err)
+	}
+
+	h, err := NewPasswordHasher(testHasherConfig("argon2id"))
+	require.NoError(t, err)
+	for algorithm, hash := range hashes {
+		ok, err := h.Verify(hash, "password")
+		require.NoError(t, err, algorithm)
+		require.True(t, ok, algorithm)
+		require.Equal(t, algorithm != "argon2id", h.NeedsRehash(hash), algorithm)
+	}
+}
+
+func TestPasswordHasher_Verify_invalid_hash(t *testing.T) {
+	h, err := NewPasswordHasher(testHasherConfig("argon2id"))
+	require.NoError(t, err)
+
+	for _, hash := range []string{
+		"",
+		"plaintext",
+		"$md5$salt$hash",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$aGFzaA",
+		"$scrypt$ln=4,r=1,p=1$c2FsdA$!!!",
+		"$scrypt$ln=64,r=1,p=1$c2FsdA$aGFzaA",
+	} {
+		ok, err := h.Verify(hash, "password")
+		require.Error(t, err, hash)
+		require.False(t, ok, hash)
+		require.True(t, h.NeedsRehash(hash), hash)
+	}
+}
@@ -0,0 +1,110 @@
+package hashers
+
+import (
+	"auth/pkg/config"
+	"crypto/subtle"
+	"fmt"
+	"golang.org/x/crypto/scrypt"
+	"math/bits"
+)
+
+// ScryptHasher is a PasswordHasher using scrypt. Its hashes are in the PHC format $scrypt$ln=,r=,p=$salt$hash
+// where ln is the log2 of the cost N.
+type ScryptHasher struct {
+	logN       int
+	r          int
+	p          int
+	saltLength int
+	keyLength  int
+}
+
+// NewScryptHasher creates a new instance of a ScryptHasher with the config.Scrypt. N is rounded down to a power of 2.
+// The parameters not set default to N=32768, r=8 and p=1.
+func NewScryptHasher(configuration config.Scrypt) *ScryptHasher {
+	h := &ScryptHasher{
+		r:          configuration.R,
+		p:          configuration.P,
+		saltLength: configuration.SaltLength,
+		keyLength:  configuration.KeyLength,
+	}
+	if configuration.N > 1 {
+		h.logN = bits.Len(uint(configuration.N)) - 1
+	} else {
+		h.logN = 15
+	}
+	if h.r == 0 {
+		h.r = 8
+	}
+	if h.p == 0 {
+		h.p = 1
+	}
+	if h.saltLength == 0 {
+		h.saltLength = 16
+	}
+	if h.keyLength == 0 {
+		h.keyLength = 32
+	}
+	return h
+}
+
+func (h *ScryptHasher) Hash(password string) (string, error) {
+	salt, err := newSalt(h.saltLength)
+	if err != nil {
+		return "", err
+	}
+	key, err := scrypt.Key([]byte(password), salt, 1<<h.logN, h.r, h.p, h.keyLength)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", h.logN, h.r, h.p, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+func (h *ScryptHasher) Verify(hash, password string) (bool, error) {
+	p, err := parseScrypt(hash)
+	if err != nil {
+		return false, err
+	}
+	key, err := scrypt.Key([]byte(password), p.salt, 1<<p.logN, p.r, p.p, len(p.key))
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(key, p.key) == 1, nil
+}
+
+func (h *ScryptHasher) NeedsRehash(hash string) bool {
+	p, err := parseScrypt(hash)
+	return err != nil ||
+		p.logN != h.logN ||
+		p.r != h.r ||
+		p.p != h.p ||
+		len(p.salt) != h.saltLength ||
+		len(p.key) != h.keyLength
+}
+
+type scryptParams struct {
+	logN int
+	r    int
+	p    int
+	salt []byte
+	key  []byte
+}
+
+// parseScrypt returns the parameters of the scrypt PHC string.
+func parseScrypt(hash string) (*scryptParams, error) {
+	var saltAndKey string
+	p := &scryptParams{}
+	_, err := fmt.Sscanf(hash, "$scrypt$ln=%d,r=%d,p=%d$%s", &p.logN, &p.r, &p.p, &saltAndKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scrypt hash: %w", err)
+	}
+	if p.logN < 1 || p.logN > 31 {
+		return nil, fmt.Errorf("invalid scrypt hash: ln %d out of range", p.logN)
+	}
+	p.salt, p.key, err = decodeSaltAndKey(saltAndKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scrypt hash: %w", err)
+	}
+	return p, nil
+}
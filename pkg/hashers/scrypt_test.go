@@ -0,0 +1,33 @@
+package hashers
+
+import (
+	"auth/pkg/config"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestScryptHasher_NeedsRehash(t *testing.T) {
+	h := NewScryptHasher(config.Scrypt{N: 16, R: 1, P: 1})
+	hash, err := h.Hash("password")
+	require.NoError(t, err)
+	require.False(t, h.NeedsRehash(hash))
+
+	// N is rounded down to a power of 2.
+	require.False(t, NewScryptHasher(config.Scrypt{N: 31, R: 1, P: 1}).NeedsRehash(hash))
+
+	upgraded := NewScryptHasher(config.Scrypt{N: 32, R: 1, P: 1})
+	require.True(t, upgraded.NeedsRehash(hash))
+	ok, err := upgraded.Verify(hash, "password")
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestScryptHasher_Verify_reference(t *testing.T) {
+	// Hash of Python's hashlib.scrypt(b"password", salt=b"somesalt", n=16, r=1, p=1, dklen=32)
+	hash := "$scrypt$ln=4,r=1,p=1$c29tZXNhbHQ$ghju5/6aLTbg6EZMQFqXLPgpRBsc0EZNBB55e4QOkyo"
+	h := NewScryptHasher(config.Scrypt{})
+
+	ok, err := h.Verify(hash, "password")
+	require.NoError(t, err)
+	require.True(t, ok)
+}
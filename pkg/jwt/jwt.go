@@ -3,36 +3,164 @@ package jwt
 import (
 	"auth/pkg/config"
 	"auth/pkg/models"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
+	"sort"
+	"strings"
 	"time"
 )
 
 type TokenGenerator interface {
-	Generate(user models.User) (string, error)
+	Generate(user models.User, options ...Option) (string, error)
+}
+
+// Option sets additional claims of a generated token.
+type Option func(claims jwt.MapClaims)
+
+// WithPasswordChange marks the token as issued by an account recovery: the user must change the password,
+// which the token allows without the current password.
+func WithPasswordChange() Option {
+	return func(claims jwt.MapClaims) {
+		claims["pwd_chg"] = true
+	}
+}
+
+// WithRoles adds the names of the roles in the roles claim and the permissions of the roles
+// in the space-separated scope claim, so the services can authorize the user from the token.
+func WithRoles(roles []models.Role) Option {
+	return func(claims jwt.MapClaims) {
+		if len(roles) == 0 {
+			return
+		}
+		names := make([]string, 0, len(roles))
+		seen := make(map[string]bool)
+		var permissions []string
+		for _, role := range roles {
+			names = append(names, role.Name)
+			for _, permission := range role.Permissions {
+				if !seen[permission] {
+					seen[permission] = true
+					permissions = append(permissions, permission)
+				}
+			}
+		}
+		sort.Strings(permissions)
+		claims["roles"] = names
+		if len(permissions) > 0 {
+			claims["scope"] = strings.Join(permissions, " ")
+		}
+	}
+}
+
+// ServiceClientType is the client_type claim of the tokens issued to the service accounts.
+const ServiceClientType = "service"
+
+// WithAPIKey marks the token as issued to a service account for the API key with the prefix,
+// the permissions of the key are carried by the space-separated scope claim.
+func WithAPIKey(prefix string, permissions []string) Option {
+	return func(claims jwt.MapClaims) {
+		claims["client_type"] = ServiceClientType
+		claims["api_key"] = prefix
+		if len(permissions) > 0 {
+			claims["scope"] = strings.Join(permissions, " ")
+		}
+	}
+}
+
+// WithOAuthClient marks the token as issued to the OAuth client with the id by the client_credentials grant,
+// the granted scopes are carried by the space-separated scope claim.
+func WithOAuthClient(clientID string, scopes []string) Option {
+	return func(claims jwt.MapClaims) {
+		claims["client_type"] = ServiceClientType
+		claims["client_id"] = clientID
+		if len(scopes) > 0 {
+			claims["scope"] = strings.Join(scopes, " ")
+		}
+	}
+}
+
+// WithScopes adds the OpenID Connect scopes granted to the client to the space-separated scope claim,
+// after the permissions of the roles, so the userinfo endpoint only returns the claims of the granted scopes.
+func WithScopes(scopes []string) Option {
+	return func(claims jwt.MapClaims) {
+		if len(scopes) == 0 {
+			return
+		}
+		scope, _ := claims["scope"].(string)
+		claims["scope"] = strings.TrimSpace(scope + " " + strings.Join(scopes, " "))
+	}
+}
+
+// WithAudience adds the audience to the aud claim, the token stays valid for the audience of the generator.
+func WithAudience(audience string) Option {
+	return func(claims jwt.MapClaims) {
+		if aud, ok := claims["aud"].(string); ok && aud != audience {
+			claims["aud"] = []string{aud, audience}
+		}
+	}
+}
+
+// WithIDToken makes the token an OpenID Connect ID token of the client with the id: the client is its audience,
+// it carries the nonce of the authentication request, if set, and the time the user authenticated.
+func WithIDToken(clientID, nonce string, authTime time.Time) Option {
+	return func(claims jwt.MapClaims) {
+		claims["aud"] = clientID
+		claims["azp"] = clientID
+		claims["auth_time"] = authTime.Unix()
+		if nonce != "" {
+			claims["nonce"] = nonce
+		}
+	}
+}
+
+// WithUserInfo adds the claims of the user granted by the OpenID Connect scopes:
+// preferred_username with the profile scope, and email with the email scope if the user has one.
+func WithUserInfo(user models.User, scopes []string) Option {
+	return func(claims jwt.MapClaims) {
+		for _, scope := range scopes {
+			switch scope {
+			case "profile":
+				claims["preferred_username"] = user.Username
+			case "email":
+				if user.Email != "" {
+					claims["email"] = user.Email
+				}
+			}
+		}
+	}
 }
 
 type generator struct {
-	signingMethod jwt.SigningMethod
-	signedString  string
-	issuer        string
-	audience      string
-	expDuration   time.Duration
+	keys        *KeyRing
+	issuer      string
+	audience    string
+	expDuration time.Duration
 }
 
-func NewTokenGenerator(tokenConfig config.Token) TokenGenerator {
+// NewTokenGenerator creates a new instance of a TokenGenerator signing the tokens with the active key of the KeyRing.
+func NewTokenGenerator(tokenConfig config.Token, keys *KeyRing) TokenGenerator {
 	return &generator{
-		signingMethod: jwt.GetSigningMethod(tokenConfig.SigningMethod),
-		signedString:  tokenConfig.SignedKey,
-		expDuration:   time.Minute * time.Duration(tokenConfig.ExpDuration),
-		issuer:        tokenConfig.Issuer,
-		audience:      tokenConfig.Audience,
+		keys:        keys,
+		expDuration: time.Minute * time.Duration(tokenConfig.ExpDuration),
+		issuer:      tokenConfig.Issuer,
+		audience:    tokenConfig.Audience,
 	}
 }
 
-// Generate generates a token from the models.User
-func (g *generator) Generate(user models.User) (string, error) {
-	token := jwt.New(g.signingMethod)
+// Generate generates a token from the models.User, carrying its tenant, with the claims of the options
+func (g *generator) Generate(user models.User, options ...Option) (string, error) {
+	key, err := g.keys.SigningKey()
+	if err != nil {
+		return "", fmt.Errorf("error creating the token: %w", err)
+	}
+	jti, err := newTokenID()
+	if err != nil {
+		return "", fmt.Errorf("error creating the token: %w", err)
+	}
+	token := jwt.New(key.SigningMethod)
+	token.Header["kid"] = key.ID
 
 	claims := token.Claims.(jwt.MapClaims)
 
@@ -41,7 +169,15 @@ func (g *generator) Generate(user models.User) (string, error) {
 	claims["aud"] = g.audience
 	claims["exp"] = time.Now().Add(g.expDuration).Unix()
 	claims["iat"] = time.Now().Unix()
-	tokenString, err := token.SignedString([]byte(g.signedString))
+	claims["jti"] = jti
+	claims["gen"] = user.TokenGeneration
+	if user.Tenant != "" {
+		claims["tenant"] = user.Tenant
+	}
+	for _, option := range options {
+		option(claims)
+	}
+	tokenString, err := token.SignedString(key.signingKey)
 
 	if err != nil {
 		return "", fmt.Errorf("error creating the token: %w", err)
@@ -49,3 +185,12 @@ func (g *generator) Generate(user models.User) (string, error) {
 
 	return tokenString, nil
 }
+
+// newTokenID returns a random token id used as jti claim, so a token can be revoked before it expires.
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
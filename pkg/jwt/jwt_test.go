@@ -1,24 +1,250 @@
 package jwt
 
 import (
+	"auth/pkg/config"
 	"auth/pkg/models"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func Test_generator_Generate(t *testing.T) {
 
 	g := &generator{
-		signingMethod: jwt.SigningMethodHS256,
-		signedString:  "signedstring",
-		issuer:        "test",
-		audience:      "audience",
-		expDuration:   5,
+		keys:        newTestKeyRing(t, config.Token{SigningMethod: "HS256", SignedKey: "signedstring"}),
+		issuer:      "test",
+		audience:    "audience",
+		expDuration: 5,
 	}
-	user := models.User{Username: "test"}
+	user := models.User{Username: "test", TokenGeneration: 3}
 
 	token, err := g.Generate(user)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	require.Equal(t, "default", parsed.Header["kid"])
+	require.NotEmpty(t, parsed.Claims.(jwt.MapClaims)["jti"])
+	require.Equal(t, float64(3), parsed.Claims.(jwt.MapClaims)["gen"])
+	require.NotContains(t, parsed.Claims.(jwt.MapClaims), "pwd_chg")
+	require.NotContains(t, parsed.Claims.(jwt.MapClaims), "tenant")
+
+	other, err := g.Generate(user)
+	require.NoError(t, err)
+	otherParsed, _, err := jwt.NewParser().ParseUnverified(other, jwt.MapClaims{})
+	require.NoError(t, err)
+	require.NotEqual(t, parsed.Claims.(jwt.MapClaims)["jti"], otherParsed.Claims.(jwt.MapClaims)["jti"])
+}
+
+func Test_generator_Generate_password_change(t *testing.T) {
+	keys := newTestKeyRing(t, config.Token{SigningMethod: "HS256", SignedKey: "signedstring"})
+	g := &generator{keys: keys, issuer: "test", audience: "audience", expDuration: time.Minute}
+	v := &verifier{keys: keys, issuer: "test", audience: "audience", now: time.Now}
+
+	token, err := g.Generate(models.User{Username: "test"}, WithPasswordChange())
+	require.NoError(t, err)
+
+	claims, err := v.Verify(token)
+	require.NoError(t, err)
+	require.True(t, claims.PasswordChange)
+}
+
+func Test_generator_Generate_tenant(t *testing.T) {
+	keys := newTestKeyRing(t, config.Token{SigningMethod: "HS256", SignedKey: "signedstring"})
+	g := &generator{keys: keys, issuer: "test", audience: "audience", expDuration: time.Minute}
+	v := &verifier{keys: keys, issuer: "test", audience: "audience", now: time.Now}
+
+	token, err := g.Generate(models.User{Tenant: "acme", Username: "test"})
+	require.NoError(t, err)
+
+	claims, err := v.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, "acme", claims.Tenant)
+}
+
+func Test_generator_Generate_roles(t *testing.T) {
+	keys := newTestKeyRing(t, config.Token{SigningMethod: "HS256", SignedKey: "signedstring"})
+	g := &generator{keys: keys, issuer: "test", audience: "audience", expDuration: time.Minute}
+	v := &verifier{keys: keys, issuer: "test", audience: "audience", now: time.Now}
+
+	token, err := g.Generate(models.User{Username: "test"}, WithRoles([]models.Role{
+		{Name: "admin", Permissions: []string{"users:write", "users:read"}},
+		{Name: "viewer", Permissions: []string{"users:read"}},
+		{Name: "guest"},
+	}))
+	require.NoError(t, err)
+
+	claims, err := v.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, []string{"admin", "viewer", "guest"}, claims.Roles)
+	require.Equal(t, []string{"users:read", "users:write"}, claims.Scopes())
+
+	token, err = g.Generate(models.User{Username: "test"}, WithRoles(nil))
+	require.NoError(t, err)
+
+	claims, err = v.Verify(token)
+	require.NoError(t, err)
+	require.Empty(t, claims.Roles)
+	require.Empty(t, claims.Scope)
+}
+
+func Test_generator_Generate_scopes(t *testing.T) {
+	keys := newTestKeyRing(t, config.Token{SigningMethod: "HS256", SignedKey: "signedstring"})
+	g := &generator{keys: keys, issuer: "test", audience: "audience", expDuration: time.Minute}
+	v := &verifier{keys: keys, issuer: "test", audience: "audience", now: time.Now}
+
+	token, err := g.Generate(models.User{Username: "test"}, WithRoles([]models.Role{{Name: "viewer", Permissions: []string{"users:read"}}}), WithScopes([]string{"openid", "email"}))
+	require.NoError(t, err)
+
+	claims, err := v.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, []string{"users:read", "openid", "email"}, claims.Scopes())
+
+	token, err = g.Generate(models.User{Username: "test"}, WithRoles(nil), WithScopes([]string{"openid"}))
+	require.NoError(t, err)
+
+	claims, err = v.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, "openid", claims.Scope)
+}
+
+func Test_generator_Generate_api_key(t *testing.T) {
+	keys := newTestKeyRing(t, config.Token{SigningMethod: "HS256", SignedKey: "signedstring"})
+	g := &generator{keys: keys, issuer: "test", audience: "audience", expDuration: time.Minute}
+	v := &verifier{keys: keys, issuer: "test", audience: "audience", now: time.Now}
+
+	token, err := g.Generate(models.User{Username: "billing", Tenant: "acme"}, WithAPIKey("ak_1", []string{"invoices:write", "users:read"}))
+	require.NoError(t, err)
+
+	claims, err := v.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, "billing", claims.Subject)
+	require.Equal(t, "acme", claims.Tenant)
+	require.Equal(t, ServiceClientType, claims.ClientType)
+	require.Equal(t, "ak_1", claims.APIKey)
+	require.Equal(t, []string{"invoices:write", "users:read"}, claims.Scopes())
+	require.Empty(t, claims.Roles)
+}
+
+func Test_generator_Generate_oauth_client(t *testing.T) {
+	keys := newTestKeyRing(t, config.Token{SigningMethod: "HS256", SignedKey: "signedstring"})
+	g := &generator{keys: keys, issuer: "test", audience: "audience", expDuration: time.Minute}
+	v := &verifier{keys: keys, issuer: "test", audience: "audience", now: time.Now}
+
+	token, err := g.Generate(models.User{Username: "billing", Tenant: "acme"}, WithOAuthClient("billing", []string{"invoices:write"}), WithAudience("https://billing.example.com"))
+	require.NoError(t, err)
+
+	claims, err := v.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, "billing", claims.Subject)
+	require.Equal(t, ServiceClientType, claims.ClientType)
+	require.Equal(t, "billing", claims.ClientID)
+	require.Empty(t, claims.APIKey)
+	require.Equal(t, []string{"invoices:write"}, claims.Scopes())
+	require.Equal(t, jwt.ClaimStrings{"audience", "https://billing.example.com"}, claims.Audience)
+
+	token, err = g.Generate(models.User{Username: "billing"}, WithAudience("audience"))
+	require.NoError(t, err)
+	claims, err = v.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, jwt.ClaimStrings{"audience"}, claims.Audience)
+}
+
+func Test_generator_Generate_id_token(t *testing.T) {
+	keys := newTestKeyRing(t, config.Token{SigningMethod: "HS256", SignedKey: "signedstring"})
+	g := &generator{keys: keys, issuer: "test", audience: "audience", expDuration: time.Minute}
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	user := models.User{Username: "test", Email: "test@example.com"}
+
+	token, err := g.Generate(user, WithIDToken("web", "n0nce", authTime), WithUserInfo(user, []string{"openid", "profile", "email"}))
+	require.NoError(t, err)
+
+	// The ID tokens are not accepted as access tokens of the audience.
+	v := &verifier{keys: keys, issuer: "test", audience: "audience", now: time.Now}
+	_, err = v.Verify(token)
+	require.Error(t, err)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte("signedstring"), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "web", claims["aud"])
+	require.Equal(t, "web", claims["azp"])
+	require.Equal(t, "n0nce", claims["nonce"])
+	require.Equal(t, float64(authTime.Unix()), claims["auth_time"])
+	require.Equal(t, "test", claims["preferred_username"])
+	require.Equal(t, "test@example.com", claims["email"])
+
+	token, err = g.Generate(user, WithIDToken("web", "", authTime), WithUserInfo(user, []string{"openid"}))
+	require.NoError(t, err)
+	claims = jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte("signedstring"), nil
+	})
+	require.NoError(t, err)
+	require.NotContains(t, claims, "nonce")
+	require.NotContains(t, claims, "preferred_username")
+	require.NotContains(t, claims, "email")
+}
+
+func Test_generator_Generate_no_signing_key(t *testing.T) {
+	_, publicKeyFile := writeTestKeys(t, "ES256")
+	g := &generator{
+		keys:        newTestKeyRing(t, config.Token{SigningMethod: "ES256", PublicKeyFile: publicKeyFile}),
+		issuer:      "test",
+		audience:    "audience",
+		expDuration: 5,
+	}
+
+	token, err := g.Generate(models.User{Username: "test"})
+	require.Error(t, err)
+	require.Empty(t, token)
+}
+
+func Test_generator_Generate_signing_methods(t *testing.T) {
+	tests := []struct {
+		name          string
+		signingMethod string
+	}{
+		{"HMAC", "HS256"},
+		{"RSA", "RS256"},
+		{"RSA-PSS", "PS256"},
+		{"ECDSA", "ES256"},
+		{"EdDSA", "EdDSA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenConfig := config.Token{
+				SigningMethod: tt.signingMethod,
+				SignedKey:     "signedstring",
+				Issuer:        "test",
+				Audience:      "audience",
+				ExpDuration:   5,
+			}
+			var publicKeyFile string
+			if tt.signingMethod != "HS256" {
+				tokenConfig.PrivateKeyFile, publicKeyFile = writeTestKeys(t, tt.signingMethod)
+			}
+			g := NewTokenGenerator(tokenConfig, newTestKeyRing(t, tokenConfig))
+
+			token, err := g.Generate(models.User{Username: "test"})
+			require.NoError(t, err)
+
+			// A relying service only knows the public key.
+			if publicKeyFile != "" {
+				tokenConfig.SignedKey = ""
+				tokenConfig.PrivateKeyFile = ""
+				tokenConfig.PublicKeyFile = publicKeyFile
+			}
+			v := NewTokenVerifier(tokenConfig, newTestKeyRing(t, tokenConfig))
+
+			claims, err := v.Verify(token)
+			require.NoError(t, err)
+			require.Equal(t, "test", claims.Subject)
+			require.NotEmpty(t, claims.ID)
+		})
+	}
 }
@@ -0,0 +1,131 @@
+package jwt
+
+import (
+	"auth/pkg/config"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// defaultKeyID is the kid of the single key configured without config.Token Keys.
+const defaultKeyID = "default"
+
+type KeySet interface {
+	//JWKS returns the public keys of the set as a JSON Web Key Set.
+	JWKS() JSONWebKeySet
+}
+
+// KeyRing holds the keys used to sign and verify the tokens. The active key signs the new tokens
+// while all the keys of the ring verify them, so the keys can be rotated without invalidating the tokens
+// signed by the previous active key.
+type KeyRing struct {
+	mu     sync.RWMutex
+	active *Key
+	keys   map[string]*Key
+}
+
+// NewKeyRing creates a new instance of a KeyRing with the keys of the config.Token.
+func NewKeyRing(tokenConfig config.Token) (*KeyRing, error) {
+	r := &KeyRing{}
+	if err := r.Load(tokenConfig); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Load replaces the keys of the ring with the keys of the config.Token. On error, the ring keeps its previous keys.
+func (r *KeyRing) Load(tokenConfig config.Token) error {
+	keyConfigs := tokenConfig.Keys
+	activeKey := tokenConfig.ActiveKey
+	if len(keyConfigs) == 0 {
+		if activeKey == "" {
+			activeKey = defaultKeyID
+		}
+		keyConfigs = []config.TokenKey{{
+			ID:             activeKey,
+			SigningMethod:  tokenConfig.SigningMethod,
+			SignedKey:      tokenConfig.SignedKey,
+			PrivateKeyFile: tokenConfig.PrivateKeyFile,
+			PublicKeyFile:  tokenConfig.PublicKeyFile,
+		}}
+	}
+
+	keys := make(map[string]*Key, len(keyConfigs))
+	for _, keyConfig := range keyConfigs {
+		if keyConfig.ID == "" {
+			return fmt.Errorf("a key id is required for each token key")
+		}
+		if _, ok := keys[keyConfig.ID]; ok {
+			return fmt.Errorf("duplicate token key id %q", keyConfig.ID)
+		}
+		key, err := loadKey(keyConfig)
+		if err != nil {
+			return fmt.Errorf("error loading the token key %q: %w", keyConfig.ID, err)
+		}
+		keys[key.ID] = key
+	}
+
+	var active *Key
+	if activeKey != "" {
+		active = keys[activeKey]
+		if active == nil {
+			return fmt.Errorf("the active token key %q is not in the keys", activeKey)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = keys
+	r.active = active
+
+	return nil
+}
+
+// SigningKey returns the active key of the ring.
+func (r *KeyRing) SigningKey() (*Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.active == nil || !r.active.CanSign() {
+		return nil, fmt.Errorf("no active signing key")
+	}
+	return r.active, nil
+}
+
+// VerificationKey returns the key with the kid. If the kid is empty, for the tokens issued without kid,
+// it returns the active key or the only key of the ring.
+func (r *KeyRing) VerificationKey(kid string) (*Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if kid == "" {
+		if r.active != nil {
+			return r.active, nil
+		}
+		if len(r.keys) == 1 {
+			for _, key := range r.keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("missing kid")
+	}
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	return key, nil
+}
+
+// JWKS returns the public keys of the ring. The HMAC secrets are never published.
+func (r *KeyRing) JWKS() JSONWebKeySet {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(r.keys))}
+	for _, key := range r.keys {
+		if jwk, ok := key.jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+	return set
+}
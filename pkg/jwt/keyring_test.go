@@ -0,0 +1,119 @@
+package jwt
+
+import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func newTestKeyRing(t testing.TB, tokenConfig config.Token) *KeyRing {
+	keys, err := NewKeyRing(tokenConfig)
+	require.NoError(t, err)
+	return keys
+}
+
+func TestKeyRing_Load_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config config.Token
+	}{
+		{"Invalid single key", config.Token{SigningMethod: "HS256"}},
+		{"Missing key id", config.Token{Keys: []config.TokenKey{{SigningMethod: "HS256", SignedKey: "secret"}}}},
+		{"Duplicate key id", config.Token{Keys: []config.TokenKey{
+			{ID: "k1", SigningMethod: "HS256", SignedKey: "secret"},
+			{ID: "k1", SigningMethod: "HS256", SignedKey: "secret2"},
+		}}},
+		{"Unknown active key", config.Token{ActiveKey: "k2", Keys: []config.TokenKey{
+			{ID: "k1", SigningMethod: "HS256", SignedKey: "secret"},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyRing(tt.config)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestKeyRing_rotation(t *testing.T) {
+	k1Private, _ := writeTestKeys(t, "ES256")
+	k2Private, _ := writeTestKeys(t, "EdDSA")
+	k1 := config.TokenKey{ID: "k1", SigningMethod: "ES256", PrivateKeyFile: k1Private}
+	k2 := config.TokenKey{ID: "k2", SigningMethod: "EdDSA", PrivateKeyFile: k2Private}
+	tokenConfig := config.Token{Issuer: "test", Audience: "audience", ExpDuration: 5, ActiveKey: "k1", Keys: []config.TokenKey{k1}}
+
+	keys := newTestKeyRing(t, tokenConfig)
+	g := NewTokenGenerator(tokenConfig, keys)
+	v := NewTokenVerifier(tokenConfig, keys)
+	user := models.User{Username: "test"}
+
+	token1, err := g.Generate(user)
+	require.NoError(t, err)
+
+	// Introduce k2 as the new active key, the tokens signed by k1 are still valid.
+	tokenConfig.ActiveKey = "k2"
+	tokenConfig.Keys = []config.TokenKey{k1, k2}
+	require.NoError(t, keys.Load(tokenConfig))
+
+	token2, err := g.Generate(user)
+	require.NoError(t, err)
+	_, err = v.Verify(token1)
+	require.NoError(t, err)
+	_, err = v.Verify(token2)
+	require.NoError(t, err)
+
+	// An invalid configuration keeps the previous keys.
+	require.Error(t, keys.Load(config.Token{ActiveKey: "k3", Keys: []config.TokenKey{k1}}))
+	_, err = v.Verify(token2)
+	require.NoError(t, err)
+
+	// Retire k1.
+	tokenConfig.Keys = []config.TokenKey{k2}
+	require.NoError(t, keys.Load(tokenConfig))
+	_, err = v.Verify(token1)
+	require.ErrorAs(t, err, &autherrors.TokenSignatureErr{})
+	_, err = v.Verify(token2)
+	require.NoError(t, err)
+}
+
+func TestKeyRing_JWKS(t *testing.T) {
+	rsaPrivate, _ := writeTestKeys(t, "RS256")
+	ecPrivate, _ := writeTestKeys(t, "ES256")
+	_, edPublic := writeTestKeys(t, "EdDSA")
+
+	keys := newTestKeyRing(t, config.Token{ActiveKey: "rsa", Keys: []config.TokenKey{
+		{ID: "rsa", SigningMethod: "RS256", PrivateKeyFile: rsaPrivate},
+		{ID: "ec", SigningMethod: "ES256", PrivateKeyFile: ecPrivate},
+		{ID: "ed", SigningMethod: "EdDSA", PublicKeyFile: edPublic},
+		{ID: "hmac", SigningMethod: "HS256", SignedKey: "secret"},
+	}})
+
+	jwks := keys.JWKS()
+	require.Len(t, jwks.Keys, 3)
+
+	ec, ed, rs := jwks.Keys[0], jwks.Keys[1], jwks.Keys[2]
+	require.Equal(t, JSONWebKey{Kty: "EC", Kid: "ec", Use: "sig", Alg: "ES256", Crv: "P-256", X: ec.X, Y: ec.Y}, ec)
+	require.Len(t, ec.X, 43)
+	require.Len(t, ec.Y, 43)
+	x, err := base64.RawURLEncoding.DecodeString(ec.X)
+	require.NoError(t, err)
+	ecKey, _ := keys.VerificationKey("ec")
+	require.Equal(t, 0, new(big.Int).SetBytes(x).Cmp(ecKey.verifyKey.(*ecdsa.PublicKey).X))
+
+	require.Equal(t, "OKP", ed.Kty)
+	require.Equal(t, "Ed25519", ed.Crv)
+	require.Equal(t, "EdDSA", ed.Alg)
+
+	require.Equal(t, "RSA", rs.Kty)
+	require.Equal(t, "AQAB", rs.E)
+	n, err := base64.RawURLEncoding.DecodeString(rs.N)
+	require.NoError(t, err)
+	rsaKey, _ := keys.VerificationKey("rsa")
+	require.Equal(t, 0, new(big.Int).SetBytes(n).Cmp(rsaKey.verifyKey.(*rsa.PublicKey).N))
+}
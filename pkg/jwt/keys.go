@@ -0,0 +1,118 @@
+package jwt
+
+import (
+	"auth/pkg/config"
+	"crypto"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"os"
+)
+
+// Key is a key of the KeyRing identified by its kid.
+// A key without signing key, like a public key of an asymmetric method, can only verify tokens.
+type Key struct {
+	ID            string
+	SigningMethod jwt.SigningMethod
+	signingKey    any
+	verifyKey     any
+}
+
+// CanSign returns true if the key can sign tokens.
+func (k *Key) CanSign() bool {
+	return k.signingKey != nil
+}
+
+// signingMethod returns the jwt.SigningMethod matching the name, or an error if the method is not supported.
+func signingMethod(name string) (jwt.SigningMethod, error) {
+	method := jwt.GetSigningMethod(name)
+	if method == nil || method == jwt.SigningMethodNone {
+		return nil, fmt.Errorf("unsupported signing method %q", name)
+	}
+	return method, nil
+}
+
+// loadKey creates a Key from the config.TokenKey: the shared secret for HMAC methods,
+// the private key read from the PEM file PrivateKeyFile for RSA, ECDSA and EdDSA methods.
+// If no private key file is set, the key is verification only and its public key is read from PublicKeyFile.
+func loadKey(keyConfig config.TokenKey) (*Key, error) {
+	method, err := signingMethod(keyConfig.SigningMethod)
+	if err != nil {
+		return nil, err
+	}
+	key := &Key{ID: keyConfig.ID, SigningMethod: method}
+
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		if keyConfig.SignedKey == "" {
+			return nil, fmt.Errorf("a signed key is required for the signing method %s", method.Alg())
+		}
+		key.signingKey = []byte(keyConfig.SignedKey)
+		key.verifyKey = key.signingKey
+		return key, nil
+	}
+
+	switch {
+	case keyConfig.PrivateKeyFile != "":
+		key.signingKey, err = loadPrivateKey(method, keyConfig.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key.verifyKey = key.signingKey.(crypto.Signer).Public()
+	case keyConfig.PublicKeyFile != "":
+		key.verifyKey, err = loadPublicKey(method, keyConfig.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("a private or public key file is required for the signing method %s", method.Alg())
+	}
+
+	return key, nil
+}
+
+func loadPrivateKey(method jwt.SigningMethod, file string) (any, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the private key file: %w", err)
+	}
+
+	var key any
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		key, err = jwt.ParseRSAPrivateKeyFromPEM(b)
+	case *jwt.SigningMethodECDSA:
+		key, err = jwt.ParseECPrivateKeyFromPEM(b)
+	case *jwt.SigningMethodEd25519:
+		key, err = jwt.ParseEdPrivateKeyFromPEM(b)
+	default:
+		return nil, fmt.Errorf("unsupported signing method %q", method.Alg())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the private key file %s: %w", file, err)
+	}
+
+	return key, nil
+}
+
+func loadPublicKey(method jwt.SigningMethod, file string) (any, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the public key file: %w", err)
+	}
+
+	var key any
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		key, err = jwt.ParseRSAPublicKeyFromPEM(b)
+	case *jwt.SigningMethodECDSA:
+		key, err = jwt.ParseECPublicKeyFromPEM(b)
+	case *jwt.SigningMethodEd25519:
+		key, err = jwt.ParseEdPublicKeyFromPEM(b)
+	default:
+		return nil, fmt.Errorf("unsupported signing method %q", method.Alg())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the public key file %s: %w", file, err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,105 @@
+package jwt
+
+import (
+	"auth/pkg/config"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestKeys generates a key pair for the signing method and writes it as PEM files in a temporary directory.
+func writeTestKeys(t testing.TB, method string) (privateKeyFile, publicKeyFile string) {
+	var key crypto.Signer
+	var err error
+	switch method {
+	case "RS256", "PS256":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EdDSA":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("no test key for the signing method %s", method)
+	}
+	require.NoError(t, err)
+
+	privateBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	publicBytes, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privateKeyFile = filepath.Join(dir, "private.pem")
+	publicKeyFile = filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(privateKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateBytes}), 0600))
+	require.NoError(t, os.WriteFile(publicKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes}), 0644))
+
+	return privateKeyFile, publicKeyFile
+}
+
+func Test_signingMethod(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		wantErr bool
+	}{
+		{"HMAC", "HS256", false},
+		{"RSA", "RS256", false},
+		{"ECDSA", "ES256", false},
+		{"EdDSA", "EdDSA", false},
+		{"None", "none", true},
+		{"Unknown", "XX256", true},
+		{"Empty", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := signingMethod(tt.method)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("signingMethod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_loadKey(t *testing.T) {
+	rsaPrivate, rsaPublic := writeTestKeys(t, "RS256")
+	ecPrivate, _ := writeTestKeys(t, "ES256")
+
+	tests := []struct {
+		name        string
+		config      config.TokenKey
+		wantErr     bool
+		wantCanSign bool
+	}{
+		{"HMAC secret", config.TokenKey{SigningMethod: "HS256", SignedKey: "secret"}, false, true},
+		{"HMAC without secret", config.TokenKey{SigningMethod: "HS256"}, true, false},
+		{"RSA private key", config.TokenKey{SigningMethod: "RS256", PrivateKeyFile: rsaPrivate}, false, true},
+		{"RSA public key", config.TokenKey{SigningMethod: "RS256", PublicKeyFile: rsaPublic}, false, false},
+		{"RSA without key file", config.TokenKey{SigningMethod: "RS256", SignedKey: "secret"}, true, false},
+		{"RSA missing private key file", config.TokenKey{SigningMethod: "RS256", PrivateKeyFile: "missing.pem"}, true, false},
+		{"RSA method with an EC key", config.TokenKey{SigningMethod: "RS256", PrivateKeyFile: ecPrivate}, true, false},
+		{"ECDSA private key", config.TokenKey{SigningMethod: "ES256", PrivateKeyFile: ecPrivate}, false, true},
+		{"Unknown method", config.TokenKey{SigningMethod: "XX256", SignedKey: "secret"}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := loadKey(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loadKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				require.NotNil(t, key.verifyKey)
+				require.Equal(t, tt.wantCanSign, key.CanSign())
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package jwt
+
+import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"strings"
+	"time"
+)
+
+// Claims are the claims carried by the tokens issued by the TokenGenerator.
+type Claims struct {
+	jwt.RegisteredClaims
+	// Scope are the space-separated permissions of the roles of the user, or of the API key of a service account.
+	Scope string `json:"scope,omitempty"`
+	// Roles are the names of the roles of the user.
+	Roles []string `json:"roles,omitempty"`
+	// Tenant is the realm of the user.
+	Tenant string `json:"tenant,omitempty"`
+	// Generation is the token generation of the user when the token was issued.
+	Generation int64 `json:"gen,omitempty"`
+	// PasswordChange is set on the tokens issued by an account recovery, the user must change the password.
+	PasswordChange bool `json:"pwd_chg,omitempty"`
+	// ClientType is ServiceClientType on the tokens issued to a service account, whose name is the subject.
+	ClientType string `json:"client_type,omitempty"`
+	// APIKey is the prefix of the API key exchanged for the token of a service account.
+	APIKey string `json:"api_key,omitempty"`
+	// ClientID is the id of the OAuth client the token was issued to by the client_credentials grant.
+	ClientID string `json:"client_id,omitempty"`
+}
+
+// Scopes returns the space-separated scope claim as a slice.
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+type TokenVerifier interface {
+	//Verify checks the signature and the claims of a token and returns its claims.
+	Verify(token string) (*Claims, error)
+}
+
+type verifier struct {
+	keys      *KeyRing
+	issuer    string
+	audience  string
+	clockSkew time.Duration
+	now       func() time.Time
+}
+
+// NewTokenVerifier creates a new instance of a TokenVerifier accepting the tokens signed by a key of the KeyRing
+// for the issuer and audience of the config.Token. Asymmetric keys only need their public key.
+func NewTokenVerifier(tokenConfig config.Token, keys *KeyRing) TokenVerifier {
+	return &verifier{
+		keys:      keys,
+		issuer:    tokenConfig.Issuer,
+		audience:  tokenConfig.Audience,
+		clockSkew: time.Second * time.Duration(tokenConfig.ClockSkew),
+		now:       time.Now,
+	}
+}
+
+// Verify parses the token, checks its signature with the key matching its kid, checks the issuer and audience,
+// then checks exp, iat and nbf with the configured clock skew.
+func (v *verifier) Verify(token string) (*Claims, error) {
+	claims := &Claims{}
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	_, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := v.keys.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != key.SigningMethod.Alg() {
+			return nil, fmt.Errorf("signing method %s is invalid for the key %q", t.Method.Alg(), key.ID)
+		}
+		return key.verifyKey, nil
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenSignatureInvalid) || errors.Is(err, jwt.ErrTokenUnverifiable) {
+			return nil, autherrors.NewTokenSignatureErr(err)
+		}
+		return nil, autherrors.NewInvalidTokenErr(err)
+	}
+
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (v *verifier) validate(claims *Claims) error {
+	now := v.now()
+
+	if claims.Issuer != v.issuer {
+		return autherrors.NewInvalidTokenErr(fmt.Errorf("unexpected issuer %q", claims.Issuer))
+	}
+	if !claims.VerifyAudience(v.audience, true) {
+		return autherrors.TokenAudienceErr{Audience: claims.Audience}
+	}
+	if claims.ExpiresAt == nil {
+		return autherrors.NewInvalidTokenErr(fmt.Errorf("missing exp claim"))
+	}
+	if now.After(claims.ExpiresAt.Add(v.clockSkew)) {
+		return autherrors.TokenExpiredErr{ExpiredAt: claims.ExpiresAt.Time}
+	}
+	if claims.IssuedAt != nil && now.Add(v.clockSkew).Before(claims.IssuedAt.Time) {
+		return autherrors.NewInvalidTokenErr(fmt.Errorf("token used before issued"))
+	}
+	if claims.NotBefore != nil && now.Add(v.clockSkew).Before(claims.NotBefore.Time) {
+		return autherrors.NewInvalidTokenErr(fmt.Errorf("token is not valid yet"))
+	}
+
+	return nil
+}
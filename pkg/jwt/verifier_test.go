@@ -0,0 +1,139 @@
+package jwt
+
+import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestVerifier(t testing.TB, now time.Time) *verifier {
+	return &verifier{
+		keys:      newTestKeyRing(t, config.Token{SigningMethod: "HS256", SignedKey: "signedstring"}),
+		issuer:    "test",
+		audience:  "audience",
+		clockSkew: 30 * time.Second,
+		now:       func() time.Time { return now },
+	}
+}
+
+func signTestToken(t *testing.T, method jwt.SigningMethod, key any, claims jwt.RegisteredClaims, kid ...string) string {
+	token := jwt.NewWithClaims(method, claims)
+	if len(kid) > 0 {
+		token.Header["kid"] = kid[0]
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func Test_verifier_Verify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	validClaims := func() jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{
+			Subject:   "test",
+			Issuer:    "test",
+			Audience:  jwt.ClaimStrings{"audience"},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		token   func() string
+		wantErr any
+	}{
+		{"Valid token", func() string {
+			return signTestToken(t, jwt.SigningMethodHS256, []byte("signedstring"), validClaims())
+		}, nil},
+		{"Expired token", func() string {
+			c := validClaims()
+			c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+			return signTestToken(t, jwt.SigningMethodHS256, []byte("signedstring"), c)
+		}, &autherrors.TokenExpiredErr{}},
+		{"Expired token within clock skew", func() string {
+			c := validClaims()
+			c.ExpiresAt = jwt.NewNumericDate(now.Add(-10 * time.Second))
+			return signTestToken(t, jwt.SigningMethodHS256, []byte("signedstring"), c)
+		}, nil},
+		{"Missing exp", func() string {
+			c := validClaims()
+			c.ExpiresAt = nil
+			return signTestToken(t, jwt.SigningMethodHS256, []byte("signedstring"), c)
+		}, &autherrors.InvalidTokenErr{}},
+		{"Wrong audience", func() string {
+			c := validClaims()
+			c.Audience = jwt.ClaimStrings{"other"}
+			return signTestToken(t, jwt.SigningMethodHS256, []byte("signedstring"), c)
+		}, &autherrors.TokenAudienceErr{}},
+		{"Wrong issuer", func() string {
+			c := validClaims()
+			c.Issuer = "other"
+			return signTestToken(t, jwt.SigningMethodHS256, []byte("signedstring"), c)
+		}, &autherrors.InvalidTokenErr{}},
+		{"Bad signature", func() string {
+			return signTestToken(t, jwt.SigningMethodHS256, []byte("otherkey"), validClaims())
+		}, &autherrors.TokenSignatureErr{}},
+		{"Unexpected signing method", func() string {
+			return signTestToken(t, jwt.SigningMethodHS384, []byte("signedstring"), validClaims())
+		}, &autherrors.TokenSignatureErr{}},
+		{"Issued in the future", func() string {
+			c := validClaims()
+			c.IssuedAt = jwt.NewNumericDate(now.Add(time.Minute))
+			return signTestToken(t, jwt.SigningMethodHS256, []byte("signedstring"), c)
+		}, &autherrors.InvalidTokenErr{}},
+		{"Not valid yet", func() string {
+			c := validClaims()
+			c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute))
+			return signTestToken(t, jwt.SigningMethodHS256, []byte("signedstring"), c)
+		}, &autherrors.InvalidTokenErr{}},
+		{"Not valid yet within clock skew", func() string {
+			c := validClaims()
+			c.NotBefore = jwt.NewNumericDate(now.Add(10 * time.Second))
+			return signTestToken(t, jwt.SigningMethodHS256, []byte("signedstring"), c)
+		}, nil},
+		{"Known kid", func() string {
+			return signTestToken(t, jwt.SigningMethodHS256, []byte("signedstring"), validClaims(), "default")
+		}, nil},
+		{"Unknown kid", func() string {
+			return signTestToken(t, jwt.SigningMethodHS256, []byte("signedstring"), validClaims(), "unknown")
+		}, &autherrors.TokenSignatureErr{}},
+		{"Malformed token", func() string {
+			return "not.a.token"
+		}, &autherrors.InvalidTokenErr{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVerifier(t, now)
+			claims, err := v.Verify(tt.token())
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				require.Equal(t, "test", claims.Subject)
+				return
+			}
+			require.Error(t, err)
+			require.ErrorAs(t, err, tt.wantErr)
+			require.Nil(t, claims)
+		})
+	}
+}
+
+func Test_verifier_Verify_generated_token(t *testing.T) {
+	g := &generator{
+		keys:        newTestKeyRing(t, config.Token{SigningMethod: "HS256", SignedKey: "signedstring"}),
+		issuer:      "test",
+		audience:    "audience",
+		expDuration: time.Minute,
+	}
+	token, err := g.Generate(models.User{Username: "test"})
+	require.NoError(t, err)
+
+	claims, err := newTestVerifier(t, time.Now()).Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, "test", claims.Subject)
+	require.Equal(t, jwt.ClaimStrings{"audience"}, claims.Audience)
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// APIKey is a stored API key of a service account. Only the hash of the key is stored,
+// the key is identified by its Prefix, which is not secret.
+type APIKey struct {
+	Prefix string
+	Hash   string
+	// Tenant is the realm of the service account. It is set by the stores.
+	Tenant         string
+	ServiceAccount string
+	// Permissions are carried by the scope claim of the tokens exchanged for the key.
+	Permissions []string
+	// ExpiresAt is the zero time if the key doesn't expire.
+	ExpiresAt time.Time
+	Revoked   bool
+	CreatedAt time.Time
+}
+
+// Active returns true if the key is neither revoked nor expired at the time now.
+func (k APIKey) Active(now time.Time) bool {
+	return !k.Revoked && (k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt))
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LoginFailures are the failed login attempts recorded with a key, a username or an address,
+// since the failures were last reset.
+type LoginFailures struct {
+	Key         string
+	Failures    int
+	LastFailure time.Time
+}
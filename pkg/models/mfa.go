@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// TOTPCredential is the TOTP secret of a user, encrypted at rest. The credential only authenticates the user
+// once Confirmed. LastCounter is the time step of the last accepted code, the codes of the earlier time steps
+// are rejected so a code can't be used twice.
+type TOTPCredential struct {
+	Username    string
+	Secret      string
+	Confirmed   bool
+	LastCounter int64
+}
+
+// TOTPEnrollment is the secret of a new TOTP credential, in base32 and as an otpauth:// URI for the authenticator apps.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// MFAChallenge is a stored challenge of the second factor of an authentication. Only the hash of the token is stored.
+type MFAChallenge struct {
+	Hash      string
+	Username  string
+	ExpiresAt time.Time
+	Used      bool
+	Attempts  int
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// OAuthClient is a registered client of the OAuth token endpoint. Only the hash of its secret is stored.
+type OAuthClient struct {
+	ID         string
+	SecretHash string
+	// Tenant is the realm of the client. It is set by the stores.
+	Tenant string
+	// Scopes are the scopes the client may request with the client_credentials grant.
+	Scopes []string
+	// Audiences are the audiences the client may request, in addition to the audience of the service.
+	Audiences []string
+	// RedirectURIs are the URIs the users are redirected to with an authorization code, matched exactly.
+	RedirectURIs []string
+	CreatedAt    time.Time
+}
+
+// AuthorizationRequest is an OpenID Connect authentication request of the authorization code flow with PKCE.
+type AuthorizationRequest struct {
+	ClientID            string
+	RedirectURI         string
+	Scopes              []string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// AuthorizationCode is a stored authorization code, issued to the client for the user. Only the hash of the code is stored.
+type AuthorizationCode struct {
+	Hash        string
+	ClientID    string
+	Username    string
+	RedirectURI string
+	Scopes      []string
+	Nonce       string
+	// CodeChallenge is the S256 PKCE challenge the code verifier must match.
+	CodeChallenge string
+	// AuthTime is the time the user authenticated.
+	AuthTime  time.Time
+	ExpiresAt time.Time
+	Used      bool
+}
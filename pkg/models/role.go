@@ -0,0 +1,8 @@
+package models
+
+// Role is a named set of permissions assigned to users. The permissions of the roles of a user
+// are carried by the scope claim of its tokens.
+type Role struct {
+	Name        string
+	Permissions []string
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Tokens are the tokens issued to an authenticated user.
+// When the user must complete the authentication with a second factor, only MFAToken is set.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	MFAToken     string
+}
+
+// UserInfo are the claims of the userinfo endpoint granted by the scopes of the access token.
+type UserInfo struct {
+	Subject string
+	// PreferredUsername is set with the profile scope.
+	PreferredUsername string
+	// Email is set with the email scope, if the user has one.
+	Email string
+}
+
+// OAuthToken is a response of the OAuth token endpoint.
+type OAuthToken struct {
+	AccessToken  string
+	RefreshToken string
+	// IDToken is the OpenID Connect ID token of the user, issued for the authorization codes.
+	IDToken string
+	// ExpiresIn is the lifetime of the access token.
+	ExpiresIn time.Duration
+	// Scopes are the scopes granted to a client, they are empty for the tokens of a user.
+	Scopes []string
+}
+
+// RefreshToken is a stored refresh token. Only the hash of the token is stored.
+// All the refresh tokens rotated from the same authentication belong to the same family.
+type RefreshToken struct {
+	Hash      string
+	FamilyID  string
+	Username  string
+	ExpiresAt time.Time
+	Used      bool
+	Revoked   bool
+}
+
+// PasswordResetToken is a stored single-use password reset token. Only the hash of the token is stored.
+type PasswordResetToken struct {
+	Hash      string
+	Username  string
+	ExpiresAt time.Time
+	Used      bool
+}
@@ -1,7 +1,20 @@
 package models
 
 type User struct {
+	// Tenant is the realm of the user, the usernames are unique per tenant. It is set by the stores.
+	Tenant   string `json:"-"`
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
+	// Email is the optional address the notifications are sent to.
+	Email string `json:"email" validate:"omitempty,email"`
+	// TokenGeneration is incremented to invalidate all the tokens issued to the user.
+	TokenGeneration int64 `json:"-"`
 	// Could have more fields like firstname, lastname... but I focused on username and password
 }
+
+// UserUpdate holds the changes of a user, the empty fields are left unchanged.
+type UserUpdate struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email" validate:"omitempty,email"`
+}
@@ -0,0 +1,63 @@
+package notifiers
+
+import (
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogNotifier writes the notifications in the log. The tokens are logged, it is meant for development only.
+type LogNotifier struct {
+	resetURL string
+	logger   *zap.Logger
+}
+
+// NewLogNotifier creates a new instance of a Notifier writing the notifications in the log.
+func NewLogNotifier(resetURL string) Notifier {
+	return &LogNotifier{resetURL: resetURL, logger: zap.L().Named("LogNotifier")}
+}
+
+func (n *LogNotifier) NotifyPasswordReset(_ context.Context, user models.User, token string, expiresAt time.Time) error {
+	n.logger.Info(
+		"password reset notification",
+		zap.String("username", user.Username),
+		zap.String("email", user.Email),
+		zap.String("message", passwordResetMessage(user, token, expiresAt, n.resetURL)),
+	)
+	return nil
+}
+
+// FileNotifier appends the notifications to a file, like a mailbox.
+type FileNotifier struct {
+	mu       sync.Mutex
+	file     string
+	resetURL string
+}
+
+// NewFileNotifier creates a new instance of a Notifier appending the notifications to the file.
+func NewFileNotifier(file, resetURL string) Notifier {
+	return &FileNotifier{file: file, resetURL: resetURL}
+}
+
+func (n *FileNotifier) NotifyPasswordReset(_ context.Context, user models.User, token string, expiresAt time.Time) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening the notification file: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "To: %s <%s>\r\nSubject: %s\r\n\r\n%s\r\n",
+		user.Username, user.Email, passwordResetSubject, passwordResetMessage(user, token, expiresAt, n.resetURL))
+	if err != nil {
+		return fmt.Errorf("error writing the notification: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package notifiers
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const passwordResetSubject = "Password reset"
+
+type Notifier interface {
+	//NotifyPasswordReset sends the password reset token, valid until expiresAt, to the user.
+	NotifyPasswordReset(ctx context.Context, user models.User, token string, expiresAt time.Time) error
+}
+
+// NewNotifier creates a new instance of the Notifier of the type of the config.Notifier: log, file or smtp.
+func NewNotifier(configuration config.Notifier) (Notifier, error) {
+	switch configuration.Type {
+	case "log":
+		return NewLogNotifier(configuration.ResetURL), nil
+	case "file":
+		if configuration.File == "" {
+			return nil, fmt.Errorf("a file is required for the file notifier")
+		}
+		return NewFileNotifier(configuration.File, configuration.ResetURL), nil
+	case "smtp":
+		return NewSMTPNotifier(configuration.SMTP, configuration.ResetURL), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", configuration.Type)
+	}
+}
+
+// passwordResetMessage returns the text of the password reset notification.
+func passwordResetMessage(user models.User, token string, expiresAt time.Time, resetURL string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hello %s,\r\n\r\n", user.Username)
+	b.WriteString("A password reset was requested for your account.\r\n")
+	if resetURL != "" {
+		fmt.Fprintf(&b, "Open the following link to choose a new password: %s%s\r\n", resetURL, url.QueryEscape(token))
+	} else {
+		fmt.Fprintf(&b, "Use the following token to choose a new password: %s\r\n", token)
+	}
+	fmt.Fprintf(&b, "It expires at %s.\r\n\r\n", expiresAt.UTC().Format(time.RFC1123))
+	b.WriteString("If you did not request it, you can ignore this message.\r\n")
+	return b.String()
+}
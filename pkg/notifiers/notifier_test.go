@@ -0,0 +1,53 @@
+package notifiers
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewNotifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.Notifier
+		want    Notifier
+		wantErr bool
+	}{
+		{"log", config.Notifier{Type: "log"}, &LogNotifier{}, false},
+		{"file", config.Notifier{Type: "file", File: "notifications.txt"}, &FileNotifier{}, false},
+		{"file without file", config.Notifier{Type: "file"}, nil, true},
+		{"smtp", config.Notifier{Type: "smtp", SMTP: config.SMTP{Host: "localhost", Port: 25}}, &SMTPNotifier{}, false},
+		{"unknown", config.Notifier{Type: "pigeon"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewNotifier(tt.config)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.IsType(t, tt.want, got)
+		})
+	}
+}
+
+func TestFileNotifier_NotifyPasswordReset(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "notifications.txt")
+	n := NewFileNotifier(file, "")
+	user := models.User{Username: "test", Email: "test@example.com"}
+
+	require.NoError(t, n.NotifyPasswordReset(context.Background(), user, "token1", time.Now().Add(time.Hour)))
+	require.NoError(t, n.NotifyPasswordReset(context.Background(), user, "token2", time.Now().Add(time.Hour)))
+
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "To: test <test@example.com>")
+	require.Contains(t, string(b), "token1")
+	require.Contains(t, string(b), "token2")
+}
@@ -0,0 +1,58 @@
+package notifiers
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/models"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPNotifier sends the notifications by email to the address of the user.
+type SMTPNotifier struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	resetURL string
+}
+
+// NewSMTPNotifier creates a new instance of a Notifier sending emails with the SMTP server of the config.SMTP.
+// The server must support STARTTLS to authenticate with UserName and Password, unless it runs on localhost.
+func NewSMTPNotifier(configuration config.SMTP, resetURL string) Notifier {
+	n := &SMTPNotifier{
+		addr:     net.JoinHostPort(configuration.Host, strconv.Itoa(configuration.Port)),
+		from:     configuration.From,
+		resetURL: resetURL,
+	}
+	if configuration.UserName != "" {
+		n.auth = smtp.PlainAuth("", configuration.UserName, configuration.Password, configuration.Host)
+	}
+	return n
+}
+
+func (n *SMTPNotifier) NotifyPasswordReset(_ context.Context, user models.User, token string, expiresAt time.Time) error {
+	if user.Email == "" {
+		return fmt.Errorf("no email address for the user %s", user.Username)
+	}
+
+	to := mail.Address{Name: user.Username, Address: user.Email}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to.String())
+	fmt.Fprintf(&msg, "Subject: %s\r\n", passwordResetSubject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(passwordResetMessage(user, token, expiresAt, n.resetURL))
+
+	if err := smtp.SendMail(n.addr, n.auth, n.from, []string{user.Email}, msg.Bytes()); err != nil {
+		return fmt.Errorf("error sending the password reset email: %w", err)
+	}
+
+	return nil
+}
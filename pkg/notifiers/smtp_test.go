@@ -0,0 +1,119 @@
+package notifiers
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeMail is a mail received by the fakeSMTPServer.
+type fakeMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer starts a minimal SMTP server on localhost accepting any mail, without TLS nor authentication.
+// It returns the config.SMTP to reach it and the channel of the received mails.
+func fakeSMTPServer(t *testing.T) (config.SMTP, <-chan fakeMail) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	mails := make(chan fakeMail, 1)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeSMTP(textproto.NewConn(conn), mails)
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return config.SMTP{Host: host, Port: p, From: "noreply@example.com"}, mails
+}
+
+func serveFakeSMTP(conn *textproto.Conn, mails chan<- fakeMail) {
+	defer conn.Close()
+	var mail fakeMail
+	_ = conn.PrintfLine("220 localhost fake SMTP")
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			_ = conn.PrintfLine("250 localhost")
+		case "MAIL":
+			mail.from = strings.TrimSuffix(strings.TrimPrefix(line[len("MAIL FROM:"):], "<"), ">")
+			_ = conn.PrintfLine("250 OK")
+		case "RCPT":
+			mail.to = append(mail.to, strings.TrimSuffix(strings.TrimPrefix(line[len("RCPT TO:"):], "<"), ">"))
+			_ = conn.PrintfLine("250 OK")
+		case "DATA":
+			_ = conn.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			data, err := conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.data = string(data)
+			mails <- mail
+			_ = conn.PrintfLine("250 OK")
+		case "QUIT":
+			_ = conn.PrintfLine("221 bye")
+			return
+		default:
+			_ = conn.PrintfLine("250 OK")
+		}
+	}
+}
+
+func TestSMTPNotifier_NotifyPasswordReset(t *testing.T) {
+	smtpConfig, mails := fakeSMTPServer(t)
+	n := NewSMTPNotifier(smtpConfig, "https://example.com/reset?token=")
+	user := models.User{Username: "test", Email: "test@example.com"}
+
+	err := n.NotifyPasswordReset(context.Background(), user, "reset+token", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	select {
+	case mail := <-mails:
+		require.Equal(t, "noreply@example.com", mail.from)
+		require.Equal(t, []string{"test@example.com"}, mail.to)
+		require.Contains(t, mail.data, "To: \"test\" <test@example.com>")
+		require.Contains(t, mail.data, "Subject: Password reset")
+		require.Contains(t, mail.data, "https://example.com/reset?token=reset%2Btoken")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+	}
+}
+
+func TestSMTPNotifier_NotifyPasswordReset_no_email(t *testing.T) {
+	smtpConfig, mails := fakeSMTPServer(t)
+	n := NewSMTPNotifier(smtpConfig, "")
+
+	err := n.NotifyPasswordReset(context.Background(), models.User{Username: "test"}, "token", time.Now().Add(time.Hour))
+	require.Error(t, err)
+	require.Empty(t, mails)
+}
+
+func TestSMTPNotifier_NotifyPasswordReset_server_down(t *testing.T) {
+	n := NewSMTPNotifier(config.SMTP{Host: "127.0.0.1", Port: 1, From: "noreply@example.com"}, "")
+
+	err := n.NotifyPasswordReset(context.Background(), models.User{Username: "test", Email: "test@example.com"}, "token", time.Now())
+	require.Error(t, err)
+}
@@ -0,0 +1,72 @@
+// Package secrets encrypts the secrets stored at rest.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the size in bytes of the AES-256 keys.
+const KeySize = 32
+
+// Cipher encrypts the secrets with AES-256-GCM. The associated data, like the kind of the secret,
+// is authenticated but not stored: a ciphertext can only be decrypted with the same associated data.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a Cipher with the key of KeySize bytes.
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("the encryption key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the cipher: %w", err)
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// NewCipherFromBase64 creates a Cipher with the base64 encoded key.
+func NewCipherFromBase64(key string) (*Cipher, error) {
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 encryption key: %w", err)
+	}
+	return NewCipher(decoded)
+}
+
+// Encrypt returns the base64 encoded nonce and ciphertext of the plaintext.
+func (c *Cipher) Encrypt(plaintext, associatedData []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("error generating the nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, plaintext, associatedData)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the plaintext of a ciphertext returned by Encrypt with the same associated data.
+func (c *Cipher) Decrypt(ciphertext string, associatedData []byte) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, errors.New("invalid ciphertext: too short")
+	}
+	nonce, sealed := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting the secret: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,37 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestCipher(t *testing.T) {
+	c, err := NewCipherFromBase64(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", KeySize))))
+	require.NoError(t, err)
+
+	ciphertext, err := c.Encrypt([]byte("secret"), []byte("totp"))
+	require.NoError(t, err)
+	require.NotContains(t, ciphertext, "secret")
+	other, err := c.Encrypt([]byte("secret"), []byte("totp"))
+	require.NoError(t, err)
+	require.NotEqual(t, ciphertext, other)
+
+	plaintext, err := c.Decrypt(ciphertext, []byte("totp"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), plaintext)
+
+	// The ciphertext is bound to the associated data.
+	_, err = c.Decrypt(ciphertext, []byte("recovery"))
+	require.Error(t, err)
+	_, err = c.Decrypt("c2hvcnQ=", []byte("totp"))
+	require.Error(t, err)
+}
+
+func TestNewCipher_invalid_key(t *testing.T) {
+	_, err := NewCipher([]byte("short"))
+	require.Error(t, err)
+	_, err = NewCipherFromBase64("not base64")
+	require.Error(t, err)
+}
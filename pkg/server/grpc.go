@@ -15,17 +15,23 @@ import (
 	"google.golang.org/grpc/status"
 	"os"
 	"strings"
+	"time"
 )
 
 type AuthServer struct {
 	pb.AuthServer
-	userService services.UserService
-	authService services.AuthService
-	logger      *zap.Logger
+	userService          services.UserService
+	authService          services.AuthService
+	passwordResetService services.PasswordResetService
+	roleService          services.RoleService
+	apiKeyService        services.APIKeyService
+	oauthService         services.OAuthService
+	logger               *zap.Logger
 }
 
-// NewGrpcServer creates a new gRPC server and registers the AuthServer with services.UserService and services.AuthService
-func NewGrpcServer(configuration config.TLS, userService services.UserService, authService services.AuthService) (*grpc.Server, error) {
+// NewGrpcServer creates a new gRPC server and registers a RealmRouter serving the realms, keyed by name.
+// The RPCs are authorized with the policies by an AuthInterceptor.
+func NewGrpcServer(configuration config.TLS, realms map[string]Realm, policies map[string]Policy) (*grpc.Server, error) {
 	//Usually I would set up logging, metrics and tracing middleware for gRPC, but I didn't for this application as it is beyond the scope of this assignment.
 	var opts []grpc.ServerOption
 	if configuration.UseTLS {
@@ -36,19 +42,37 @@ func NewGrpcServer(configuration config.TLS, userService services.UserService, a
 		creds := credentials.NewTLS(tlsConfig)
 		opts = append(opts, grpc.Creds(creds))
 	}
+	authServices := make(map[string]services.AuthService, len(realms))
+	for name, realm := range realms {
+		authServices[name] = realm.AuthService
+	}
+	interceptor := NewAuthInterceptor(authServices, policies)
+	opts = append(opts, grpc.ChainUnaryInterceptor(interceptor.Unary()), grpc.ChainStreamInterceptor(interceptor.Stream()))
 
 	srv := grpc.NewServer(opts...)
-	pb.RegisterAuthServer(srv, NewAuthServer(userService, authService))
+	pb.RegisterAuthServer(srv, NewRealmRouter(realms))
 
 	return srv, nil
 }
 
-// NewAuthServer creates a new instance of AuthServer with a services.UserService and a services.AuthService
-func NewAuthServer(userService services.UserService, authService services.AuthService) *AuthServer {
+// NewAuthServer creates a new instance of AuthServer with a services.UserService, a services.AuthService,
+// a services.PasswordResetService, a services.RoleService, a services.APIKeyService and a services.OAuthService
+func NewAuthServer(
+	userService services.UserService,
+	authService services.AuthService,
+	passwordResetService services.PasswordResetService,
+	roleService services.RoleService,
+	apiKeyService services.APIKeyService,
+	oauthService services.OAuthService,
+) *AuthServer {
 	return &AuthServer{
-		userService: userService,
-		authService: authService,
-		logger:      zap.L().Named("gRPCAuthServer"),
+		userService:          userService,
+		authService:          authService,
+		passwordResetService: passwordResetService,
+		roleService:          roleService,
+		apiKeyService:        apiKeyService,
+		oauthService:         oauthService,
+		logger:               zap.L().Named("gRPCAuthServer"),
 	}
 }
 
@@ -58,6 +82,7 @@ func (a *AuthServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 	err := a.userService.Create(ctx, models.User{
 		Username: strings.TrimSpace(req.Username),
 		Password: strings.TrimSpace(req.Password),
+		Email:    strings.TrimSpace(req.Email),
 	})
 
 	s, ok := status.FromError(err)
@@ -71,10 +96,84 @@ func (a *AuthServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 	return &pb.CreateUserResponse{Success: true}, nil
 }
 
+// GetUser returns the user from the request pb.GetUserRequest
+func (a *AuthServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	a.logger.Info("GetUser called")
+	u, err := a.userService.Get(ctx, strings.TrimSpace(req.Username))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.GetUserResponse{User: toPbUser(*u)}, nil
+}
+
+// UpdateUser updates the user from the request pb.UpdateUserRequest
+func (a *AuthServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	a.logger.Info("UpdateUser called")
+	err := a.userService.Update(ctx, strings.TrimSpace(req.Username), models.UserUpdate{
+		Username: strings.TrimSpace(req.NewUsername),
+		Password: strings.TrimSpace(req.Password),
+		Email:    strings.TrimSpace(req.Email),
+	})
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.UpdateUserResponse{Success: true}, nil
+}
+
+// DeleteUser deletes the user from the request pb.DeleteUserRequest
+func (a *AuthServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	a.logger.Info("DeleteUser called")
+	err := a.userService.Delete(ctx, strings.TrimSpace(req.Username))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.DeleteUserResponse{Success: true}, nil
+}
+
+// ListUsers returns a page of the users from the request pb.ListUsersRequest
+func (a *AuthServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	a.logger.Info("ListUsers called")
+	users, nextPageToken, err := a.userService.List(ctx, req.Prefix, strings.TrimSpace(req.PageToken), int(req.PageSize))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	response := &pb.ListUsersResponse{Users: make([]*pb.User, 0, len(users)), NextPageToken: nextPageToken}
+	for _, u := range users {
+		response.Users = append(response.Users, toPbUser(u))
+	}
+
+	return response, nil
+}
+
+// toPbUser converts a models.User to a pb.User, the password hash never leaves the service.
+func toPbUser(u models.User) *pb.User {
+	return &pb.User{Username: u.Username, Email: u.Email}
+}
+
 // Authenticate a user from the request pb.AuthenticateRequest
 func (a *AuthServer) Authenticate(ctx context.Context, req *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
 	a.logger.Info("Authenticate called")
-	token, err := a.authService.Authenticate(
+	tokens, err := a.authService.Authenticate(
 		ctx,
 		strings.TrimSpace(req.Username),
 		strings.TrimSpace(req.Password),
@@ -88,10 +187,358 @@ func (a *AuthServer) Authenticate(ctx context.Context, req *pb.AuthenticateReque
 	}
 
 	return &pb.AuthenticateResponse{
-		Token: token,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		MfaToken:     tokens.MFAToken,
+	}, nil
+}
+
+// RefreshToken exchanges the refresh token from the request pb.RefreshTokenRequest for new tokens
+func (a *AuthServer) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	a.logger.Info("RefreshToken called")
+	tokens, err := a.authService.Refresh(ctx, strings.TrimSpace(req.RefreshToken))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.RefreshTokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	}, nil
 }
 
+// Logout revokes the token and the refresh token from the request pb.LogoutRequest
+func (a *AuthServer) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	a.logger.Info("Logout called")
+	err := a.authService.Logout(ctx, strings.TrimSpace(req.Token), strings.TrimSpace(req.RefreshToken))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.LogoutResponse{Success: true}, nil
+}
+
+// ChangePassword replaces the password of the user of the token from the request pb.ChangePasswordRequest
+func (a *AuthServer) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	a.logger.Info("ChangePassword called")
+	err := a.authService.ChangePassword(
+		ctx,
+		strings.TrimSpace(req.Token),
+		strings.TrimSpace(req.CurrentPassword),
+		strings.TrimSpace(req.NewPassword),
+	)
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.ChangePasswordResponse{Success: true}, nil
+}
+
+// RequestPasswordReset sends a password reset token to the user from the request pb.RequestPasswordResetRequest.
+// The response is the same whether the user exists or not.
+func (a *AuthServer) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	a.logger.Info("RequestPasswordReset called")
+	err := a.passwordResetService.RequestReset(ctx, strings.TrimSpace(req.Username))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.RequestPasswordResetResponse{Success: true}, nil
+}
+
+// ResetPassword replaces the password of the user of the reset token from the request pb.ResetPasswordRequest
+func (a *AuthServer) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	a.logger.Info("ResetPassword called")
+	err := a.passwordResetService.Reset(ctx, strings.TrimSpace(req.Token), strings.TrimSpace(req.NewPassword))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.ResetPasswordResponse{Success: true}, nil
+}
+
+// Introspect returns the state of the token from the request pb.IntrospectRequest
+func (a *AuthServer) Introspect(ctx context.Context, req *pb.IntrospectRequest) (*pb.IntrospectResponse, error) {
+	a.logger.Info("Introspect called")
+	claims, err := a.authService.Introspect(ctx, strings.TrimSpace(req.Token))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	if claims == nil {
+		return &pb.IntrospectResponse{Active: false}, nil
+	}
+
+	response := &pb.IntrospectResponse{
+		Active:         true,
+		Subject:        claims.Subject,
+		Audience:       claims.Audience,
+		Scopes:         claims.Scopes(),
+		Issuer:         claims.Issuer,
+		PasswordChange: claims.PasswordChange,
+		Roles:          claims.Roles,
+		Tenant:         claims.Tenant,
+		ClientType:     claims.ClientType,
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.IssuedAt = claims.IssuedAt.Unix()
+	}
+
+	return response, nil
+}
+
+// GetJWKS returns the public keys verifying the tokens
+func (a *AuthServer) GetJWKS(ctx context.Context, _ *pb.GetJWKSRequest) (*pb.GetJWKSResponse, error) {
+	a.logger.Info("GetJWKS called")
+	jwks, err := a.authService.JWKS(ctx)
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	response := &pb.GetJWKSResponse{Keys: make([]*pb.JSONWebKey, 0, len(jwks.Keys))}
+	for _, k := range jwks.Keys {
+		response.Keys = append(response.Keys, &pb.JSONWebKey{
+			Kty: k.Kty,
+			Kid: k.Kid,
+			Use: k.Use,
+			Alg: k.Alg,
+			N:   k.N,
+			E:   k.E,
+			Crv: k.Crv,
+			X:   k.X,
+			Y:   k.Y,
+		})
+	}
+
+	return response, nil
+}
+
+// UnlockUser unlocks the user from the request pb.UnlockUserRequest locked after too many failed logins
+func (a *AuthServer) UnlockUser(ctx context.Context, req *pb.UnlockUserRequest) (*pb.UnlockUserResponse, error) {
+	a.logger.Info("UnlockUser called")
+	err := a.authService.UnlockUser(ctx, strings.TrimSpace(req.Username))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.UnlockUserResponse{Success: true}, nil
+}
+
+// AssignRole assigns a role to a user from the request pb.AssignRoleRequest
+func (a *AuthServer) AssignRole(ctx context.Context, req *pb.AssignRoleRequest) (*pb.AssignRoleResponse, error) {
+	a.logger.Info("AssignRole called")
+	err := a.roleService.AssignRole(ctx, strings.TrimSpace(req.Username), strings.TrimSpace(req.Role))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.AssignRoleResponse{Success: true}, nil
+}
+
+// RevokeRole revokes a role of a user from the request pb.RevokeRoleRequest
+func (a *AuthServer) RevokeRole(ctx context.Context, req *pb.RevokeRoleRequest) (*pb.RevokeRoleResponse, error) {
+	a.logger.Info("RevokeRole called")
+	err := a.roleService.RevokeRole(ctx, strings.TrimSpace(req.Username), strings.TrimSpace(req.Role))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.RevokeRoleResponse{Success: true}, nil
+}
+
+// ListRoles lists the roles of a user, or all the roles, from the request pb.ListRolesRequest
+func (a *AuthServer) ListRoles(ctx context.Context, req *pb.ListRolesRequest) (*pb.ListRolesResponse, error) {
+	a.logger.Info("ListRoles called")
+	roles, err := a.roleService.ListRoles(ctx, strings.TrimSpace(req.Username))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	response := &pb.ListRolesResponse{Roles: make([]*pb.Role, 0, len(roles))}
+	for _, r := range roles {
+		response.Roles = append(response.Roles, &pb.Role{Name: r.Name, Permissions: r.Permissions})
+	}
+
+	return response, nil
+}
+
+// CreateAPIKey creates an API key of a service account from the request pb.CreateAPIKeyRequest
+func (a *AuthServer) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	a.logger.Info("CreateAPIKey called")
+	permissions := make([]string, 0, len(req.Permissions))
+	for _, p := range req.Permissions {
+		permissions = append(permissions, strings.TrimSpace(p))
+	}
+	var expiresAt time.Time
+	if req.ExpiresAt != 0 {
+		expiresAt = time.Unix(req.ExpiresAt, 0)
+	}
+	apiKey, key, err := a.apiKeyService.CreateAPIKey(ctx, strings.TrimSpace(req.ServiceAccount), permissions, expiresAt)
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.CreateAPIKeyResponse{Key: key, ApiKey: toPbAPIKey(*apiKey)}, nil
+}
+
+// ListAPIKeys lists the API keys of a service account, or of all the service accounts, from the request pb.ListAPIKeysRequest
+func (a *AuthServer) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	a.logger.Info("ListAPIKeys called")
+	keys, err := a.apiKeyService.ListAPIKeys(ctx, strings.TrimSpace(req.ServiceAccount))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	response := &pb.ListAPIKeysResponse{ApiKeys: make([]*pb.APIKey, 0, len(keys))}
+	for _, k := range keys {
+		response.ApiKeys = append(response.ApiKeys, toPbAPIKey(k))
+	}
+
+	return response, nil
+}
+
+// RevokeAPIKey revokes an API key from the request pb.RevokeAPIKeyRequest
+func (a *AuthServer) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyResponse, error) {
+	a.logger.Info("RevokeAPIKey called")
+	err := a.apiKeyService.RevokeAPIKey(ctx, strings.TrimSpace(req.Prefix))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.RevokeAPIKeyResponse{Success: true}, nil
+}
+
+// ExchangeAPIKey exchanges an API key for an access token of its service account from the request pb.ExchangeAPIKeyRequest
+func (a *AuthServer) ExchangeAPIKey(ctx context.Context, req *pb.ExchangeAPIKeyRequest) (*pb.ExchangeAPIKeyResponse, error) {
+	a.logger.Info("ExchangeAPIKey called")
+	token, err := a.authService.ExchangeAPIKey(ctx, strings.TrimSpace(req.Key))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.ExchangeAPIKeyResponse{Token: token}, nil
+}
+
+// CreateOAuthClient registers an OAuth client of the token and authorization endpoints from the request pb.CreateOAuthClientRequest
+func (a *AuthServer) CreateOAuthClient(ctx context.Context, req *pb.CreateOAuthClientRequest) (*pb.CreateOAuthClientResponse, error) {
+	a.logger.Info("CreateOAuthClient called")
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scopes = append(scopes, strings.TrimSpace(scope))
+	}
+	audiences := make([]string, 0, len(req.Audiences))
+	for _, audience := range req.Audiences {
+		audiences = append(audiences, strings.TrimSpace(audience))
+	}
+	redirectURIs := make([]string, 0, len(req.RedirectUris))
+	for _, redirectURI := range req.RedirectUris {
+		redirectURIs = append(redirectURIs, strings.TrimSpace(redirectURI))
+	}
+	secret, err := a.oauthService.CreateClient(ctx, strings.TrimSpace(req.ClientId), scopes, audiences, redirectURIs)
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.CreateOAuthClientResponse{ClientSecret: secret}, nil
+}
+
+// DeleteOAuthClient deletes an OAuth client from the request pb.DeleteOAuthClientRequest
+func (a *AuthServer) DeleteOAuthClient(ctx context.Context, req *pb.DeleteOAuthClientRequest) (*pb.DeleteOAuthClientResponse, error) {
+	a.logger.Info("DeleteOAuthClient called")
+	err := a.oauthService.DeleteClient(ctx, strings.TrimSpace(req.ClientId))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.DeleteOAuthClientResponse{Success: true}, nil
+}
+
+// toPbAPIKey converts a models.APIKey to a pb.APIKey, without its hash.
+func toPbAPIKey(k models.APIKey) *pb.APIKey {
+	apiKey := &pb.APIKey{
+		Prefix:         k.Prefix,
+		ServiceAccount: k.ServiceAccount,
+		Permissions:    k.Permissions,
+		Revoked:        k.Revoked,
+		CreatedAt:      k.CreatedAt.Unix(),
+	}
+	if !k.ExpiresAt.IsZero() {
+		apiKey.ExpiresAt = k.ExpiresAt.Unix()
+	}
+	return apiKey
+}
+
 func setupTLSConfig(cfg config.TLS) (*tls.Config, error) {
 	var err error
 	tlsConfig := &tls.Config{}
@@ -124,3 +571,100 @@ func setupTLSConfig(cfg config.TLS) (*tls.Config, error) {
 	}
 	return tlsConfig, nil
 }
+
+// EnrollTOTP generates a TOTP secret for the user of the token from the request pb.EnrollTOTPRequest
+func (a *AuthServer) EnrollTOTP(ctx context.Context, req *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	a.logger.Info("EnrollTOTP called")
+	enrollment, err := a.authService.EnrollTOTP(ctx, strings.TrimSpace(req.Token))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.EnrollTOTPResponse{Secret: enrollment.Secret, Uri: enrollment.URI}, nil
+}
+
+// ConfirmTOTP activates the TOTP secret of the user of the token with the code from the request pb.ConfirmTOTPRequest
+func (a *AuthServer) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	a.logger.Info("ConfirmTOTP called")
+	err := a.authService.ConfirmTOTP(ctx, strings.TrimSpace(req.Token), strings.TrimSpace(req.Code))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.ConfirmTOTPResponse{Success: true}, nil
+}
+
+// VerifyMFA exchanges the MFA token and the code from the request pb.VerifyMFARequest for the tokens of the user
+func (a *AuthServer) VerifyMFA(ctx context.Context, req *pb.VerifyMFARequest) (*pb.VerifyMFAResponse, error) {
+	a.logger.Info("VerifyMFA called")
+	tokens, err := a.authService.VerifyMFA(ctx, strings.TrimSpace(req.MfaToken), strings.TrimSpace(req.Code))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.VerifyMFAResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+// GenerateRecoveryCodes issues new recovery codes to the user of the token from the request pb.GenerateRecoveryCodesRequest
+func (a *AuthServer) GenerateRecoveryCodes(ctx context.Context, req *pb.GenerateRecoveryCodesRequest) (*pb.GenerateRecoveryCodesResponse, error) {
+	a.logger.Info("GenerateRecoveryCodes called")
+	codes, err := a.authService.GenerateRecoveryCodes(ctx, strings.TrimSpace(req.Token))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.GenerateRecoveryCodesResponse{Codes: codes}, nil
+}
+
+// CountRecoveryCodes returns the number of unused recovery codes of the user of the token from the request pb.CountRecoveryCodesRequest
+func (a *AuthServer) CountRecoveryCodes(ctx context.Context, req *pb.CountRecoveryCodesRequest) (*pb.CountRecoveryCodesResponse, error) {
+	a.logger.Info("CountRecoveryCodes called")
+	count, err := a.authService.CountRecoveryCodes(ctx, strings.TrimSpace(req.Token))
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.CountRecoveryCodesResponse{Remaining: int32(count)}, nil
+}
+
+// AuthenticateWithRecoveryCode authenticates a user with the recovery code from the request pb.AuthenticateWithRecoveryCodeRequest
+func (a *AuthServer) AuthenticateWithRecoveryCode(ctx context.Context, req *pb.AuthenticateWithRecoveryCodeRequest) (*pb.AuthenticateWithRecoveryCodeResponse, error) {
+	a.logger.Info("AuthenticateWithRecoveryCode called")
+	tokens, err := a.authService.AuthenticateWithRecoveryCode(
+		ctx,
+		strings.TrimSpace(req.Username),
+		strings.TrimSpace(req.Code),
+	)
+	s, ok := status.FromError(err)
+	if err != nil && ok {
+		return nil, s.Err()
+	} else if err != nil {
+		a.logger.Error("unknown error", zap.Error(err))
+		return nil, s.Err()
+	}
+
+	return &pb.AuthenticateWithRecoveryCodeResponse{Token: tokens.AccessToken}, nil
+}
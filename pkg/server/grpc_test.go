@@ -2,19 +2,27 @@ package server
 
 import (
 	"auth/pkg/errors"
+	"auth/pkg/jwt"
 	"auth/pkg/models"
 	"auth/pkg/pb"
 	"auth/pkg/tests"
 	"context"
 	"fmt"
+	gojwt "github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"testing"
+	"time"
 )
 
 var (
 	mockAuthentication *tests.MockAuthService
 	mockUserService    *tests.MockUserService
+	mockPasswordReset  *tests.MockPasswordResetService
+	mockRoleService    *tests.MockRoleService
+	mockAPIKeyService  *tests.MockAPIKeyService
+	mockOAuthService   *tests.MockOAuthService
+	mockOIDCService    *tests.MockOIDCService
 )
 
 func setupTest(t testing.TB) func(t testing.TB) {
@@ -22,6 +30,11 @@ func setupTest(t testing.TB) func(t testing.TB) {
 	defer ctrl.Finish()
 	mockAuthentication = tests.NewMockAuthService(ctrl)
 	mockUserService = tests.NewMockUserService(ctrl)
+	mockPasswordReset = tests.NewMockPasswordResetService(ctrl)
+	mockRoleService = tests.NewMockRoleService(ctrl)
+	mockAPIKeyService = tests.NewMockAPIKeyService(ctrl)
+	mockOAuthService = tests.NewMockOAuthService(ctrl)
+	mockOIDCService = tests.NewMockOIDCService(ctrl)
 
 	return func(t testing.TB) {
 	}
@@ -39,7 +52,7 @@ func TestAuthServer_CreateUser_no_error(t *testing.T) {
 		Password: password,
 	}
 	mockUserService.EXPECT().Create(ctx, user).Return(nil).Times(1)
-	server := NewAuthServer(mockUserService, mockAuthentication)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
 
 	response, err := server.CreateUser(ctx, &pb.CreateUserRequest{
 		Username: username,
@@ -62,7 +75,7 @@ func TestAuthServer_CreateUser_create_username_exists(t *testing.T) {
 		Password: password,
 	}
 	mockUserService.EXPECT().Create(ctx, user).Return(errors.UsernameAlreadyExistErr{Name: username}).Times(1)
-	server := NewAuthServer(mockUserService, mockAuthentication)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
 
 	response, err := server.CreateUser(ctx, &pb.CreateUserRequest{
 		Username: username,
@@ -86,7 +99,7 @@ func TestAuthServer_CreateUser_create_username_error(t *testing.T) {
 		Password: password,
 	}
 	mockUserService.EXPECT().Create(ctx, user).Return(fmt.Errorf("unexpected")).Times(1)
-	server := NewAuthServer(mockUserService, mockAuthentication)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
 
 	response, err := server.CreateUser(ctx, &pb.CreateUserRequest{
 		Username: username,
@@ -98,6 +111,103 @@ func TestAuthServer_CreateUser_create_username_error(t *testing.T) {
 	require.Empty(t, response)
 }
 
+func TestAuthServer_GetUser_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockUserService.EXPECT().Get(ctx, "test").Return(&models.User{Username: "test", Password: "hash", Email: "test@example.com"}, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.GetUser(ctx, &pb.GetUserRequest{Username: " test "})
+
+	require.NoError(t, err)
+	require.Equal(t, "test", response.User.Username)
+	require.Equal(t, "test@example.com", response.User.Email)
+}
+
+func TestAuthServer_GetUser_not_found(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockUserService.EXPECT().Get(ctx, "test").Return(nil, errors.UserNotFoundErr{Name: "test"}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.GetUser(ctx, &pb.GetUserRequest{Username: "test"})
+
+	require.Error(t, err)
+	require.EqualError(t, err, "rpc error: code = NotFound desc = user test not found")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_UpdateUser_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockUserService.EXPECT().Update(ctx, "test", models.UserUpdate{Username: "renamed", Password: "password"}).Return(nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.UpdateUser(ctx, &pb.UpdateUserRequest{Username: "test", NewUsername: "renamed", Password: "password"})
+
+	require.NoError(t, err)
+	require.True(t, response.Success)
+}
+
+func TestAuthServer_DeleteUser_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockUserService.EXPECT().Delete(ctx, "test").Return(nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.DeleteUser(ctx, &pb.DeleteUserRequest{Username: "test"})
+
+	require.NoError(t, err)
+	require.True(t, response.Success)
+}
+
+func TestAuthServer_DeleteUser_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockUserService.EXPECT().Delete(ctx, "test").Return(fmt.Errorf("something went wrong")).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.DeleteUser(ctx, &pb.DeleteUserRequest{Username: "test"})
+
+	require.Error(t, err)
+	require.EqualError(t, err, "rpc error: code = Unknown desc = something went wrong")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_ListUsers_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	users := []models.User{{Username: "alice1", Password: "hash"}, {Username: "alice2", Password: "hash"}}
+
+	mockUserService.EXPECT().List(ctx, "alice", "token", 2).Return(users, "next", nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.ListUsers(ctx, &pb.ListUsersRequest{Prefix: "alice", PageToken: "token", PageSize: 2})
+
+	require.NoError(t, err)
+	require.Len(t, response.Users, 2)
+	require.Equal(t, "alice1", response.Users[0].Username)
+	require.Equal(t, "alice2", response.Users[1].Username)
+	require.Equal(t, "next", response.NextPageToken)
+}
+
 func TestAuthServer_Authenticate_no_error(t *testing.T) {
 	teardownTest := setupTest(t)
 	defer teardownTest(t)
@@ -106,8 +216,8 @@ func TestAuthServer_Authenticate_no_error(t *testing.T) {
 	username := "test"
 	password := "password"
 
-	mockAuthentication.EXPECT().Authenticate(ctx, username, password).Return("token", nil).Times(1)
-	server := NewAuthServer(mockUserService, mockAuthentication)
+	mockAuthentication.EXPECT().Authenticate(ctx, username, password).Return(&models.Tokens{AccessToken: "token", RefreshToken: "refresh"}, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
 
 	response, err := server.Authenticate(ctx, &pb.AuthenticateRequest{
 		Username: username,
@@ -117,6 +227,7 @@ func TestAuthServer_Authenticate_no_error(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, response.Token)
 	require.Equal(t, "token", response.Token)
+	require.Equal(t, "refresh", response.RefreshToken)
 }
 
 func TestAuthServer_Authenticate_authService_authFailed(t *testing.T) {
@@ -127,8 +238,8 @@ func TestAuthServer_Authenticate_authService_authFailed(t *testing.T) {
 	username := "test"
 	password := "password"
 
-	mockAuthentication.EXPECT().Authenticate(ctx, username, password).Return("", errors.AuthenticationFailErr(username)).Times(1)
-	server := NewAuthServer(mockUserService, mockAuthentication)
+	mockAuthentication.EXPECT().Authenticate(ctx, username, password).Return(nil, errors.AuthenticationFailErr(username)).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
 
 	response, err := server.Authenticate(ctx, &pb.AuthenticateRequest{
 		Username: username,
@@ -148,8 +259,8 @@ func TestAuthServer_Authenticate_authService_error(t *testing.T) {
 	username := "test"
 	password := "password"
 
-	mockAuthentication.EXPECT().Authenticate(ctx, username, password).Return("", fmt.Errorf("unexpected")).Times(1)
-	server := NewAuthServer(mockUserService, mockAuthentication)
+	mockAuthentication.EXPECT().Authenticate(ctx, username, password).Return(nil, fmt.Errorf("unexpected")).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
 
 	response, err := server.Authenticate(ctx, &pb.AuthenticateRequest{
 		Username: username,
@@ -160,3 +271,653 @@ func TestAuthServer_Authenticate_authService_error(t *testing.T) {
 	require.EqualError(t, err, "rpc error: code = Unknown desc = unexpected")
 	require.Empty(t, response)
 }
+
+func TestAuthServer_RefreshToken_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().Refresh(ctx, "refresh").Return(&models.Tokens{AccessToken: "token", RefreshToken: "refresh2"}, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: "refresh"})
+
+	require.NoError(t, err)
+	require.Equal(t, "token", response.Token)
+	require.Equal(t, "refresh2", response.RefreshToken)
+}
+
+func TestAuthServer_RefreshToken_invalid(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().Refresh(ctx, "refresh").Return(nil, errors.InvalidRefreshTokenErr{}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: "refresh"})
+
+	require.Error(t, err)
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid refresh token")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_Logout_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().Logout(ctx, "token", "refresh").Return(nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.Logout(ctx, &pb.LogoutRequest{Token: " token ", RefreshToken: "refresh"})
+
+	require.NoError(t, err)
+	require.True(t, response.Success)
+}
+
+func TestAuthServer_Logout_revoked(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().Logout(ctx, "token", "").Return(errors.TokenRevokedErr{ID: "jti"}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.Logout(ctx, &pb.LogoutRequest{Token: "token"})
+
+	require.Error(t, err)
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = token revoked")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_ChangePassword_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().ChangePassword(ctx, "token", "current", "new").Return(nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.ChangePassword(ctx, &pb.ChangePasswordRequest{Token: "token", CurrentPassword: "current", NewPassword: "new"})
+
+	require.NoError(t, err)
+	require.True(t, response.Success)
+}
+
+func TestAuthServer_ChangePassword_authFailed(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().ChangePassword(ctx, "token", "wrong", "new").Return(errors.AuthenticationFailErr("test")).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.ChangePassword(ctx, &pb.ChangePasswordRequest{Token: "token", CurrentPassword: "wrong", NewPassword: "new"})
+
+	require.Error(t, err)
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = authentication failed")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_RequestPasswordReset_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockPasswordReset.EXPECT().RequestReset(ctx, "test").Return(nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.RequestPasswordReset(ctx, &pb.RequestPasswordResetRequest{Username: " test "})
+
+	require.NoError(t, err)
+	require.True(t, response.Success)
+}
+
+func TestAuthServer_ResetPassword_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockPasswordReset.EXPECT().Reset(ctx, "token", "new").Return(nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.ResetPassword(ctx, &pb.ResetPasswordRequest{Token: "token", NewPassword: "new"})
+
+	require.NoError(t, err)
+	require.True(t, response.Success)
+}
+
+func TestAuthServer_ResetPassword_invalidToken(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockPasswordReset.EXPECT().Reset(ctx, "token", "new").Return(errors.InvalidPasswordResetTokenErr{}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.ResetPassword(ctx, &pb.ResetPasswordRequest{Token: "token", NewPassword: "new"})
+
+	require.Error(t, err)
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid password reset token")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_UnlockUser_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().UnlockUser(ctx, "test").Return(nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.UnlockUser(ctx, &pb.UnlockUserRequest{Username: " test "})
+
+	require.NoError(t, err)
+	require.True(t, response.Success)
+}
+
+func TestAuthServer_Authenticate_locked(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	until := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mockAuthentication.EXPECT().Authenticate(ctx, "test", "password").Return(nil, errors.AccountLockedErr{Until: until}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.Authenticate(ctx, &pb.AuthenticateRequest{Username: "test", Password: "password"})
+
+	require.EqualError(t, err, "rpc error: code = PermissionDenied desc = account temporarily locked until 2023-01-02T03:04:05Z")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_Authenticate_throttled(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().Authenticate(ctx, "test", "password").Return(nil, errors.LoginThrottledErr{RetryAfter: 1500 * time.Millisecond}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.Authenticate(ctx, &pb.AuthenticateRequest{Username: "test", Password: "password"})
+
+	require.EqualError(t, err, "rpc error: code = ResourceExhausted desc = too many login attempts, retry in 2s")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_Authenticate_mfa_required(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().Authenticate(ctx, "test", "password").Return(&models.Tokens{MFAToken: "mfa"}, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.Authenticate(ctx, &pb.AuthenticateRequest{Username: "test", Password: "password"})
+
+	require.NoError(t, err)
+	require.Equal(t, "mfa", response.MfaToken)
+	require.Empty(t, response.Token)
+	require.Empty(t, response.RefreshToken)
+}
+
+func TestAuthServer_EnrollTOTP_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	enrollment := &models.TOTPEnrollment{Secret: "SECRET", URI: "otpauth://totp/auth:test?secret=SECRET"}
+
+	mockAuthentication.EXPECT().EnrollTOTP(ctx, "token").Return(enrollment, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.EnrollTOTP(ctx, &pb.EnrollTOTPRequest{Token: " token "})
+
+	require.NoError(t, err)
+	require.Equal(t, enrollment.Secret, response.Secret)
+	require.Equal(t, enrollment.URI, response.Uri)
+}
+
+func TestAuthServer_EnrollTOTP_already_enabled(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().EnrollTOTP(ctx, "token").Return(nil, errors.TOTPAlreadyEnabledErr{Name: "test"}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.EnrollTOTP(ctx, &pb.EnrollTOTPRequest{Token: "token"})
+
+	require.EqualError(t, err, "rpc error: code = FailedPrecondition desc = TOTP already enabled")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_ConfirmTOTP_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().ConfirmTOTP(ctx, "token", "123456").Return(nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.ConfirmTOTP(ctx, &pb.ConfirmTOTPRequest{Token: "token", Code: " 123456 "})
+
+	require.NoError(t, err)
+	require.True(t, response.Success)
+}
+
+func TestAuthServer_VerifyMFA_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().VerifyMFA(ctx, "mfa", "123456").Return(&models.Tokens{AccessToken: "token", RefreshToken: "refresh"}, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.VerifyMFA(ctx, &pb.VerifyMFARequest{MfaToken: "mfa", Code: "123456"})
+
+	require.NoError(t, err)
+	require.Equal(t, "token", response.Token)
+	require.Equal(t, "refresh", response.RefreshToken)
+}
+
+func TestAuthServer_VerifyMFA_invalid_code(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().VerifyMFA(ctx, "mfa", "000000").Return(nil, errors.InvalidMFACodeErr{}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.VerifyMFA(ctx, &pb.VerifyMFARequest{MfaToken: "mfa", Code: "000000"})
+
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid MFA code")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_GenerateRecoveryCodes_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	codes := []string{"aaaaa-bbbbb", "ccccc-ddddd"}
+
+	mockAuthentication.EXPECT().GenerateRecoveryCodes(ctx, "token").Return(codes, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.GenerateRecoveryCodes(ctx, &pb.GenerateRecoveryCodesRequest{Token: " token "})
+
+	require.NoError(t, err)
+	require.Equal(t, codes, response.Codes)
+}
+
+func TestAuthServer_CountRecoveryCodes_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().CountRecoveryCodes(ctx, "token").Return(3, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.CountRecoveryCodes(ctx, &pb.CountRecoveryCodesRequest{Token: "token"})
+
+	require.NoError(t, err)
+	require.Equal(t, int32(3), response.Remaining)
+}
+
+func TestAuthServer_AuthenticateWithRecoveryCode_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().AuthenticateWithRecoveryCode(ctx, "test", "aaaaa-bbbbb").Return(&models.Tokens{AccessToken: "token"}, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.AuthenticateWithRecoveryCode(ctx, &pb.AuthenticateWithRecoveryCodeRequest{Username: "test", Code: " aaaaa-bbbbb "})
+
+	require.NoError(t, err)
+	require.Equal(t, "token", response.Token)
+}
+
+func TestAuthServer_AuthenticateWithRecoveryCode_authFailed(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().AuthenticateWithRecoveryCode(ctx, "test", "wrong").Return(nil, errors.AuthenticationFailErr("test")).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.AuthenticateWithRecoveryCode(ctx, &pb.AuthenticateWithRecoveryCodeRequest{Username: "test", Code: "wrong"})
+
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = authentication failed")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_Introspect_active(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	token := "token"
+	exp := time.Now().Add(time.Minute)
+	claims := &jwt.Claims{
+		RegisteredClaims: gojwt.RegisteredClaims{
+			Subject:   "test",
+			Issuer:    "issuer",
+			Audience:  gojwt.ClaimStrings{"audience"},
+			ExpiresAt: gojwt.NewNumericDate(exp),
+		},
+		Scope: "read write",
+		Roles: []string{"admin"},
+	}
+
+	mockAuthentication.EXPECT().Introspect(ctx, token).Return(claims, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.Introspect(ctx, &pb.IntrospectRequest{Token: token})
+
+	require.NoError(t, err)
+	require.True(t, response.Active)
+	require.Equal(t, "test", response.Subject)
+	require.Equal(t, "issuer", response.Issuer)
+	require.Equal(t, []string{"audience"}, response.Audience)
+	require.Equal(t, []string{"read", "write"}, response.Scopes)
+	require.Equal(t, []string{"admin"}, response.Roles)
+	require.Equal(t, exp.Unix(), response.ExpiresAt)
+}
+
+func TestAuthServer_Introspect_inactive(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	token := "token"
+
+	mockAuthentication.EXPECT().Introspect(ctx, token).Return(nil, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.Introspect(ctx, &pb.IntrospectRequest{Token: token})
+
+	require.NoError(t, err)
+	require.False(t, response.Active)
+	require.Empty(t, response.Subject)
+}
+
+func TestAuthServer_Introspect_authService_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	token := "token"
+
+	mockAuthentication.EXPECT().Introspect(ctx, token).Return(nil, fmt.Errorf("unexpected")).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.Introspect(ctx, &pb.IntrospectRequest{Token: token})
+
+	require.Error(t, err)
+	require.EqualError(t, err, "rpc error: code = Unknown desc = unexpected")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_GetJWKS_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	jwks := jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{
+		{Kty: "RSA", Kid: "k1", Use: "sig", Alg: "RS256", N: "n", E: "AQAB"},
+		{Kty: "EC", Kid: "k2", Use: "sig", Alg: "ES256", Crv: "P-256", X: "x", Y: "y"},
+	}}
+
+	mockAuthentication.EXPECT().JWKS(ctx).Return(jwks, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.GetJWKS(ctx, &pb.GetJWKSRequest{})
+
+	require.NoError(t, err)
+	require.Len(t, response.Keys, 2)
+	require.Equal(t, "k1", response.Keys[0].Kid)
+	require.Equal(t, "AQAB", response.Keys[0].E)
+	require.Equal(t, "P-256", response.Keys[1].Crv)
+	require.Equal(t, "y", response.Keys[1].Y)
+}
+
+func TestAuthServer_GetJWKS_authService_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().JWKS(ctx).Return(jwt.JSONWebKeySet{}, fmt.Errorf("unexpected")).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.GetJWKS(ctx, &pb.GetJWKSRequest{})
+
+	require.Error(t, err)
+	require.EqualError(t, err, "rpc error: code = Unknown desc = unexpected")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_AssignRole_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockRoleService.EXPECT().AssignRole(ctx, "test", "admin").Return(nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.AssignRole(ctx, &pb.AssignRoleRequest{Username: " test ", Role: " admin "})
+
+	require.NoError(t, err)
+	require.True(t, response.Success)
+}
+
+func TestAuthServer_AssignRole_role_not_found(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockRoleService.EXPECT().AssignRole(ctx, "test", "admin").Return(errors.RoleNotFoundErr{Name: "admin"}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.AssignRole(ctx, &pb.AssignRoleRequest{Username: "test", Role: "admin"})
+
+	require.EqualError(t, err, "rpc error: code = NotFound desc = role admin not found")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_RevokeRole_not_assigned(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockRoleService.EXPECT().RevokeRole(ctx, "test", "admin").Return(errors.RoleNotAssignedErr{Name: "test", Role: "admin"}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.RevokeRole(ctx, &pb.RevokeRoleRequest{Username: "test", Role: "admin"})
+
+	require.EqualError(t, err, "rpc error: code = NotFound desc = role admin not assigned to user test")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_ListRoles_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	roles := []models.Role{{Name: "admin", Permissions: []string{"users:read", "users:write"}}, {Name: "guest"}}
+
+	mockRoleService.EXPECT().ListRoles(ctx, "test").Return(roles, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.ListRoles(ctx, &pb.ListRolesRequest{Username: "test"})
+
+	require.NoError(t, err)
+	require.Len(t, response.Roles, 2)
+	require.Equal(t, "admin", response.Roles[0].Name)
+	require.Equal(t, []string{"users:read", "users:write"}, response.Roles[0].Permissions)
+	require.Equal(t, "guest", response.Roles[1].Name)
+	require.Empty(t, response.Roles[1].Permissions)
+}
+
+func TestAuthServer_CreateAPIKey_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	created := time.Now().Truncate(time.Second)
+	apiKey := &models.APIKey{
+		Prefix:         "ak_1",
+		Hash:           "hash",
+		ServiceAccount: "billing",
+		Permissions:    []string{"invoices:write"},
+		ExpiresAt:      expiresAt,
+		CreatedAt:      created,
+	}
+
+	mockAPIKeyService.EXPECT().CreateAPIKey(ctx, "billing", []string{"invoices:write"}, expiresAt).Return(apiKey, "ak_1_secret", nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.CreateAPIKey(ctx, &pb.CreateAPIKeyRequest{
+		ServiceAccount: " billing ",
+		Permissions:    []string{"invoices:write "},
+		ExpiresAt:      expiresAt.Unix(),
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "ak_1_secret", response.Key)
+	require.Equal(t, "ak_1", response.ApiKey.Prefix)
+	require.Equal(t, "billing", response.ApiKey.ServiceAccount)
+	require.Equal(t, []string{"invoices:write"}, response.ApiKey.Permissions)
+	require.Equal(t, expiresAt.Unix(), response.ApiKey.ExpiresAt)
+	require.Equal(t, created.Unix(), response.ApiKey.CreatedAt)
+}
+
+func TestAuthServer_ListAPIKeys_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	keys := []models.APIKey{
+		{Prefix: "ak_1", ServiceAccount: "billing", Revoked: true},
+		{Prefix: "ak_2", ServiceAccount: "reports", Permissions: []string{"users:read"}},
+	}
+
+	mockAPIKeyService.EXPECT().ListAPIKeys(ctx, "").Return(keys, nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.ListAPIKeys(ctx, &pb.ListAPIKeysRequest{})
+
+	require.NoError(t, err)
+	require.Len(t, response.ApiKeys, 2)
+	require.True(t, response.ApiKeys[0].Revoked)
+	require.Zero(t, response.ApiKeys[0].ExpiresAt)
+	require.Equal(t, "reports", response.ApiKeys[1].ServiceAccount)
+	require.Equal(t, []string{"users:read"}, response.ApiKeys[1].Permissions)
+}
+
+func TestAuthServer_RevokeAPIKey_not_found(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAPIKeyService.EXPECT().RevokeAPIKey(ctx, "ak_1").Return(errors.APIKeyNotFoundErr{Prefix: "ak_1"}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.RevokeAPIKey(ctx, &pb.RevokeAPIKeyRequest{Prefix: "ak_1"})
+
+	require.EqualError(t, err, "rpc error: code = NotFound desc = API key ak_1 not found")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_ExchangeAPIKey_invalid(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockAuthentication.EXPECT().ExchangeAPIKey(ctx, "ak_1_secret").Return("", errors.InvalidAPIKeyErr{}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.ExchangeAPIKey(ctx, &pb.ExchangeAPIKeyRequest{Key: "ak_1_secret"})
+
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid API key")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_CreateOAuthClient_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockOAuthService.EXPECT().CreateClient(ctx, "billing", []string{"invoices:write"}, []string{"https://billing.example.com"}, []string{"https://billing.example.com/callback"}).Return("secret", nil).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.CreateOAuthClient(ctx, &pb.CreateOAuthClientRequest{
+		ClientId:     " billing ",
+		Scopes:       []string{"invoices:write "},
+		Audiences:    []string{" https://billing.example.com"},
+		RedirectUris: []string{"https://billing.example.com/callback "},
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "secret", response.ClientSecret)
+}
+
+func TestAuthServer_CreateOAuthClient_already_exist(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockOAuthService.EXPECT().CreateClient(ctx, "billing", []string{}, []string{}, []string{}).Return("", errors.OAuthClientAlreadyExistErr{ID: "billing"}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.CreateOAuthClient(ctx, &pb.CreateOAuthClientRequest{ClientId: "billing"})
+
+	require.EqualError(t, err, "rpc error: code = AlreadyExists desc = OAuth client billing already exists")
+	require.Empty(t, response)
+}
+
+func TestAuthServer_DeleteOAuthClient_not_found(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockOAuthService.EXPECT().DeleteClient(ctx, "billing").Return(errors.OAuthClientNotFoundErr{ID: "billing"}).Times(1)
+	server := NewAuthServer(mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService)
+
+	response, err := server.DeleteOAuthClient(ctx, &pb.DeleteOAuthClientRequest{ClientId: "billing"})
+
+	require.EqualError(t, err, "rpc error: code = NotFound desc = OAuth client billing not found")
+	require.Empty(t, response)
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"auth/pkg/services"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type httpHandler struct {
+	authService  services.AuthService
+	oauthService services.OAuthService
+	oidcService  services.OIDCService
+	logger       *zap.Logger
+}
+
+// NewHTTPHandler creates a new http.Handler serving for each realm:
+//   - the JSON Web Key Set of its services.AuthService on /realms/<realm>/.well-known/jwks.json
+//   - the OAuth token endpoint of its services.OAuthService on /realms/<realm>/oauth/token
+//   - the OpenID Connect discovery document, authorization and userinfo endpoints of its services.OIDCService
+//     on /realms/<realm>/.well-known/openid-configuration, /realms/<realm>/authorize and /realms/<realm>/userinfo
+//
+// The config.DefaultRealm is also served on the same paths without the /realms/<realm> prefix.
+func NewHTTPHandler(realms map[string]Realm) http.Handler {
+	logger := zap.L().Named("HTTPAuthServer")
+	mux := http.NewServeMux()
+	for name, realm := range realms {
+		h := &httpHandler{
+			authService:  realm.AuthService,
+			oauthService: realm.OAuthService,
+			oidcService:  realm.OIDCService,
+			logger:       logger.With(zap.String("realm", name)),
+		}
+		prefixes := []string{"/realms/" + name}
+		if name == config.DefaultRealm {
+			prefixes = append(prefixes, "")
+		}
+		for _, prefix := range prefixes {
+			mux.HandleFunc(prefix+"/.well-known/jwks.json", h.jwks)
+			mux.HandleFunc(prefix+"/oauth/token", h.token)
+			mux.HandleFunc(prefix+"/.well-known/openid-configuration", h.openIDConfiguration)
+			mux.HandleFunc(prefix+"/authorize", h.authorize)
+			mux.HandleFunc(prefix+"/userinfo", h.userInfo)
+		}
+	}
+	return withPeer(mux)
+}
+
+// withPeer sets the address of the HTTP client as the gRPC peer of the requests,
+// so the services throttle the logins of the HTTP endpoints by address like the gRPC logins.
+func withPeer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.RemoteAddr != "" {
+			r = r.WithContext(peer.NewContext(r.Context(), &peer.Peer{Addr: remoteAddr(r.RemoteAddr)}))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// remoteAddr is the host:port address of an HTTP client.
+type remoteAddr string
+
+func (a remoteAddr) Network() string { return "tcp" }
+
+func (a remoteAddr) String() string { return string(a) }
+
+func (h *httpHandler) jwks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	jwks, err := h.authService.JWKS(r.Context())
+	if err != nil {
+		h.logger.Error("unknown error", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(jwks); err != nil {
+		h.logger.Error("error writing the response", zap.Error(err))
+	}
+}
+
+// tokenResponse is the successful response of the OAuth token endpoint, RFC 6749 section 5.1
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// tokenError is the error response of the OAuth token endpoint, RFC 6749 section 5.2
+type tokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// token issues the tokens of the client_credentials, password and authorization_code grants, the parameters are form encoded
+// in the body of a POST request. The client authenticates with HTTP Basic or the client_id and client_secret parameters.
+func (h *httpHandler) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		h.writeTokenError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	clientID, clientSecret, basic := clientCredentials(r)
+
+	var token *models.OAuthToken
+	var err error
+	switch grantType := r.PostForm.Get("grant_type"); grantType {
+	case "client_credentials":
+		h.logger.Debug("client_credentials grant called", zap.String("clientID", clientID))
+		token, err = h.oauthService.ClientCredentials(r.Context(), clientID, clientSecret, strings.Fields(r.PostForm.Get("scope")), r.PostForm.Get("audience"))
+	case "password":
+		h.logger.Debug("password grant called", zap.String("clientID", clientID))
+		username, password := r.PostForm.Get("username"), r.PostForm.Get("password")
+		if username == "" || password == "" {
+			h.writeTokenError(w, http.StatusBadRequest, "invalid_request", "username and password required")
+			return
+		}
+		token, err = h.oauthService.Password(r.Context(), clientID, clientSecret, username, password)
+	case "authorization_code":
+		h.logger.Debug("authorization_code grant called", zap.String("clientID", clientID))
+		code := r.PostForm.Get("code")
+		if code == "" {
+			h.writeTokenError(w, http.StatusBadRequest, "invalid_request", "code required")
+			return
+		}
+		token, err = h.oidcService.ExchangeCode(r.Context(), clientID, clientSecret, code, r.PostForm.Get("redirect_uri"), r.PostForm.Get("code_verifier"))
+	case "":
+		h.writeTokenError(w, http.StatusBadRequest, "invalid_request", "grant_type required")
+		return
+	default:
+		h.writeTokenError(w, http.StatusBadRequest, "unsupported_grant_type", "grant type "+grantType+" not supported")
+		return
+	}
+	if err != nil {
+		h.writeGrantError(w, err, basic)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	err = json.NewEncoder(w).Encode(tokenResponse{
+		AccessToken:  token.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(token.ExpiresIn.Seconds()),
+		RefreshToken: token.RefreshToken,
+		IDToken:      token.IDToken,
+		Scope:        strings.Join(token.Scopes, " "),
+	})
+	if err != nil {
+		h.logger.Error("error writing the response", zap.Error(err))
+	}
+}
+
+// clientCredentials returns the credentials of the client from the HTTP Basic authorization, whose id and secret
+// are form encoded, or else from the client_id and client_secret parameters. basic is true for HTTP Basic.
+func clientCredentials(r *http.Request) (id, secret string, basic bool) {
+	if id, secret, ok := r.BasicAuth(); ok {
+		if unescaped, err := url.QueryUnescape(id); err == nil {
+			id = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(secret); err == nil {
+			secret = unescaped
+		}
+		return id, secret, true
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret"), false
+}
+
+// errorDescriptions are the fixed descriptions of the OAuth errors, RFC 6749 section 5.2 and RFC 8707 section 2.
+// The details of the errors are only logged, they would tell the clients which users, clients or scopes exist.
+var errorDescriptions = map[string]string{
+	"invalid_request": "The request is missing a required parameter, includes an invalid parameter value, or is otherwise malformed.",
+	"invalid_client":  "Client authentication failed.",
+	"invalid_grant":   "The authorization grant or the resource owner credentials are invalid, expired or revoked.",
+	"invalid_scope":   "The requested scope is invalid, unknown, or exceeds the scope granted to the client.",
+	"invalid_target":  "The requested audience is invalid or unknown.",
+}
+
+// writeGrantError writes the OAuth error of the services.OAuthService error with its fixed description. The failed
+// authentications of the users are invalid grants, the errors without gRPC status are failures of the service.
+func (h *httpHandler) writeGrantError(w http.ResponseWriter, err error, basic bool) {
+	var (
+		clientErr   autherrors.InvalidClientErr
+		scopeErr    autherrors.InvalidScopeErr
+		audienceErr autherrors.InvalidAudienceErr
+	)
+	code, oauthErr := http.StatusBadRequest, ""
+	switch {
+	case errors.As(err, &clientErr):
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		code, oauthErr = http.StatusUnauthorized, "invalid_client"
+	case errors.As(err, &scopeErr):
+		oauthErr = "invalid_scope"
+	case errors.As(err, &audienceErr):
+		oauthErr = "invalid_target"
+	default:
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			oauthErr = "invalid_request"
+		case codes.Unauthenticated, codes.PermissionDenied, codes.FailedPrecondition, codes.ResourceExhausted:
+			oauthErr = "invalid_grant"
+		default:
+			h.logger.Error("unknown error", zap.Error(err))
+			h.writeTokenError(w, http.StatusInternalServerError, "server_error", "")
+			return
+		}
+	}
+	h.logger.Info("grant refused", zap.String("error", oauthErr), zap.Error(err))
+	h.writeTokenError(w, code, oauthErr, errorDescriptions[oauthErr])
+}
+
+func (h *httpHandler) writeTokenError(w http.ResponseWriter, code int, oauthErr, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(tokenError{Error: oauthErr, ErrorDescription: description}); err != nil {
+		h.logger.Error("error writing the response", zap.Error(err))
+	}
+}
@@ -0,0 +1,250 @@
+package server
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/errors"
+	"auth/pkg/jwt"
+	"auth/pkg/models"
+	"auth/pkg/tests"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/peer"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// httpRealms returns the Realm of the config.DefaultRealm with the mock services.
+func httpRealms() map[string]Realm {
+	return map[string]Realm{config.DefaultRealm: {AuthService: mockAuthentication, OAuthService: mockOAuthService, OIDCService: mockOIDCService}}
+}
+
+// postForm returns a POST request of the form to the path.
+func postForm(path string, form url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestHTTPHandler_jwks(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	jwks := jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{{Kty: "OKP", Kid: "k1", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: "x"}}}
+	mockAuthentication.EXPECT().JWKS(gomock.Any()).Return(jwks, nil).Times(1)
+	handler := NewHTTPHandler(httpRealms())
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	var got jwt.JSONWebKeySet
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&got))
+	require.Equal(t, jwks, got)
+}
+
+func TestHTTPHandler_jwks_method_not_allowed(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	mockAuthentication.EXPECT().JWKS(gomock.Any()).Times(0)
+	handler := NewHTTPHandler(httpRealms())
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/.well-known/jwks.json", nil))
+
+	require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
+
+func TestHTTPHandler_jwks_authService_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	mockAuthentication.EXPECT().JWKS(gomock.Any()).Return(jwt.JSONWebKeySet{}, fmt.Errorf("unexpected")).Times(1)
+	handler := NewHTTPHandler(httpRealms())
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil).WithContext(context.Background()))
+
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestHTTPHandler_jwks_realm(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	jwks := jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{{Kty: "oct", Kid: "acme"}}}
+	mockAuthentication.EXPECT().JWKS(gomock.Any()).Times(0)
+	acme := tests.NewMockAuthService(gomock.NewController(t))
+	acme.EXPECT().JWKS(gomock.Any()).Return(jwks, nil).Times(1)
+	handler := NewHTTPHandler(map[string]Realm{"default": {AuthService: mockAuthentication}, "acme": {AuthService: acme}})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/realms/acme/.well-known/jwks.json", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var got jwt.JSONWebKeySet
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&got))
+	require.Equal(t, jwks, got)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/realms/unknown/.well-known/jwks.json", nil))
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestHTTPHandler_token_client_credentials(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	token := &models.OAuthToken{AccessToken: "sdjklfjasdkl.jfsda.fasdf", ExpiresIn: 5 * time.Minute, Scopes: []string{"invoices:read"}}
+	mockOAuthService.EXPECT().ClientCredentials(gomock.Any(), "billing", "s3cr:t", []string{"invoices:read"}, "https://billing.example.com").Return(token, nil).Times(1)
+	handler := NewHTTPHandler(httpRealms())
+
+	request := postForm("/oauth/token", url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"invoices:read"},
+		"audience":   {"https://billing.example.com"},
+	})
+	request.SetBasicAuth("billing", url.QueryEscape("s3cr:t"))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	require.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+	require.JSONEq(t, `{"access_token":"sdjklfjasdkl.jfsda.fasdf","token_type":"Bearer","expires_in":300,"scope":"invoices:read"}`, recorder.Body.String())
+}
+
+func TestHTTPHandler_token_password(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	token := &models.OAuthToken{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 5 * time.Minute}
+	mockOAuthService.EXPECT().Password(gomock.Any(), "web", "secret", "test", "password").Return(token, nil).Times(1)
+	handler := NewHTTPHandler(httpRealms())
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, postForm("/oauth/token", url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {"web"},
+		"client_secret": {"secret"},
+		"username":      {"test"},
+		"password":      {"password"},
+	}))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"access_token":"access","token_type":"Bearer","expires_in":300,"refresh_token":"refresh"}`, recorder.Body.String())
+}
+
+func TestHTTPHandler_token_password_peer(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	// The address of the client is the peer of the login, so the logins are throttled by address.
+	token := &models.OAuthToken{AccessToken: "access", ExpiresIn: 5 * time.Minute}
+	mockOAuthService.EXPECT().Password(gomock.Any(), "web", "secret", "test", "password").DoAndReturn(
+		func(ctx context.Context, _, _, _, _ string) (*models.OAuthToken, error) {
+			p, ok := peer.FromContext(ctx)
+			require.True(t, ok)
+			require.Equal(t, "10.0.0.1:5000", p.Addr.String())
+			return token, nil
+		}).Times(1)
+	handler := NewHTTPHandler(httpRealms())
+
+	request := postForm("/oauth/token", url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {"web"},
+		"client_secret": {"secret"},
+		"username":      {"test"},
+		"password":      {"password"},
+	})
+	request.RemoteAddr = "10.0.0.1:5000"
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestHTTPHandler_token_errors(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	gomock.InOrder(
+		mockOAuthService.EXPECT().ClientCredentials(gomock.Any(), "billing", "wrong", []string{}, "").Return(nil, errors.InvalidClientErr{}).Times(1),
+		mockOAuthService.EXPECT().ClientCredentials(gomock.Any(), "billing", "secret", []string{"users:write"}, "").Return(nil, errors.InvalidScopeErr{Scope: "users:write"}).Times(1),
+		mockOAuthService.EXPECT().ClientCredentials(gomock.Any(), "billing", "secret", []string{}, "other").Return(nil, errors.InvalidAudienceErr{Audience: "other"}).Times(1),
+		mockOAuthService.EXPECT().Password(gomock.Any(), "web", "secret", "test", "wrong").Return(nil, errors.AuthenticationFailErr("test")).Times(1),
+		mockOAuthService.EXPECT().Password(gomock.Any(), "web", "secret", "test", "password").Return(nil, fmt.Errorf("unexpected")).Times(1),
+	)
+	handler := NewHTTPHandler(httpRealms())
+
+	for _, tc := range []struct {
+		name        string
+		form        url.Values
+		code        int
+		error       string
+		description string
+	}{
+		{"invalid client", url.Values{"grant_type": {"client_credentials"}, "client_id": {"billing"}, "client_secret": {"wrong"}}, http.StatusUnauthorized, "invalid_client", "Client authentication failed."},
+		{"invalid scope", url.Values{"grant_type": {"client_credentials"}, "client_id": {"billing"}, "client_secret": {"secret"}, "scope": {"users:write"}}, http.StatusBadRequest, "invalid_scope", "The requested scope is invalid, unknown, or exceeds the scope granted to the client."},
+		{"invalid audience", url.Values{"grant_type": {"client_credentials"}, "client_id": {"billing"}, "client_secret": {"secret"}, "audience": {"other"}}, http.StatusBadRequest, "invalid_target", "The requested audience is invalid or unknown."},
+		{"invalid grant", url.Values{"grant_type": {"password"}, "client_id": {"web"}, "client_secret": {"secret"}, "username": {"test"}, "password": {"wrong"}}, http.StatusBadRequest, "invalid_grant", "The authorization grant or the resource owner credentials are invalid, expired or revoked."},
+		{"server error", url.Values{"grant_type": {"password"}, "client_id": {"web"}, "client_secret": {"secret"}, "username": {"test"}, "password": {"password"}}, http.StatusInternalServerError, "server_error", ""},
+		{"missing grant type", url.Values{}, http.StatusBadRequest, "invalid_request", "grant_type required"},
+		{"missing password", url.Values{"grant_type": {"password"}, "username": {"test"}}, http.StatusBadRequest, "invalid_request", "username and password required"},
+		{"missing code", url.Values{"grant_type": {"authorization_code"}, "client_id": {"web"}, "client_secret": {"secret"}}, http.StatusBadRequest, "invalid_request", "code required"},
+		{"unsupported grant type", url.Values{"grant_type": {"implicit"}}, http.StatusBadRequest, "unsupported_grant_type", "grant type implicit not supported"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, postForm("/oauth/token", tc.form))
+
+			require.Equal(t, tc.code, recorder.Code)
+			require.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+			var got map[string]string
+			require.NoError(t, json.NewDecoder(recorder.Body).Decode(&got))
+			require.Equal(t, tc.error, got["error"])
+			require.Equal(t, tc.description, got["error_description"])
+		})
+	}
+}
+
+func TestHTTPHandler_token_method_not_allowed(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	handler := NewHTTPHandler(httpRealms())
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/oauth/token?grant_type=client_credentials", nil))
+
+	require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	require.Equal(t, "POST", recorder.Header().Get("Allow"))
+}
+
+func TestHTTPHandler_token_realm(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	acme := tests.NewMockOAuthService(gomock.NewController(t))
+	acme.EXPECT().ClientCredentials(gomock.Any(), "billing", "secret", []string{}, "").Return(&models.OAuthToken{AccessToken: "acme"}, nil).Times(1)
+	mockOAuthService.EXPECT().ClientCredentials(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	handler := NewHTTPHandler(map[string]Realm{"default": {OAuthService: mockOAuthService}, "acme": {OAuthService: acme}})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, postForm("/realms/acme/oauth/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"billing"},
+		"client_secret": {"secret"},
+	}))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `"access_token":"acme"`)
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/pb"
+	"auth/pkg/services"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// Policy is the access policy of an RPC.
+type Policy struct {
+	// Public RPCs are called without token. The RPCs receiving the token in their request are public,
+	// they check the token themselves.
+	Public bool
+	// Roles allowed to call the RPC, the token must carry one of them. Any authenticated user is allowed if empty.
+	Roles []string
+}
+
+var (
+	// PublicPolicy allows the calls without token.
+	PublicPolicy = Policy{Public: true}
+	// AuthenticatedPolicy allows the calls with a valid token.
+	AuthenticatedPolicy = Policy{}
+)
+
+// RequireRole returns a Policy allowing the calls with a valid token carrying one of the roles.
+func RequireRole(roles ...string) Policy {
+	return Policy{Roles: roles}
+}
+
+// Principal is the authenticated user calling an RPC.
+type Principal struct {
+	Realm    string
+	Username string
+	Roles    []string
+	Scopes   []string
+}
+
+// HasRole returns true if the principal has one of the roles.
+func (p *Principal) HasRole(roles ...string) bool {
+	for _, r := range p.Roles {
+		for _, role := range roles {
+			if r == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a copy of the context carrying the principal.
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal of the RPC, or nil if the RPC was called without token.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// MethodName returns the full gRPC method name of the RPC of the pb.AuthServer, like /auth.auth/CreateUser.
+func MethodName(rpc string) string {
+	return "/" + pb.Auth_ServiceDesc.ServiceName + "/" + rpc
+}
+
+// DefaultPolicies returns the policies of the RPCs of the pb.AuthServer. The RPCs administering the users,
+// the roles, the API keys and the OAuth clients require the adminRole.
+func DefaultPolicies(adminRole string) map[string]Policy {
+	admin := RequireRole(adminRole)
+	return map[string]Policy{
+		MethodName("CreateUser"):                   PublicPolicy,
+		MethodName("GetUser"):                      admin,
+		MethodName("UpdateUser"):                   admin,
+		MethodName("DeleteUser"):                   admin,
+		MethodName("ListUsers"):                    admin,
+		MethodName("Authenticate"):                 PublicPolicy,
+		MethodName("RefreshToken"):                 PublicPolicy,
+		MethodName("Logout"):                       PublicPolicy,
+		MethodName("ChangePassword"):               PublicPolicy,
+		MethodName("RequestPasswordReset"):         PublicPolicy,
+		MethodName("ResetPassword"):                PublicPolicy,
+		MethodName("Introspect"):                   PublicPolicy,
+		MethodName("GetJWKS"):                      PublicPolicy,
+		MethodName("UnlockUser"):                   admin,
+		MethodName("EnrollTOTP"):                   PublicPolicy,
+		MethodName("ConfirmTOTP"):                  PublicPolicy,
+		MethodName("VerifyMFA"):                    PublicPolicy,
+		MethodName("GenerateRecoveryCodes"):        PublicPolicy,
+		MethodName("CountRecoveryCodes"):           PublicPolicy,
+		MethodName("AuthenticateWithRecoveryCode"): PublicPolicy,
+		MethodName("AssignRole"):                   admin,
+		MethodName("RevokeRole"):                   admin,
+		MethodName("ListRoles"):                    admin,
+		MethodName("CreateAPIKey"):                 admin,
+		MethodName("ListAPIKeys"):                  admin,
+		MethodName("RevokeAPIKey"):                 admin,
+		MethodName("ExchangeAPIKey"):               PublicPolicy,
+		MethodName("CreateOAuthClient"):            admin,
+		MethodName("DeleteOAuthClient"):            admin,
+	}
+}
+
+// AuthInterceptor authorizes the RPCs with the bearer token of the authorization metadata
+// and the policy of the method. The methods without policy are denied, a new RPC must be given a policy.
+// The requests to an unknown realm are rejected, the tokens are verified by the realm of the request.
+type AuthInterceptor struct {
+	realms   map[string]services.AuthService
+	policies map[string]Policy
+	logger   *zap.Logger
+}
+
+// NewAuthInterceptor creates a new instance of AuthInterceptor verifying the tokens with the services.AuthService
+// of each realm.
+func NewAuthInterceptor(realms map[string]services.AuthService, policies map[string]Policy) *AuthInterceptor {
+	return &AuthInterceptor{
+		realms:   realms,
+		policies: policies,
+		logger:   zap.L().Named("AuthInterceptor"),
+	}
+}
+
+// Unary returns the grpc.UnaryServerInterceptor authorizing the unary RPCs.
+func (i *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := i.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, status.Convert(err).Err()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns the grpc.StreamServerInterceptor authorizing the streaming RPCs.
+func (i *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return status.Convert(err).Err()
+		}
+		return handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorize checks the realm of the request and the bearer token against the policy of the method,
+// and returns the context with the principal. The token of a public method is not checked.
+func (i *AuthInterceptor) authorize(ctx context.Context, method string) (context.Context, error) {
+	policy, ok := i.policies[method]
+	if !ok {
+		i.logger.Warn("method without policy called", zap.String("method", method))
+		return nil, autherrors.PermissionDeniedErr{Method: method}
+	}
+	realm := RealmName(ctx)
+	authService, ok := i.realms[realm]
+	if !ok {
+		return nil, autherrors.RealmNotFoundErr{Name: realm}
+	}
+	if policy.Public {
+		return ctx, nil
+	}
+
+	token := bearerToken(ctx)
+	if token == "" {
+		return nil, autherrors.MissingTokenErr{}
+	}
+	claims, err := authService.Introspect(ctx, token)
+	if err != nil {
+		i.logger.Error("error verifying the token", zap.Error(err))
+		return nil, err
+	}
+	if claims == nil {
+		return nil, autherrors.NewInvalidTokenErr(fmt.Errorf("inactive token"))
+	}
+	// The tokens issued by an account recovery only change the password.
+	if claims.PasswordChange {
+		return nil, autherrors.PasswordChangeRequiredErr{Name: claims.Subject}
+	}
+
+	principal := &Principal{Realm: realm, Username: claims.Subject, Roles: claims.Roles, Scopes: claims.Scopes()}
+	if len(policy.Roles) > 0 && !principal.HasRole(policy.Roles...) {
+		return nil, autherrors.PermissionDeniedErr{Name: principal.Username, Method: method}
+	}
+
+	return ContextWithPrincipal(ctx, principal), nil
+}
+
+// bearerToken returns the token of the authorization metadata "Bearer <token>", or an empty string.
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, value := range md.Get("authorization") {
+		scheme, token, found := strings.Cut(value, " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
+
+// authorizedStream is a grpc.ServerStream with the context carrying the principal.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
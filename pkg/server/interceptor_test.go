@@ -0,0 +1,218 @@
+package server
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/jwt"
+	"auth/pkg/pb"
+	"auth/pkg/services"
+	"auth/pkg/tests"
+	"context"
+	gojwt "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+// defaultRealm returns the services.AuthService of the config.DefaultRealm only.
+func defaultRealm(authService services.AuthService) map[string]services.AuthService {
+	return map[string]services.AuthService{config.DefaultRealm: authService}
+}
+
+func bearerContext(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+// callUnary calls the unary interceptor with a handler returning the principal of its context.
+func callUnary(interceptor *AuthInterceptor, ctx context.Context, method string) (*Principal, error) {
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	response, err := interceptor.Unary()(ctx, nil, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return PrincipalFromContext(ctx), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.(*Principal), nil
+}
+
+func TestDefaultPolicies_all_methods(t *testing.T) {
+	policies := DefaultPolicies("admin")
+	for _, method := range pb.Auth_ServiceDesc.Methods {
+		_, ok := policies[MethodName(method.MethodName)]
+		require.True(t, ok, "no policy for %s", method.MethodName)
+	}
+	require.Len(t, policies, len(pb.Auth_ServiceDesc.Methods))
+}
+
+func TestAuthInterceptor_public(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	interceptor := NewAuthInterceptor(defaultRealm(mockAuthentication), DefaultPolicies("admin"))
+
+	principal, err := callUnary(interceptor, context.Background(), MethodName("Authenticate"))
+
+	require.NoError(t, err)
+	require.Nil(t, principal)
+}
+
+func TestAuthInterceptor_unknown_method(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	interceptor := NewAuthInterceptor(defaultRealm(mockAuthentication), DefaultPolicies("admin"))
+
+	_, err := callUnary(interceptor, bearerContext("token"), MethodName("NewAdminMethod"))
+
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestAuthInterceptor_missing_token(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	interceptor := NewAuthInterceptor(defaultRealm(mockAuthentication), DefaultPolicies("admin"))
+
+	_, err := callUnary(interceptor, context.Background(), MethodName("ListUsers"))
+
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = missing bearer token")
+}
+
+func TestAuthInterceptor_inactive_token(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := bearerContext("token")
+	mockAuthentication.EXPECT().Introspect(ctx, "token").Return(nil, nil).Times(1)
+	interceptor := NewAuthInterceptor(defaultRealm(mockAuthentication), DefaultPolicies("admin"))
+
+	_, err := callUnary(interceptor, ctx, MethodName("ListUsers"))
+
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid token")
+}
+
+func TestAuthInterceptor_missing_role(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := bearerContext("token")
+	claims := &jwt.Claims{RegisteredClaims: gojwt.RegisteredClaims{Subject: "test"}, Roles: []string{"viewer"}}
+	mockAuthentication.EXPECT().Introspect(ctx, "token").Return(claims, nil).Times(1)
+	interceptor := NewAuthInterceptor(defaultRealm(mockAuthentication), DefaultPolicies("admin"))
+
+	_, err := callUnary(interceptor, ctx, MethodName("ListUsers"))
+
+	require.EqualError(t, err, "rpc error: code = PermissionDenied desc = permission denied")
+}
+
+func TestAuthInterceptor_password_change_token(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := bearerContext("token")
+	claims := &jwt.Claims{RegisteredClaims: gojwt.RegisteredClaims{Subject: "test"}, Roles: []string{"admin"}, PasswordChange: true}
+	mockAuthentication.EXPECT().Introspect(ctx, "token").Return(claims, nil).Times(1)
+	interceptor := NewAuthInterceptor(defaultRealm(mockAuthentication), DefaultPolicies("admin"))
+
+	_, err := callUnary(interceptor, ctx, MethodName("ListUsers"))
+
+	require.EqualError(t, err, "rpc error: code = PermissionDenied desc = password change required")
+}
+
+func TestAuthInterceptor_authorized(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := bearerContext("token")
+	claims := &jwt.Claims{RegisteredClaims: gojwt.RegisteredClaims{Subject: "test"}, Roles: []string{"viewer", "admin"}, Scope: "users:read"}
+	mockAuthentication.EXPECT().Introspect(ctx, "token").Return(claims, nil).Times(1)
+	interceptor := NewAuthInterceptor(defaultRealm(mockAuthentication), DefaultPolicies("admin"))
+
+	principal, err := callUnary(interceptor, ctx, MethodName("ListUsers"))
+
+	require.NoError(t, err)
+	require.Equal(t, &Principal{Realm: config.DefaultRealm, Username: "test", Roles: []string{"viewer", "admin"}, Scopes: []string{"users:read"}}, principal)
+}
+
+func TestAuthInterceptor_authenticated_policy(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "bearer token"))
+	claims := &jwt.Claims{RegisteredClaims: gojwt.RegisteredClaims{Subject: "test"}}
+	mockAuthentication.EXPECT().Introspect(ctx, "token").Return(claims, nil).Times(1)
+	interceptor := NewAuthInterceptor(defaultRealm(mockAuthentication), map[string]Policy{"/test/Method": AuthenticatedPolicy})
+
+	principal, err := callUnary(interceptor, ctx, "/test/Method")
+
+	require.NoError(t, err)
+	require.Equal(t, "test", principal.Username)
+}
+
+func TestAuthInterceptor_unknown_realm(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RealmMetadataKey, "unknown"))
+	interceptor := NewAuthInterceptor(defaultRealm(mockAuthentication), DefaultPolicies("admin"))
+
+	_, err := callUnary(interceptor, ctx, MethodName("Authenticate"))
+
+	require.EqualError(t, err, "rpc error: code = InvalidArgument desc = realm unknown not found")
+}
+
+func TestAuthInterceptor_realm(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer token", RealmMetadataKey, "acme"))
+	claims := &jwt.Claims{RegisteredClaims: gojwt.RegisteredClaims{Subject: "test"}, Roles: []string{"admin"}, Tenant: "acme"}
+	acme := tests.NewMockAuthService(gomock.NewController(t))
+	acme.EXPECT().Introspect(ctx, "token").Return(claims, nil).Times(1)
+	mockAuthentication.EXPECT().Introspect(gomock.Any(), gomock.Any()).Times(0)
+	realms := map[string]services.AuthService{config.DefaultRealm: mockAuthentication, "acme": acme}
+	interceptor := NewAuthInterceptor(realms, DefaultPolicies("admin"))
+
+	principal, err := callUnary(interceptor, ctx, MethodName("GetUser"))
+
+	require.NoError(t, err)
+	require.Equal(t, "acme", principal.Realm)
+	require.Equal(t, "test", principal.Username)
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestAuthInterceptor_stream(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := bearerContext("token")
+	claims := &jwt.Claims{RegisteredClaims: gojwt.RegisteredClaims{Subject: "test"}, Roles: []string{"admin"}}
+	mockAuthentication.EXPECT().Introspect(ctx, "token").Return(claims, nil).Times(1)
+	interceptor := NewAuthInterceptor(defaultRealm(mockAuthentication), map[string]Policy{"/test/Stream": RequireRole("admin")})
+
+	var principal *Principal
+	info := &grpc.StreamServerInfo{FullMethod: "/test/Stream"}
+	err := interceptor.Stream()(nil, &testServerStream{ctx: ctx}, info, func(_ interface{}, stream grpc.ServerStream) error {
+		principal = PrincipalFromContext(stream.Context())
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "test", principal.Username)
+
+	err = interceptor.Stream()(nil, &testServerStream{ctx: context.Background()}, info, func(interface{}, grpc.ServerStream) error {
+		return nil
+	})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = missing bearer token")
+}
@@ -0,0 +1,395 @@
+package server
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"auth/pkg/services"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// openIDConfiguration is the OpenID Provider Metadata of the discovery document, OpenID Connect Discovery 1.0 section 3
+type openIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+// openIDConfiguration serves the discovery document of the realm. The endpoints are relative to the issuer
+// of the realm, which is the URL the realm is served on.
+func (h *httpHandler) openIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	jwks, err := h.authService.JWKS(r.Context())
+	if err != nil {
+		h.logger.Error("unknown error", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	// Only the ID tokens signed by the published keys can be verified by the clients.
+	algs := make([]string, 0, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		if !contains(algs, key.Alg) {
+			algs = append(algs, key.Alg)
+		}
+	}
+
+	issuer := strings.TrimSuffix(h.oidcService.Issuer(), "/")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	err = json.NewEncoder(w).Encode(openIDConfiguration{
+		Issuer:                            h.oidcService.Issuer(),
+		AuthorizationEndpoint:             issuer + "/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		UserInfoEndpoint:                  issuer + "/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{"code"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algs,
+		ScopesSupported:                   []string{services.OpenIDScope, "profile", "email"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "preferred_username", "email"},
+		GrantTypesSupported:               []string{"authorization_code", "client_credentials", "password"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	})
+	if err != nil {
+		h.logger.Error("error writing the response", zap.Error(err))
+	}
+}
+
+var loginTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Sign in</title>
+<style>
+body { font-family: sans-serif; max-width: 22em; margin: 4em auto; padding: 0 1em; }
+label, input, button { display: block; width: 100%; box-sizing: border-box; }
+input { margin: .25em 0 1em; padding: .5em; }
+button { padding: .5em; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+{{if .Message}}
+<h1>Sign in failed</h1>
+<p class="error">{{.Message}}</p>
+{{else}}
+<h1>Sign in</h1>
+<p>to continue to {{.Request.ClientID}}</p>
+{{if .Error}}<p class="error" role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+<input type="hidden" name="response_type" value="code">
+<input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Scope}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+{{if .MFAToken}}
+<input type="hidden" name="mfa_token" value="{{.MFAToken}}">
+<label for="code">Authentication code</label>
+<input id="code" name="code" inputmode="numeric" autocomplete="one-time-code" required autofocus>
+{{else}}
+<label for="username">Username</label>
+<input id="username" name="username" autocomplete="username" required autofocus>
+<label for="password">Password</label>
+<input id="password" name="password" type="password" autocomplete="current-password" required>
+{{end}}
+<button type="submit">Sign in</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+// loginPage is the data of the loginTemplate: the login form of the Request, or the Message of an error
+// that cannot be returned to the client. The CSRFToken is set by renderLogin.
+type loginPage struct {
+	Action    string
+	Request   models.AuthorizationRequest
+	MFAToken  string
+	CSRFToken string
+	Error     string
+	Message   string
+}
+
+// Scope returns the scope parameter of the request.
+func (p loginPage) Scope() string {
+	return strings.Join(p.Request.Scopes, " ")
+}
+
+// authorize serves the authorization endpoint of the authorization code flow: a GET request renders the login page
+// of the authentication request, which posts the credentials of the user and then the TOTP code if the user
+// has multi-factor authentication. The user is redirected to the client with the authorization code.
+func (h *httpHandler) authorize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		h.renderLogin(w, r, http.StatusBadRequest, loginPage{Message: "Invalid request."})
+		return
+	}
+	req := models.AuthorizationRequest{
+		ClientID:            r.Form.Get("client_id"),
+		RedirectURI:         r.Form.Get("redirect_uri"),
+		Scopes:              strings.Fields(r.Form.Get("scope")),
+		State:               r.Form.Get("state"),
+		Nonce:               r.Form.Get("nonce"),
+		CodeChallenge:       r.Form.Get("code_challenge"),
+		CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+	}
+	h.logger.Debug("authorize called", zap.String("clientID", req.ClientID))
+
+	if err := h.oidcService.Authorize(r.Context(), req); err != nil {
+		h.writeAuthorizeError(w, r, req, err)
+		return
+	}
+	if responseType := r.Form.Get("response_type"); responseType != "code" {
+		redirectError(w, r, req, "unsupported_response_type", "response type "+responseType+" not supported")
+		return
+	}
+
+	page := loginPage{Action: r.URL.Path, Request: req}
+	if r.Method == http.MethodGet {
+		h.renderLogin(w, r, http.StatusOK, page)
+		return
+	}
+	// The login form is only posted by the login page of the browser, the other sites cannot sign the user in.
+	if !validCSRFToken(r) {
+		page.Error = "The sign in expired, please sign in again."
+		h.renderLogin(w, r, http.StatusForbidden, page)
+		return
+	}
+
+	var code string
+	var err error
+	if page.MFAToken = r.PostForm.Get("mfa_token"); page.MFAToken != "" {
+		code, err = h.oidcService.LoginMFA(r.Context(), req, page.MFAToken, r.PostForm.Get("code"))
+	} else {
+		code, page.MFAToken, err = h.oidcService.Login(r.Context(), req, r.PostForm.Get("username"), r.PostForm.Get("password"))
+		if err == nil && page.MFAToken != "" {
+			h.renderLogin(w, r, http.StatusOK, page)
+			return
+		}
+	}
+	if err != nil {
+		// The failed logins are shown on the login page, the users start over when the MFA token is no longer valid.
+		s, ok := status.FromError(err)
+		switch {
+		case errors.As(err, &autherrors.InvalidMFATokenErr{}):
+			page.MFAToken = ""
+			page.Error = "The sign in expired, please sign in again."
+			h.renderLogin(w, r, http.StatusUnauthorized, page)
+		case errors.As(err, &autherrors.AccountLockedErr{}):
+			page.MFAToken = ""
+			page.Error = s.Message()
+			h.renderLogin(w, r, http.StatusForbidden, page)
+		case ok && s.Code() == codes.Unauthenticated:
+			page.Error = s.Message()
+			h.renderLogin(w, r, http.StatusUnauthorized, page)
+		case ok && s.Code() == codes.ResourceExhausted:
+			page.Error = s.Message()
+			h.renderLogin(w, r, http.StatusTooManyRequests, page)
+		default:
+			h.writeAuthorizeError(w, r, req, err)
+		}
+		return
+	}
+
+	redirect(w, r, req.RedirectURI, url.Values{"code": {code}, "state": {req.State}})
+}
+
+// writeAuthorizeError renders the errors of the client and of the redirect URI on the login page, since the user
+// cannot be redirected to an unregistered URI. The other errors are returned to the client with the redirect,
+// RFC 6749 section 4.1.2.1
+func (h *httpHandler) writeAuthorizeError(w http.ResponseWriter, r *http.Request, req models.AuthorizationRequest, err error) {
+	var (
+		clientErr   autherrors.InvalidClientErr
+		redirectErr autherrors.InvalidRedirectURIErr
+		scopeErr    autherrors.InvalidScopeErr
+	)
+	switch {
+	case errors.As(err, &clientErr):
+		h.renderLogin(w, r, http.StatusBadRequest, loginPage{Message: "Unknown client."})
+	case errors.As(err, &redirectErr):
+		h.renderLogin(w, r, http.StatusBadRequest, loginPage{Message: "Invalid redirect URI."})
+	case errors.As(err, &scopeErr):
+		h.logger.Info("authorization refused", zap.String("error", "invalid_scope"), zap.Error(err))
+		redirectError(w, r, req, "invalid_scope", errorDescriptions["invalid_scope"])
+	case status.Code(err) == codes.InvalidArgument:
+		redirectError(w, r, req, "invalid_request", status.Convert(err).Message())
+	default:
+		h.logger.Error("unknown error", zap.Error(err))
+		redirectError(w, r, req, "server_error", "")
+	}
+}
+
+// redirectError redirects the user to the client with the OAuth error.
+func redirectError(w http.ResponseWriter, r *http.Request, req models.AuthorizationRequest, oauthErr, description string) {
+	params := url.Values{"error": {oauthErr}, "state": {req.State}}
+	if description != "" {
+		params.Set("error_description", description)
+	}
+	redirect(w, r, req.RedirectURI, params)
+}
+
+// redirect redirects the user to the redirect URI with the parameters added to its query, the empty parameters are omitted.
+func redirect(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	query := u.Query()
+	for name, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query.Set(name, values[0])
+		}
+	}
+	u.RawQuery = query.Encode()
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// renderLogin renders the login page, which cannot be framed by other sites nor load any other resource.
+// The login form holds the CSRF token of the cookie of the browser.
+func (h *httpHandler) renderLogin(w http.ResponseWriter, r *http.Request, code int, page loginPage) {
+	if page.Message == "" {
+		token, err := csrfToken(w, r)
+		if err != nil {
+			h.logger.Error("error generating the CSRF token", zap.Error(err))
+			code, page = http.StatusInternalServerError, loginPage{Message: "Internal error."}
+		}
+		page.CSRFToken = token
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	w.WriteHeader(code)
+	if err := loginTemplate.Execute(w, page); err != nil {
+		h.logger.Error("error writing the response", zap.Error(err))
+	}
+}
+
+// csrfCookie is the cookie of the CSRF token of the login form.
+const csrfCookie = "auth_csrf"
+
+// csrfTokenLength is the length of the base64 encoding of the 32 random bytes of a CSRF token.
+const csrfTokenLength = 43
+
+// csrfToken returns the CSRF token of the cookie of the browser, or a new token set in the cookie.
+// The cookie is only sent by the pages of the authorization endpoint.
+func csrfToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	if cookie, err := r.Cookie(csrfCookie); err == nil && len(cookie.Value) == csrfTokenLength {
+		return cookie.Value, nil
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    token,
+		Path:     r.URL.Path,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return token, nil
+}
+
+// validCSRFToken returns true if the CSRF token of the posted form is the token of the cookie of the browser.
+func validCSRFToken(r *http.Request) bool {
+	cookie, err := r.Cookie(csrfCookie)
+	if err != nil || len(cookie.Value) != csrfTokenLength {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.PostForm.Get("csrf_token"))) == 1
+}
+
+// userInfoResponse is the response of the userinfo endpoint, OpenID Connect Core 1.0 section 5.3.2
+type userInfoResponse struct {
+	Subject           string `json:"sub"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Email             string `json:"email,omitempty"`
+}
+
+// userInfo returns the claims of the user of the access token sent as bearer token, RFC 6750 section 2.1
+func (h *httpHandler) userInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="userinfo"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	info, err := h.oidcService.UserInfo(r.Context(), strings.TrimSpace(token))
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="userinfo", error="invalid_token"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		h.logger.Error("unknown error", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	err = json.NewEncoder(w).Encode(userInfoResponse{
+		Subject:           info.Subject,
+		PreferredUsername: info.PreferredUsername,
+		Email:             info.Email,
+	})
+	if err != nil {
+		h.logger.Error("error writing the response", zap.Error(err))
+	}
+}
+
+// contains returns true if the values contain the value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
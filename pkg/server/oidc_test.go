@@ -0,0 +1,398 @@
+package server
+
+import (
+	"auth/pkg/errors"
+	"auth/pkg/jwt"
+	"auth/pkg/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/peer"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// authorizationRequest is the authentication request of the web client sent to the authorization endpoint.
+var authorizationRequest = models.AuthorizationRequest{
+	ClientID:            "web",
+	RedirectURI:         "https://app.example.com/callback",
+	Scopes:              []string{"openid", "profile"},
+	State:               "xyz",
+	Nonce:               "n0nce",
+	CodeChallenge:       "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+	CodeChallengeMethod: "S256",
+}
+
+// authorizationParams returns the parameters of the authorizationRequest.
+func authorizationParams() url.Values {
+	return url.Values{
+		"response_type":         {"code"},
+		"client_id":             {authorizationRequest.ClientID},
+		"redirect_uri":          {authorizationRequest.RedirectURI},
+		"scope":                 {"openid profile"},
+		"state":                 {authorizationRequest.State},
+		"nonce":                 {authorizationRequest.Nonce},
+		"code_challenge":        {authorizationRequest.CodeChallenge},
+		"code_challenge_method": {authorizationRequest.CodeChallengeMethod},
+	}
+}
+
+// testCSRFToken is the CSRF token of the cookie of the browser and of its login form.
+var testCSRFToken = strings.Repeat("c", csrfTokenLength)
+
+// postLogin returns a POST request of the login form to the authorization endpoint, with the CSRF token of the browser.
+func postLogin(form url.Values) *http.Request {
+	form.Set("csrf_token", testCSRFToken)
+	r := postForm("/authorize", form)
+	r.AddCookie(&http.Cookie{Name: csrfCookie, Value: testCSRFToken})
+	return r
+}
+
+func TestHTTPHandler_openIDConfiguration(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	jwks := jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{{Kid: "k1", Alg: "ES256"}, {Kid: "k2", Alg: "ES256"}, {Kid: "k3", Alg: "EdDSA"}}}
+	mockAuthentication.EXPECT().JWKS(gomock.Any()).Return(jwks, nil).Times(1)
+	mockOIDCService.EXPECT().Issuer().Return("https://auth.example.com/realms/default").AnyTimes()
+	handler := NewHTTPHandler(httpRealms())
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/realms/default/.well-known/openid-configuration", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	var got openIDConfiguration
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&got))
+	require.Equal(t, "https://auth.example.com/realms/default", got.Issuer)
+	require.Equal(t, "https://auth.example.com/realms/default/authorize", got.AuthorizationEndpoint)
+	require.Equal(t, "https://auth.example.com/realms/default/oauth/token", got.TokenEndpoint)
+	require.Equal(t, "https://auth.example.com/realms/default/userinfo", got.UserInfoEndpoint)
+	require.Equal(t, "https://auth.example.com/realms/default/.well-known/jwks.json", got.JWKSURI)
+	require.Equal(t, []string{"ES256", "EdDSA"}, got.IDTokenSigningAlgValuesSupported)
+	require.Equal(t, []string{"S256"}, got.CodeChallengeMethodsSupported)
+}
+
+func TestHTTPHandler_authorize_login_page(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	mockOIDCService.EXPECT().Authorize(gomock.Any(), authorizationRequest).Return(nil).Times(1)
+	handler := NewHTTPHandler(httpRealms())
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/authorize?"+authorizationParams().Encode(), nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+	require.Equal(t, "DENY", recorder.Header().Get("X-Frame-Options"))
+	require.Contains(t, recorder.Header().Get("Content-Security-Policy"), "frame-ancestors 'none'")
+	body := recorder.Body.String()
+	require.Contains(t, body, `<form method="post" action="/authorize">`)
+	require.Contains(t, body, `name="redirect_uri" value="https://app.example.com/callback"`)
+	require.Contains(t, body, `name="scope" value="openid profile"`)
+	require.Contains(t, body, `name="password"`)
+
+	// The browser gets the CSRF token of the login form in a cookie.
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, csrfCookie, cookies[0].Name)
+	require.True(t, cookies[0].HttpOnly)
+	require.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+	require.Len(t, cookies[0].Value, csrfTokenLength)
+	require.Contains(t, body, `name="csrf_token" value="`+cookies[0].Value+`"`)
+
+	// The token of the cookie is kept.
+	request := httptest.NewRequest(http.MethodGet, "/authorize?"+authorizationParams().Encode(), nil)
+	request.AddCookie(&http.Cookie{Name: csrfCookie, Value: testCSRFToken})
+	mockOIDCService.EXPECT().Authorize(gomock.Any(), authorizationRequest).Return(nil).Times(1)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, recorder.Result().Cookies())
+	require.Contains(t, recorder.Body.String(), `name="csrf_token" value="`+testCSRFToken+`"`)
+}
+
+func TestHTTPHandler_authorize_login_csrf(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	// The user is not signed in by the forms posted without the CSRF token of the browser.
+	mockOIDCService.EXPECT().Authorize(gomock.Any(), authorizationRequest).Return(nil).Times(3)
+	handler := NewHTTPHandler(httpRealms())
+
+	for _, tc := range []struct {
+		name   string
+		cookie string
+		token  string
+	}{
+		{"no cookie", "", testCSRFToken},
+		{"no token", testCSRFToken, ""},
+		{"other token", testCSRFToken, strings.Repeat("d", csrfTokenLength)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			form := authorizationParams()
+			form.Set("username", "test")
+			form.Set("password", "password")
+			form.Set("csrf_token", tc.token)
+			request := postForm("/authorize", form)
+			if tc.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: csrfCookie, Value: tc.cookie})
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			require.Equal(t, http.StatusForbidden, recorder.Code)
+			require.Contains(t, recorder.Body.String(), "The sign in expired, please sign in again.")
+			require.Contains(t, recorder.Body.String(), `name="password"`)
+		})
+	}
+}
+
+func TestHTTPHandler_authorize_login(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	mockOIDCService.EXPECT().Authorize(gomock.Any(), authorizationRequest).Return(nil).Times(1)
+	mockOIDCService.EXPECT().Login(gomock.Any(), authorizationRequest, "test", "password").Return("c0de", "", nil).Times(1)
+	handler := NewHTTPHandler(httpRealms())
+
+	form := authorizationParams()
+	form.Set("username", "test")
+	form.Set("password", "password")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, postLogin(form))
+
+	require.Equal(t, http.StatusFound, recorder.Code)
+	require.Equal(t, "https://app.example.com/callback?code=c0de&state=xyz", recorder.Header().Get("Location"))
+}
+
+func TestHTTPHandler_authorize_login_mfa(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	mockOIDCService.EXPECT().Authorize(gomock.Any(), authorizationRequest).Return(nil).Times(2)
+	mockOIDCService.EXPECT().Login(gomock.Any(), authorizationRequest, "test", "password").Return("", "mfa", nil).Times(1)
+	mockOIDCService.EXPECT().LoginMFA(gomock.Any(), authorizationRequest, "mfa", "123456").Return("c0de", nil).Times(1)
+	handler := NewHTTPHandler(httpRealms())
+
+	form := authorizationParams()
+	form.Set("username", "test")
+	form.Set("password", "password")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, postLogin(form))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `name="mfa_token" value="mfa"`)
+	require.NotContains(t, recorder.Body.String(), `name="password"`)
+
+	form = authorizationParams()
+	form.Set("mfa_token", "mfa")
+	form.Set("code", "123456")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, postLogin(form))
+
+	require.Equal(t, http.StatusFound, recorder.Code)
+	require.Equal(t, "https://app.example.com/callback?code=c0de&state=xyz", recorder.Header().Get("Location"))
+}
+
+func TestHTTPHandler_authorize_login_failed(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	mockOIDCService.EXPECT().Authorize(gomock.Any(), authorizationRequest).Return(nil).Times(2)
+	gomock.InOrder(
+		mockOIDCService.EXPECT().Login(gomock.Any(), authorizationRequest, "test", "wrong").Return("", "", errors.AuthenticationFailErr("test")).Times(1),
+		mockOIDCService.EXPECT().LoginMFA(gomock.Any(), authorizationRequest, "expired", "123456").Return("", errors.InvalidMFATokenErr{}).Times(1),
+	)
+	handler := NewHTTPHandler(httpRealms())
+
+	form := authorizationParams()
+	form.Set("username", "test")
+	form.Set("password", "wrong")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, postLogin(form))
+
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "authentication failed")
+	require.Contains(t, recorder.Body.String(), `name="password"`)
+
+	form = authorizationParams()
+	form.Set("mfa_token", "expired")
+	form.Set("code", "123456")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, postLogin(form))
+
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.NotContains(t, recorder.Body.String(), `name="mfa_token"`)
+	require.Contains(t, recorder.Body.String(), `name="password"`)
+}
+
+func TestHTTPHandler_authorize_login_locked(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	until := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockOIDCService.EXPECT().Authorize(gomock.Any(), authorizationRequest).Return(nil).Times(1)
+	mockOIDCService.EXPECT().Login(gomock.Any(), authorizationRequest, "test", "password").Return("", "", errors.AccountLockedErr{Until: until}).Times(1)
+	handler := NewHTTPHandler(httpRealms())
+
+	// The locked account is shown on the login page, the user is not sent back to the client.
+	form := authorizationParams()
+	form.Set("username", "test")
+	form.Set("password", "password")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, postLogin(form))
+
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.Empty(t, recorder.Header().Get("Location"))
+	require.Contains(t, recorder.Body.String(), "account temporarily locked until 2030-01-02T03:04:05Z")
+	require.Contains(t, recorder.Body.String(), `name="password"`)
+}
+
+func TestHTTPHandler_authorize_login_peer(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	// The address of the browser is the peer of the login, so the logins are throttled by address.
+	mockOIDCService.EXPECT().Authorize(gomock.Any(), authorizationRequest).Return(nil).Times(1)
+	mockOIDCService.EXPECT().Login(gomock.Any(), authorizationRequest, "test", "password").DoAndReturn(
+		func(ctx context.Context, _ models.AuthorizationRequest, _, _ string) (string, string, error) {
+			p, ok := peer.FromContext(ctx)
+			require.True(t, ok)
+			require.Equal(t, "10.0.0.1:5000", p.Addr.String())
+			return "c0de", "", nil
+		}).Times(1)
+	handler := NewHTTPHandler(httpRealms())
+
+	form := authorizationParams()
+	form.Set("username", "test")
+	form.Set("password", "password")
+	request := postLogin(form)
+	request.RemoteAddr = "10.0.0.1:5000"
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusFound, recorder.Code)
+}
+
+func TestHTTPHandler_authorize_errors(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	gomock.InOrder(
+		mockOIDCService.EXPECT().Authorize(gomock.Any(), gomock.Any()).Return(errors.InvalidClientErr{}).Times(1),
+		mockOIDCService.EXPECT().Authorize(gomock.Any(), gomock.Any()).Return(errors.InvalidRedirectURIErr{RedirectURI: "https://evil.example.com"}).Times(1),
+		mockOIDCService.EXPECT().Authorize(gomock.Any(), gomock.Any()).Return(errors.InvalidScopeErr{Scope: "admin"}).Times(1),
+		mockOIDCService.EXPECT().Authorize(gomock.Any(), gomock.Any()).Return(errors.NewValidationErr(fmt.Errorf("S256 code challenge required"))).Times(1),
+		mockOIDCService.EXPECT().Authorize(gomock.Any(), gomock.Any()).Return(nil).Times(1),
+	)
+	handler := NewHTTPHandler(httpRealms())
+
+	for _, tc := range []struct {
+		name     string
+		code     int
+		location string
+	}{
+		{"unknown client", http.StatusBadRequest, ""},
+		{"unregistered redirect URI", http.StatusBadRequest, ""},
+		{"invalid scope", http.StatusFound, "https://app.example.com/callback?error=invalid_scope&error_description=The+requested+scope+is+invalid%2C+unknown%2C+or+exceeds+the+scope+granted+to+the+client.&state=xyz"},
+		{"invalid request", http.StatusFound, "https://app.example.com/callback?error=invalid_request&error_description=S256+code+challenge+required&state=xyz"},
+		{"unsupported response type", http.StatusFound, "https://app.example.com/callback?error=unsupported_response_type&error_description=response+type+token+not+supported&state=xyz"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := authorizationParams()
+			if tc.name == "unsupported response type" {
+				params.Set("response_type", "token")
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/authorize?"+params.Encode(), nil))
+
+			require.Equal(t, tc.code, recorder.Code)
+			require.Equal(t, tc.location, recorder.Header().Get("Location"))
+		})
+	}
+}
+
+func TestHTTPHandler_token_authorization_code(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	token := &models.OAuthToken{AccessToken: "access", RefreshToken: "refresh", IDToken: "id", ExpiresIn: 5 * time.Minute, Scopes: []string{"openid"}}
+	gomock.InOrder(
+		mockOIDCService.EXPECT().ExchangeCode(gomock.Any(), "web", "secret", "c0de", "https://app.example.com/callback", "verifier").Return(token, nil).Times(1),
+		mockOIDCService.EXPECT().ExchangeCode(gomock.Any(), "web", "secret", "c0de", "https://app.example.com/callback", "verifier").Return(nil, errors.InvalidAuthorizationCodeErr{}).Times(1),
+	)
+	handler := NewHTTPHandler(httpRealms())
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"c0de"},
+		"redirect_uri":  {"https://app.example.com/callback"},
+		"code_verifier": {"verifier"},
+	}
+
+	request := postForm("/oauth/token", form)
+	request.SetBasicAuth("web", "secret")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"access_token":"access","token_type":"Bearer","expires_in":300,"refresh_token":"refresh","id_token":"id","scope":"openid"}`, recorder.Body.String())
+
+	request = postForm("/oauth/token", form)
+	request.SetBasicAuth("web", "secret")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.JSONEq(t, `{"error":"invalid_grant","error_description":"The authorization grant or the resource owner credentials are invalid, expired or revoked."}`, recorder.Body.String())
+}
+
+func TestHTTPHandler_userInfo(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	gomock.InOrder(
+		mockOIDCService.EXPECT().UserInfo(gomock.Any(), "access").Return(&models.UserInfo{Subject: "test", PreferredUsername: "test", Email: "test@example.com"}, nil).Times(1),
+		mockOIDCService.EXPECT().UserInfo(gomock.Any(), "openid").Return(&models.UserInfo{Subject: "test"}, nil).Times(1),
+		mockOIDCService.EXPECT().UserInfo(gomock.Any(), "revoked").Return(nil, errors.TokenRevokedErr{ID: "1"}).Times(1),
+	)
+	handler := NewHTTPHandler(httpRealms())
+
+	request := httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+	request.Header.Set("Authorization", "Bearer access")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"sub":"test","preferred_username":"test","email":"test@example.com"}`, recorder.Body.String())
+
+	request = httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+	request.Header.Set("Authorization", "Bearer openid")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"sub":"test"}`, recorder.Body.String())
+
+	request = httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+	request.Header.Set("Authorization", "bearer revoked")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/userinfo", nil))
+
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Equal(t, `Bearer realm="userinfo"`, recorder.Header().Get("WWW-Authenticate"))
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
+	"auth/pkg/pb"
+	"auth/pkg/services"
+	"context"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// RealmMetadataKey is the metadata key selecting the realm of a request, the requests without it are served
+// by the config.DefaultRealm.
+const RealmMetadataKey = "realm"
+
+// Realm holds the services of a realm: its users, password policy and tokens are separate from the other realms.
+type Realm struct {
+	UserService          services.UserService
+	AuthService          services.AuthService
+	PasswordResetService services.PasswordResetService
+	RoleService          services.RoleService
+	APIKeyService        services.APIKeyService
+	OAuthService         services.OAuthService
+	OIDCService          services.OIDCService
+}
+
+// RealmName returns the name of the realm selected by the realm metadata of the request.
+func RealmName(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return config.DefaultRealm
+	}
+	for _, value := range md.Get(RealmMetadataKey) {
+		if name := strings.TrimSpace(value); name != "" {
+			return name
+		}
+	}
+	return config.DefaultRealm
+}
+
+// RealmRouter is a pb.AuthServer serving each RPC with the AuthServer of the realm selected by the metadata.
+// The RPCs not routed are unimplemented.
+type RealmRouter struct {
+	pb.UnimplementedAuthServer
+	servers map[string]*AuthServer
+}
+
+// NewRealmRouter creates a new instance of RealmRouter with an AuthServer for each of the realms.
+func NewRealmRouter(realms map[string]Realm) *RealmRouter {
+	servers := make(map[string]*AuthServer, len(realms))
+	for name, realm := range realms {
+		servers[name] = NewAuthServer(realm.UserService, realm.AuthService, realm.PasswordResetService, realm.RoleService, realm.APIKeyService, realm.OAuthService)
+	}
+	return &RealmRouter{servers: servers}
+}
+
+// route serves the request with the rpc of the AuthServer of the realm of the request, or returns a RealmNotFoundErr.
+func route[Req, Resp any](r *RealmRouter, ctx context.Context, req Req, rpc func(*AuthServer, context.Context, Req) (Resp, error)) (Resp, error) {
+	name := RealmName(ctx)
+	s, ok := r.servers[name]
+	if !ok {
+		var response Resp
+		return response, status.Convert(autherrors.RealmNotFoundErr{Name: name}).Err()
+	}
+	return rpc(s, ctx, req)
+}
+
+func (r *RealmRouter) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	return route(r, ctx, req, (*AuthServer).CreateUser)
+}
+
+func (r *RealmRouter) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	return route(r, ctx, req, (*AuthServer).GetUser)
+}
+
+func (r *RealmRouter) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	return route(r, ctx, req, (*AuthServer).UpdateUser)
+}
+
+func (r *RealmRouter) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	return route(r, ctx, req, (*AuthServer).DeleteUser)
+}
+
+func (r *RealmRouter) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	return route(r, ctx, req, (*AuthServer).ListUsers)
+}
+
+func (r *RealmRouter) Authenticate(ctx context.Context, req *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	return route(r, ctx, req, (*AuthServer).Authenticate)
+}
+
+func (r *RealmRouter) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	return route(r, ctx, req, (*AuthServer).RefreshToken)
+}
+
+func (r *RealmRouter) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	return route(r, ctx, req, (*AuthServer).Logout)
+}
+
+func (r *RealmRouter) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	return route(r, ctx, req, (*AuthServer).ChangePassword)
+}
+
+func (r *RealmRouter) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	return route(r, ctx, req, (*AuthServer).RequestPasswordReset)
+}
+
+func (r *RealmRouter) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	return route(r, ctx, req, (*AuthServer).ResetPassword)
+}
+
+func (r *RealmRouter) Introspect(ctx context.Context, req *pb.IntrospectRequest) (*pb.IntrospectResponse, error) {
+	return route(r, ctx, req, (*AuthServer).Introspect)
+}
+
+func (r *RealmRouter) GetJWKS(ctx context.Context, req *pb.GetJWKSRequest) (*pb.GetJWKSResponse, error) {
+	return route(r, ctx, req, (*AuthServer).GetJWKS)
+}
+
+func (r *RealmRouter) UnlockUser(ctx context.Context, req *pb.UnlockUserRequest) (*pb.UnlockUserResponse, error) {
+	return route(r, ctx, req, (*AuthServer).UnlockUser)
+}
+
+func (r *RealmRouter) AssignRole(ctx context.Context, req *pb.AssignRoleRequest) (*pb.AssignRoleResponse, error) {
+	return route(r, ctx, req, (*AuthServer).AssignRole)
+}
+
+func (r *RealmRouter) RevokeRole(ctx context.Context, req *pb.RevokeRoleRequest) (*pb.RevokeRoleResponse, error) {
+	return route(r, ctx, req, (*AuthServer).RevokeRole)
+}
+
+func (r *RealmRouter) ListRoles(ctx context.Context, req *pb.ListRolesRequest) (*pb.ListRolesResponse, error) {
+	return route(r, ctx, req, (*AuthServer).ListRoles)
+}
+
+func (r *RealmRouter) EnrollTOTP(ctx context.Context, req *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	return route(r, ctx, req, (*AuthServer).EnrollTOTP)
+}
+
+func (r *RealmRouter) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	return route(r, ctx, req, (*AuthServer).ConfirmTOTP)
+}
+
+func (r *RealmRouter) VerifyMFA(ctx context.Context, req *pb.VerifyMFARequest) (*pb.VerifyMFAResponse, error) {
+	return route(r, ctx, req, (*AuthServer).VerifyMFA)
+}
+
+func (r *RealmRouter) GenerateRecoveryCodes(ctx context.Context, req *pb.GenerateRecoveryCodesRequest) (*pb.GenerateRecoveryCodesResponse, error) {
+	return route(r, ctx, req, (*AuthServer).GenerateRecoveryCodes)
+}
+
+func (r *RealmRouter) CountRecoveryCodes(ctx context.Context, req *pb.CountRecoveryCodesRequest) (*pb.CountRecoveryCodesResponse, error) {
+	return route(r, ctx, req, (*AuthServer).CountRecoveryCodes)
+}
+
+func (r *RealmRouter) AuthenticateWithRecoveryCode(ctx context.Context, req *pb.AuthenticateWithRecoveryCodeRequest) (*pb.AuthenticateWithRecoveryCodeResponse, error) {
+	return route(r, ctx, req, (*AuthServer).AuthenticateWithRecoveryCode)
+}
+
+func (r *RealmRouter) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	return route(r, ctx, req, (*AuthServer).CreateAPIKey)
+}
+
+func (r *RealmRouter) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	return route(r, ctx, req, (*AuthServer).ListAPIKeys)
+}
+
+func (r *RealmRouter) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyResponse, error) {
+	return route(r, ctx, req, (*AuthServer).RevokeAPIKey)
+}
+
+func (r *RealmRouter) ExchangeAPIKey(ctx context.Context, req *pb.ExchangeAPIKeyRequest) (*pb.ExchangeAPIKeyResponse, error) {
+	return route(r, ctx, req, (*AuthServer).ExchangeAPIKey)
+}
+
+func (r *RealmRouter) CreateOAuthClient(ctx context.Context, req *pb.CreateOAuthClientRequest) (*pb.CreateOAuthClientResponse, error) {
+	return route(r, ctx, req, (*AuthServer).CreateOAuthClient)
+}
+
+func (r *RealmRouter) DeleteOAuthClient(ctx context.Context, req *pb.DeleteOAuthClientRequest) (*pb.DeleteOAuthClientResponse, error) {
+	return route(r, ctx, req, (*AuthServer).DeleteOAuthClient)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/models"
+	"auth/pkg/pb"
+	"auth/pkg/tests"
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"reflect"
+	"testing"
+)
+
+func realmContext(realm string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(RealmMetadataKey, realm))
+}
+
+func TestRealmName(t *testing.T) {
+	require.Equal(t, config.DefaultRealm, RealmName(context.Background()))
+	require.Equal(t, config.DefaultRealm, RealmName(realmContext(" ")))
+	require.Equal(t, "acme", RealmName(realmContext(" acme ")))
+}
+
+func TestRealmRouter_routes_to_realm(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := realmContext("acme")
+	acmeUsers := tests.NewMockUserService(gomock.NewController(t))
+	acmeUsers.EXPECT().Get(ctx, "test").Return(&models.User{Username: "test"}, nil).Times(1)
+	mockUserService.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+	router := NewRealmRouter(map[string]Realm{
+		config.DefaultRealm: {mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService, mockOIDCService},
+		"acme":              {acmeUsers, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService, mockOIDCService},
+	})
+
+	response, err := router.GetUser(ctx, &pb.GetUserRequest{Username: "test"})
+
+	require.NoError(t, err)
+	require.Equal(t, "test", response.User.Username)
+}
+
+func TestRealmRouter_default_realm(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	mockUserService.EXPECT().Delete(ctx, "test").Return(nil).Times(1)
+	router := NewRealmRouter(map[string]Realm{
+		config.DefaultRealm: {mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService, mockOIDCService},
+	})
+
+	response, err := router.DeleteUser(ctx, &pb.DeleteUserRequest{Username: "test"})
+
+	require.NoError(t, err)
+	require.True(t, response.Success)
+}
+
+func TestRealmRouter_unknown_realm(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	router := NewRealmRouter(map[string]Realm{
+		config.DefaultRealm: {mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService, mockOIDCService},
+	})
+
+	response, err := router.CreateUser(realmContext("unknown"), &pb.CreateUserRequest{Username: "test", Password: "password"})
+
+	require.EqualError(t, err, "rpc error: code = InvalidArgument desc = realm unknown not found")
+	require.Nil(t, response)
+}
+
+func TestRealmRouter_routes_all_methods(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	router := reflect.ValueOf(NewRealmRouter(map[string]Realm{
+		config.DefaultRealm: {mockUserService, mockAuthentication, mockPasswordReset, mockRoleService, mockAPIKeyService, mockOAuthService, mockOIDCService},
+	}))
+	server := reflect.TypeOf((*pb.AuthServer)(nil)).Elem()
+	for i := 0; i < server.NumMethod(); i++ {
+		method := server.Method(i)
+		if !method.IsExported() {
+			continue
+		}
+		t.Run(method.Name, func(t *testing.T) {
+			// A routed method looks up the realm, the methods not routed are unimplemented.
+			results := router.MethodByName(method.Name).Call([]reflect.Value{
+				reflect.ValueOf(realmContext("unknown")),
+				reflect.New(method.Type.In(1).Elem()),
+			})
+
+			err, _ := results[1].Interface().(error)
+			require.Equal(t, codes.InvalidArgument, status.Code(err), err)
+			require.Equal(t, "realm unknown not found", status.Convert(err).Message())
+			require.True(t, results[0].IsNil())
+		})
+	}
+}
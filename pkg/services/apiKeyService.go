@@ -0,0 +1,160 @@
+package services
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/jwt"
+	"auth/pkg/models"
+	"auth/pkg/stores"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// apiKeyScheme starts the API keys, so they are recognized in the logs and by the secret scanners.
+const apiKeyScheme = "ak_"
+
+// newAPIKey returns a random API key formatted as ak_<id>_<secret> and its prefix ak_<id>, which identifies the key.
+func newAPIKey() (key, prefix string, err error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("error generating an API key: %w", err)
+	}
+	secret, err := newOpaqueToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	prefix = apiKeyScheme + hex.EncodeToString(b)
+	return prefix + "_" + secret, prefix, nil
+}
+
+// apiKeyPrefix returns the prefix of the API key, or false if the key is malformed.
+func apiKeyPrefix(key string) (string, bool) {
+	if !strings.HasPrefix(key, apiKeyScheme) {
+		return "", false
+	}
+	id, secret, found := strings.Cut(strings.TrimPrefix(key, apiKeyScheme), "_")
+	if !found || id == "" || secret == "" {
+		return "", false
+	}
+	return apiKeyScheme + id, true
+}
+
+type APIKeyService interface {
+	//CreateAPIKey creates an API key of the service account with the permissions, expiring at expiresAt
+	//unless it is the zero time. The key is only returned on creation, only its hash is stored.
+	CreateAPIKey(ctx context.Context, serviceAccount string, permissions []string, expiresAt time.Time) (*models.APIKey, string, error)
+	//ListAPIKeys returns the API keys of the service account, or of all the service accounts if it is empty.
+	ListAPIKeys(ctx context.Context, serviceAccount string) ([]models.APIKey, error)
+	//RevokeAPIKey revokes the API key with the prefix, the tokens exchanged for the key are no longer active.
+	RevokeAPIKey(ctx context.Context, prefix string) error
+}
+
+type apiKeyService struct {
+	apiKeyStore stores.APIKeyStore
+	logger      *zap.Logger
+}
+
+// NewAPIKeyService creates a new instance of an APIKeyService storing the hashes of the API keys in the apiKeyStore.
+func NewAPIKeyService(apiKeyStore stores.APIKeyStore) APIKeyService {
+	return &apiKeyService{
+		apiKeyStore: apiKeyStore,
+		logger:      zap.L().Named("APIKeyService"),
+	}
+}
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, serviceAccount string, permissions []string, expiresAt time.Time) (*models.APIKey, string, error) {
+	if serviceAccount == "" {
+		return nil, "", autherrors.NewValidationErr(fmt.Errorf("service account required"))
+	}
+	for _, permission := range permissions {
+		if permission == "" || strings.ContainsAny(permission, " \t\n") {
+			return nil, "", autherrors.NewValidationErr(fmt.Errorf("invalid permission %q", permission))
+		}
+	}
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return nil, "", autherrors.NewValidationErr(fmt.Errorf("expiration in the past"))
+	}
+
+	key, prefix, err := newAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	apiKey := models.APIKey{
+		Prefix:         prefix,
+		Hash:           hashToken(key),
+		ServiceAccount: serviceAccount,
+		Permissions:    permissions,
+		ExpiresAt:      expiresAt,
+	}
+	if err := s.apiKeyStore.Create(ctx, apiKey); err != nil {
+		return nil, "", fmt.Errorf("error storing the API key: %w", err)
+	}
+	s.logger.Info("API key created", zap.String("serviceAccount", serviceAccount), zap.String("prefix", prefix))
+
+	return &apiKey, key, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, serviceAccount string) ([]models.APIKey, error) {
+	keys, err := s.apiKeyStore.List(ctx, serviceAccount)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, prefix string) error {
+	revoked, err := s.apiKeyStore.Revoke(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("error revoking the API key: %w", err)
+	}
+	if !revoked {
+		return autherrors.APIKeyNotFoundErr{Prefix: prefix}
+	}
+	s.logger.Info("API key revoked", zap.String("prefix", prefix))
+
+	return nil
+}
+
+func (as *JwtAuthService) ExchangeAPIKey(ctx context.Context, apiKey string) (string, error) {
+	prefix, ok := apiKeyPrefix(apiKey)
+	if !ok {
+		return "", autherrors.InvalidAPIKeyErr{}
+	}
+	key, err := as.APIKeyStore.Get(ctx, prefix)
+	if err != nil {
+		return "", fmt.Errorf("error getting the API key from store: %w", err)
+	}
+	if key == nil || subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashToken(apiKey))) != 1 || !key.Active(time.Now()) {
+		return "", autherrors.InvalidAPIKeyErr{}
+	}
+
+	token, err := as.JwtGenerator.Generate(
+		models.User{Username: key.ServiceAccount, Tenant: key.Tenant},
+		jwt.WithAPIKey(key.Prefix, key.Permissions),
+	)
+	if err != nil {
+		return "", fmt.Errorf("error generating the token: %w", err)
+	}
+
+	return token, nil
+}
+
+// verifyAPIKey checks that the API key exchanged for the token of a service account is still active
+// and belongs to the service account and the tenant of the token.
+func (as *JwtAuthService) verifyAPIKey(ctx context.Context, claims *jwt.Claims) error {
+	key, err := as.APIKeyStore.Get(ctx, claims.APIKey)
+	if err != nil {
+		return fmt.Errorf("error getting the API key from store: %w", err)
+	}
+	if key == nil || !key.Active(time.Now()) || key.ServiceAccount != claims.Subject || key.Tenant != claims.Tenant {
+		return autherrors.TokenRevokedErr{ID: claims.ID}
+	}
+
+	return nil
+}
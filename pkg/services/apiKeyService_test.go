@@ -0,0 +1,209 @@
+package services
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/hashers"
+	"auth/pkg/jwt"
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_apiKeyService_CreateAPIKey_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+	var stored models.APIKey
+
+	mockAPIKeyStore.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, key models.APIKey) error {
+		stored = key
+		return nil
+	}).Times(1)
+
+	s := NewAPIKeyService(mockAPIKeyStore)
+
+	//Act
+	apiKey, key, err := s.CreateAPIKey(ctx, "billing", []string{"invoices:write"}, expiresAt)
+
+	//Verify
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(key, apiKey.Prefix+"_"))
+	require.True(t, strings.HasPrefix(apiKey.Prefix, "ak_"))
+	require.Equal(t, hashToken(key), stored.Hash)
+	require.Equal(t, models.APIKey{
+		Prefix:         apiKey.Prefix,
+		Hash:           hashToken(key),
+		ServiceAccount: "billing",
+		Permissions:    []string{"invoices:write"},
+		ExpiresAt:      expiresAt,
+	}, stored)
+	require.Equal(t, stored, *apiKey)
+	prefix, ok := apiKeyPrefix(key)
+	require.True(t, ok)
+	require.Equal(t, apiKey.Prefix, prefix)
+}
+
+func Test_apiKeyService_CreateAPIKey_invalid(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	s := NewAPIKeyService(mockAPIKeyStore)
+
+	//Act
+	_, _, noAccountErr := s.CreateAPIKey(ctx, "", nil, time.Time{})
+	_, _, permissionErr := s.CreateAPIKey(ctx, "billing", []string{"users:read users:write"}, time.Time{})
+	_, _, expiredErr := s.CreateAPIKey(ctx, "billing", nil, time.Now().Add(-time.Minute))
+
+	//Verify
+	require.EqualError(t, noAccountErr, "service account required")
+	require.EqualError(t, permissionErr, `invalid permission "users:read users:write"`)
+	require.EqualError(t, expiredErr, "expiration in the past")
+}
+
+func Test_apiKeyService_RevokeAPIKey_not_found(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockAPIKeyStore.EXPECT().Revoke(ctx, "ak_1").Return(false, nil).Times(1)
+
+	s := NewAPIKeyService(mockAPIKeyStore)
+
+	//Act
+	err := s.RevokeAPIKey(ctx, "ak_1")
+
+	//Verify
+	require.Equal(t, autherrors.APIKeyNotFoundErr{Prefix: "ak_1"}, err)
+}
+
+func Test_authService_ExchangeAPIKey_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	apiKey := "ak_0123456789abcdef_secret"
+	key := models.APIKey{
+		Prefix:         "ak_0123456789abcdef",
+		Hash:           hashToken(apiKey),
+		Tenant:         "default",
+		ServiceAccount: "billing",
+		Permissions:    []string{"invoices:write"},
+	}
+
+	mockAPIKeyStore.EXPECT().Get(ctx, "ak_0123456789abcdef").Return(&key, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(models.User{Username: "billing", Tenant: "default"}, gomock.Any()).Return("sdjklfjasdkl.jfsda.fasdf", nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	token, err := s.ExchangeAPIKey(ctx, apiKey)
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, "sdjklfjasdkl.jfsda.fasdf", token)
+}
+
+func Test_authService_ExchangeAPIKey_invalid(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	apiKey := "ak_0123456789abcdef_secret"
+	active := models.APIKey{Prefix: "ak_0123456789abcdef", Hash: hashToken(apiKey), ServiceAccount: "billing"}
+	revoked := active
+	revoked.Revoked = true
+	expired := active
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+	gomock.InOrder(
+		mockAPIKeyStore.EXPECT().Get(ctx, "ak_0123456789abcdef").Return(nil, nil).Times(1),
+		mockAPIKeyStore.EXPECT().Get(ctx, "ak_0123456789abcdef").Return(&active, nil).Times(1),
+		mockAPIKeyStore.EXPECT().Get(ctx, "ak_0123456789abcdef").Return(&revoked, nil).Times(1),
+		mockAPIKeyStore.EXPECT().Get(ctx, "ak_0123456789abcdef").Return(&expired, nil).Times(1),
+	)
+	mockJwtGenerator.EXPECT().Generate(gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	_, malformedErr := s.ExchangeAPIKey(ctx, "secret")
+	_, unknownErr := s.ExchangeAPIKey(ctx, apiKey)
+	_, wrongSecretErr := s.ExchangeAPIKey(ctx, "ak_0123456789abcdef_other")
+	_, revokedErr := s.ExchangeAPIKey(ctx, apiKey)
+	_, expiredErr := s.ExchangeAPIKey(ctx, apiKey)
+
+	//Verify
+	for _, err := range []error{malformedErr, unknownErr, wrongSecretErr, revokedErr, expiredErr} {
+		require.Equal(t, autherrors.InvalidAPIKeyErr{}, err)
+	}
+}
+
+func Test_authService_Introspect_service_account(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	claims := &jwt.Claims{Tenant: "default", ClientType: jwt.ServiceClientType, APIKey: "ak_1"}
+	claims.Subject = "billing"
+	key := models.APIKey{Prefix: "ak_1", Tenant: "default", ServiceAccount: "billing"}
+	revoked := key
+	revoked.Revoked = true
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(2)
+	gomock.InOrder(
+		mockAPIKeyStore.EXPECT().Get(ctx, "ak_1").Return(&key, nil).Times(1),
+		mockAPIKeyStore.EXPECT().Get(ctx, "ak_1").Return(&revoked, nil).Times(1),
+	)
+	mockUserStore.EXPECT().Get(ctx, gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	active, activeErr := s.Introspect(ctx, token)
+	inactive, inactiveErr := s.Introspect(ctx, token)
+
+	//Verify
+	require.NoError(t, activeErr)
+	require.Equal(t, claims, active)
+	require.NoError(t, inactiveErr)
+	require.Nil(t, inactive)
+}
+
+func Test_authService_ChangePassword_service_account(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	claims := &jwt.Claims{ClientType: jwt.ServiceClientType, APIKey: "ak_1"}
+	claims.Subject = "billing"
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	err := s.ChangePassword(ctx, token, "current", "newpassword")
+
+	//Verify
+	require.EqualError(t, err, "invalid token: token of the service account billing")
+}
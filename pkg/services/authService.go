@@ -2,57 +2,595 @@ package services
 
 import (
 	autherrors "auth/pkg/errors"
+	"auth/pkg/hashers"
 	"auth/pkg/jwt"
+	"auth/pkg/models"
 	"auth/pkg/stores"
+	"auth/pkg/validators"
 	"context"
 	"errors"
 	"fmt"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
 )
 
+// dummyPassword is hashed to compare the passwords of the unknown usernames.
+const dummyPassword = "dummy password"
+
 type AuthService interface {
 	//Authenticate a user from a username and password
-	Authenticate(ctx context.Context, username, password string) (string, error)
+	Authenticate(ctx context.Context, username, password string) (*models.Tokens, error)
+	//AuthenticateUser authenticates a user from a username and password like Authenticate, without issuing tokens.
+	//It returns the user, or an MFA token redeemed by VerifyMFAUser if the user has multi-factor authentication.
+	AuthenticateUser(ctx context.Context, username, password string) (*models.User, string, error)
+	//Refresh exchanges a refresh token for new tokens. The refresh token can only be used once.
+	Refresh(ctx context.Context, refreshToken string) (*models.Tokens, error)
+	//ChangePassword replaces the password of the user of the token after checking the current password,
+	//which is not checked with a token issued by AuthenticateWithRecoveryCode.
+	//All the tokens previously issued to the user are invalidated.
+	ChangePassword(ctx context.Context, token, currentPassword, newPassword string) error
+	//Logout revokes the access token and, if set, all the refresh tokens rotated with the refresh token.
+	Logout(ctx context.Context, token, refreshToken string) error
+	//Introspect returns the claims of an active token, or nil if the token is not active or revoked.
+	Introspect(ctx context.Context, token string) (*jwt.Claims, error)
+	//JWKS returns the public keys verifying the tokens.
+	JWKS(ctx context.Context) (jwt.JSONWebKeySet, error)
+	//UnlockUser forgets the failed logins of the user with the username, which unlocks it.
+	UnlockUser(ctx context.Context, username string) error
+	//EnrollTOTP generates a TOTP secret for the user of the token, activated by ConfirmTOTP.
+	EnrollTOTP(ctx context.Context, token string) (*models.TOTPEnrollment, error)
+	//ConfirmTOTP activates the TOTP secret of the user of the token with a code of the secret.
+	//The next authentications of the user return an MFA token redeemed by VerifyMFA.
+	ConfirmTOTP(ctx context.Context, token, code string) error
+	//VerifyMFA exchanges an MFA token returned by Authenticate and a TOTP code for the tokens of the user.
+	VerifyMFA(ctx context.Context, mfaToken, code string) (*models.Tokens, error)
+	//VerifyMFAUser exchanges an MFA token returned by AuthenticateUser and a TOTP code for the user, without issuing tokens.
+	VerifyMFAUser(ctx context.Context, mfaToken, code string) (*models.User, error)
+	//GenerateRecoveryCodes issues a new batch of single-use recovery codes to the user of the token,
+	//the codes issued before are invalidated.
+	GenerateRecoveryCodes(ctx context.Context, token string) ([]string, error)
+	//CountRecoveryCodes returns the number of unused recovery codes of the user of the token.
+	CountRecoveryCodes(ctx context.Context, token string) (int, error)
+	//AuthenticateWithRecoveryCode authenticates a user with a recovery code instead of the password and the second factor.
+	//It only returns an access token, which requires the user to change the password and allows it
+	//without the current password.
+	AuthenticateWithRecoveryCode(ctx context.Context, username, code string) (*models.Tokens, error)
+	//ExchangeAPIKey exchanges an API key for an access token of its service account, carrying the permissions of the key.
+	//The token is active until it expires or the key is revoked.
+	ExchangeAPIKey(ctx context.Context, apiKey string) (string, error)
+	//IssueTokens issues the tokens of a new session of the user with the username, who has been authenticated by the caller.
+	//The access token also carries the OpenID Connect scopes granted to the client, the refreshed tokens don't.
+	IssueTokens(ctx context.Context, username string, scopes []string) (*models.Tokens, error)
 }
 
 // JwtAuthService is an implementation of AuthService that returns a JWT.
 type JwtAuthService struct {
-	UserStore    stores.UserStore
-	JwtGenerator jwt.TokenGenerator
-	logger       *zap.Logger
+	UserStore          stores.UserStore
+	RefreshTokenStore  stores.RefreshTokenStore
+	RevocationStore    stores.RevocationStore
+	JwtGenerator       jwt.TokenGenerator
+	JwtVerifier        jwt.TokenVerifier
+	KeySet             jwt.KeySet
+	PasswordValidator  validators.Validator
+	PasswordHasher     hashers.PasswordHasher
+	LoginThrottler     LoginThrottler
+	MFAService         MFAService
+	RecoveryCodeStore  stores.RecoveryCodeStore
+	RoleStore          stores.RoleStore
+	APIKeyStore        stores.APIKeyStore
+	OAuthClientStore   stores.OAuthClientStore
+	RefreshExpDuration time.Duration
+	logger             *zap.Logger
+	dummyHashOnce      sync.Once
+	dummyHash          string
+}
+
+// NewJwtAuthService creates a new instance of an AuthService using JWT.
+// The new passwords are checked by the passwordValidator and hashed with the passwordHasher.
+// The passwords hashed with outdated algorithms or parameters are hashed again on authentication.
+// The failed authentications are throttled by the loginThrottler.
+// If the mfaService is not nil, the users enrolled in it complete their authentication with a TOTP code.
+// The hashes of the recovery codes are stored in the recoveryCodeStore.
+// The tokens carry the roles of the user from the roleStore and their permissions.
+// The API keys of the service accounts are checked with the apiKeyStore, the OAuth clients with the oauthClientStore.
+// The refresh tokens issued with the JWT expire after refreshExpDuration.
+func NewJwtAuthService(
+	userStore stores.UserStore,
+	refreshTokenStore stores.RefreshTokenStore,
+	revocationStore stores.RevocationStore,
+	jwtGenerator jwt.TokenGenerator,
+	jwtVerifier jwt.TokenVerifier,
+	keySet jwt.KeySet,
+	passwordValidator validators.Validator,
+	passwordHasher hashers.PasswordHasher,
+	loginThrottler LoginThrottler,
+	mfaService MFAService,
+	recoveryCodeStore stores.RecoveryCodeStore,
+	roleStore stores.RoleStore,
+	apiKeyStore stores.APIKeyStore,
+	oauthClientStore stores.OAuthClientStore,
+	refreshExpDuration time.Duration,
+) AuthService {
+	as := &JwtAuthService{
+		UserStore:          userStore,
+		RefreshTokenStore:  refreshTokenStore,
+		RevocationStore:    revocationStore,
+		JwtGenerator:       jwtGenerator,
+		JwtVerifier:        jwtVerifier,
+		KeySet:             keySet,
+		PasswordValidator:  passwordValidator,
+		PasswordHasher:     passwordHasher,
+		LoginThrottler:     loginThrottler,
+		MFAService:         mfaService,
+		RecoveryCodeStore:  recoveryCodeStore,
+		RoleStore:          roleStore,
+		APIKeyStore:        apiKeyStore,
+		OAuthClientStore:   oauthClientStore,
+		RefreshExpDuration: refreshExpDuration,
+		logger:             zap.L().Named("AuthService"),
+	}
+	// The dummy hash is computed upfront, so the first unknown username is not slower to reject.
+	as.dummyHashOnce.Do(as.hashDummyPassword)
+
+	return as
 }
 
-// NewJwtAuthService creates a new instance of an AuthService using JWT
-func NewJwtAuthService(userStore stores.UserStore, jwtGenerator jwt.TokenGenerator) AuthService {
-	return &JwtAuthService{
-		UserStore:    userStore,
-		JwtGenerator: jwtGenerator,
-		logger:       zap.L().Named("AuthService"),
+func (as *JwtAuthService) Authenticate(ctx context.Context, username, password string) (*models.Tokens, error) {
+	u, mfaToken, err := as.AuthenticateUser(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+	if mfaToken != "" {
+		return &models.Tokens{MFAToken: mfaToken}, nil
+	}
+
+	familyID, err := newOpaqueToken(16)
+	if err != nil {
+		return nil, err
 	}
+	return as.issueTokens(ctx, *u, familyID)
 }
 
-func (as *JwtAuthService) Authenticate(ctx context.Context, username, password string) (string, error) {
+func (as *JwtAuthService) AuthenticateUser(ctx context.Context, username, password string) (*models.User, string, error) {
+	address := peerAddress(ctx)
+	if err := as.LoginThrottler.Check(ctx, username, address); err != nil {
+		return nil, "", err
+	}
+
 	u, err := as.UserStore.Get(ctx, username)
 	if err != nil {
-		return "", fmt.Errorf("error getting user %s from store: %w", username, err)
+		return nil, "", fmt.Errorf("error getting user %s from store: %w", username, err)
 	}
 	if u == nil {
-		return "", autherrors.AuthenticationFailErr(username)
+		as.dummyVerify(password)
+		as.failLogin(ctx, username, address)
+		return nil, "", autherrors.AuthenticationFailErr(username)
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
+	ok, err := as.PasswordHasher.Verify(u.Password, password)
 	if err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return "", autherrors.AuthenticationFailErr(u.Username)
-		}
 		as.logger.Error("failed to compare passwords", zap.Error(err))
-		return "", fmt.Errorf("error comparing password: %w", err)
+		return nil, "", fmt.Errorf("error comparing password: %w", err)
+	}
+	if !ok {
+		as.failLogin(ctx, username, address)
+		return nil, "", autherrors.AuthenticationFailErr(u.Username)
+	}
+	if as.PasswordHasher.NeedsRehash(u.Password) {
+		as.rehash(ctx, *u, password)
+	}
+
+	if as.MFAService != nil {
+		enabled, err := as.MFAService.Enabled(ctx, u.Username)
+		if err != nil {
+			return nil, "", err
+		}
+		// The failed logins are only forgotten once the second factor is verified,
+		// so the failed codes count towards the lockout of the user.
+		if enabled {
+			mfaToken, err := as.MFAService.Challenge(ctx, u.Username)
+			if err != nil {
+				return nil, "", err
+			}
+			return nil, mfaToken, nil
+		}
+	}
+	if err := as.LoginThrottler.Succeed(ctx, username); err != nil {
+		as.logger.Error("failed to reset the failed logins", zap.Error(err))
+	}
+
+	return u, "", nil
+}
+
+// dummyVerify compares the password with a hash of the current hasher, so rejecting an unknown username
+// takes as long as rejecting a wrong password and the response time doesn't reveal whether the user exists.
+func (as *JwtAuthService) dummyVerify(password string) {
+	as.dummyHashOnce.Do(as.hashDummyPassword)
+	if as.dummyHash != "" {
+		_, _ = as.PasswordHasher.Verify(as.dummyHash, password)
+	}
+}
+
+// hashDummyPassword computes the hash compared by dummyVerify.
+func (as *JwtAuthService) hashDummyPassword() {
+	hash, err := as.PasswordHasher.Hash(dummyPassword)
+	if err != nil {
+		as.logger.Error("failed to hash the dummy password", zap.Error(err))
+		return
+	}
+	as.dummyHash = hash
+}
+
+// failLogin records the failed login, a failure is only logged.
+func (as *JwtAuthService) failLogin(ctx context.Context, username, address string) {
+	if err := as.LoginThrottler.Fail(ctx, username, address); err != nil {
+		as.logger.Error("failed to record the failed login", zap.Error(err))
+	}
+}
+
+// rehash replaces the outdated hash of the password of the user with a hash of the current algorithm and parameters,
+// unless the password changed meanwhile. A failure is only logged: the authentication already succeeded
+// and the hash is replaced on the next one.
+func (as *JwtAuthService) rehash(ctx context.Context, u models.User, password string) {
+	hashedPassword, err := as.PasswordHasher.Hash(password)
+	if err != nil {
+		as.logger.Error("failed to rehash the password", zap.Error(err))
+		return
+	}
+	if _, err := as.UserStore.UpdatePasswordHash(ctx, u.Username, u.Password, hashedPassword); err != nil {
+		as.logger.Error("failed to store the rehashed password", zap.Error(err))
+	}
+}
+
+func (as *JwtAuthService) Refresh(ctx context.Context, refreshToken string) (*models.Tokens, error) {
+	hash := hashToken(refreshToken)
+	rt, err := as.RefreshTokenStore.Get(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the refresh token from store: %w", err)
+	}
+	if rt == nil || rt.Revoked || time.Now().After(rt.ExpiresAt) {
+		return nil, autherrors.InvalidRefreshTokenErr{}
+	}
+	if rt.Used {
+		return nil, as.revokeFamily(ctx, rt)
 	}
 
-	token, err := as.JwtGenerator.Generate(*u)
+	// Two concurrent requests with the same token can pass the checks above, only one can use it.
+	ok, err := as.RefreshTokenStore.Use(ctx, hash)
 	if err != nil {
-		return "", fmt.Errorf("error generating the token: %w", err)
+		return nil, fmt.Errorf("error using the refresh token: %w", err)
+	}
+	if !ok {
+		return nil, as.revokeFamily(ctx, rt)
+	}
+
+	u, err := as.UserStore.Get(ctx, rt.Username)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user %s from store: %w", rt.Username, err)
+	}
+	if u == nil {
+		return nil, autherrors.InvalidRefreshTokenErr{}
 	}
-	return token, nil
+
+	return as.issueTokens(ctx, *u, rt.FamilyID)
+}
+
+// revokeFamily revokes all the refresh tokens rotated from the same authentication as the reused refresh token,
+// the token might have been stolen.
+func (as *JwtAuthService) revokeFamily(ctx context.Context, rt *models.RefreshToken) error {
+	as.logger.Warn("refresh token reused, revoking the token family", zap.String("username", rt.Username))
+	if err := as.RefreshTokenStore.RevokeFamily(ctx, rt.FamilyID); err != nil {
+		return fmt.Errorf("error revoking the refresh token family: %w", err)
+	}
+	return autherrors.InvalidRefreshTokenErr{}
+}
+
+func (as *JwtAuthService) IssueTokens(ctx context.Context, username string, scopes []string) (*models.Tokens, error) {
+	u, err := as.UserStore.Get(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user %s from store: %w", username, err)
+	}
+	if u == nil {
+		return nil, autherrors.UserNotFoundErr{Name: username}
+	}
+
+	familyID, err := newOpaqueToken(16)
+	if err != nil {
+		return nil, err
+	}
+	return as.issueTokens(ctx, *u, familyID, jwt.WithScopes(scopes))
+}
+
+// issueTokens generates an access token with the roles of the user and the options, and a new refresh token of the family
+// for the user. The roles are read on each issue, so a refreshed token carries the roles assigned or revoked since the authentication.
+func (as *JwtAuthService) issueTokens(ctx context.Context, user models.User, familyID string, options ...jwt.Option) (*models.Tokens, error) {
+	roles, err := as.RoleStore.ListByUser(ctx, user.Username)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the roles of the user: %w", err)
+	}
+
+	token, err := as.JwtGenerator.Generate(user, append([]jwt.Option{jwt.WithRoles(roles)}, options...)...)
+	if err != nil {
+		return nil, fmt.Errorf("error generating the token: %w", err)
+	}
+
+	refreshToken, err := newOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	err = as.RefreshTokenStore.Create(ctx, models.RefreshToken{
+		Hash:      hashToken(refreshToken),
+		FamilyID:  familyID,
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(as.RefreshExpDuration),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error storing the refresh token: %w", err)
+	}
+
+	return &models.Tokens{AccessToken: token, RefreshToken: refreshToken}, nil
+}
+
+func (as *JwtAuthService) ChangePassword(ctx context.Context, token, currentPassword, newPassword string) error {
+	claims, u, err := as.verify(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	// The tokens issued by an account recovery change the password without the current one.
+	if !claims.PasswordChange {
+		ok, err := as.PasswordHasher.Verify(u.Password, currentPassword)
+		if err != nil {
+			as.logger.Error("failed to compare passwords", zap.Error(err))
+			return fmt.Errorf("error comparing password: %w", err)
+		}
+		if !ok {
+			return autherrors.AuthenticationFailErr(u.Username)
+		}
+	}
+
+	if err := as.PasswordValidator.Validate(newPassword); err != nil {
+		return autherrors.NewValidationErr(err)
+	}
+	hashedPassword, err := as.PasswordHasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("error during password hashing: %w", err)
+	}
+
+	user := *u
+	user.Password = hashedPassword
+	// The tokens carrying the previous generation are no longer accepted.
+	user.TokenGeneration++
+	found, err := as.UserStore.Update(ctx, u.Username, user)
+	if err != nil {
+		return fmt.Errorf("error updating the user: %w", err)
+	}
+	if !found {
+		return autherrors.UserNotFoundErr{Name: u.Username}
+	}
+	if err := as.RefreshTokenStore.RevokeUser(ctx, u.Username); err != nil {
+		return fmt.Errorf("error revoking the refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (as *JwtAuthService) Logout(ctx context.Context, token, refreshToken string) error {
+	claims, _, err := as.verify(ctx, token)
+	if err != nil {
+		return err
+	}
+	if claims.ID == "" {
+		return autherrors.NewInvalidTokenErr(fmt.Errorf("missing jti claim"))
+	}
+
+	var rt *models.RefreshToken
+	if refreshToken != "" {
+		rt, err = as.RefreshTokenStore.Get(ctx, hashToken(refreshToken))
+		if err != nil {
+			return fmt.Errorf("error getting the refresh token from store: %w", err)
+		}
+		if rt == nil || rt.Username != claims.Subject {
+			return autherrors.InvalidRefreshTokenErr{}
+		}
+	}
+
+	if err := as.RevocationStore.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return fmt.Errorf("error revoking the token: %w", err)
+	}
+	if rt != nil {
+		if err := as.RefreshTokenStore.RevokeFamily(ctx, rt.FamilyID); err != nil {
+			return fmt.Errorf("error revoking the refresh token family: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (as *JwtAuthService) Introspect(ctx context.Context, token string) (*jwt.Claims, error) {
+	claims, err := as.verifyToken(ctx, token)
+	if err == nil {
+		// The tokens of the service accounts are active while their API key is, the tokens of the OAuth clients
+		// while their client exists.
+		if claims.ClientType == jwt.ServiceClientType {
+			if claims.APIKey != "" {
+				err = as.verifyAPIKey(ctx, claims)
+			} else {
+				err = as.verifyClient(ctx, claims)
+			}
+		} else {
+			_, err = as.verifyUser(ctx, claims)
+		}
+	}
+	if err != nil {
+		// The invalid, expired and revoked tokens are inactive, any other error is a failure of the service.
+		if status.Code(err) != codes.Unauthenticated {
+			return nil, err
+		}
+		as.logger.Debug("inactive token", zap.Error(err))
+		return nil, nil
+	}
+
+	return claims, nil
+}
+
+// verify checks the token of a user with verifyToken and verifyUser. It returns the claims and the user of the token,
+// the tokens of the service accounts are rejected.
+func (as *JwtAuthService) verify(ctx context.Context, token string) (*jwt.Claims, *models.User, error) {
+	claims, err := as.verifyToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if claims.ClientType == jwt.ServiceClientType {
+		return nil, nil, autherrors.NewInvalidTokenErr(fmt.Errorf("token of the service account %s", claims.Subject))
+	}
+	u, err := as.verifyUser(ctx, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return claims, u, nil
+}
+
+// verifyToken checks the token with the JwtVerifier and checks that the token is not revoked.
+func (as *JwtAuthService) verifyToken(ctx context.Context, token string) (*jwt.Claims, error) {
+	claims, err := as.JwtVerifier.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.ID != "" {
+		revoked, err := as.RevocationStore.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error checking the token revocation: %w", err)
+		}
+		if revoked {
+			return nil, autherrors.TokenRevokedErr{ID: claims.ID}
+		}
+	}
+
+	return claims, nil
+}
+
+// verifyUser checks that the token was issued for the current token generation and the tenant of its user,
+// and returns the user.
+func (as *JwtAuthService) verifyUser(ctx context.Context, claims *jwt.Claims) (*models.User, error) {
+	u, err := as.UserStore.Get(ctx, claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user %s from store: %w", claims.Subject, err)
+	}
+	if u == nil || u.TokenGeneration != claims.Generation {
+		return nil, autherrors.TokenRevokedErr{ID: claims.ID}
+	}
+	// The realms may share their keys, a token is only valid in the realm of its user.
+	if claims.Tenant != u.Tenant {
+		return nil, autherrors.NewInvalidTokenErr(fmt.Errorf("unexpected tenant %q", claims.Tenant))
+	}
+
+	return u, nil
+}
+
+// verifyClient checks that the OAuth client of a client_credentials token exists in the realm of the token.
+func (as *JwtAuthService) verifyClient(ctx context.Context, claims *jwt.Claims) error {
+	client, err := as.OAuthClientStore.Get(ctx, claims.Subject)
+	if err != nil {
+		return fmt.Errorf("error getting the OAuth client %s from store: %w", claims.Subject, err)
+	}
+	// The realms may share their keys, a token is only valid in the realm of its client.
+	if client == nil || client.Tenant != claims.Tenant {
+		return autherrors.TokenRevokedErr{ID: claims.ID}
+	}
+
+	return nil
+}
+
+// verifyWithoutPasswordChange verifies the token as verify does, and rejects the tokens of a user who must change
+// the password: they only allow the password change.
+func (as *JwtAuthService) verifyWithoutPasswordChange(ctx context.Context, token string) (*jwt.Claims, *models.User, error) {
+	claims, u, err := as.verify(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if claims.PasswordChange {
+		return nil, nil, autherrors.PasswordChangeRequiredErr{Name: u.Username}
+	}
+
+	return claims, u, nil
+}
+
+func (as *JwtAuthService) JWKS(ctx context.Context) (jwt.JSONWebKeySet, error) {
+	return as.KeySet.JWKS(), nil
+}
+
+func (as *JwtAuthService) UnlockUser(ctx context.Context, username string) error {
+	if err := as.LoginThrottler.Unlock(ctx, username); err != nil {
+		return fmt.Errorf("error unlocking the user %s: %w", username, err)
+	}
+	return nil
+}
+
+func (as *JwtAuthService) EnrollTOTP(ctx context.Context, token string) (*models.TOTPEnrollment, error) {
+	if as.MFAService == nil {
+		return nil, autherrors.MFADisabledErr{}
+	}
+	_, u, err := as.verifyWithoutPasswordChange(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return as.MFAService.Enroll(ctx, u.Username)
+}
+
+func (as *JwtAuthService) ConfirmTOTP(ctx context.Context, token, code string) error {
+	if as.MFAService == nil {
+		return autherrors.MFADisabledErr{}
+	}
+	_, u, err := as.verifyWithoutPasswordChange(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	return as.MFAService.Confirm(ctx, u.Username, code)
+}
+
+func (as *JwtAuthService) VerifyMFA(ctx context.Context, mfaToken, code string) (*models.Tokens, error) {
+	u, err := as.VerifyMFAUser(ctx, mfaToken, code)
+	if err != nil {
+		return nil, err
+	}
+
+	familyID, err := newOpaqueToken(16)
+	if err != nil {
+		return nil, err
+	}
+	return as.issueTokens(ctx, *u, familyID)
+}
+
+func (as *JwtAuthService) VerifyMFAUser(ctx context.Context, mfaToken, code string) (*models.User, error) {
+	if as.MFAService == nil {
+		return nil, autherrors.MFADisabledErr{}
+	}
+	username, err := as.MFAService.Redeem(ctx, mfaToken, code)
+	if err != nil {
+		if errors.As(err, &autherrors.InvalidMFACodeErr{}) {
+			as.failLogin(ctx, username, peerAddress(ctx))
+		}
+		return nil, err
+	}
+	if err := as.LoginThrottler.Succeed(ctx, username); err != nil {
+		as.logger.Error("failed to reset the failed logins", zap.Error(err))
+	}
+
+	u, err := as.UserStore.Get(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user %s from store: %w", username, err)
+	}
+	if u == nil {
+		return nil, autherrors.InvalidMFATokenErr{}
+	}
+
+	return u, nil
 }
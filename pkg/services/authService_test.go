@@ -2,13 +2,19 @@ package services
 
 import (
 	autherrors "auth/pkg/errors"
+	"auth/pkg/hashers"
+	"auth/pkg/jwt"
 	"auth/pkg/models"
 	"context"
 	"fmt"
+	gojwt "github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"testing"
+	"time"
 )
 
 func Test_authService_Authenticate_no_error(t *testing.T) {
@@ -19,20 +25,36 @@ func Test_authService_Authenticate_no_error(t *testing.T) {
 	ctx := context.Background()
 	password := "test"
 	username := "user"
-	hash, _ := bcrypt.GenerateFromPassword([]byte(password), 10)
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
 	user := models.User{Username: username, Password: string(hash)}
 
+	mockThrottler.EXPECT().Check(ctx, username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Succeed(ctx, username).Return(nil).Times(1)
 	mockUserStore.EXPECT().Get(ctx, username).Return(&user, nil).Times(1)
-	mockJwtGenerator.EXPECT().Generate(user).Return("sdjklfjasdkl.jfsda.fasdf", nil).Times(1)
+	mockRoleStore.EXPECT().ListByUser(ctx, user.Username).Return(nil, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(user, gomock.Any()).Return("sdjklfjasdkl.jfsda.fasdf", nil).Times(1)
+	mockRefreshStore.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, rt models.RefreshToken) error {
+		require.Equal(t, username, rt.Username)
+		require.NotEmpty(t, rt.FamilyID)
+		require.NotEmpty(t, rt.Hash)
+		require.True(t, rt.ExpiresAt.After(time.Now()))
+		return nil
+	}).Times(1)
 
 	s := &JwtAuthService{
-		UserStore:    mockUserStore,
-		JwtGenerator: mockJwtGenerator,
+		UserStore:          mockUserStore,
+		RefreshTokenStore:  mockRefreshStore,
+		RoleStore:          mockRoleStore,
+		JwtGenerator:       mockJwtGenerator,
+		PasswordHasher:     hashers.NewBcryptHasher(bcrypt.MinCost),
+		LoginThrottler:     mockThrottler,
+		RefreshExpDuration: time.Hour,
 	}
 
-	token, err := s.Authenticate(ctx, username, password)
+	tokens, err := s.Authenticate(ctx, username, password)
 	require.NoError(t, err)
-	require.NotEmpty(t, token)
+	require.Equal(t, "sdjklfjasdkl.jfsda.fasdf", tokens.AccessToken)
+	require.NotEmpty(t, tokens.RefreshToken)
 }
 
 func Test_authService_Authenticate_store_get_error(t *testing.T) {
@@ -43,16 +65,20 @@ func Test_authService_Authenticate_store_get_error(t *testing.T) {
 	ctx := context.Background()
 	password := "test"
 	username := "user"
-	hash, _ := bcrypt.GenerateFromPassword([]byte(password), 10)
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
 	user := models.User{Username: username, Password: string(hash)}
 	errorMsg := "something went wrong"
 
+	mockThrottler.EXPECT().Check(ctx, username, "").Return(nil).Times(1)
 	mockUserStore.EXPECT().Get(ctx, username).Return(nil, fmt.Errorf(errorMsg)).Times(1)
 	mockJwtGenerator.EXPECT().Generate(&user).Return("sdjklfjasdkl.jfsda.fasdf", nil).Times(0)
 
 	s := &JwtAuthService{
-		UserStore:    mockUserStore,
-		JwtGenerator: mockJwtGenerator,
+		UserStore:         mockUserStore,
+		RefreshTokenStore: mockRefreshStore,
+		RoleStore:         mockRoleStore,
+		JwtGenerator:      mockJwtGenerator,
+		LoginThrottler:    mockThrottler,
 	}
 
 	//Act
@@ -74,10 +100,12 @@ func Test_authService_Authenticate_store_no_user(t *testing.T) {
 	password := "test"
 	username := "user"
 
+	mockThrottler.EXPECT().Check(ctx, username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Fail(ctx, username, "").Return(nil).Times(1)
 	mockUserStore.EXPECT().Get(ctx, username).Return(nil, nil).Times(1)
 	mockJwtGenerator.EXPECT().Generate(gomock.Any()).Times(0)
 
-	s := NewJwtAuthService(mockUserStore, mockJwtGenerator)
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
 
 	//Act
 	token, err := s.Authenticate(ctx, username, password)
@@ -96,13 +124,15 @@ func Test_authService_Authenticate_password_mismatch(t *testing.T) {
 	ctx := context.Background()
 	password := "test"
 	username := "user"
-	hash, _ := bcrypt.GenerateFromPassword([]byte("other"), 10)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("other"), bcrypt.MinCost)
 	user := models.User{Username: username, Password: string(hash)}
 
+	mockThrottler.EXPECT().Check(ctx, username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Fail(ctx, username, "").Return(nil).Times(1)
 	mockUserStore.EXPECT().Get(ctx, username).Return(&user, nil).Times(1)
 	mockJwtGenerator.EXPECT().Generate(&user).Times(0)
 
-	s := NewJwtAuthService(mockUserStore, mockJwtGenerator)
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
 
 	//Act
 	token, err := s.Authenticate(ctx, username, password)
@@ -124,10 +154,11 @@ func Test_authService_Authenticate_bcrypt_error(t *testing.T) {
 
 	user := models.User{Username: username, Password: ""}
 
+	mockThrottler.EXPECT().Check(ctx, username, "").Return(nil).Times(1)
 	mockUserStore.EXPECT().Get(ctx, username).Return(&user, nil).Times(1)
 	mockJwtGenerator.EXPECT().Generate(&user).Times(0)
 
-	s := NewJwtAuthService(mockUserStore, mockJwtGenerator)
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
 
 	//Act
 	token, err := s.Authenticate(ctx, username, password)
@@ -139,6 +170,154 @@ func Test_authService_Authenticate_bcrypt_error(t *testing.T) {
 
 }
 
+func Test_authService_Authenticate_rehash(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	user := models.User{Username: "user", Password: "$2a$04$outdated"}
+
+	mockThrottler.EXPECT().Check(ctx, user.Username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Succeed(ctx, user.Username).Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockHasher.EXPECT().Verify(user.Password, "password").Return(true, nil).Times(1)
+	mockHasher.EXPECT().NeedsRehash(user.Password).Return(true).Times(1)
+	mockHasher.EXPECT().Hash(dummyPassword).Return("$argon2id$dummy", nil).Times(1)
+	mockHasher.EXPECT().Hash("password").Return("$argon2id$current", nil).Times(1)
+	mockUserStore.EXPECT().UpdatePasswordHash(ctx, user.Username, user.Password, "$argon2id$current").Return(true, nil).Times(1)
+	mockRoleStore.EXPECT().ListByUser(ctx, user.Username).Return(nil, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(user, gomock.Any()).Return("token", nil).Times(1)
+	mockRefreshStore.EXPECT().Create(ctx, gomock.Any()).Return(nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, mockHasher, mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.Authenticate(ctx, user.Username, "password")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, "token", tokens.AccessToken)
+}
+
+func Test_authService_Authenticate_rehash_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	user := models.User{Username: "user", Password: "$2a$04$outdated"}
+
+	mockThrottler.EXPECT().Check(ctx, user.Username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Succeed(ctx, user.Username).Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockHasher.EXPECT().Verify(user.Password, "password").Return(true, nil).Times(1)
+	mockHasher.EXPECT().NeedsRehash(user.Password).Return(true).Times(1)
+	mockHasher.EXPECT().Hash(dummyPassword).Return("$argon2id$dummy", nil).Times(1)
+	mockHasher.EXPECT().Hash("password").Return("$argon2id$current", nil).Times(1)
+	mockUserStore.EXPECT().UpdatePasswordHash(ctx, user.Username, user.Password, "$argon2id$current").Return(false, fmt.Errorf("something went wrong")).Times(1)
+	mockRoleStore.EXPECT().ListByUser(ctx, user.Username).Return(nil, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(user, gomock.Any()).Return("token", nil).Times(1)
+	mockRefreshStore.EXPECT().Create(ctx, gomock.Any()).Return(nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, mockHasher, mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.Authenticate(ctx, user.Username, "password")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, "token", tokens.AccessToken)
+}
+
+func Test_authService_Authenticate_throttled(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	username := "user"
+
+	mockThrottler.EXPECT().Check(ctx, username, "").Return(autherrors.AccountLockedErr{Until: time.Now().Add(time.Minute)}).Times(1)
+	mockUserStore.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+	mockThrottler.EXPECT().Fail(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.Authenticate(ctx, username, "password")
+
+	//Verify
+	require.Error(t, err)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Empty(t, tokens)
+}
+
+func Test_authService_Authenticate_roles(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	password := "test"
+	username := "user"
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	user := models.User{Username: username, Password: string(hash)}
+	roles := []models.Role{{Name: "admin", Permissions: []string{"users:read", "users:write"}}}
+
+	mockThrottler.EXPECT().Check(ctx, username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Succeed(ctx, username).Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, username).Return(&user, nil).Times(1)
+	mockRoleStore.EXPECT().ListByUser(ctx, username).Return(roles, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(user, gomock.Any()).DoAndReturn(func(_ models.User, options ...jwt.Option) (string, error) {
+		claims := gojwt.MapClaims{}
+		for _, option := range options {
+			option(claims)
+		}
+		require.Equal(t, []string{"admin"}, claims["roles"])
+		require.Equal(t, "users:read users:write", claims["scope"])
+		return "token", nil
+	}).Times(1)
+	mockRefreshStore.EXPECT().Create(ctx, gomock.Any()).Return(nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.Authenticate(ctx, username, password)
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, "token", tokens.AccessToken)
+}
+
+func Test_authService_Authenticate_role_store_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	password := "test"
+	username := "user"
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	user := models.User{Username: username, Password: string(hash)}
+	errorMsg := "can't list the roles"
+
+	mockThrottler.EXPECT().Check(ctx, username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Succeed(ctx, username).Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, username).Return(&user, nil).Times(1)
+	mockRoleStore.EXPECT().ListByUser(ctx, username).Return(nil, fmt.Errorf(errorMsg)).Times(1)
+	mockJwtGenerator.EXPECT().Generate(gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.Authenticate(ctx, username, password)
+
+	//Verify
+	require.EqualError(t, err, fmt.Sprintf("error listing the roles of the user: %s", errorMsg))
+	require.Nil(t, tokens)
+}
+
 func Test_authService_Authenticate_generator_error(t *testing.T) {
 	teardownTest := setupTest(t)
 	defer teardownTest(t)
@@ -147,14 +326,17 @@ func Test_authService_Authenticate_generator_error(t *testing.T) {
 	ctx := context.Background()
 	password := "test"
 	username := "user"
-	hash, _ := bcrypt.GenerateFromPassword([]byte(password), 10)
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
 	user := models.User{Username: username, Password: string(hash)}
 	errorMsg := "can't generate the token"
 
+	mockThrottler.EXPECT().Check(ctx, username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Succeed(ctx, username).Return(nil).Times(1)
 	mockUserStore.EXPECT().Get(ctx, username).Return(&user, nil).Times(1)
-	mockJwtGenerator.EXPECT().Generate(user).Return("", fmt.Errorf(errorMsg)).Times(1)
+	mockRoleStore.EXPECT().ListByUser(ctx, user.Username).Return(nil, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(user, gomock.Any()).Return("", fmt.Errorf(errorMsg)).Times(1)
 
-	s := NewJwtAuthService(mockUserStore, mockJwtGenerator)
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
 
 	//Act
 	token, err := s.Authenticate(ctx, username, password)
@@ -164,3 +346,737 @@ func Test_authService_Authenticate_generator_error(t *testing.T) {
 	require.EqualError(t, err, fmt.Sprintf("error generating the token: %s", errorMsg))
 	require.Empty(t, token)
 }
+
+func Test_authService_Authenticate_refresh_store_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	password := "test"
+	username := "user"
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	user := models.User{Username: username, Password: string(hash)}
+	errorMsg := "something went wrong"
+
+	mockThrottler.EXPECT().Check(ctx, username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Succeed(ctx, username).Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, username).Return(&user, nil).Times(1)
+	mockRoleStore.EXPECT().ListByUser(ctx, user.Username).Return(nil, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(user, gomock.Any()).Return("sdjklfjasdkl.jfsda.fasdf", nil).Times(1)
+	mockRefreshStore.EXPECT().Create(ctx, gomock.Any()).Return(fmt.Errorf(errorMsg)).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.Authenticate(ctx, username, password)
+
+	//Verify
+	require.Error(t, err)
+	require.EqualError(t, err, fmt.Sprintf("error storing the refresh token: %s", errorMsg))
+	require.Nil(t, tokens)
+}
+
+func Test_authService_Refresh_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	refreshToken := "refresh"
+	user := models.User{Username: "user", Password: "hash"}
+	stored := models.RefreshToken{Hash: hashToken(refreshToken), FamilyID: "family", Username: user.Username, ExpiresAt: time.Now().Add(time.Hour)}
+
+	mockRefreshStore.EXPECT().Get(ctx, stored.Hash).Return(&stored, nil).Times(1)
+	mockRefreshStore.EXPECT().Use(ctx, stored.Hash).Return(true, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockRoleStore.EXPECT().ListByUser(ctx, user.Username).Return(nil, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(user, gomock.Any()).Return("sdjklfjasdkl.jfsda.fasdf", nil).Times(1)
+	mockRefreshStore.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, rt models.RefreshToken) error {
+		require.Equal(t, "family", rt.FamilyID)
+		require.NotEqual(t, stored.Hash, rt.Hash)
+		return nil
+	}).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.Refresh(ctx, refreshToken)
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, "sdjklfjasdkl.jfsda.fasdf", tokens.AccessToken)
+	require.NotEmpty(t, tokens.RefreshToken)
+	require.NotEqual(t, refreshToken, tokens.RefreshToken)
+}
+
+func Test_authService_Refresh_invalid_token(t *testing.T) {
+	refreshToken := "refresh"
+	tests := []struct {
+		name   string
+		stored *models.RefreshToken
+	}{
+		{"Unknown token", nil},
+		{"Revoked token", &models.RefreshToken{Hash: hashToken(refreshToken), FamilyID: "family", Username: "user", ExpiresAt: time.Now().Add(time.Hour), Revoked: true}},
+		{"Expired token", &models.RefreshToken{Hash: hashToken(refreshToken), FamilyID: "family", Username: "user", ExpiresAt: time.Now().Add(-time.Hour)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teardownTest := setupTest(t)
+			defer teardownTest(t)
+
+			ctx := context.Background()
+			mockRefreshStore.EXPECT().Get(ctx, hashToken(refreshToken)).Return(tt.stored, nil).Times(1)
+			mockRefreshStore.EXPECT().Use(ctx, gomock.Any()).Times(0)
+			mockRefreshStore.EXPECT().RevokeFamily(ctx, gomock.Any()).Times(0)
+			mockJwtGenerator.EXPECT().Generate(gomock.Any()).Times(0)
+
+			s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+			tokens, err := s.Refresh(ctx, refreshToken)
+
+			require.Error(t, err)
+			require.ErrorIs(t, err, autherrors.InvalidRefreshTokenErr{})
+			require.Nil(t, tokens)
+		})
+	}
+}
+
+func Test_authService_Refresh_reuse_revokes_family(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	refreshToken := "refresh"
+	stored := models.RefreshToken{Hash: hashToken(refreshToken), FamilyID: "family", Username: "user", ExpiresAt: time.Now().Add(time.Hour), Used: true}
+
+	mockRefreshStore.EXPECT().Get(ctx, stored.Hash).Return(&stored, nil).Times(1)
+	mockRefreshStore.EXPECT().RevokeFamily(ctx, "family").Return(nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.Refresh(ctx, refreshToken)
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.InvalidRefreshTokenErr{})
+	require.Nil(t, tokens)
+}
+
+func Test_authService_Refresh_concurrent_use_revokes_family(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	refreshToken := "refresh"
+	stored := models.RefreshToken{Hash: hashToken(refreshToken), FamilyID: "family", Username: "user", ExpiresAt: time.Now().Add(time.Hour)}
+
+	mockRefreshStore.EXPECT().Get(ctx, stored.Hash).Return(&stored, nil).Times(1)
+	mockRefreshStore.EXPECT().Use(ctx, stored.Hash).Return(false, nil).Times(1)
+	mockRefreshStore.EXPECT().RevokeFamily(ctx, "family").Return(nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.Refresh(ctx, refreshToken)
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.InvalidRefreshTokenErr{})
+	require.Nil(t, tokens)
+}
+
+func Test_authService_Introspect_active(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	claims := &jwt.Claims{Scope: "read write"}
+	claims.Subject = "user"
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&models.User{Username: "user"}, nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	got, err := s.Introspect(ctx, token)
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, claims, got)
+}
+
+func Test_authService_Introspect_inactive(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(nil, autherrors.TokenExpiredErr{}).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	got, err := s.Introspect(ctx, token)
+
+	//Verify
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+func Test_authService_JWKS(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	jwks := jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{{Kty: "OKP", Kid: "k1", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: "x"}}}
+
+	mockKeySet.EXPECT().JWKS().Return(jwks).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	got, err := s.JWKS(ctx)
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, jwks, got)
+}
+
+func Test_authService_Introspect_revoked(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	claims := &jwt.Claims{}
+	claims.ID = "jti"
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockRevocationStore.EXPECT().IsRevoked(ctx, "jti").Return(true, nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	got, err := s.Introspect(ctx, token)
+
+	//Verify
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+func Test_authService_Introspect_revocation_store_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	claims := &jwt.Claims{}
+	claims.ID = "jti"
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockRevocationStore.EXPECT().IsRevoked(ctx, "jti").Return(false, fmt.Errorf("something went wrong")).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	got, err := s.Introspect(ctx, token)
+
+	//Verify
+	require.Error(t, err)
+	require.Nil(t, got)
+}
+
+func Test_authService_Logout_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	refreshToken := "refresh"
+	expiresAt := time.Now().Add(time.Minute)
+	claims := &jwt.Claims{}
+	claims.ID = "jti"
+	claims.Subject = "user"
+	claims.ExpiresAt = gojwt.NewNumericDate(expiresAt)
+	stored := models.RefreshToken{Hash: hashToken(refreshToken), FamilyID: "family", Username: "user"}
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockRevocationStore.EXPECT().IsRevoked(ctx, "jti").Return(false, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&models.User{Username: "user"}, nil).Times(1)
+	mockRefreshStore.EXPECT().Get(ctx, stored.Hash).Return(&stored, nil).Times(1)
+	mockRevocationStore.EXPECT().Revoke(ctx, "jti", claims.ExpiresAt.Time).Return(nil).Times(1)
+	mockRefreshStore.EXPECT().RevokeFamily(ctx, "family").Return(nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	err := s.Logout(ctx, token, refreshToken)
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_authService_Logout_refresh_token_of_other_user(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	refreshToken := "refresh"
+	claims := &jwt.Claims{}
+	claims.ID = "jti"
+	claims.Subject = "user"
+	claims.ExpiresAt = gojwt.NewNumericDate(time.Now().Add(time.Minute))
+	stored := models.RefreshToken{Hash: hashToken(refreshToken), FamilyID: "family", Username: "other"}
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockRevocationStore.EXPECT().IsRevoked(ctx, "jti").Return(false, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&models.User{Username: "user"}, nil).Times(1)
+	mockRefreshStore.EXPECT().Get(ctx, stored.Hash).Return(&stored, nil).Times(1)
+	mockRevocationStore.EXPECT().Revoke(ctx, gomock.Any(), gomock.Any()).Times(0)
+	mockRefreshStore.EXPECT().RevokeFamily(ctx, gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	err := s.Logout(ctx, token, refreshToken)
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.InvalidRefreshTokenErr{})
+}
+
+func Test_authService_Logout_revoked_token(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	claims := &jwt.Claims{}
+	claims.ID = "jti"
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockRevocationStore.EXPECT().IsRevoked(ctx, "jti").Return(true, nil).Times(1)
+	mockRevocationStore.EXPECT().Revoke(ctx, gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	err := s.Logout(ctx, token, "")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.TokenRevokedErr{ID: "jti"})
+}
+
+func Test_authService_Introspect_previous_generation(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	claims := &jwt.Claims{Generation: 1}
+	claims.ID = "jti"
+	claims.Subject = "user"
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockRevocationStore.EXPECT().IsRevoked(ctx, "jti").Return(false, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&models.User{Username: "user", TokenGeneration: 2}, nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	got, err := s.Introspect(ctx, token)
+
+	//Verify
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+func Test_authService_Introspect_other_tenant(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	claims := &jwt.Claims{Tenant: "other"}
+	claims.Subject = "user"
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&models.User{Tenant: "default", Username: "user"}, nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	got, err := s.Introspect(ctx, token)
+
+	//Verify
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+func Test_authService_ChangePassword_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	claims := &jwt.Claims{Generation: 1}
+	claims.ID = "jti"
+	claims.Subject = "user"
+	hash, _ := bcrypt.GenerateFromPassword([]byte("current"), bcrypt.MinCost)
+	user := models.User{Username: "user", Password: string(hash), TokenGeneration: 1}
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockRevocationStore.EXPECT().IsRevoked(ctx, "jti").Return(false, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&user, nil).Times(1)
+	mockValidator.EXPECT().Validate("newpassword").Return(nil).Times(1)
+	mockUserStore.EXPECT().Update(ctx, "user", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, u models.User) (bool, error) {
+		require.Equal(t, "user", u.Username)
+		require.Equal(t, int64(2), u.TokenGeneration)
+		require.NoError(t, bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("newpassword")))
+		return true, nil
+	}).Times(1)
+	mockRefreshStore.EXPECT().RevokeUser(ctx, "user").Return(nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	err := s.ChangePassword(ctx, token, "current", "newpassword")
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_authService_ChangePassword_wrong_current_password(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	claims := &jwt.Claims{}
+	claims.Subject = "user"
+	hash, _ := bcrypt.GenerateFromPassword([]byte("current"), bcrypt.MinCost)
+	user := models.User{Username: "user", Password: string(hash)}
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&user, nil).Times(1)
+	mockValidator.EXPECT().Validate(gomock.Any()).Times(0)
+	mockUserStore.EXPECT().Update(ctx, gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	err := s.ChangePassword(ctx, token, "wrong", "newpassword")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.AuthenticationFailErr("user"))
+}
+
+func Test_authService_ChangePassword_invalid_new_password(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	claims := &jwt.Claims{}
+	claims.Subject = "user"
+	hash, _ := bcrypt.GenerateFromPassword([]byte("current"), bcrypt.MinCost)
+	user := models.User{Username: "user", Password: string(hash)}
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&user, nil).Times(1)
+	mockValidator.EXPECT().Validate("a").Return(fmt.Errorf("password doesn't meet security criteria")).Times(1)
+	mockUserStore.EXPECT().Update(ctx, gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	err := s.ChangePassword(ctx, token, "current", "a")
+
+	//Verify
+	require.Error(t, err)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_authService_UnlockUser(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockThrottler.EXPECT().Unlock(ctx, "user").Return(nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	err := s.UnlockUser(ctx, "user")
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_authService_Authenticate_mfa_required(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("test"), bcrypt.MinCost)
+	user := models.User{Username: "user", Password: string(hash)}
+
+	mockThrottler.EXPECT().Check(ctx, user.Username, "").Return(nil).Times(1)
+	// The failed logins are only forgotten once the second factor is verified.
+	mockThrottler.EXPECT().Succeed(gomock.Any(), gomock.Any()).Times(0)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockMFAService.EXPECT().Enabled(ctx, user.Username).Return(true, nil).Times(1)
+	mockMFAService.EXPECT().Challenge(ctx, user.Username).Return("mfa", nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(gomock.Any()).Times(0)
+	mockRefreshStore.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, mockMFAService, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.Authenticate(ctx, user.Username, "test")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, &models.Tokens{MFAToken: "mfa"}, tokens)
+}
+
+func Test_authService_Authenticate_mfa_not_enabled(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("test"), bcrypt.MinCost)
+	user := models.User{Username: "user", Password: string(hash)}
+
+	mockThrottler.EXPECT().Check(ctx, user.Username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Succeed(ctx, user.Username).Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockMFAService.EXPECT().Enabled(ctx, user.Username).Return(false, nil).Times(1)
+	mockMFAService.EXPECT().Challenge(gomock.Any(), gomock.Any()).Times(0)
+	mockRoleStore.EXPECT().ListByUser(ctx, user.Username).Return(nil, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(user, gomock.Any()).Return("token", nil).Times(1)
+	mockRefreshStore.EXPECT().Create(ctx, gomock.Any()).Return(nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, mockMFAService, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.Authenticate(ctx, user.Username, "test")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, "token", tokens.AccessToken)
+	require.Empty(t, tokens.MFAToken)
+}
+
+func Test_authService_VerifyMFA_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	user := models.User{Username: "user"}
+
+	mockMFAService.EXPECT().Redeem(ctx, "mfa", "123456").Return(user.Username, nil).Times(1)
+	mockThrottler.EXPECT().Succeed(ctx, user.Username).Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockRoleStore.EXPECT().ListByUser(ctx, user.Username).Return(nil, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(user, gomock.Any()).Return("token", nil).Times(1)
+	mockRefreshStore.EXPECT().Create(ctx, gomock.Any()).Return(nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, mockMFAService, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.VerifyMFA(ctx, "mfa", "123456")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, "token", tokens.AccessToken)
+	require.NotEmpty(t, tokens.RefreshToken)
+}
+
+func Test_authService_AuthenticateUser_no_tokens(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("test"), bcrypt.MinCost)
+	user := models.User{Username: "user", Password: string(hash)}
+
+	mockThrottler.EXPECT().Check(ctx, user.Username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Succeed(ctx, user.Username).Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockMFAService.EXPECT().Enabled(ctx, user.Username).Return(false, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(gomock.Any(), gomock.Any()).Times(0)
+	mockRefreshStore.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, mockMFAService, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	u, mfaToken, err := s.AuthenticateUser(ctx, user.Username, "test")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, user.Username, u.Username)
+	require.Empty(t, mfaToken)
+}
+
+func Test_authService_AuthenticateUser_mfa_required(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("test"), bcrypt.MinCost)
+	user := models.User{Username: "user", Password: string(hash)}
+
+	mockThrottler.EXPECT().Check(ctx, user.Username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Succeed(gomock.Any(), gomock.Any()).Times(0)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockMFAService.EXPECT().Enabled(ctx, user.Username).Return(true, nil).Times(1)
+	mockMFAService.EXPECT().Challenge(ctx, user.Username).Return("mfa", nil).Times(1)
+	mockRefreshStore.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, mockMFAService, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	u, mfaToken, err := s.AuthenticateUser(ctx, user.Username, "test")
+
+	//Verify
+	require.NoError(t, err)
+	require.Nil(t, u)
+	require.Equal(t, "mfa", mfaToken)
+}
+
+func Test_authService_VerifyMFAUser_no_tokens(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	user := models.User{Username: "user"}
+
+	mockMFAService.EXPECT().Redeem(ctx, "mfa", "123456").Return(user.Username, nil).Times(1)
+	mockThrottler.EXPECT().Succeed(ctx, user.Username).Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(gomock.Any(), gomock.Any()).Times(0)
+	mockRefreshStore.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, mockMFAService, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	u, err := s.VerifyMFAUser(ctx, "mfa", "123456")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, &user, u)
+}
+
+func Test_authService_VerifyMFA_invalid_code(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockMFAService.EXPECT().Redeem(ctx, "mfa", "000000").Return("user", autherrors.InvalidMFACodeErr{}).Times(1)
+	// The failed codes count towards the lockout of the user.
+	mockThrottler.EXPECT().Fail(ctx, "user", "").Return(nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, mockMFAService, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.VerifyMFA(ctx, "mfa", "000000")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.InvalidMFACodeErr{})
+	require.Nil(t, tokens)
+}
+
+func Test_authService_VerifyMFA_invalid_token(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockMFAService.EXPECT().Redeem(ctx, "mfa", "123456").Return("", autherrors.InvalidMFATokenErr{}).Times(1)
+	mockThrottler.EXPECT().Fail(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, mockMFAService, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.VerifyMFA(ctx, "mfa", "123456")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.InvalidMFATokenErr{})
+	require.Nil(t, tokens)
+}
+
+func Test_authService_EnrollTOTP_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	claims := &jwt.Claims{}
+	claims.Subject = "user"
+	enrollment := &models.TOTPEnrollment{Secret: "SECRET", URI: "otpauth://totp/auth:user?secret=SECRET"}
+
+	mockJwtVerifier.EXPECT().Verify("token").Return(claims, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&models.User{Username: "user"}, nil).Times(1)
+	mockMFAService.EXPECT().Enroll(ctx, "user").Return(enrollment, nil).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, mockMFAService, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	got, err := s.EnrollTOTP(ctx, "token")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, enrollment, got)
+}
+
+func Test_authService_EnrollTOTP_mfa_disabled(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockJwtVerifier.EXPECT().Verify(gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	got, err := s.EnrollTOTP(ctx, "token")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.MFADisabledErr{})
+	require.Nil(t, got)
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"auth/pkg/secrets"
+	"auth/pkg/stores"
+	"auth/pkg/totp"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// defaultMFAMaxAttempts is the number of codes accepted by an MFA token when the configuration doesn't set it.
+const defaultMFAMaxAttempts = 5
+
+// totpSecretData is the associated data of the encrypted TOTP secrets, a ciphertext of another kind of secret
+// is not decrypted as a TOTP secret.
+var totpSecretData = []byte("totp")
+
+type MFAService interface {
+	//Enroll generates a new TOTP secret for the user with the username. The secret is only used once confirmed.
+	Enroll(ctx context.Context, username string) (*models.TOTPEnrollment, error)
+	//Confirm activates the TOTP secret of the user with the username with a code of the secret.
+	Confirm(ctx context.Context, username, code string) error
+	//Enabled returns true if the user with the username has an active TOTP secret.
+	Enabled(ctx context.Context, username string) (bool, error)
+	//Challenge returns a new MFA token of the user with the username, redeemed with a code by Redeem.
+	Challenge(ctx context.Context, username string) (string, error)
+	//Redeem checks the code of the MFA token and returns the username of the token. The token can only be redeemed once.
+	//The username is also returned with autherrors.InvalidMFACodeErr, so the failure can be throttled.
+	Redeem(ctx context.Context, mfaToken, code string) (string, error)
+}
+
+type mfaService struct {
+	totpStore            stores.TOTPStore
+	challengeStore       stores.MFAChallengeStore
+	cipher               *secrets.Cipher
+	issuer               string
+	challengeExpDuration time.Duration
+	maxAttempts          int
+	skew                 int
+	logger               *zap.Logger
+}
+
+// NewMFAService creates a new instance of an MFAService with TOTP codes.
+// The TOTP secrets are encrypted with the cipher before they are stored.
+func NewMFAService(
+	totpStore stores.TOTPStore,
+	challengeStore stores.MFAChallengeStore,
+	cipher *secrets.Cipher,
+	configuration config.MFA,
+) MFAService {
+	s := &mfaService{
+		totpStore:            totpStore,
+		challengeStore:       challengeStore,
+		cipher:               cipher,
+		issuer:               configuration.Issuer,
+		challengeExpDuration: time.Minute * time.Duration(configuration.ChallengeExpDuration),
+		maxAttempts:          configuration.MaxAttempts,
+		skew:                 configuration.Skew,
+		logger:               zap.L().Named("MFAService"),
+	}
+	if s.maxAttempts <= 0 {
+		s.maxAttempts = defaultMFAMaxAttempts
+	}
+	return s
+}
+
+func (s *mfaService) Enroll(ctx context.Context, username string) (*models.TOTPEnrollment, error) {
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.cipher.Encrypt(secret, totpSecretData)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting the TOTP secret: %w", err)
+	}
+
+	enrolled, err := s.totpStore.Enroll(ctx, username, encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("error storing the TOTP secret: %w", err)
+	}
+	if !enrolled {
+		return nil, autherrors.TOTPAlreadyEnabledErr{Name: username}
+	}
+
+	return &models.TOTPEnrollment{
+		Secret: totp.EncodeSecret(secret),
+		URI:    totp.URI(s.issuer, username, secret),
+	}, nil
+}
+
+func (s *mfaService) Confirm(ctx context.Context, username, code string) error {
+	c, err := s.totpStore.Get(ctx, username)
+	if err != nil {
+		return fmt.Errorf("error getting the TOTP credential from store: %w", err)
+	}
+	if c == nil {
+		return autherrors.TOTPNotEnrolledErr{Name: username}
+	}
+	if c.Confirmed {
+		return autherrors.TOTPAlreadyEnabledErr{Name: username}
+	}
+
+	counter, err := s.validate(c, code)
+	if err != nil {
+		return err
+	}
+	// The time step of the code is recorded, the same code can't authenticate the user.
+	confirmed, err := s.totpStore.Confirm(ctx, username, counter)
+	if err != nil {
+		return fmt.Errorf("error confirming the TOTP credential: %w", err)
+	}
+	if !confirmed {
+		return autherrors.InvalidMFACodeErr{}
+	}
+
+	return nil
+}
+
+func (s *mfaService) Enabled(ctx context.Context, username string) (bool, error) {
+	c, err := s.totpStore.Get(ctx, username)
+	if err != nil {
+		return false, fmt.Errorf("error getting the TOTP credential from store: %w", err)
+	}
+	return c != nil && c.Confirmed, nil
+}
+
+func (s *mfaService) Challenge(ctx context.Context, username string) (string, error) {
+	token, err := newOpaqueToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = s.challengeStore.Create(ctx, models.MFAChallenge{
+		Hash:      hashToken(token),
+		Username:  username,
+		ExpiresAt: time.Now().Add(s.challengeExpDuration),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error storing the MFA challenge: %w", err)
+	}
+
+	return token, nil
+}
+
+func (s *mfaService) Redeem(ctx context.Context, mfaToken, code string) (string, error) {
+	hash := hashToken(mfaToken)
+	ch, err := s.challengeStore.Get(ctx, hash)
+	if err != nil {
+		return "", fmt.Errorf("error getting the MFA challenge from store: %w", err)
+	}
+	if ch == nil || ch.Used || time.Now().After(ch.ExpiresAt) {
+		return "", autherrors.InvalidMFATokenErr{}
+	}
+
+	// The attempt is counted before the code is checked, so concurrent requests can't try more codes.
+	attempted, err := s.challengeStore.Attempt(ctx, hash, s.maxAttempts)
+	if err != nil {
+		return "", fmt.Errorf("error attempting the MFA challenge: %w", err)
+	}
+	if !attempted {
+		return "", autherrors.InvalidMFATokenErr{}
+	}
+
+	c, err := s.totpStore.Get(ctx, ch.Username)
+	if err != nil {
+		return "", fmt.Errorf("error getting the TOTP credential from store: %w", err)
+	}
+	if c == nil || !c.Confirmed {
+		return "", autherrors.InvalidMFATokenErr{}
+	}
+	counter, err := s.validate(c, code)
+	if err != nil {
+		return ch.Username, err
+	}
+	// Two concurrent requests with the same code can pass the validation, only one can use its time step.
+	used, err := s.totpStore.UseCounter(ctx, ch.Username, counter)
+	if err != nil {
+		return "", fmt.Errorf("error using the TOTP code: %w", err)
+	}
+	if !used {
+		return ch.Username, autherrors.InvalidMFACodeErr{}
+	}
+
+	ok, err := s.challengeStore.Use(ctx, hash)
+	if err != nil {
+		return "", fmt.Errorf("error using the MFA challenge: %w", err)
+	}
+	if !ok {
+		return "", autherrors.InvalidMFATokenErr{}
+	}
+
+	return ch.Username, nil
+}
+
+// validate decrypts the secret of the TOTP credential and returns the time step of the code,
+// the codes of the time steps already used are rejected.
+func (s *mfaService) validate(c *models.TOTPCredential, code string) (int64, error) {
+	secret, err := s.cipher.Decrypt(c.Secret, totpSecretData)
+	if err != nil {
+		s.logger.Error("failed to decrypt the TOTP secret", zap.String("username", c.Username), zap.Error(err))
+		return 0, fmt.Errorf("error decrypting the TOTP secret: %w", err)
+	}
+	counter, ok := totp.Validate(secret, code, time.Now(), s.skew, c.LastCounter)
+	if !ok {
+		return 0, autherrors.InvalidMFACodeErr{}
+	}
+	return counter, nil
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"auth/pkg/secrets"
+	"auth/pkg/totp"
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestMFAService(t *testing.T) (MFAService, *secrets.Cipher) {
+	cipher, err := secrets.NewCipher([]byte(strings.Repeat("k", secrets.KeySize)))
+	require.NoError(t, err)
+	return NewMFAService(mockTOTPStore, mockChallengeStore, cipher, config.MFA{
+		Issuer:               "auth",
+		ChallengeExpDuration: 5,
+		MaxAttempts:          3,
+		Skew:                 1,
+	}), cipher
+}
+
+// newTestCredential returns a TOTP secret and its credential encrypted with the cipher.
+func newTestCredential(t *testing.T, cipher *secrets.Cipher, username string, confirmed bool, lastCounter int64) ([]byte, *models.TOTPCredential) {
+	secret, err := totp.NewSecret()
+	require.NoError(t, err)
+	encrypted, err := cipher.Encrypt(secret, totpSecretData)
+	require.NoError(t, err)
+	return secret, &models.TOTPCredential{Username: username, Secret: encrypted, Confirmed: confirmed, LastCounter: lastCounter}
+}
+
+func Test_mfaService_Enroll_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	s, cipher := newTestMFAService(t)
+	var stored string
+
+	mockTOTPStore.EXPECT().Enroll(ctx, "user", gomock.Any()).DoAndReturn(func(_ context.Context, _, secret string) (bool, error) {
+		stored = secret
+		return true, nil
+	}).Times(1)
+
+	//Act
+	enrollment, err := s.Enroll(ctx, "user")
+
+	//Verify
+	require.NoError(t, err)
+	require.NotContains(t, stored, enrollment.Secret)
+	secret, err := cipher.Decrypt(stored, totpSecretData)
+	require.NoError(t, err)
+	require.Equal(t, totp.EncodeSecret(secret), enrollment.Secret)
+	u, err := url.Parse(enrollment.URI)
+	require.NoError(t, err)
+	require.Equal(t, "/auth:user", u.Path)
+	require.Equal(t, enrollment.Secret, u.Query().Get("secret"))
+}
+
+func Test_mfaService_Enroll_already_enabled(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	s, _ := newTestMFAService(t)
+
+	mockTOTPStore.EXPECT().Enroll(ctx, "user", gomock.Any()).Return(false, nil).Times(1)
+
+	//Act
+	enrollment, err := s.Enroll(ctx, "user")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.TOTPAlreadyEnabledErr{Name: "user"})
+	require.Nil(t, enrollment)
+}
+
+func Test_mfaService_Confirm_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	s, cipher := newTestMFAService(t)
+	secret, credential := newTestCredential(t, cipher, "user", false, 0)
+	counter := totp.Counter(time.Now())
+
+	mockTOTPStore.EXPECT().Get(ctx, "user").Return(credential, nil).Times(1)
+	mockTOTPStore.EXPECT().Confirm(ctx, "user", counter).Return(true, nil).Times(1)
+
+	//Act
+	err := s.Confirm(ctx, "user", totp.Code(secret, counter, totp.Digits))
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_mfaService_Confirm_invalid_code(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	s, cipher := newTestMFAService(t)
+	secret, credential := newTestCredential(t, cipher, "user", false, 0)
+	code := totp.Code(secret, totp.Counter(time.Now())+5, totp.Digits)
+
+	mockTOTPStore.EXPECT().Get(ctx, "user").Return(credential, nil).Times(1)
+	mockTOTPStore.EXPECT().Confirm(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	err := s.Confirm(ctx, "user", code)
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.InvalidMFACodeErr{})
+}
+
+func Test_mfaService_Confirm_not_enrolled(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	s, _ := newTestMFAService(t)
+
+	mockTOTPStore.EXPECT().Get(ctx, "user").Return(nil, nil).Times(1)
+
+	//Act
+	err := s.Confirm(ctx, "user", "123456")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.TOTPNotEnrolledErr{Name: "user"})
+}
+
+func Test_mfaService_Challenge_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	s, _ := newTestMFAService(t)
+	var stored models.MFAChallenge
+
+	mockChallengeStore.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, c models.MFAChallenge) error {
+		stored = c
+		return nil
+	}).Times(1)
+
+	//Act
+	token, err := s.Challenge(ctx, "user")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, "user", stored.Username)
+	require.Equal(t, hashToken(token), stored.Hash)
+	require.WithinDuration(t, time.Now().Add(5*time.Minute), stored.ExpiresAt, time.Second)
+}
+
+func Test_mfaService_Redeem_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	s, cipher := newTestMFAService(t)
+	counter := totp.Counter(time.Now())
+	secret, credential := newTestCredential(t, cipher, "user", true, counter-1)
+	hash := hashToken("mfa")
+
+	mockChallengeStore.EXPECT().Get(ctx, hash).Return(&models.MFAChallenge{Hash: hash, Username: "user", ExpiresAt: time.Now().Add(time.Minute)}, nil).Times(1)
+	mockChallengeStore.EXPECT().Attempt(ctx, hash, 3).Return(true, nil).Times(1)
+	mockTOTPStore.EXPECT().Get(ctx, "user").Return(credential, nil).Times(1)
+	mockTOTPStore.EXPECT().UseCounter(ctx, "user", counter).Return(true, nil).Times(1)
+	mockChallengeStore.EXPECT().Use(ctx, hash).Return(true, nil).Times(1)
+
+	//Act
+	username, err := s.Redeem(ctx, "mfa", totp.Code(secret, counter, totp.Digits))
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, "user", username)
+}
+
+func Test_mfaService_Redeem_code_reused(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	s, cipher := newTestMFAService(t)
+	counter := totp.Counter(time.Now())
+	secret, credential := newTestCredential(t, cipher, "user", true, counter)
+	hash := hashToken("mfa")
+
+	mockChallengeStore.EXPECT().Get(ctx, hash).Return(&models.MFAChallenge{Hash: hash, Username: "user", ExpiresAt: time.Now().Add(time.Minute)}, nil).Times(1)
+	mockChallengeStore.EXPECT().Attempt(ctx, hash, 3).Return(true, nil).Times(1)
+	mockTOTPStore.EXPECT().Get(ctx, "user").Return(credential, nil).Times(1)
+	mockTOTPStore.EXPECT().UseCounter(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockChallengeStore.EXPECT().Use(gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	username, err := s.Redeem(ctx, "mfa", totp.Code(secret, counter, totp.Digits))
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.InvalidMFACodeErr{})
+	require.Equal(t, "user", username)
+}
+
+func Test_mfaService_Redeem_concurrent_code_use(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	s, cipher := newTestMFAService(t)
+	counter := totp.Counter(time.Now())
+	secret, credential := newTestCredential(t, cipher, "user", true, 0)
+	hash := hashToken("mfa")
+
+	mockChallengeStore.EXPECT().Get(ctx, hash).Return(&models.MFAChallenge{Hash: hash, Username: "user", ExpiresAt: time.Now().Add(time.Minute)}, nil).Times(1)
+	mockChallengeStore.EXPECT().Attempt(ctx, hash, 3).Return(true, nil).Times(1)
+	mockTOTPStore.EXPECT().Get(ctx, "user").Return(credential, nil).Times(1)
+	mockTOTPStore.EXPECT().UseCounter(ctx, "user", counter).Return(false, nil).Times(1)
+	mockChallengeStore.EXPECT().Use(gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	_, err := s.Redeem(ctx, "mfa", totp.Code(secret, counter, totp.Digits))
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.InvalidMFACodeErr{})
+}
+
+func Test_mfaService_Redeem_invalid_token(t *testing.T) {
+	tests := []struct {
+		name      string
+		challenge *models.MFAChallenge
+	}{
+		{"unknown", nil},
+		{"used", &models.MFAChallenge{Username: "user", ExpiresAt: time.Now().Add(time.Minute), Used: true}},
+		{"expired", &models.MFAChallenge{Username: "user", ExpiresAt: time.Now().Add(-time.Minute)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teardownTest := setupTest(t)
+			defer teardownTest(t)
+
+			//Prepare
+			ctx := context.Background()
+			s, _ := newTestMFAService(t)
+
+			mockChallengeStore.EXPECT().Get(ctx, hashToken("mfa")).Return(tt.challenge, nil).Times(1)
+			mockChallengeStore.EXPECT().Attempt(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			//Act
+			username, err := s.Redeem(ctx, "mfa", "123456")
+
+			//Verify
+			require.ErrorIs(t, err, autherrors.InvalidMFATokenErr{})
+			require.Empty(t, username)
+		})
+	}
+}
+
+func Test_mfaService_Redeem_too_many_attempts(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	s, _ := newTestMFAService(t)
+	hash := hashToken("mfa")
+
+	mockChallengeStore.EXPECT().Get(ctx, hash).Return(&models.MFAChallenge{Hash: hash, Username: "user", ExpiresAt: time.Now().Add(time.Minute), Attempts: 3}, nil).Times(1)
+	mockChallengeStore.EXPECT().Attempt(ctx, hash, 3).Return(false, nil).Times(1)
+	mockTOTPStore.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	_, err := s.Redeem(ctx, "mfa", "123456")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.InvalidMFATokenErr{})
+}
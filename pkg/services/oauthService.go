@@ -0,0 +1,187 @@
+package services
+
+import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
+	"auth/pkg/jwt"
+	"auth/pkg/models"
+	"auth/pkg/stores"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"go.uber.org/zap"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type OAuthService interface {
+	//CreateClient registers the OAuth client with the id, allowed to request the scopes and the audiences,
+	//and to redirect the users of the OpenID Connect logins to the redirect URIs.
+	//The secret of the client is only returned on creation, only its hash is stored.
+	CreateClient(ctx context.Context, id string, scopes, audiences, redirectURIs []string) (string, error)
+	//DeleteClient deletes the OAuth client with the id, the tokens already issued to the client become inactive.
+	DeleteClient(ctx context.Context, id string) error
+	//ClientCredentials authenticates the client and issues an access token to the client, with the requested scopes
+	//or all the allowed scopes if none are requested, valid for the audience if it is not empty.
+	ClientCredentials(ctx context.Context, clientID, clientSecret string, scopes []string, audience string) (*models.OAuthToken, error)
+	//Password authenticates the client, then issues the tokens of the user with the username and password.
+	//The users with multi-factor authentication must authenticate with the Authenticate RPC.
+	Password(ctx context.Context, clientID, clientSecret, username, password string) (*models.OAuthToken, error)
+}
+
+type oauthService struct {
+	oauthClientStore stores.OAuthClientStore
+	authService      AuthService
+	jwtGenerator     jwt.TokenGenerator
+	audience         string
+	expDuration      time.Duration
+	logger           *zap.Logger
+}
+
+// NewOAuthService creates a new instance of an OAuthService authenticating the clients of the oauthClientStore.
+// The users are authenticated by the authService, the tokens of the clients are generated by the jwtGenerator
+// for the audience and with the expiration of the config.Token.
+func NewOAuthService(oauthClientStore stores.OAuthClientStore, authService AuthService, jwtGenerator jwt.TokenGenerator, tokenConfig config.Token) OAuthService {
+	return &oauthService{
+		oauthClientStore: oauthClientStore,
+		authService:      authService,
+		jwtGenerator:     jwtGenerator,
+		audience:         tokenConfig.Audience,
+		expDuration:      time.Minute * time.Duration(tokenConfig.ExpDuration),
+		logger:           zap.L().Named("OAuthService"),
+	}
+}
+
+func (s *oauthService) CreateClient(ctx context.Context, id string, scopes, audiences, redirectURIs []string) (string, error) {
+	if id == "" || strings.ContainsAny(id, " \t\n") {
+		return "", autherrors.NewValidationErr(fmt.Errorf("invalid client id %q", id))
+	}
+	for _, scope := range scopes {
+		if scope == "" || strings.ContainsAny(scope, " \t\n") {
+			return "", autherrors.NewValidationErr(fmt.Errorf("invalid scope %q", scope))
+		}
+	}
+	for _, audience := range audiences {
+		if audience == "" {
+			return "", autherrors.NewValidationErr(fmt.Errorf("empty audience"))
+		}
+	}
+	for _, redirectURI := range redirectURIs {
+		// The redirect URIs are compared as strings, RFC 6749 section 3.1.2
+		u, err := url.Parse(redirectURI)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			return "", autherrors.NewValidationErr(fmt.Errorf("invalid redirect URI %q", redirectURI))
+		}
+	}
+	existing, err := s.oauthClientStore.Get(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("error getting the OAuth client from store: %w", err)
+	}
+	if existing != nil {
+		return "", autherrors.OAuthClientAlreadyExistErr{ID: id}
+	}
+
+	secret, err := newOpaqueToken(32)
+	if err != nil {
+		return "", err
+	}
+	client := models.OAuthClient{
+		ID:           id,
+		SecretHash:   hashToken(secret),
+		Scopes:       scopes,
+		Audiences:    audiences,
+		RedirectURIs: redirectURIs,
+	}
+	if err := s.oauthClientStore.Create(ctx, client); err != nil {
+		return "", fmt.Errorf("error storing the OAuth client: %w", err)
+	}
+	s.logger.Info("OAuth client created", zap.String("clientID", id))
+
+	return secret, nil
+}
+
+func (s *oauthService) DeleteClient(ctx context.Context, id string) error {
+	deleted, err := s.oauthClientStore.Delete(ctx, id)
+	if err != nil {
+		return fmt.Errorf("error deleting the OAuth client: %w", err)
+	}
+	if !deleted {
+		return autherrors.OAuthClientNotFoundErr{ID: id}
+	}
+	s.logger.Info("OAuth client deleted", zap.String("clientID", id))
+
+	return nil
+}
+
+func (s *oauthService) ClientCredentials(ctx context.Context, clientID, clientSecret string, scopes []string, audience string) (*models.OAuthToken, error) {
+	client, err := authenticateClient(ctx, s.oauthClientStore, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	for _, scope := range scopes {
+		if !contains(client.Scopes, scope) {
+			return nil, autherrors.InvalidScopeErr{Scope: scope}
+		}
+	}
+	options := []jwt.Option{jwt.WithOAuthClient(client.ID, scopes)}
+	if audience != "" && audience != s.audience {
+		if !contains(client.Audiences, audience) {
+			return nil, autherrors.InvalidAudienceErr{Audience: audience}
+		}
+		options = append(options, jwt.WithAudience(audience))
+	}
+
+	token, err := s.jwtGenerator.Generate(models.User{Username: client.ID, Tenant: client.Tenant}, options...)
+	if err != nil {
+		return nil, fmt.Errorf("error generating the token: %w", err)
+	}
+
+	return &models.OAuthToken{AccessToken: token, ExpiresIn: s.expDuration, Scopes: scopes}, nil
+}
+
+func (s *oauthService) Password(ctx context.Context, clientID, clientSecret, username, password string) (*models.OAuthToken, error) {
+	if _, err := authenticateClient(ctx, s.oauthClientStore, clientID, clientSecret); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.authService.Authenticate(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+	if tokens.MFAToken != "" {
+		return nil, autherrors.MFARequiredErr{Name: username}
+	}
+
+	return &models.OAuthToken{AccessToken: tokens.AccessToken, RefreshToken: tokens.RefreshToken, ExpiresIn: s.expDuration}, nil
+}
+
+// authenticateClient returns the OAuth client of the oauthClientStore with the id if the secret is its secret.
+func authenticateClient(ctx context.Context, oauthClientStore stores.OAuthClientStore, id, secret string) (*models.OAuthClient, error) {
+	if id == "" || secret == "" {
+		return nil, autherrors.InvalidClientErr{}
+	}
+	client, err := oauthClientStore.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the OAuth client from store: %w", err)
+	}
+	if client == nil || subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hashToken(secret))) != 1 {
+		return nil, autherrors.InvalidClientErr{}
+	}
+
+	return client, nil
+}
+
+// contains returns true if the values contain the value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
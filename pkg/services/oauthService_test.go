@@ -0,0 +1,264 @@
+package services
+
+import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
+	"auth/pkg/hashers"
+	"auth/pkg/jwt"
+	"auth/pkg/models"
+	"auth/pkg/tests"
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+	"time"
+)
+
+var oauthTokenConfig = config.Token{Audience: "auth", ExpDuration: 5}
+
+func Test_oauthService_CreateClient_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	var stored models.OAuthClient
+
+	mockOAuthClientStore.EXPECT().Get(ctx, "billing").Return(nil, nil).Times(1)
+	mockOAuthClientStore.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, client models.OAuthClient) error {
+		stored = client
+		return nil
+	}).Times(1)
+
+	s := NewOAuthService(mockOAuthClientStore, nil, mockJwtGenerator, oauthTokenConfig)
+
+	//Act
+	secret, err := s.CreateClient(ctx, "billing", []string{"invoices:write"}, []string{"https://billing.example.com"}, []string{"https://billing.example.com/callback"})
+
+	//Verify
+	require.NoError(t, err)
+	require.NotEmpty(t, secret)
+	require.Equal(t, models.OAuthClient{
+		ID:           "billing",
+		SecretHash:   hashToken(secret),
+		Scopes:       []string{"invoices:write"},
+		Audiences:    []string{"https://billing.example.com"},
+		RedirectURIs: []string{"https://billing.example.com/callback"},
+	}, stored)
+}
+
+func Test_oauthService_CreateClient_invalid(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockOAuthClientStore.EXPECT().Get(ctx, "billing").Return(&models.OAuthClient{ID: "billing"}, nil).Times(1)
+	mockOAuthClientStore.EXPECT().Create(ctx, gomock.Any()).Times(0)
+
+	s := NewOAuthService(mockOAuthClientStore, nil, mockJwtGenerator, oauthTokenConfig)
+
+	//Act
+	_, idErr := s.CreateClient(ctx, "", nil, nil, nil)
+	_, scopeErr := s.CreateClient(ctx, "billing", []string{"users:read users:write"}, nil, nil)
+	_, audienceErr := s.CreateClient(ctx, "billing", nil, []string{""}, nil)
+	_, relativeErr := s.CreateClient(ctx, "billing", nil, nil, []string{"/callback"})
+	_, fragmentErr := s.CreateClient(ctx, "billing", nil, nil, []string{"https://billing.example.com/callback#token"})
+	_, existErr := s.CreateClient(ctx, "billing", nil, nil, nil)
+
+	//Verify
+	require.EqualError(t, idErr, `invalid client id ""`)
+	require.EqualError(t, scopeErr, `invalid scope "users:read users:write"`)
+	require.EqualError(t, audienceErr, "empty audience")
+	require.EqualError(t, relativeErr, `invalid redirect URI "/callback"`)
+	require.EqualError(t, fragmentErr, `invalid redirect URI "https://billing.example.com/callback#token"`)
+	require.Equal(t, autherrors.OAuthClientAlreadyExistErr{ID: "billing"}, existErr)
+}
+
+func Test_oauthService_DeleteClient_not_found(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockOAuthClientStore.EXPECT().Delete(ctx, "billing").Return(false, nil).Times(1)
+
+	s := NewOAuthService(mockOAuthClientStore, nil, mockJwtGenerator, oauthTokenConfig)
+
+	//Act
+	err := s.DeleteClient(ctx, "billing")
+
+	//Verify
+	require.Equal(t, autherrors.OAuthClientNotFoundErr{ID: "billing"}, err)
+}
+
+func Test_oauthService_ClientCredentials_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	client := models.OAuthClient{
+		ID:         "billing",
+		SecretHash: hashToken("secret"),
+		Tenant:     "default",
+		Scopes:     []string{"invoices:read", "invoices:write"},
+		Audiences:  []string{"https://billing.example.com"},
+	}
+
+	mockOAuthClientStore.EXPECT().Get(ctx, "billing").Return(&client, nil).Times(3)
+	mockJwtGenerator.EXPECT().Generate(models.User{Username: "billing", Tenant: "default"}, gomock.Any()).Return("sdjklfjasdkl.jfsda.fasdf", nil).Times(3)
+
+	s := NewOAuthService(mockOAuthClientStore, nil, mockJwtGenerator, oauthTokenConfig)
+
+	//Act
+	all, allErr := s.ClientCredentials(ctx, "billing", "secret", nil, "")
+	requested, requestedErr := s.ClientCredentials(ctx, "billing", "secret", []string{"invoices:read"}, "https://billing.example.com")
+	service, serviceErr := s.ClientCredentials(ctx, "billing", "secret", nil, "auth")
+
+	//Verify
+	require.NoError(t, allErr)
+	require.Equal(t, &models.OAuthToken{AccessToken: "sdjklfjasdkl.jfsda.fasdf", ExpiresIn: 5 * time.Minute, Scopes: client.Scopes}, all)
+	require.NoError(t, requestedErr)
+	require.Equal(t, []string{"invoices:read"}, requested.Scopes)
+	require.NoError(t, serviceErr)
+	require.Equal(t, "sdjklfjasdkl.jfsda.fasdf", service.AccessToken)
+}
+
+func Test_oauthService_ClientCredentials_invalid(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	client := models.OAuthClient{ID: "billing", SecretHash: hashToken("secret"), Scopes: []string{"invoices:read"}}
+
+	mockOAuthClientStore.EXPECT().Get(ctx, "unknown").Return(nil, nil).Times(1)
+	mockOAuthClientStore.EXPECT().Get(ctx, "billing").Return(&client, nil).Times(3)
+	mockJwtGenerator.EXPECT().Generate(gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewOAuthService(mockOAuthClientStore, nil, mockJwtGenerator, oauthTokenConfig)
+
+	//Act
+	_, noSecretErr := s.ClientCredentials(ctx, "billing", "", nil, "")
+	_, unknownErr := s.ClientCredentials(ctx, "unknown", "secret", nil, "")
+	_, wrongSecretErr := s.ClientCredentials(ctx, "billing", "other", nil, "")
+	_, scopeErr := s.ClientCredentials(ctx, "billing", "secret", []string{"invoices:write"}, "")
+	_, audienceErr := s.ClientCredentials(ctx, "billing", "secret", nil, "https://billing.example.com")
+
+	//Verify
+	for _, err := range []error{noSecretErr, unknownErr, wrongSecretErr} {
+		require.Equal(t, autherrors.InvalidClientErr{}, err)
+	}
+	require.Equal(t, autherrors.InvalidScopeErr{Scope: "invoices:write"}, scopeErr)
+	require.Equal(t, autherrors.InvalidAudienceErr{Audience: "https://billing.example.com"}, audienceErr)
+}
+
+func Test_oauthService_Password_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	mockAuthService := tests.NewMockAuthService(gomock.NewController(t))
+
+	mockOAuthClientStore.EXPECT().Get(ctx, "web").Return(&models.OAuthClient{ID: "web", SecretHash: hashToken("secret")}, nil).Times(1)
+	mockAuthService.EXPECT().Authenticate(ctx, "test", "password").Return(&models.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil).Times(1)
+
+	s := NewOAuthService(mockOAuthClientStore, mockAuthService, mockJwtGenerator, oauthTokenConfig)
+
+	//Act
+	token, err := s.Password(ctx, "web", "secret", "test", "password")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, &models.OAuthToken{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 5 * time.Minute}, token)
+}
+
+func Test_oauthService_Password_invalid(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	mockAuthService := tests.NewMockAuthService(gomock.NewController(t))
+
+	mockOAuthClientStore.EXPECT().Get(ctx, "web").Return(&models.OAuthClient{ID: "web", SecretHash: hashToken("secret")}, nil).Times(3)
+	gomock.InOrder(
+		mockAuthService.EXPECT().Authenticate(ctx, "test", "wrong").Return(nil, autherrors.AuthenticationFailErr("test")).Times(1),
+		mockAuthService.EXPECT().Authenticate(ctx, "test", "password").Return(&models.Tokens{MFAToken: "mfa"}, nil).Times(1),
+	)
+
+	s := NewOAuthService(mockOAuthClientStore, mockAuthService, mockJwtGenerator, oauthTokenConfig)
+
+	//Act
+	_, clientErr := s.Password(ctx, "web", "other", "test", "password")
+	_, passwordErr := s.Password(ctx, "web", "secret", "test", "wrong")
+	_, mfaErr := s.Password(ctx, "web", "secret", "test", "password")
+
+	//Verify
+	require.Equal(t, autherrors.InvalidClientErr{}, clientErr)
+	require.Equal(t, autherrors.AuthenticationFailErr("test"), passwordErr)
+	require.Equal(t, autherrors.MFARequiredErr{Name: "test"}, mfaErr)
+}
+
+func Test_authService_Introspect_oauth_client(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "sdjklfjasdkl.jfsda.fasdf"
+	claims := &jwt.Claims{Tenant: "default", ClientType: jwt.ServiceClientType, ClientID: "billing"}
+	claims.Subject = "billing"
+
+	mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+	mockOAuthClientStore.EXPECT().Get(ctx, "billing").Return(&models.OAuthClient{ID: "billing", Tenant: "default"}, nil).Times(1)
+	mockAPIKeyStore.EXPECT().Get(ctx, gomock.Any()).Times(0)
+	mockUserStore.EXPECT().Get(ctx, gomock.Any()).Times(0)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	active, err := s.Introspect(ctx, token)
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, claims, active)
+}
+
+func Test_authService_Introspect_oauth_client_inactive(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		client *models.OAuthClient
+	}{
+		{"deleted client", nil},
+		{"client of another realm", &models.OAuthClient{ID: "billing", Tenant: "other"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			teardownTest := setupTest(t)
+			defer teardownTest(t)
+
+			//Prepare
+			ctx := context.Background()
+			token := "sdjklfjasdkl.jfsda.fasdf"
+			claims := &jwt.Claims{Tenant: "default", ClientType: jwt.ServiceClientType, ClientID: "billing"}
+			claims.Subject = "billing"
+
+			mockJwtVerifier.EXPECT().Verify(token).Return(claims, nil).Times(1)
+			mockOAuthClientStore.EXPECT().Get(ctx, "billing").Return(tc.client, nil).Times(1)
+
+			s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+			//Act
+			active, err := s.Introspect(ctx, token)
+
+			//Verify
+			require.NoError(t, err)
+			require.Nil(t, active)
+		})
+	}
+}
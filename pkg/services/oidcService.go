@@ -0,0 +1,250 @@
+package services
+
+import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
+	"auth/pkg/jwt"
+	"auth/pkg/models"
+	"auth/pkg/stores"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// authorizationCodeExpDuration is the lifetime of the authorization codes, which are exchanged right after the redirect.
+const authorizationCodeExpDuration = time.Minute
+
+// OpenIDScope is the scope requested by the OpenID Connect authentication requests.
+const OpenIDScope = "openid"
+
+type OIDCService interface {
+	//Issuer returns the issuer of the ID tokens, which identifies the OpenID provider.
+	Issuer() string
+	//Authorize checks the authentication request: the client must be registered with the redirect URI,
+	//request the openid scope and send an S256 PKCE challenge.
+	Authorize(ctx context.Context, req models.AuthorizationRequest) error
+	//Login authenticates the user with the username and password and returns an authorization code for the request.
+	//The users with multi-factor authentication get an MFA token instead, redeemed by LoginMFA.
+	Login(ctx context.Context, req models.AuthorizationRequest, username, password string) (code, mfaToken string, err error)
+	//LoginMFA exchanges an MFA token returned by Login and a TOTP code for an authorization code for the request.
+	LoginMFA(ctx context.Context, req models.AuthorizationRequest, mfaToken, totpCode string) (string, error)
+	//ExchangeCode authenticates the client and exchanges the authorization code issued to the client for the redirect URI
+	//for the tokens of the user and an ID token, if the code verifier matches the PKCE challenge. A code can only be used once.
+	ExchangeCode(ctx context.Context, clientID, clientSecret, code, redirectURI, codeVerifier string) (*models.OAuthToken, error)
+	//UserInfo returns the claims of the user of an active access token granted by the scopes of the token.
+	UserInfo(ctx context.Context, token string) (*models.UserInfo, error)
+}
+
+type oidcService struct {
+	oauthClientStore       stores.OAuthClientStore
+	authorizationCodeStore stores.AuthorizationCodeStore
+	userStore              stores.UserStore
+	authService            AuthService
+	jwtGenerator           jwt.TokenGenerator
+	issuer                 string
+	expDuration            time.Duration
+	logger                 *zap.Logger
+}
+
+// NewOIDCService creates a new instance of an OIDCService issuing authorization codes to the clients
+// of the oauthClientStore, whose hashes are stored in the authorizationCodeStore. The users of the userStore
+// are authenticated by the authService, the ID tokens are generated by the jwtGenerator
+// with the issuer and the expiration of the config.Token.
+func NewOIDCService(
+	oauthClientStore stores.OAuthClientStore,
+	authorizationCodeStore stores.AuthorizationCodeStore,
+	userStore stores.UserStore,
+	authService AuthService,
+	jwtGenerator jwt.TokenGenerator,
+	tokenConfig config.Token,
+) OIDCService {
+	return &oidcService{
+		oauthClientStore:       oauthClientStore,
+		authorizationCodeStore: authorizationCodeStore,
+		userStore:              userStore,
+		authService:            authService,
+		jwtGenerator:           jwtGenerator,
+		issuer:                 tokenConfig.Issuer,
+		expDuration:            time.Minute * time.Duration(tokenConfig.ExpDuration),
+		logger:                 zap.L().Named("OIDCService"),
+	}
+}
+
+func (s *oidcService) Issuer() string {
+	return s.issuer
+}
+
+func (s *oidcService) Authorize(ctx context.Context, req models.AuthorizationRequest) error {
+	if req.ClientID == "" {
+		return autherrors.InvalidClientErr{}
+	}
+	client, err := s.oauthClientStore.Get(ctx, req.ClientID)
+	if err != nil {
+		return fmt.Errorf("error getting the OAuth client from store: %w", err)
+	}
+	if client == nil {
+		return autherrors.InvalidClientErr{}
+	}
+	if !contains(client.RedirectURIs, req.RedirectURI) {
+		return autherrors.InvalidRedirectURIErr{RedirectURI: req.RedirectURI}
+	}
+
+	if !contains(req.Scopes, OpenIDScope) {
+		return autherrors.NewValidationErr(fmt.Errorf("openid scope required"))
+	}
+	for _, scope := range req.Scopes {
+		if scope != OpenIDScope && scope != "profile" && scope != "email" {
+			return autherrors.InvalidScopeErr{Scope: scope}
+		}
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return autherrors.NewValidationErr(fmt.Errorf("S256 code challenge required"))
+	}
+
+	return nil
+}
+
+func (s *oidcService) Login(ctx context.Context, req models.AuthorizationRequest, username, password string) (string, string, error) {
+	if err := s.Authorize(ctx, req); err != nil {
+		return "", "", err
+	}
+	user, mfaToken, err := s.authService.AuthenticateUser(ctx, username, password)
+	if err != nil {
+		return "", "", err
+	}
+	if mfaToken != "" {
+		return "", mfaToken, nil
+	}
+
+	code, err := s.issueCode(ctx, req, *user)
+	return code, "", err
+}
+
+func (s *oidcService) LoginMFA(ctx context.Context, req models.AuthorizationRequest, mfaToken, totpCode string) (string, error) {
+	if err := s.Authorize(ctx, req); err != nil {
+		return "", err
+	}
+	user, err := s.authService.VerifyMFAUser(ctx, mfaToken, totpCode)
+	if err != nil {
+		return "", err
+	}
+
+	return s.issueCode(ctx, req, *user)
+}
+
+// issueCode stores an authorization code for the request and the user of the login,
+// the client gets the tokens of the user for the code.
+func (s *oidcService) issueCode(ctx context.Context, req models.AuthorizationRequest, user models.User) (string, error) {
+	code, err := newOpaqueToken(32)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	err = s.authorizationCodeStore.Create(ctx, models.AuthorizationCode{
+		Hash:          hashToken(code),
+		ClientID:      req.ClientID,
+		Username:      user.Username,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        req.Scopes,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      now,
+		ExpiresAt:     now.Add(authorizationCodeExpDuration),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error storing the authorization code: %w", err)
+	}
+	s.logger.Info("authorization code issued", zap.String("clientID", req.ClientID), zap.String("username", user.Username))
+
+	return code, nil
+}
+
+func (s *oidcService) ExchangeCode(ctx context.Context, clientID, clientSecret, code, redirectURI, codeVerifier string) (*models.OAuthToken, error) {
+	client, err := authenticateClient(ctx, s.oauthClientStore, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.authorizationCodeStore.Get(ctx, hashToken(code))
+	if err != nil {
+		return nil, fmt.Errorf("error getting the authorization code from store: %w", err)
+	}
+	if c == nil || c.Used || !c.ExpiresAt.After(time.Now()) || c.ClientID != client.ID || c.RedirectURI != redirectURI ||
+		!verifyCodeChallenge(c.CodeChallenge, codeVerifier) {
+		return nil, autherrors.InvalidAuthorizationCodeErr{}
+	}
+	used, err := s.authorizationCodeStore.Use(ctx, c.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("error using the authorization code: %w", err)
+	}
+	// The code was exchanged concurrently.
+	if !used {
+		return nil, autherrors.InvalidAuthorizationCodeErr{}
+	}
+
+	user, err := s.userStore.Get(ctx, c.Username)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user %s from store: %w", c.Username, err)
+	}
+	if user == nil {
+		return nil, autherrors.InvalidAuthorizationCodeErr{}
+	}
+	tokens, err := s.authService.IssueTokens(ctx, user.Username, c.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	idToken, err := s.jwtGenerator.Generate(*user, jwt.WithIDToken(client.ID, c.Nonce, c.AuthTime), jwt.WithUserInfo(*user, c.Scopes))
+	if err != nil {
+		return nil, fmt.Errorf("error generating the ID token: %w", err)
+	}
+
+	return &models.OAuthToken{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		IDToken:      idToken,
+		ExpiresIn:    s.expDuration,
+		Scopes:       c.Scopes,
+	}, nil
+}
+
+// verifyCodeChallenge returns true if the S256 challenge is the hash of the PKCE code verifier, RFC 7636 section 4.6
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(challenge), []byte(base64.RawURLEncoding.EncodeToString(sum[:]))) == 1
+}
+
+func (s *oidcService) UserInfo(ctx context.Context, token string) (*models.UserInfo, error) {
+	claims, err := s.authService.Introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if claims == nil || claims.ClientType == jwt.ServiceClientType {
+		return nil, autherrors.NewInvalidTokenErr(fmt.Errorf("inactive token"))
+	}
+	user, err := s.userStore.Get(ctx, claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user %s from store: %w", claims.Subject, err)
+	}
+	if user == nil {
+		return nil, autherrors.NewInvalidTokenErr(fmt.Errorf("inactive token"))
+	}
+
+	// The claims are granted by the scopes like the claims of the ID token.
+	info := &models.UserInfo{Subject: user.Username}
+	for _, scope := range claims.Scopes() {
+		switch scope {
+		case "profile":
+			info.PreferredUsername = user.Username
+		case "email":
+			info.Email = user.Email
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
+	"auth/pkg/hashers"
+	"auth/pkg/jwt"
+	"auth/pkg/models"
+	"auth/pkg/tests"
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+	"time"
+)
+
+var oidcTokenConfig = config.Token{Audience: "auth", Issuer: "https://auth.example.com", ExpDuration: 5}
+
+// codeVerifier is the PKCE code verifier of the S256 codeChallenge.
+const (
+	codeVerifier  = "dBjftJeZ4CVP-mJ92ZUj7-EvUGEQ1xBD3fXYv6xE_oE"
+	codeChallenge = "XwIoMVq4_uFo1MB_uIZHn4tjIJiPKUXKQ0J7Gq-9epM"
+)
+
+var (
+	webClient = models.OAuthClient{
+		ID:           "web",
+		SecretHash:   hashToken("secret"),
+		RedirectURIs: []string{"https://app.example.com/callback"},
+	}
+	authorizationRequest = models.AuthorizationRequest{
+		ClientID:            "web",
+		RedirectURI:         "https://app.example.com/callback",
+		Scopes:              []string{"openid", "email"},
+		State:               "xyz",
+		Nonce:               "n0nce",
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: "S256",
+	}
+)
+
+func Test_oidcService_Authorize_invalid(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	unknown := authorizationRequest
+	unknown.ClientID = "unknown"
+	redirect := authorizationRequest
+	redirect.RedirectURI = "https://evil.example.com/callback"
+	noOpenID := authorizationRequest
+	noOpenID.Scopes = []string{"email"}
+	scope := authorizationRequest
+	scope.Scopes = []string{"openid", "users:write"}
+	plain := authorizationRequest
+	plain.CodeChallengeMethod = "plain"
+
+	mockOAuthClientStore.EXPECT().Get(ctx, "unknown").Return(nil, nil).Times(1)
+	mockOAuthClientStore.EXPECT().Get(ctx, "web").Return(&webClient, nil).Times(5)
+
+	s := NewOIDCService(mockOAuthClientStore, mockAuthCodeStore, mockUserStore, nil, mockJwtGenerator, oidcTokenConfig)
+
+	//Act
+	validErr := s.Authorize(ctx, authorizationRequest)
+	unknownErr := s.Authorize(ctx, unknown)
+	redirectErr := s.Authorize(ctx, redirect)
+	noOpenIDErr := s.Authorize(ctx, noOpenID)
+	scopeErr := s.Authorize(ctx, scope)
+	plainErr := s.Authorize(ctx, plain)
+
+	//Verify
+	require.NoError(t, validErr)
+	require.Equal(t, autherrors.InvalidClientErr{}, unknownErr)
+	require.Equal(t, autherrors.InvalidRedirectURIErr{RedirectURI: "https://evil.example.com/callback"}, redirectErr)
+	require.EqualError(t, noOpenIDErr, "openid scope required")
+	require.Equal(t, autherrors.InvalidScopeErr{Scope: "users:write"}, scopeErr)
+	require.EqualError(t, plainErr, "S256 code challenge required")
+}
+
+func Test_oidcService_Login_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	mockAuthService := tests.NewMockAuthService(gomock.NewController(t))
+	var stored models.AuthorizationCode
+
+	mockOAuthClientStore.EXPECT().Get(ctx, "web").Return(&webClient, nil).Times(1)
+	// No tokens are issued by the login, the client gets the tokens of the user for the code.
+	mockAuthService.EXPECT().AuthenticateUser(ctx, "Test", "password").Return(&models.User{Username: "test"}, "", nil).Times(1)
+	mockAuthService.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockAuthCodeStore.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, code models.AuthorizationCode) error {
+		stored = code
+		return nil
+	}).Times(1)
+
+	s := NewOIDCService(mockOAuthClientStore, mockAuthCodeStore, mockUserStore, mockAuthService, mockJwtGenerator, oidcTokenConfig)
+
+	//Act
+	code, mfaToken, err := s.Login(ctx, authorizationRequest, "Test", "password")
+
+	//Verify
+	require.NoError(t, err)
+	require.Empty(t, mfaToken)
+	require.Equal(t, hashToken(code), stored.Hash)
+	require.Equal(t, "test", stored.Username)
+	require.Equal(t, "web", stored.ClientID)
+	require.Equal(t, authorizationRequest.RedirectURI, stored.RedirectURI)
+	require.Equal(t, authorizationRequest.Scopes, stored.Scopes)
+	require.Equal(t, "n0nce", stored.Nonce)
+	require.Equal(t, codeChallenge, stored.CodeChallenge)
+	require.WithinDuration(t, time.Now(), stored.AuthTime, time.Second)
+	require.Equal(t, stored.AuthTime.Add(authorizationCodeExpDuration), stored.ExpiresAt)
+}
+
+func Test_oidcService_Login_mfa(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	mockAuthService := tests.NewMockAuthService(gomock.NewController(t))
+	var stored models.AuthorizationCode
+
+	mockOAuthClientStore.EXPECT().Get(ctx, "web").Return(&webClient, nil).Times(2)
+	mockAuthService.EXPECT().AuthenticateUser(ctx, "test", "password").Return(nil, "mfa", nil).Times(1)
+	mockAuthService.EXPECT().VerifyMFAUser(ctx, "mfa", "123456").Return(&models.User{Username: "test"}, nil).Times(1)
+	mockAuthCodeStore.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, code models.AuthorizationCode) error {
+		stored = code
+		return nil
+	}).Times(1)
+
+	s := NewOIDCService(mockOAuthClientStore, mockAuthCodeStore, mockUserStore, mockAuthService, mockJwtGenerator, oidcTokenConfig)
+
+	//Act
+	code, mfaToken, loginErr := s.Login(ctx, authorizationRequest, "test", "password")
+	mfaCode, mfaErr := s.LoginMFA(ctx, authorizationRequest, mfaToken, "123456")
+
+	//Verify
+	require.NoError(t, loginErr)
+	require.Empty(t, code)
+	require.Equal(t, "mfa", mfaToken)
+	require.NoError(t, mfaErr)
+	require.Equal(t, hashToken(mfaCode), stored.Hash)
+	require.Equal(t, "test", stored.Username)
+}
+
+func Test_oidcService_Login_failed(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	mockAuthService := tests.NewMockAuthService(gomock.NewController(t))
+	redirect := authorizationRequest
+	redirect.RedirectURI = "https://evil.example.com/callback"
+
+	mockOAuthClientStore.EXPECT().Get(ctx, "web").Return(&webClient, nil).Times(2)
+	mockAuthService.EXPECT().AuthenticateUser(ctx, "test", "wrong").Return(nil, "", autherrors.AuthenticationFailErr("test")).Times(1)
+	mockAuthCodeStore.EXPECT().Create(ctx, gomock.Any()).Times(0)
+
+	s := NewOIDCService(mockOAuthClientStore, mockAuthCodeStore, mockUserStore, mockAuthService, mockJwtGenerator, oidcTokenConfig)
+
+	//Act
+	_, _, redirectErr := s.Login(ctx, redirect, "test", "password")
+	_, _, passwordErr := s.Login(ctx, authorizationRequest, "test", "wrong")
+
+	//Verify
+	require.Equal(t, autherrors.InvalidRedirectURIErr{RedirectURI: "https://evil.example.com/callback"}, redirectErr)
+	require.Equal(t, autherrors.AuthenticationFailErr("test"), passwordErr)
+}
+
+func Test_oidcService_ExchangeCode_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	mockAuthService := tests.NewMockAuthService(gomock.NewController(t))
+	user := models.User{Username: "test", Email: "test@example.com", Tenant: "default"}
+	code := models.AuthorizationCode{
+		Hash:          hashToken("c0de"),
+		ClientID:      "web",
+		Username:      "test",
+		RedirectURI:   "https://app.example.com/callback",
+		Scopes:        []string{"openid", "email"},
+		Nonce:         "n0nce",
+		CodeChallenge: codeChallenge,
+		AuthTime:      time.Now(),
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+
+	mockOAuthClientStore.EXPECT().Get(ctx, "web").Return(&webClient, nil).Times(1)
+	mockAuthCodeStore.EXPECT().Get(ctx, hashToken("c0de")).Return(&code, nil).Times(1)
+	mockAuthCodeStore.EXPECT().Use(ctx, hashToken("c0de")).Return(true, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "test").Return(&user, nil).Times(1)
+	mockAuthService.EXPECT().IssueTokens(ctx, "test", []string{"openid", "email"}).Return(&models.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(user, gomock.Len(2)).Return("id", nil).Times(1)
+
+	s := NewOIDCService(mockOAuthClientStore, mockAuthCodeStore, mockUserStore, mockAuthService, mockJwtGenerator, oidcTokenConfig)
+
+	//Act
+	token, err := s.ExchangeCode(ctx, "web", "secret", "c0de", "https://app.example.com/callback", codeVerifier)
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, &models.OAuthToken{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		IDToken:      "id",
+		ExpiresIn:    5 * time.Minute,
+		Scopes:       []string{"openid", "email"},
+	}, token)
+}
+
+func Test_oidcService_ExchangeCode_invalid(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	mockAuthService := tests.NewMockAuthService(gomock.NewController(t))
+	code := models.AuthorizationCode{
+		Hash:          hashToken("c0de"),
+		ClientID:      "web",
+		Username:      "test",
+		RedirectURI:   "https://app.example.com/callback",
+		CodeChallenge: codeChallenge,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+	used := code
+	used.Used = true
+	expired := code
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	other := code
+	other.ClientID = "other"
+
+	mockOAuthClientStore.EXPECT().Get(ctx, "web").Return(&webClient, nil).Times(9)
+	gomock.InOrder(
+		mockAuthCodeStore.EXPECT().Get(ctx, hashToken("unknown")).Return(nil, nil).Times(1),
+		mockAuthCodeStore.EXPECT().Get(ctx, hashToken("c0de")).Return(&used, nil).Times(1),
+		mockAuthCodeStore.EXPECT().Get(ctx, hashToken("c0de")).Return(&expired, nil).Times(1),
+		mockAuthCodeStore.EXPECT().Get(ctx, hashToken("c0de")).Return(&other, nil).Times(1),
+		mockAuthCodeStore.EXPECT().Get(ctx, hashToken("c0de")).Return(&code, nil).Times(4),
+	)
+	// The code was exchanged concurrently by the last request.
+	mockAuthCodeStore.EXPECT().Use(ctx, hashToken("c0de")).Return(false, nil).Times(1)
+	mockAuthService.EXPECT().IssueTokens(ctx, gomock.Any(), gomock.Any()).Times(0)
+
+	s := NewOIDCService(mockOAuthClientStore, mockAuthCodeStore, mockUserStore, mockAuthService, mockJwtGenerator, oidcTokenConfig)
+
+	//Act
+	_, clientErr := s.ExchangeCode(ctx, "web", "wrong", "c0de", "https://app.example.com/callback", codeVerifier)
+	_, unknownErr := s.ExchangeCode(ctx, "web", "secret", "unknown", "https://app.example.com/callback", codeVerifier)
+	_, usedErr := s.ExchangeCode(ctx, "web", "secret", "c0de", "https://app.example.com/callback", codeVerifier)
+	_, expiredErr := s.ExchangeCode(ctx, "web", "secret", "c0de", "https://app.example.com/callback", codeVerifier)
+	_, otherErr := s.ExchangeCode(ctx, "web", "secret", "c0de", "https://app.example.com/callback", codeVerifier)
+	_, redirectErr := s.ExchangeCode(ctx, "web", "secret", "c0de", "https://app.example.com/other", codeVerifier)
+	_, verifierErr := s.ExchangeCode(ctx, "web", "secret", "c0de", "https://app.example.com/callback", codeVerifier[1:]+"x")
+	_, noVerifierErr := s.ExchangeCode(ctx, "web", "secret", "c0de", "https://app.example.com/callback", "")
+	_, concurrentErr := s.ExchangeCode(ctx, "web", "secret", "c0de", "https://app.example.com/callback", codeVerifier)
+
+	//Verify
+	require.Equal(t, autherrors.InvalidClientErr{}, clientErr)
+	for _, err := range []error{unknownErr, usedErr, expiredErr, otherErr, redirectErr, verifierErr, noVerifierErr, concurrentErr} {
+		require.Equal(t, autherrors.InvalidAuthorizationCodeErr{}, err)
+	}
+}
+
+func Test_oidcService_UserInfo(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	mockAuthService := tests.NewMockAuthService(gomock.NewController(t))
+	user := models.User{Username: "test", Email: "test@example.com"}
+	claims := &jwt.Claims{Scope: "users:read openid profile email"}
+	claims.Subject = "test"
+	openid := &jwt.Claims{Scope: "users:read openid"}
+	openid.Subject = "test"
+	service := &jwt.Claims{ClientType: jwt.ServiceClientType}
+	service.Subject = "billing"
+
+	gomock.InOrder(
+		mockAuthService.EXPECT().Introspect(ctx, "access").Return(claims, nil).Times(1),
+		mockAuthService.EXPECT().Introspect(ctx, "openid").Return(openid, nil).Times(1),
+		mockAuthService.EXPECT().Introspect(ctx, "revoked").Return(nil, nil).Times(1),
+		mockAuthService.EXPECT().Introspect(ctx, "service").Return(service, nil).Times(1),
+	)
+	mockUserStore.EXPECT().Get(ctx, "test").Return(&user, nil).Times(2)
+
+	s := NewOIDCService(mockOAuthClientStore, mockAuthCodeStore, mockUserStore, mockAuthService, mockJwtGenerator, oidcTokenConfig)
+
+	//Act
+	got, err := s.UserInfo(ctx, "access")
+	gotOpenID, openIDErr := s.UserInfo(ctx, "openid")
+	_, revokedErr := s.UserInfo(ctx, "revoked")
+	_, serviceErr := s.UserInfo(ctx, "service")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, &models.UserInfo{Subject: "test", PreferredUsername: "test", Email: "test@example.com"}, got)
+	// The claims of the profile and email scopes are only returned for the tokens granted the scopes.
+	require.NoError(t, openIDErr)
+	require.Equal(t, &models.UserInfo{Subject: "test"}, gotOpenID)
+	require.EqualError(t, revokedErr, "invalid token: inactive token")
+	require.EqualError(t, serviceErr, "invalid token: inactive token")
+	require.Equal(t, "https://auth.example.com", s.Issuer())
+}
+
+func Test_authService_IssueTokens(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	user := models.User{Username: "test"}
+
+	gomock.InOrder(
+		mockUserStore.EXPECT().Get(ctx, "test").Return(&user, nil).Times(1),
+		mockUserStore.EXPECT().Get(ctx, "deleted").Return(nil, nil).Times(1),
+	)
+	mockRoleStore.EXPECT().ListByUser(ctx, "test").Return(nil, nil).Times(1)
+	// The access token carries the roles and the scopes.
+	mockJwtGenerator.EXPECT().Generate(user, gomock.Len(2)).Return("sdjklfjasdkl.jfsda.fasdf", nil).Times(1)
+	mockRefreshStore.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, rt models.RefreshToken) error {
+		require.Equal(t, "test", rt.Username)
+		require.NotEmpty(t, rt.FamilyID)
+		return nil
+	}).Times(1)
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	//Act
+	tokens, err := s.IssueTokens(ctx, "test", []string{"openid"})
+	_, deletedErr := s.IssueTokens(ctx, "deleted", []string{"openid"})
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, "sdjklfjasdkl.jfsda.fasdf", tokens.AccessToken)
+	require.NotEmpty(t, tokens.RefreshToken)
+	require.Equal(t, autherrors.UserNotFoundErr{Name: "deleted"}, deletedErr)
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/hashers"
+	"auth/pkg/models"
+	"auth/pkg/notifiers"
+	"auth/pkg/stores"
+	"auth/pkg/validators"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// resetQueueSize is the number of password reset tokens waiting to be sent, the requests beyond it are dropped.
+const resetQueueSize = 100
+
+type PasswordResetService interface {
+	//RequestReset sends a single-use password reset token to the user with the username.
+	//It succeeds whether the user exists or not.
+	RequestReset(ctx context.Context, username string) error
+	//Reset replaces the password of the user of the reset token and invalidates the tokens issued to the user.
+	Reset(ctx context.Context, resetToken, newPassword string) error
+	//Shutdown stops accepting the requests and waits until the pending tokens are sent or the context is done,
+	//in which case the pending tokens are abandoned.
+	Shutdown(ctx context.Context) error
+}
+
+type passwordResetService struct {
+	userStore         stores.UserStore
+	resetStore        stores.PasswordResetStore
+	refreshTokenStore stores.RefreshTokenStore
+	throttler         LoginThrottler
+	notifier          notifiers.Notifier
+	passwordValidator validators.Validator
+	hasher            hashers.PasswordHasher
+	expDuration       time.Duration
+	logger            *zap.Logger
+
+	mu      sync.Mutex
+	closed  bool
+	queue   chan models.User
+	done    chan struct{}
+	sendCtx context.Context
+	cancel  context.CancelFunc
+}
+
+// NewPasswordResetService creates a new instance of a PasswordResetService sending the reset tokens,
+// valid for expDuration, with the notifier. The requests are limited per username and per address by the throttler.
+// The new passwords are checked by the passwordValidator and hashed with the hasher.
+// The tokens are sent by a worker until Shutdown is called.
+func NewPasswordResetService(
+	userStore stores.UserStore,
+	resetStore stores.PasswordResetStore,
+	refreshTokenStore stores.RefreshTokenStore,
+	throttler LoginThrottler,
+	notifier notifiers.Notifier,
+	passwordValidator validators.Validator,
+	hasher hashers.PasswordHasher,
+	expDuration time.Duration,
+) PasswordResetService {
+	sendCtx, cancel := context.WithCancel(context.Background())
+	s := &passwordResetService{
+		userStore:         userStore,
+		resetStore:        resetStore,
+		refreshTokenStore: refreshTokenStore,
+		throttler:         throttler,
+		notifier:          notifier,
+		passwordValidator: passwordValidator,
+		hasher:            hasher,
+		expDuration:       expDuration,
+		logger:            zap.L().Named("PasswordResetService"),
+		queue:             make(chan models.User, resetQueueSize),
+		done:              make(chan struct{}),
+		sendCtx:           sendCtx,
+		cancel:            cancel,
+	}
+	go s.run()
+	return s
+}
+
+func (s *passwordResetService) RequestReset(ctx context.Context, username string) error {
+	// The requests are counted whether the user exists or not, so the throttling doesn't reveal the users.
+	address := peerAddress(ctx)
+	if err := s.throttler.Check(ctx, username, address); err != nil {
+		return resetThrottledErr(err)
+	}
+	if err := s.throttler.Fail(ctx, username, address); err != nil {
+		return fmt.Errorf("error counting the password reset request: %w", err)
+	}
+
+	u, err := s.userStore.Get(ctx, username)
+	if err != nil {
+		return fmt.Errorf("error getting user %s from store: %w", username, err)
+	}
+	if u == nil {
+		s.logger.Debug("password reset requested for an unknown user")
+		return nil
+	}
+
+	// The token is created and sent by the worker: the requests of the known users take the same time
+	// as the requests of the unknown users, and their failures may not reveal that the user exists.
+	s.enqueue(*u)
+
+	return nil
+}
+
+func (s *passwordResetService) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
+
+// enqueue queues the user for the worker, the request is dropped if the queue is full or the service is shut down.
+func (s *passwordResetService) enqueue(user models.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		s.logger.Warn("password reset requested during the shutdown, the request is dropped")
+		return
+	}
+	select {
+	case s.queue <- user:
+	default:
+		s.logger.Warn("too many pending password resets, the request is dropped")
+	}
+}
+
+// run sends the reset tokens of the queued users until the queue is closed or the shutdown is cancelled.
+func (s *passwordResetService) run() {
+	defer close(s.done)
+	for user := range s.queue {
+		if s.sendCtx.Err() != nil {
+			return
+		}
+		if err := s.sendResetToken(s.sendCtx, user); err != nil {
+			s.logger.Error("failed to send the password reset token", zap.Error(err))
+		}
+	}
+}
+
+// resetThrottledErr returns the error of the throttler as a PasswordResetThrottledErr,
+// the reset requests don't lock the account.
+func resetThrottledErr(err error) error {
+	switch e := err.(type) {
+	case autherrors.AccountLockedErr:
+		return autherrors.PasswordResetThrottledErr{RetryAfter: time.Until(e.Until)}
+	case autherrors.LoginThrottledErr:
+		return autherrors.PasswordResetThrottledErr{RetryAfter: e.RetryAfter}
+	default:
+		return fmt.Errorf("error checking the password reset requests: %w", err)
+	}
+}
+
+// sendResetToken stores a new reset token of the user and sends it with the notifier.
+func (s *passwordResetService) sendResetToken(ctx context.Context, user models.User) error {
+	token, err := newOpaqueToken(32)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(s.expDuration)
+	err = s.resetStore.Create(ctx, models.PasswordResetToken{
+		Hash:      hashToken(token),
+		Username:  user.Username,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("error storing the password reset token: %w", err)
+	}
+	if err := s.notifier.NotifyPasswordReset(ctx, user, token, expiresAt); err != nil {
+		return fmt.Errorf("error sending the password reset notification: %w", err)
+	}
+
+	return nil
+}
+
+func (s *passwordResetService) Reset(ctx context.Context, resetToken, newPassword string) error {
+	hash := hashToken(resetToken)
+	rt, err := s.resetStore.Get(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("error getting the password reset token from store: %w", err)
+	}
+	if rt == nil || rt.Used || time.Now().After(rt.ExpiresAt) {
+		return autherrors.InvalidPasswordResetTokenErr{}
+	}
+
+	// The password is validated before the token is used, so a rejected password does not burn the token.
+	if err := s.passwordValidator.Validate(newPassword); err != nil {
+		return autherrors.NewValidationErr(err)
+	}
+
+	ok, err := s.resetStore.Use(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("error using the password reset token: %w", err)
+	}
+	if !ok {
+		return autherrors.InvalidPasswordResetTokenErr{}
+	}
+
+	u, err := s.userStore.Get(ctx, rt.Username)
+	if err != nil {
+		return fmt.Errorf("error getting user %s from store: %w", rt.Username, err)
+	}
+	if u == nil {
+		return autherrors.InvalidPasswordResetTokenErr{}
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("error during password hashing: %w", err)
+	}
+	user := *u
+	user.Password = hashedPassword
+	user.TokenGeneration++
+	found, err := s.userStore.Update(ctx, u.Username, user)
+	if err != nil {
+		return fmt.Errorf("error updating the user: %w", err)
+	}
+	if !found {
+		return autherrors.InvalidPasswordResetTokenErr{}
+	}
+	if err := s.refreshTokenStore.RevokeUser(ctx, u.Username); err != nil {
+		return fmt.Errorf("error revoking the refresh tokens: %w", err)
+	}
+
+	return nil
+}
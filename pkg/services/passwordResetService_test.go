@@ -0,0 +1,302 @@
+package services
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/hashers"
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestPasswordResetService(t *testing.T) PasswordResetService {
+	s := NewPasswordResetService(mockUserStore, mockResetStore, mockRefreshStore, mockThrottler, mockNotifier, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), time.Hour)
+	t.Cleanup(func() {
+		require.NoError(t, s.Shutdown(context.Background()))
+	})
+	return s
+}
+
+func Test_passwordResetService_RequestReset_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}})
+	user := models.User{Username: "user", Email: "user@example.com"}
+	var stored models.PasswordResetToken
+	sent := make(chan string, 1)
+
+	mockThrottler.EXPECT().Check(ctx, user.Username, "10.0.0.1").Return(nil).Times(1)
+	mockThrottler.EXPECT().Fail(ctx, user.Username, "10.0.0.1").Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockResetStore.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, rt models.PasswordResetToken) error {
+		stored = rt
+		return nil
+	}).Times(1)
+	mockNotifier.EXPECT().NotifyPasswordReset(gomock.Any(), user, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ models.User, token string, _ time.Time) error {
+			sent <- token
+			return nil
+		}).Times(1)
+
+	//Act
+	err := newTestPasswordResetService(t).RequestReset(ctx, user.Username)
+
+	//Verify
+	require.NoError(t, err)
+	token := <-sent
+	require.Equal(t, user.Username, stored.Username)
+	require.Equal(t, hashToken(token), stored.Hash)
+	require.NotEqual(t, token, stored.Hash)
+	require.True(t, stored.ExpiresAt.After(time.Now()))
+}
+
+func Test_passwordResetService_RequestReset_unknown_user(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockThrottler.EXPECT().Check(ctx, "unknown", "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Fail(ctx, "unknown", "").Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "unknown").Return(nil, nil).Times(1)
+	mockResetStore.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+	mockNotifier.EXPECT().NotifyPasswordReset(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	err := newTestPasswordResetService(t).RequestReset(ctx, "unknown")
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_passwordResetService_RequestReset_store_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	user := models.User{Username: "user", Email: "user@example.com"}
+	created := make(chan struct{})
+
+	mockThrottler.EXPECT().Check(ctx, user.Username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Fail(ctx, user.Username, "").Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockResetStore.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ models.PasswordResetToken) error {
+		close(created)
+		return fmt.Errorf("unexpected")
+	}).Times(1)
+	mockNotifier.EXPECT().NotifyPasswordReset(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	err := newTestPasswordResetService(t).RequestReset(ctx, user.Username)
+
+	//Verify
+	// The failure of the token is only logged, like the requests of the unknown users succeed.
+	require.NoError(t, err)
+	<-created
+}
+
+func Test_passwordResetService_RequestReset_throttled(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "delayed", err: autherrors.LoginThrottledErr{RetryAfter: time.Second}},
+		{name: "locked", err: autherrors.AccountLockedErr{Until: time.Now().Add(time.Minute)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teardownTest := setupTest(t)
+			defer teardownTest(t)
+
+			//Prepare
+			ctx := context.Background()
+
+			mockThrottler.EXPECT().Check(ctx, "user", "").Return(tt.err).Times(1)
+			mockThrottler.EXPECT().Fail(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockUserStore.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+
+			//Act
+			err := newTestPasswordResetService(t).RequestReset(ctx, "user")
+
+			//Verify
+			require.IsType(t, autherrors.PasswordResetThrottledErr{}, err)
+			require.Equal(t, codes.ResourceExhausted, status.Code(err))
+		})
+	}
+}
+
+func Test_passwordResetService_Shutdown(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	user := models.User{Username: "user", Email: "user@example.com"}
+
+	mockThrottler.EXPECT().Check(ctx, user.Username, "").Return(nil).Times(2)
+	mockThrottler.EXPECT().Fail(ctx, user.Username, "").Return(nil).Times(2)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(2)
+	mockResetStore.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockNotifier.EXPECT().NotifyPasswordReset(gomock.Any(), user, gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	s := newTestPasswordResetService(t)
+	require.NoError(t, s.RequestReset(ctx, user.Username))
+
+	//Act
+	err := s.Shutdown(ctx)
+
+	//Verify
+	// The pending token is sent before the shutdown returns, the later requests are dropped.
+	require.NoError(t, err)
+	require.NoError(t, s.RequestReset(ctx, user.Username))
+}
+
+func Test_passwordResetService_Shutdown_deadline(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	user := models.User{Username: "user", Email: "user@example.com"}
+	started := make(chan struct{})
+
+	mockThrottler.EXPECT().Check(ctx, user.Username, "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Fail(ctx, user.Username, "").Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockResetStore.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockNotifier.EXPECT().NotifyPasswordReset(gomock.Any(), user, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ models.User, _ string, _ time.Time) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}).Times(1)
+	s := NewPasswordResetService(mockUserStore, mockResetStore, mockRefreshStore, mockThrottler, mockNotifier, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), time.Hour)
+	require.NoError(t, s.RequestReset(ctx, user.Username))
+	<-started
+	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	//Act
+	err := s.Shutdown(shutdownCtx)
+
+	//Verify
+	// The notification in progress is cancelled.
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	<-s.(*passwordResetService).done
+}
+
+func Test_passwordResetService_Reset_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	token := "token"
+	user := models.User{Username: "user", Password: "old", TokenGeneration: 2}
+
+	mockResetStore.EXPECT().Get(ctx, hashToken(token)).Return(&models.PasswordResetToken{
+		Hash:      hashToken(token),
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}, nil).Times(1)
+	mockValidator.EXPECT().Validate("newpassword").Return(nil).Times(1)
+	mockResetStore.EXPECT().Use(ctx, hashToken(token)).Return(true, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&user, nil).Times(1)
+	mockUserStore.EXPECT().Update(ctx, user.Username, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, u models.User) (bool, error) {
+		require.NoError(t, bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("newpassword")))
+		require.Equal(t, int64(3), u.TokenGeneration)
+		return true, nil
+	}).Times(1)
+	mockRefreshStore.EXPECT().RevokeUser(ctx, user.Username).Return(nil).Times(1)
+
+	//Act
+	err := newTestPasswordResetService(t).Reset(ctx, token, "newpassword")
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_passwordResetService_Reset_invalid_token(t *testing.T) {
+	tests := []struct {
+		name  string
+		token *models.PasswordResetToken
+	}{
+		{name: "unknown", token: nil},
+		{name: "used", token: &models.PasswordResetToken{Username: "user", ExpiresAt: time.Now().Add(time.Minute), Used: true}},
+		{name: "expired", token: &models.PasswordResetToken{Username: "user", ExpiresAt: time.Now().Add(-time.Minute)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teardownTest := setupTest(t)
+			defer teardownTest(t)
+
+			//Prepare
+			ctx := context.Background()
+
+			mockResetStore.EXPECT().Get(ctx, hashToken("token")).Return(tt.token, nil).Times(1)
+			mockResetStore.EXPECT().Use(gomock.Any(), gomock.Any()).Times(0)
+			mockUserStore.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			//Act
+			err := newTestPasswordResetService(t).Reset(ctx, "token", "newpassword")
+
+			//Verify
+			require.ErrorIs(t, err, autherrors.InvalidPasswordResetTokenErr{})
+		})
+	}
+}
+
+func Test_passwordResetService_Reset_invalid_password(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockResetStore.EXPECT().Get(ctx, hashToken("token")).Return(&models.PasswordResetToken{
+		Username:  "user",
+		ExpiresAt: time.Now().Add(time.Minute),
+	}, nil).Times(1)
+	mockValidator.EXPECT().Validate("weak").Return(fmt.Errorf("password too short")).Times(1)
+	mockResetStore.EXPECT().Use(gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	err := newTestPasswordResetService(t).Reset(ctx, "token", "weak")
+
+	//Verify
+	require.Error(t, err)
+	require.IsType(t, autherrors.ValidationErr{}, err)
+}
+
+func Test_passwordResetService_Reset_token_used_concurrently(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockResetStore.EXPECT().Get(ctx, hashToken("token")).Return(&models.PasswordResetToken{
+		Username:  "user",
+		ExpiresAt: time.Now().Add(time.Minute),
+	}, nil).Times(1)
+	mockValidator.EXPECT().Validate("newpassword").Return(nil).Times(1)
+	mockResetStore.EXPECT().Use(ctx, hashToken("token")).Return(false, nil).Times(1)
+	mockUserStore.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	err := newTestPasswordResetService(t).Reset(ctx, "token", "newpassword")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.InvalidPasswordResetTokenErr{})
+}
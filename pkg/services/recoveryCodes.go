@@ -0,0 +1,154 @@
+package services
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/jwt"
+	"auth/pkg/models"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// recoveryCodeCount is the number of recovery codes of a batch.
+const recoveryCodeCount = 10
+
+// recoveryCodeEncoding encodes the recovery codes with lowercase letters and digits, easy to read and type.
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// newRecoveryCode returns a random recovery code of 50 bits, formatted as xxxxx-xxxxx.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating a recovery code: %w", err)
+	}
+	code := recoveryCodeEncoding.EncodeToString(b)[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode returns the code without its separators and in lowercase, the form of the hashed codes.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func (as *JwtAuthService) GenerateRecoveryCodes(ctx context.Context, token string) ([]string, error) {
+	_, u, err := as.verifyWithoutPasswordChange(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := as.PasswordHasher.Hash(normalizeRecoveryCode(code))
+		if err != nil {
+			return nil, fmt.Errorf("error hashing the recovery code: %w", err)
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hash)
+	}
+	batchID, err := newOpaqueToken(16)
+	if err != nil {
+		return nil, err
+	}
+	if err := as.RecoveryCodeStore.Replace(ctx, u.Username, batchID, hashes); err != nil {
+		return nil, fmt.Errorf("error storing the recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (as *JwtAuthService) CountRecoveryCodes(ctx context.Context, token string) (int, error) {
+	_, u, err := as.verify(ctx, token)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := as.RecoveryCodeStore.CountUnused(ctx, u.Username)
+	if err != nil {
+		return 0, fmt.Errorf("error counting the recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+func (as *JwtAuthService) AuthenticateWithRecoveryCode(ctx context.Context, username, code string) (*models.Tokens, error) {
+	address := peerAddress(ctx)
+	if err := as.LoginThrottler.Check(ctx, username, address); err != nil {
+		return nil, err
+	}
+
+	u, err := as.UserStore.Get(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user %s from store: %w", username, err)
+	}
+	if u == nil {
+		_, _ = as.compareRecoveryCode(nil, normalizeRecoveryCode(code))
+		as.failLogin(ctx, username, address)
+		return nil, autherrors.AuthenticationFailErr(username)
+	}
+
+	hash, err := as.matchRecoveryCode(ctx, u.Username, normalizeRecoveryCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if hash == "" {
+		as.failLogin(ctx, username, address)
+		return nil, autherrors.AuthenticationFailErr(u.Username)
+	}
+	// Two concurrent requests with the same code can match it, only one can use it.
+	used, err := as.RecoveryCodeStore.Use(ctx, u.Username, hash)
+	if err != nil {
+		return nil, fmt.Errorf("error using the recovery code: %w", err)
+	}
+	if !used {
+		as.failLogin(ctx, username, address)
+		return nil, autherrors.AuthenticationFailErr(u.Username)
+	}
+	if err := as.LoginThrottler.Succeed(ctx, username); err != nil {
+		as.logger.Error("failed to reset the failed logins", zap.Error(err))
+	}
+
+	// Without a refresh token, the session of the recovery ends with its access token, or with the password change.
+	token, err := as.JwtGenerator.Generate(*u, jwt.WithPasswordChange())
+	if err != nil {
+		return nil, fmt.Errorf("error generating the token: %w", err)
+	}
+	return &models.Tokens{AccessToken: token}, nil
+}
+
+// matchRecoveryCode returns the hash of the unused recovery code of the user matching the code, or an empty string.
+func (as *JwtAuthService) matchRecoveryCode(ctx context.Context, username, code string) (string, error) {
+	hashes, err := as.RecoveryCodeStore.ListUnused(ctx, username)
+	if err != nil {
+		return "", fmt.Errorf("error getting the recovery codes from store: %w", err)
+	}
+	return as.compareRecoveryCode(hashes, code)
+}
+
+// compareRecoveryCode returns the hash of the hashes matching the code, or an empty string. The code is compared with
+// at least recoveryCodeCount hashes, completed with the dummy hash, and with all of them even after a match: the time
+// of a request reveals neither whether the user exists nor how many codes the user has left.
+func (as *JwtAuthService) compareRecoveryCode(hashes []string, code string) (string, error) {
+	match := ""
+	for i := 0; i < len(hashes) || i < recoveryCodeCount; i++ {
+		if i >= len(hashes) {
+			as.dummyVerify(code)
+			continue
+		}
+		ok, err := as.PasswordHasher.Verify(hashes[i], code)
+		if err != nil {
+			as.logger.Error("failed to compare recovery codes", zap.Error(err))
+			return "", fmt.Errorf("error comparing recovery code: %w", err)
+		}
+		if ok && match == "" {
+			match = hashes[i]
+		}
+	}
+	return match, nil
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/hashers"
+	"auth/pkg/jwt"
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func newTestRecoveryAuthService() AuthService {
+	return NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hashers.NewBcryptHasher(bcrypt.MinCost), mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+}
+
+// hashRecoveryCodes returns the bcrypt hashes of the codes.
+func hashRecoveryCodes(t *testing.T, codes ...string) []string {
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.MinCost)
+		require.NoError(t, err)
+		hashes = append(hashes, string(hash))
+	}
+	return hashes
+}
+
+func Test_authService_GenerateRecoveryCodes_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	claims := &jwt.Claims{}
+	claims.Subject = "user"
+	var stored []string
+
+	mockJwtVerifier.EXPECT().Verify("token").Return(claims, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&models.User{Username: "user"}, nil).Times(1)
+	mockRecoveryStore.EXPECT().Replace(ctx, "user", gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _, batchID string, hashes []string) error {
+		require.NotEmpty(t, batchID)
+		stored = hashes
+		return nil
+	}).Times(1)
+
+	//Act
+	codes, err := newTestRecoveryAuthService().GenerateRecoveryCodes(ctx, "token")
+
+	//Verify
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, stored, recoveryCodeCount)
+	unique := map[string]bool{}
+	for i, code := range codes {
+		require.Regexp(t, regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`), code)
+		require.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored[i]), []byte(normalizeRecoveryCode(code))))
+		unique[code] = true
+	}
+	require.Len(t, unique, recoveryCodeCount)
+}
+
+func Test_authService_GenerateRecoveryCodes_password_change_required(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	claims := &jwt.Claims{PasswordChange: true}
+	claims.Subject = "user"
+
+	mockJwtVerifier.EXPECT().Verify("token").Return(claims, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&models.User{Username: "user"}, nil).Times(1)
+	mockRecoveryStore.EXPECT().Replace(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	codes, err := newTestRecoveryAuthService().GenerateRecoveryCodes(ctx, "token")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.PasswordChangeRequiredErr{Name: "user"})
+	require.Empty(t, codes)
+}
+
+func Test_authService_CountRecoveryCodes(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	claims := &jwt.Claims{}
+	claims.Subject = "user"
+
+	mockJwtVerifier.EXPECT().Verify("token").Return(claims, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&models.User{Username: "user"}, nil).Times(1)
+	mockRecoveryStore.EXPECT().CountUnused(ctx, "user").Return(7, nil).Times(1)
+
+	//Act
+	count, err := newTestRecoveryAuthService().CountRecoveryCodes(ctx, "token")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, 7, count)
+}
+
+// countingHasher counts the verifications of the PasswordHasher.
+type countingHasher struct {
+	hashers.PasswordHasher
+	verified int
+}
+
+func (h *countingHasher) Verify(hash, password string) (bool, error) {
+	h.verified++
+	return h.PasswordHasher.Verify(hash, password)
+}
+
+func Test_authService_AuthenticateWithRecoveryCode_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	user := models.User{Username: "user"}
+	hashes := hashRecoveryCodes(t, "aaaaabbbbb", "cccccddddd")
+
+	mockThrottler.EXPECT().Check(ctx, "user", "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Succeed(ctx, "user").Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&user, nil).Times(1)
+	mockRecoveryStore.EXPECT().ListUnused(ctx, "user").Return(hashes, nil).Times(1)
+	mockRecoveryStore.EXPECT().Use(ctx, "user", hashes[1]).Return(true, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(user, gomock.Any()).Return("token", nil).Times(1)
+	mockRefreshStore.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	tokens, err := newTestRecoveryAuthService().AuthenticateWithRecoveryCode(ctx, "user", "CCCCC-DDDDD")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, &models.Tokens{AccessToken: "token"}, tokens)
+}
+
+func Test_authService_AuthenticateWithRecoveryCode_wrong_code(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	user := models.User{Username: "user"}
+
+	mockThrottler.EXPECT().Check(ctx, "user", "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Fail(ctx, "user", "").Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&user, nil).Times(1)
+	mockRecoveryStore.EXPECT().ListUnused(ctx, "user").Return(hashRecoveryCodes(t, "aaaaabbbbb"), nil).Times(1)
+	mockRecoveryStore.EXPECT().Use(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockJwtGenerator.EXPECT().Generate(gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	tokens, err := newTestRecoveryAuthService().AuthenticateWithRecoveryCode(ctx, "user", "zzzzz-zzzzz")
+
+	//Verify
+	require.EqualError(t, err, autherrors.AuthenticationFailErr("user").Error())
+	require.Nil(t, tokens)
+}
+
+func Test_authService_AuthenticateWithRecoveryCode_code_already_used(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	user := models.User{Username: "user"}
+	hashes := hashRecoveryCodes(t, "aaaaabbbbb")
+
+	mockThrottler.EXPECT().Check(ctx, "user", "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Fail(ctx, "user", "").Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&user, nil).Times(1)
+	mockRecoveryStore.EXPECT().ListUnused(ctx, "user").Return(hashes, nil).Times(1)
+	mockRecoveryStore.EXPECT().Use(ctx, "user", hashes[0]).Return(false, nil).Times(1)
+	mockJwtGenerator.EXPECT().Generate(gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	tokens, err := newTestRecoveryAuthService().AuthenticateWithRecoveryCode(ctx, "user", "aaaaa-bbbbb")
+
+	//Verify
+	require.EqualError(t, err, autherrors.AuthenticationFailErr("user").Error())
+	require.Nil(t, tokens)
+}
+
+func Test_authService_AuthenticateWithRecoveryCode_unknown_user(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockThrottler.EXPECT().Check(ctx, "unknown", "").Return(nil).Times(1)
+	mockThrottler.EXPECT().Fail(ctx, "unknown", "").Return(nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "unknown").Return(nil, nil).Times(1)
+	mockRecoveryStore.EXPECT().ListUnused(gomock.Any(), gomock.Any()).Times(0)
+
+	//Act
+	tokens, err := newTestRecoveryAuthService().AuthenticateWithRecoveryCode(ctx, "unknown", "aaaaa-bbbbb")
+
+	//Verify
+	require.EqualError(t, err, autherrors.AuthenticationFailErr("unknown").Error())
+	require.Nil(t, tokens)
+}
+
+func Test_authService_ChangePassword_after_recovery(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	claims := &jwt.Claims{Generation: 1, PasswordChange: true}
+	claims.Subject = "user"
+	hash, _ := bcrypt.GenerateFromPassword([]byte("forgotten"), bcrypt.MinCost)
+	user := models.User{Username: "user", Password: string(hash), TokenGeneration: 1}
+
+	mockJwtVerifier.EXPECT().Verify("token").Return(claims, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&user, nil).Times(1)
+	mockValidator.EXPECT().Validate("newpassword").Return(nil).Times(1)
+	mockUserStore.EXPECT().Update(ctx, "user", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, u models.User) (bool, error) {
+		require.Equal(t, int64(2), u.TokenGeneration)
+		require.NoError(t, bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("newpassword")))
+		return true, nil
+	}).Times(1)
+	mockRefreshStore.EXPECT().RevokeUser(ctx, "user").Return(nil).Times(1)
+
+	//Act
+	err := newTestRecoveryAuthService().ChangePassword(ctx, "token", "", "newpassword")
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_authService_AuthenticateWithRecoveryCode_same_work(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	user := models.User{Username: "user"}
+	hasher := &countingHasher{PasswordHasher: hashers.NewBcryptHasher(bcrypt.MinCost)}
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hasher, mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	mockThrottler.EXPECT().Check(ctx, gomock.Any(), "").Return(nil).Times(3)
+	mockThrottler.EXPECT().Fail(ctx, gomock.Any(), "").Return(nil).Times(3)
+	mockUserStore.EXPECT().Get(ctx, "unknown").Return(nil, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "user").Return(&user, nil).Times(2)
+	gomock.InOrder(
+		mockRecoveryStore.EXPECT().ListUnused(ctx, "user").Return(nil, nil).Times(1),
+		mockRecoveryStore.EXPECT().ListUnused(ctx, "user").Return(hashRecoveryCodes(t, "aaaaabbbbb", "cccccddddd"), nil).Times(1),
+	)
+
+	//Act & Verify
+	// The unknown users, the users without codes and the users with a few codes cost the same verifications.
+	for _, username := range []string{"unknown", "user", "user"} {
+		hasher.verified = 0
+		_, err := s.AuthenticateWithRecoveryCode(ctx, username, "zzzzz-zzzzz")
+		require.Error(t, err)
+		require.Equal(t, recoveryCodeCount, hasher.verified, username)
+	}
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"auth/pkg/stores"
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+// CollectRevokedTokens deletes the revoked tokens from the store every interval until the context is done.
+// A revoked token is kept for the grace duration after its expiration, the clock skew accepted by the verifier.
+func CollectRevokedTokens(ctx context.Context, store stores.RevocationStore, interval, grace time.Duration) {
+	logger := zap.L().Named("RevocationCollector")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := store.DeleteExpired(ctx, time.Now().Add(-grace))
+			if err != nil {
+				logger.Error("failed to delete the expired revoked tokens", zap.Error(err))
+				continue
+			}
+			logger.Debug("expired revoked tokens deleted", zap.Int64("count", n))
+		}
+	}
+}
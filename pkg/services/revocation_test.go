@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func Test_CollectRevokedTokens(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	collected := make(chan time.Time, 1)
+	mockRevocationStore.EXPECT().DeleteExpired(ctx, gomock.Any()).
+		DoAndReturn(func(_ context.Context, before time.Time) (int64, error) {
+			cancel()
+			select {
+			case collected <- before:
+			default:
+			}
+			return 1, nil
+		}).MinTimes(1)
+
+	//Act
+	done := make(chan struct{})
+	go func() {
+		CollectRevokedTokens(ctx, mockRevocationStore, time.Millisecond, time.Minute)
+		close(done)
+	}()
+
+	//Verify
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the collector did not stop with the context")
+	}
+	require.Len(t, collected, 1)
+	require.True(t, (<-collected).Before(time.Now().Add(-time.Minute)))
+}
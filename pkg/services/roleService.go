@@ -0,0 +1,126 @@
+package services
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"auth/pkg/stores"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+)
+
+type RoleService interface {
+	//DefineRoles creates the roles with their permissions, the permissions of the existing roles are replaced.
+	DefineRoles(ctx context.Context, roles []models.Role) error
+	//AssignRole assigns the role to the user with the username.
+	//The role is carried by the tokens issued to the user after the assignment.
+	AssignRole(ctx context.Context, username, role string) error
+	//RevokeRole revokes the role of the user with the username.
+	//The tokens issued before the revocation carry the role until they expire.
+	RevokeRole(ctx context.Context, username, role string) error
+	//ListRoles returns the roles assigned to the user with the username, or all the roles if the username is empty.
+	ListRoles(ctx context.Context, username string) ([]models.Role, error)
+}
+
+type roleService struct {
+	userStore stores.UserStore
+	roleStore stores.RoleStore
+	logger    *zap.Logger
+}
+
+// NewRoleService creates a new instance of a RoleService storing the roles in the roleStore.
+func NewRoleService(userStore stores.UserStore, roleStore stores.RoleStore) RoleService {
+	return &roleService{
+		userStore: userStore,
+		roleStore: roleStore,
+		logger:    zap.L().Named("RoleService"),
+	}
+}
+
+func (s *roleService) DefineRoles(ctx context.Context, roles []models.Role) error {
+	for _, role := range roles {
+		if role.Name == "" {
+			return autherrors.NewValidationErr(fmt.Errorf("role name required"))
+		}
+		if err := s.roleStore.Define(ctx, role); err != nil {
+			return fmt.Errorf("error defining the role: %w", err)
+		}
+		s.logger.Info("role defined", zap.String("role", role.Name), zap.Strings("permissions", role.Permissions))
+	}
+
+	return nil
+}
+
+func (s *roleService) AssignRole(ctx context.Context, username, role string) error {
+	if err := s.check(ctx, username, role); err != nil {
+		return err
+	}
+
+	if err := s.roleStore.Assign(ctx, username, role); err != nil {
+		return fmt.Errorf("error assigning the role: %w", err)
+	}
+
+	return nil
+}
+
+func (s *roleService) RevokeRole(ctx context.Context, username, role string) error {
+	if err := s.check(ctx, username, role); err != nil {
+		return err
+	}
+
+	found, err := s.roleStore.Revoke(ctx, username, role)
+	if err != nil {
+		return fmt.Errorf("error revoking the role: %w", err)
+	}
+	if !found {
+		return autherrors.RoleNotAssignedErr{Name: username, Role: role}
+	}
+
+	return nil
+}
+
+func (s *roleService) ListRoles(ctx context.Context, username string) ([]models.Role, error) {
+	if username == "" {
+		roles, err := s.roleStore.List(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error listing the roles: %w", err)
+		}
+		return roles, nil
+	}
+
+	u, err := s.userStore.Get(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user from store: %w", err)
+	}
+	if u == nil {
+		return nil, autherrors.UserNotFoundErr{Name: username}
+	}
+
+	roles, err := s.roleStore.ListByUser(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// check returns an error if the user with the username or the role doesn't exist.
+func (s *roleService) check(ctx context.Context, username, role string) error {
+	u, err := s.userStore.Get(ctx, username)
+	if err != nil {
+		return fmt.Errorf("error getting user from store: %w", err)
+	}
+	if u == nil {
+		return autherrors.UserNotFoundErr{Name: username}
+	}
+
+	r, err := s.roleStore.Get(ctx, role)
+	if err != nil {
+		return fmt.Errorf("error getting the role: %w", err)
+	}
+	if r == nil {
+		return autherrors.RoleNotFoundErr{Name: role}
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_roleService_DefineRoles(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	roles := []models.Role{
+		{Name: "admin", Permissions: []string{"users:read", "users:write"}},
+		{Name: "viewer", Permissions: []string{"users:read"}},
+	}
+
+	mockRoleStore.EXPECT().Define(ctx, roles[0]).Return(nil).Times(1)
+	mockRoleStore.EXPECT().Define(ctx, roles[1]).Return(nil).Times(1)
+
+	s := NewRoleService(mockUserStore, mockRoleStore)
+
+	//Act
+	err := s.DefineRoles(ctx, roles)
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_roleService_DefineRoles_no_name(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	s := NewRoleService(mockUserStore, mockRoleStore)
+
+	//Act
+	err := s.DefineRoles(ctx, []models.Role{{Permissions: []string{"users:read"}}})
+
+	//Verify
+	require.EqualError(t, err, "role name required")
+}
+
+func Test_roleService_AssignRole_no_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockUserStore.EXPECT().Get(ctx, "test").Return(&models.User{Username: "test"}, nil).Times(1)
+	mockRoleStore.EXPECT().Get(ctx, "admin").Return(&models.Role{Name: "admin"}, nil).Times(1)
+	mockRoleStore.EXPECT().Assign(ctx, "test", "admin").Return(nil).Times(1)
+
+	s := NewRoleService(mockUserStore, mockRoleStore)
+
+	//Act
+	err := s.AssignRole(ctx, "test", "admin")
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_roleService_AssignRole_user_not_found(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockUserStore.EXPECT().Get(ctx, "test").Return(nil, nil).Times(1)
+	mockRoleStore.EXPECT().Assign(ctx, "test", "admin").Times(0)
+
+	s := NewRoleService(mockUserStore, mockRoleStore)
+
+	//Act
+	err := s.AssignRole(ctx, "test", "admin")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.UserNotFoundErr{Name: "test"})
+}
+
+func Test_roleService_AssignRole_role_not_found(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockUserStore.EXPECT().Get(ctx, "test").Return(&models.User{Username: "test"}, nil).Times(1)
+	mockRoleStore.EXPECT().Get(ctx, "admin").Return(nil, nil).Times(1)
+	mockRoleStore.EXPECT().Assign(ctx, "test", "admin").Times(0)
+
+	s := NewRoleService(mockUserStore, mockRoleStore)
+
+	//Act
+	err := s.AssignRole(ctx, "test", "admin")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.RoleNotFoundErr{Name: "admin"})
+}
+
+func Test_roleService_RevokeRole_not_assigned(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+
+	mockUserStore.EXPECT().Get(ctx, "test").Return(&models.User{Username: "test"}, nil).Times(1)
+	mockRoleStore.EXPECT().Get(ctx, "admin").Return(&models.Role{Name: "admin"}, nil).Times(1)
+	mockRoleStore.EXPECT().Revoke(ctx, "test", "admin").Return(false, nil).Times(1)
+
+	s := NewRoleService(mockUserStore, mockRoleStore)
+
+	//Act
+	err := s.RevokeRole(ctx, "test", "admin")
+
+	//Verify
+	require.ErrorIs(t, err, autherrors.RoleNotAssignedErr{Name: "test", Role: "admin"})
+}
+
+func Test_roleService_ListRoles(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	all := []models.Role{{Name: "admin"}, {Name: "viewer"}}
+	assigned := []models.Role{{Name: "viewer"}}
+
+	mockRoleStore.EXPECT().List(ctx).Return(all, nil).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "test").Return(&models.User{Username: "test"}, nil).Times(1)
+	mockRoleStore.EXPECT().ListByUser(ctx, "test").Return(assigned, nil).Times(1)
+
+	s := NewRoleService(mockUserStore, mockRoleStore)
+
+	//Act
+	roles, err := s.ListRoles(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, all, roles)
+	roles, err = s.ListRoles(ctx, "test")
+
+	//Verify
+	require.NoError(t, err)
+	require.Equal(t, assigned, roles)
+}
+
+func Test_roleService_ListRoles_store_error(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	errorMsg := "can't list the roles"
+
+	mockRoleStore.EXPECT().List(ctx).Return(nil, fmt.Errorf(errorMsg)).Times(1)
+
+	s := NewRoleService(mockUserStore, mockRoleStore)
+
+	//Act
+	roles, err := s.ListRoles(ctx, "")
+
+	//Verify
+	require.EqualError(t, err, fmt.Sprintf("error listing the roles: %s", errorMsg))
+	require.Nil(t, roles)
+}
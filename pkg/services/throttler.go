@@ -0,0 +1,182 @@
+package services
+
+import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"auth/pkg/stores"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/peer"
+	"net"
+	"time"
+)
+
+type LoginThrottler interface {
+	//Check returns an error if the logins of the username, or from the address, are throttled or locked.
+	Check(ctx context.Context, username, address string) error
+	//Fail records a failed login of the username from the address.
+	Fail(ctx context.Context, username, address string) error
+	//Succeed forgets the failed logins of the username.
+	Succeed(ctx context.Context, username string) error
+	//Unlock forgets the failed logins of the username, which unlocks it.
+	Unlock(ctx context.Context, username string) error
+}
+
+type loginThrottler struct {
+	store         stores.LoginFailureStore
+	prefix        string
+	window        time.Duration
+	maxFailures   int
+	ipMaxFailures int
+	lockout       time.Duration
+	baseDelay     time.Duration
+	maxDelay      time.Duration
+	logger        *zap.Logger
+}
+
+// NewLoginThrottler creates a new instance of a LoginThrottler recording the failed logins in the store.
+func NewLoginThrottler(store stores.LoginFailureStore, configuration config.Throttle) LoginThrottler {
+	t := &loginThrottler{
+		store:         store,
+		window:        time.Minute * time.Duration(configuration.Window),
+		maxFailures:   configuration.MaxFailures,
+		ipMaxFailures: configuration.IPMaxFailures,
+		lockout:       time.Minute * time.Duration(configuration.Lockout),
+		baseDelay:     time.Second * time.Duration(configuration.BaseDelay),
+		maxDelay:      time.Second * time.Duration(configuration.MaxDelay),
+		logger:        zap.L().Named("LoginThrottler"),
+	}
+	if t.maxDelay < t.baseDelay {
+		t.maxDelay = t.baseDelay
+	}
+	return t
+}
+
+// NewPasswordResetThrottler creates a new instance of a LoginThrottler counting the password reset requests,
+// instead of the failed logins, in the store. Its counts are kept apart from the failed logins.
+func NewPasswordResetThrottler(store stores.LoginFailureStore, configuration config.Throttle) LoginThrottler {
+	t := NewLoginThrottler(store, configuration).(*loginThrottler)
+	t.prefix = "reset:"
+	t.logger = zap.L().Named("PasswordResetThrottler")
+	return t
+}
+
+func (t *loginThrottler) Check(ctx context.Context, username, address string) error {
+	if t.window == 0 {
+		return nil
+	}
+	// The usernames are throttled whether they exist or not, so the throttling doesn't reveal them.
+	f, err := t.store.Get(ctx, t.usernameKey(username))
+	if err != nil {
+		return err
+	}
+	if f != nil && t.locked(f, t.maxFailures) {
+		return autherrors.AccountLockedErr{Until: f.LastFailure.Add(t.lockout)}
+	}
+	if err := t.throttled(f); err != nil {
+		return err
+	}
+
+	if address == "" {
+		return nil
+	}
+	f, err = t.store.Get(ctx, t.addressKey(address))
+	if err != nil {
+		return err
+	}
+	if f != nil && t.locked(f, t.ipMaxFailures) {
+		return autherrors.LoginThrottledErr{RetryAfter: time.Until(f.LastFailure.Add(t.lockout))}
+	}
+	return t.throttled(f)
+}
+
+func (t *loginThrottler) Fail(ctx context.Context, username, address string) error {
+	if t.window == 0 {
+		return nil
+	}
+	now := time.Now()
+	f, err := t.store.Add(ctx, t.usernameKey(username), now, now.Add(-t.window))
+	if err != nil {
+		return err
+	}
+	if t.locked(f, t.maxFailures) {
+		t.logger.Warn("account locked", zap.String("username", username), zap.Int("failures", f.Failures))
+	}
+	if address == "" {
+		return nil
+	}
+	f, err = t.store.Add(ctx, t.addressKey(address), now, now.Add(-t.window))
+	if err != nil {
+		return err
+	}
+	if t.locked(f, t.ipMaxFailures) {
+		t.logger.Warn("address locked", zap.String("address", address), zap.Int("failures", f.Failures))
+	}
+
+	return nil
+}
+
+func (t *loginThrottler) Succeed(ctx context.Context, username string) error {
+	if t.window == 0 {
+		return nil
+	}
+	return t.store.Reset(ctx, t.usernameKey(username))
+}
+
+func (t *loginThrottler) Unlock(ctx context.Context, username string) error {
+	return t.store.Reset(ctx, t.usernameKey(username))
+}
+
+// locked returns true if the failures reached maxFailures and the lockout is not over.
+func (t *loginThrottler) locked(f *models.LoginFailures, maxFailures int) bool {
+	return maxFailures > 0 && f.Failures >= maxFailures && time.Now().Before(f.LastFailure.Add(t.lockout))
+}
+
+// throttled returns a LoginThrottledErr if the delay after the last failure is not over.
+func (t *loginThrottler) throttled(f *models.LoginFailures) error {
+	if f == nil || time.Since(f.LastFailure) > t.window {
+		return nil
+	}
+	if retryAfter := time.Until(f.LastFailure.Add(t.delay(f.Failures))); retryAfter > 0 {
+		return autherrors.LoginThrottledErr{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// delay returns the delay after the failures: baseDelay doubled on each failure after the first one, up to maxDelay.
+func (t *loginThrottler) delay(failures int) time.Duration {
+	if failures < 1 {
+		return 0
+	}
+	d := t.baseDelay
+	for i := 1; i < failures && d < t.maxDelay; i++ {
+		d *= 2
+	}
+	if d > t.maxDelay {
+		return t.maxDelay
+	}
+	return d
+}
+
+func (t *loginThrottler) usernameKey(username string) string {
+	return fmt.Sprintf("%suser:%s", t.prefix, username)
+}
+
+func (t *loginThrottler) addressKey(address string) string {
+	return fmt.Sprintf("%sip:%s", t.prefix, address)
+}
+
+// peerAddress returns the IP address of the gRPC peer of the context, empty if there is none.
+func peerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
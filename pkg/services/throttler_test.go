@@ -0,0 +1,176 @@
+package services
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net"
+	"testing"
+	"time"
+)
+
+var testThrottle = config.Throttle{
+	Window:        15,
+	MaxFailures:   3,
+	IPMaxFailures: 10,
+	Lockout:       10,
+	BaseDelay:     1,
+	MaxDelay:      8,
+}
+
+func Test_loginThrottler_Check(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		user     *models.LoginFailures
+		address  *models.LoginFailures
+		wantCode codes.Code
+	}{
+		{name: "no failure"},
+		{name: "delay over", user: &models.LoginFailures{Failures: 2, LastFailure: now.Add(-3 * time.Second)}},
+		{name: "delayed", user: &models.LoginFailures{Failures: 2, LastFailure: now}, wantCode: codes.ResourceExhausted},
+		{name: "locked", user: &models.LoginFailures{Failures: 3, LastFailure: now.Add(-time.Minute)}, wantCode: codes.PermissionDenied},
+		{name: "lockout over", user: &models.LoginFailures{Failures: 3, LastFailure: now.Add(-11 * time.Minute)}},
+		{name: "failures forgotten", user: &models.LoginFailures{Failures: 30, LastFailure: now.Add(-time.Hour)}},
+		{name: "address delayed", address: &models.LoginFailures{Failures: 1, LastFailure: now}, wantCode: codes.ResourceExhausted},
+		{name: "address locked", address: &models.LoginFailures{Failures: 10, LastFailure: now.Add(-time.Minute)}, wantCode: codes.ResourceExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teardownTest := setupTest(t)
+			defer teardownTest(t)
+
+			//Prepare
+			ctx := context.Background()
+			mockLoginFailureStore.EXPECT().Get(ctx, "user:test").Return(tt.user, nil).Times(1)
+			mockLoginFailureStore.EXPECT().Get(ctx, "ip:10.0.0.1").Return(tt.address, nil).MaxTimes(1)
+			throttler := NewLoginThrottler(mockLoginFailureStore, testThrottle)
+
+			//Act
+			err := throttler.Check(ctx, "test", "10.0.0.1")
+
+			//Verify
+			if tt.wantCode == codes.OK {
+				require.NoError(t, err)
+				return
+			}
+			require.Equal(t, tt.wantCode, status.Code(err))
+			require.NotContains(t, err.Error(), "test")
+		})
+	}
+}
+
+func Test_loginThrottler_disabled(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	mockLoginFailureStore.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+	mockLoginFailureStore.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	throttler := NewLoginThrottler(mockLoginFailureStore, config.Throttle{})
+
+	//Act & Verify
+	require.NoError(t, throttler.Fail(ctx, "test", "10.0.0.1"))
+	require.NoError(t, throttler.Check(ctx, "test", "10.0.0.1"))
+}
+
+func Test_loginThrottler_Fail(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	for _, key := range []string{"user:test", "ip:10.0.0.1"} {
+		mockLoginFailureStore.EXPECT().Add(ctx, key, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, key string, failedAt, since time.Time) (*models.LoginFailures, error) {
+				require.Equal(t, 15*time.Minute, failedAt.Sub(since))
+				return &models.LoginFailures{Key: key, Failures: 1, LastFailure: failedAt}, nil
+			}).Times(1)
+	}
+	throttler := NewLoginThrottler(mockLoginFailureStore, testThrottle)
+
+	//Act
+	err := throttler.Fail(ctx, "test", "10.0.0.1")
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_passwordResetThrottler_Fail(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	for _, key := range []string{"reset:user:test", "reset:ip:10.0.0.1"} {
+		mockLoginFailureStore.EXPECT().Add(ctx, key, gomock.Any(), gomock.Any()).Return(&models.LoginFailures{Key: key, Failures: 1}, nil).Times(1)
+	}
+	throttler := NewPasswordResetThrottler(mockLoginFailureStore, testThrottle)
+
+	//Act
+	err := throttler.Fail(ctx, "test", "10.0.0.1")
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_loginThrottler_Fail_no_address(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	mockLoginFailureStore.EXPECT().Add(ctx, "user:test", gomock.Any(), gomock.Any()).Return(&models.LoginFailures{Failures: 1}, nil).Times(1)
+	throttler := NewLoginThrottler(mockLoginFailureStore, testThrottle)
+
+	//Act
+	err := throttler.Fail(ctx, "test", "")
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_loginThrottler_Unlock(t *testing.T) {
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	ctx := context.Background()
+	mockLoginFailureStore.EXPECT().Reset(ctx, "user:test").Return(nil).Times(1)
+	throttler := NewLoginThrottler(mockLoginFailureStore, testThrottle)
+
+	//Act
+	err := throttler.Unlock(ctx, "test")
+
+	//Verify
+	require.NoError(t, err)
+}
+
+func Test_loginThrottler_delay(t *testing.T) {
+	throttler := NewLoginThrottler(nil, testThrottle).(*loginThrottler)
+
+	for failures, want := range map[int]time.Duration{
+		0:    0,
+		1:    time.Second,
+		2:    2 * time.Second,
+		3:    4 * time.Second,
+		4:    8 * time.Second,
+		5:    8 * time.Second,
+		1000: 8 * time.Second,
+	} {
+		require.Equal(t, want, throttler.delay(failures), failures)
+	}
+}
+
+func Test_peerAddress(t *testing.T) {
+	require.Empty(t, peerAddress(context.Background()))
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}})
+	require.Equal(t, "10.0.0.1", peerAddress(ctx))
+}
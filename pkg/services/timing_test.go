@@ -0,0 +1,70 @@
+package services
+
+import (
+	"auth/pkg/hashers"
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Test_authService_Authenticate_timing checks that rejecting an unknown username and rejecting a wrong password
+// take the same time, so the response time doesn't reveal whether a user exists.
+func Test_authService_Authenticate_timing(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test skipped in short mode")
+	}
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	//Prepare
+	const samples = 31
+	const tolerance = 0.3
+	ctx := context.Background()
+	hasher := hashers.NewBcryptHasher(6)
+	hash, err := hasher.Hash("password")
+	require.NoError(t, err)
+	user := models.User{Username: "known", Password: hash}
+
+	mockUserStore.EXPECT().Get(ctx, "known").Return(&user, nil).AnyTimes()
+	mockUserStore.EXPECT().Get(ctx, "unknown").Return(nil, nil).AnyTimes()
+	mockThrottler.EXPECT().Check(ctx, gomock.Any(), "").Return(nil).AnyTimes()
+	mockThrottler.EXPECT().Fail(ctx, gomock.Any(), "").Return(nil).AnyTimes()
+
+	s := NewJwtAuthService(mockUserStore, mockRefreshStore, mockRevocationStore, mockJwtGenerator, mockJwtVerifier, mockKeySet, mockValidator, hasher, mockThrottler, nil, mockRecoveryStore, mockRoleStore, mockAPIKeyStore, mockOAuthClientStore, time.Hour)
+
+	measure := func(username string) time.Duration {
+		start := time.Now()
+		_, err := s.Authenticate(ctx, username, "wrong")
+		elapsed := time.Since(start)
+		require.Error(t, err)
+		return elapsed
+	}
+
+	//Act
+	// The samples of both paths are interleaved, so a load change on the machine affects both.
+	var known, unknown []time.Duration
+	for i := 0; i < samples; i++ {
+		known = append(known, measure("known"))
+		unknown = append(unknown, measure("unknown"))
+	}
+
+	//Verify
+	knownMedian, unknownMedian := median(known), median(unknown)
+	t.Logf("median known user: %s, unknown user: %s", knownMedian, unknownMedian)
+	diff := knownMedian - unknownMedian
+	if diff < 0 {
+		diff = -diff
+	}
+	require.Lessf(t, float64(diff), tolerance*float64(knownMedian),
+		"the medians %s and %s differ by more than %.0f%%", knownMedian, unknownMedian, tolerance*100)
+}
+
+func median(durations []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
@@ -2,33 +2,57 @@ package services
 
 import (
 	autherrors "auth/pkg/errors"
+	"auth/pkg/hashers"
 	"auth/pkg/models"
 	"auth/pkg/stores"
 	"auth/pkg/validators"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
 )
 
 type UserService interface {
 	//Create a new user from a models.User
 	Create(ctx context.Context, user models.User) error
+	//Get the user with the username.
+	Get(ctx context.Context, username string) (*models.User, error)
+	//Update the user with the username from a models.UserUpdate.
+	Update(ctx context.Context, username string, update models.UserUpdate) error
+	//Delete the user with the username.
+	Delete(ctx context.Context, username string) error
+	//List returns a page of the users whose username starts with the prefix, ordered by username,
+	//and the token of the next page, empty for the last page. A page token is only valid for its prefix.
+	List(ctx context.Context, prefix, pageToken string, pageSize int) ([]models.User, string, error)
+}
+
+// pageCursor is the position of the next page of a listing: the last username of the previous page,
+// and the prefix of the listing, so the token can't be replayed for another prefix.
+type pageCursor struct {
+	Prefix string `json:"p"`
+	After  string `json:"a"`
 }
 
 type userService struct {
 	userStore stores.UserStore
 	validator validators.Validator
-	hashCost  int
+	hasher    hashers.PasswordHasher
 	logger    *zap.Logger
 }
 
-// NewUserService creates a new instance of an UserService.
-func NewUserService(userStore stores.UserStore, validator validators.Validator, hashCost int) UserService {
+// NewUserService creates a new instance of an UserService hashing the passwords with the hasher.
+func NewUserService(userStore stores.UserStore, validator validators.Validator, hasher hashers.PasswordHasher) UserService {
 	return &userService{
 		userStore: userStore,
 		validator: validator,
-		hashCost:  hashCost,
+		hasher:    hasher,
 		logger:    zap.L().Named("UserService"),
 	}
 }
@@ -39,27 +63,141 @@ func (s *userService) Create(ctx context.Context, userRequest models.User) error
 		return fmt.Errorf("validation error: %w", err)
 	}
 
-	u, err := s.userStore.Get(ctx, userRequest.Username)
-	if err != nil {
-		return fmt.Errorf("error getting user from store: %w", err)
-	}
-	if u != nil {
-		return autherrors.UsernameAlreadyExistErr{Name: userRequest.Username}
-	}
-
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userRequest.Password), s.hashCost)
+	hashedPassword, err := s.hasher.Hash(userRequest.Password)
 	if err != nil {
 		return fmt.Errorf("error during password hashing: %w", err)
 	}
 	user := models.User{
 		Username: userRequest.Username,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
+		Email:    userRequest.Email,
 	}
 
+	// The store rejects an existing username, even if it was created concurrently
 	err = s.userStore.Create(ctx, user)
+	var exists autherrors.UsernameAlreadyExistErr
+	if errors.As(err, &exists) {
+		return exists
+	}
 	if err != nil {
 		return fmt.Errorf("error creating the user: %w", err)
 	}
 
 	return nil
 }
+
+func (s *userService) Get(ctx context.Context, username string) (*models.User, error) {
+	u, err := s.userStore.Get(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user from store: %w", err)
+	}
+	if u == nil {
+		return nil, autherrors.UserNotFoundErr{Name: username}
+	}
+
+	return u, nil
+}
+
+func (s *userService) Update(ctx context.Context, username string, update models.UserUpdate) error {
+	err := s.validator.Validate(update)
+	if err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+
+	u, err := s.Get(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	user := *u
+	if update.Username != "" {
+		user.Username = update.Username
+	}
+
+	if update.Email != "" {
+		user.Email = update.Email
+	}
+
+	if update.Password != "" {
+		hashedPassword, err := s.hasher.Hash(update.Password)
+		if err != nil {
+			return fmt.Errorf("error during password hashing: %w", err)
+		}
+		user.Password = hashedPassword
+	}
+
+	found, err := s.userStore.Update(ctx, username, user)
+	var exists autherrors.UsernameAlreadyExistErr
+	if errors.As(err, &exists) {
+		return exists
+	}
+	if err != nil {
+		return fmt.Errorf("error updating the user: %w", err)
+	}
+	if !found {
+		return autherrors.UserNotFoundErr{Name: username}
+	}
+
+	return nil
+}
+
+func (s *userService) Delete(ctx context.Context, username string) error {
+	found, err := s.userStore.Delete(ctx, username)
+	if err != nil {
+		return fmt.Errorf("error deleting the user: %w", err)
+	}
+	if !found {
+		return autherrors.UserNotFoundErr{Name: username}
+	}
+
+	return nil
+}
+
+func (s *userService) List(ctx context.Context, prefix, pageToken string, pageSize int) ([]models.User, string, error) {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	} else if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	after, err := decodePageToken(pageToken, prefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// One more user is requested to know if there is a next page.
+	users, err := s.userStore.List(ctx, prefix, after, pageSize+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("error listing the users: %w", err)
+	}
+	if len(users) <= pageSize {
+		return users, "", nil
+	}
+
+	users = users[:pageSize]
+	next, err := json.Marshal(pageCursor{Prefix: prefix, After: users[pageSize-1].Username})
+	if err != nil {
+		return nil, "", fmt.Errorf("error encoding the page token: %w", err)
+	}
+	return users, base64.RawURLEncoding.EncodeToString(next), nil
+}
+
+// decodePageToken returns the last username of the previous page of the token, empty for the first page.
+// The token must have been issued for the prefix.
+func decodePageToken(token, prefix string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", autherrors.NewValidationErr(fmt.Errorf("invalid page token"))
+	}
+	var t pageCursor
+	if err := json.Unmarshal(b, &t); err != nil {
+		return "", autherrors.NewValidationErr(fmt.Errorf("invalid page token"))
+	}
+	if t.Prefix != prefix {
+		return "", autherrors.NewValidationErr(fmt.Errorf("the page token was issued for another prefix"))
+	}
+	return t.After, nil
+}
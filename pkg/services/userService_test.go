@@ -2,27 +2,66 @@ package services
 
 import (
 	autherrors "auth/pkg/errors"
+	"auth/pkg/hashers"
 	"auth/pkg/models"
 	"auth/pkg/tests"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"testing"
 )
 
 var (
-	mockUserStore    *tests.MockUserStore
-	mockValidator    *tests.MockValidator
-	mockJwtGenerator *tests.MockTokenGenerator
+	mockUserStore         *tests.MockUserStore
+	mockRefreshStore      *tests.MockRefreshTokenStore
+	mockRevocationStore   *tests.MockRevocationStore
+	mockValidator         *tests.MockValidator
+	mockJwtGenerator      *tests.MockTokenGenerator
+	mockJwtVerifier       *tests.MockTokenVerifier
+	mockKeySet            *tests.MockKeySet
+	mockResetStore        *tests.MockPasswordResetStore
+	mockNotifier          *tests.MockNotifier
+	mockHasher            *tests.MockPasswordHasher
+	mockThrottler         *tests.MockLoginThrottler
+	mockLoginFailureStore *tests.MockLoginFailureStore
+	mockTOTPStore         *tests.MockTOTPStore
+	mockChallengeStore    *tests.MockMFAChallengeStore
+	mockMFAService        *tests.MockMFAService
+	mockRecoveryStore     *tests.MockRecoveryCodeStore
+	mockRoleStore         *tests.MockRoleStore
+	mockAPIKeyStore       *tests.MockAPIKeyStore
+	mockOAuthClientStore  *tests.MockOAuthClientStore
+	mockAuthCodeStore     *tests.MockAuthorizationCodeStore
 )
 
 func setupTest(t testing.TB) func(t testing.TB) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	mockUserStore = tests.NewMockUserStore(ctrl)
+	mockRefreshStore = tests.NewMockRefreshTokenStore(ctrl)
+	mockRevocationStore = tests.NewMockRevocationStore(ctrl)
 	mockValidator = tests.NewMockValidator(ctrl)
 	mockJwtGenerator = tests.NewMockTokenGenerator(ctrl)
+	mockJwtVerifier = tests.NewMockTokenVerifier(ctrl)
+	mockKeySet = tests.NewMockKeySet(ctrl)
+	mockResetStore = tests.NewMockPasswordResetStore(ctrl)
+	mockNotifier = tests.NewMockNotifier(ctrl)
+	mockHasher = tests.NewMockPasswordHasher(ctrl)
+	mockThrottler = tests.NewMockLoginThrottler(ctrl)
+	mockLoginFailureStore = tests.NewMockLoginFailureStore(ctrl)
+	mockTOTPStore = tests.NewMockTOTPStore(ctrl)
+	mockChallengeStore = tests.NewMockMFAChallengeStore(ctrl)
+	mockMFAService = tests.NewMockMFAService(ctrl)
+	mockRecoveryStore = tests.NewMockRecoveryCodeStore(ctrl)
+	mockRoleStore = tests.NewMockRoleStore(ctrl)
+	mockAPIKeyStore = tests.NewMockAPIKeyStore(ctrl)
+	mockOAuthClientStore = tests.NewMockOAuthClientStore(ctrl)
+	mockAuthCodeStore = tests.NewMockAuthorizationCodeStore(ctrl)
 
 	return func(t testing.TB) {
 	}
@@ -37,12 +76,12 @@ func Test_userService_Create_no_error(t *testing.T) {
 	user := models.User{Username: "test", Password: "test"}
 
 	mockValidator.EXPECT().Validate(user).Return(nil).Times(1)
-	mockUserStore.EXPECT().Get(ctx, user.Username).Times(1)
 	mockUserStore.EXPECT().Create(ctx, gomock.Any()).Times(1)
 
 	s := &userService{
 		userStore: mockUserStore,
 		validator: mockValidator,
+		hasher:    hashers.NewBcryptHasher(bcrypt.MinCost),
 	}
 
 	err := s.Create(ctx, user)
@@ -59,12 +98,12 @@ func Test_userService_Create_validator_error(t *testing.T) {
 
 	errorMsg := "something is not valid"
 	mockValidator.EXPECT().Validate(user).Return(fmt.Errorf(errorMsg)).Times(1)
-	mockUserStore.EXPECT().Get(ctx, user.Username).Times(0)
 	mockUserStore.EXPECT().Create(ctx, gomock.Any()).Times(0)
 
 	s := &userService{
 		userStore: mockUserStore,
 		validator: mockValidator,
+		hasher:    hashers.NewBcryptHasher(bcrypt.MinCost),
 	}
 
 	err := s.Create(ctx, user)
@@ -72,7 +111,7 @@ func Test_userService_Create_validator_error(t *testing.T) {
 	require.EqualError(t, err, fmt.Sprintf("validation error: %s", errorMsg))
 }
 
-func Test_userService_Create_store_get_error(t *testing.T) {
+func Test_userService_Create_existing_user(t *testing.T) {
 
 	teardownTest := setupTest(t)
 	defer teardownTest(t)
@@ -80,63 +119,310 @@ func Test_userService_Create_store_get_error(t *testing.T) {
 	ctx := context.Background()
 	user := models.User{Username: "test", Password: "test"}
 
-	errorMsg := "something went wrong"
 	mockValidator.EXPECT().Validate(user).Return(nil).Times(1)
-	mockUserStore.EXPECT().Get(ctx, user.Username).Return(nil, fmt.Errorf(errorMsg)).Times(1)
-	mockUserStore.EXPECT().Create(ctx, gomock.Any()).Times(0)
+	mockUserStore.EXPECT().Create(ctx, gomock.Any()).Return(autherrors.UsernameAlreadyExistErr{Name: user.Username}).Times(1)
 
 	s := &userService{
 		userStore: mockUserStore,
 		validator: mockValidator,
+		hasher:    hashers.NewBcryptHasher(bcrypt.MinCost),
 	}
 
 	err := s.Create(ctx, user)
 	require.Error(t, err)
-	require.EqualError(t, err, fmt.Sprintf("error getting user from store: %s", errorMsg))
+	require.Equal(t, autherrors.UsernameAlreadyExistErr{Name: user.Username}, err)
 }
 
-func Test_userService_Create_store_get_existing_user(t *testing.T) {
+func Test_userService_Create_store_create_error(t *testing.T) {
 
 	teardownTest := setupTest(t)
 	defer teardownTest(t)
 
 	ctx := context.Background()
 	user := models.User{Username: "test", Password: "test"}
-	existingUser := models.User{Username: "test", Password: "jkljkljkljkljkl"}
+	errorMsg := "something went wrong"
 
 	mockValidator.EXPECT().Validate(user).Return(nil).Times(1)
-	mockUserStore.EXPECT().Get(ctx, user.Username).Return(&existingUser, nil).Times(1)
-	mockUserStore.EXPECT().Create(ctx, gomock.Any()).Times(0)
+	mockUserStore.EXPECT().Create(ctx, gomock.Any()).Return(fmt.Errorf(errorMsg)).Times(1)
 
 	s := &userService{
 		userStore: mockUserStore,
 		validator: mockValidator,
+		hasher:    hashers.NewBcryptHasher(bcrypt.MinCost),
 	}
 
 	err := s.Create(ctx, user)
 	require.Error(t, err)
-	require.EqualError(t, err, autherrors.UsernameAlreadyExistErr{Name: user.Username}.Error())
+	require.EqualError(t, err, fmt.Sprintf("error creating the user: %s", errorMsg))
 }
 
-func Test_userService_Create_store_create_error(t *testing.T) {
+func Test_userService_Get_not_found(t *testing.T) {
 
 	teardownTest := setupTest(t)
 	defer teardownTest(t)
 
 	ctx := context.Background()
-	user := models.User{Username: "test", Password: "test"}
-	errorMsg := "something went wrong"
 
-	mockValidator.EXPECT().Validate(user).Return(nil).Times(1)
-	mockUserStore.EXPECT().Get(ctx, user.Username).Return(nil, nil).Times(1)
-	mockUserStore.EXPECT().Create(ctx, gomock.Any()).Return(fmt.Errorf(errorMsg)).Times(1)
+	mockUserStore.EXPECT().Get(ctx, "test").Return(nil, nil).Times(1)
 
 	s := &userService{
 		userStore: mockUserStore,
 		validator: mockValidator,
+		hasher:    hashers.NewBcryptHasher(bcrypt.MinCost),
 	}
 
-	err := s.Create(ctx, user)
+	u, err := s.Get(ctx, "test")
+	require.ErrorIs(t, err, autherrors.UserNotFoundErr{Name: "test"})
+	require.Nil(t, u)
+}
+
+func Test_userService_Update_no_error(t *testing.T) {
+
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	existingUser := models.User{Username: "test", Password: "jkljkljkljkljkl"}
+
+	mockUserStore.EXPECT().Get(ctx, "test").Return(&existingUser, nil).Times(1)
+	mockValidator.EXPECT().Validate(models.UserUpdate{Username: "renamed", Password: "newpassword"}).Return(nil).Times(1)
+	mockUserStore.EXPECT().Update(ctx, "test", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, u models.User) (bool, error) {
+		require.Equal(t, "renamed", u.Username)
+		require.NoError(t, bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("newpassword")))
+		return true, nil
+	}).Times(1)
+
+	s := &userService{
+		userStore: mockUserStore,
+		validator: mockValidator,
+		hasher:    hashers.NewBcryptHasher(bcrypt.MinCost),
+	}
+
+	err := s.Update(ctx, "test", models.UserUpdate{Username: "renamed", Password: "newpassword"})
+	require.NoError(t, err)
+}
+
+func Test_userService_Update_keeps_password(t *testing.T) {
+
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	existingUser := models.User{Username: "test", Password: "jkljkljkljkljkl"}
+
+	mockUserStore.EXPECT().Get(ctx, "test").Return(&existingUser, nil).Times(1)
+	mockValidator.EXPECT().Validate(models.UserUpdate{Username: "renamed", Email: "renamed@example.com"}).Return(nil).Times(1)
+	mockUserStore.EXPECT().Update(ctx, "test", models.User{
+		Username: "renamed",
+		Password: existingUser.Password,
+		Email:    "renamed@example.com",
+	}).Return(true, nil).Times(1)
+
+	s := &userService{
+		userStore: mockUserStore,
+		validator: mockValidator,
+		hasher:    hashers.NewBcryptHasher(bcrypt.MinCost),
+	}
+
+	err := s.Update(ctx, "test", models.UserUpdate{Username: "renamed", Email: "renamed@example.com"})
+	require.NoError(t, err)
+}
+
+func Test_userService_Update_username_exists(t *testing.T) {
+
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	existingUser := models.User{Username: "test", Password: "jkljkljkljkljkl"}
+
+	mockUserStore.EXPECT().Get(ctx, "test").Return(&existingUser, nil).Times(1)
+	mockValidator.EXPECT().Validate(models.UserUpdate{Username: "other"}).Return(nil).Times(1)
+	mockUserStore.EXPECT().Update(ctx, "test", gomock.Any()).Return(false, autherrors.UsernameAlreadyExistErr{Name: "other"}).Times(1)
+
+	s := &userService{
+		userStore: mockUserStore,
+		validator: mockValidator,
+		hasher:    hashers.NewBcryptHasher(bcrypt.MinCost),
+	}
+
+	err := s.Update(ctx, "test", models.UserUpdate{Username: "other"})
+	require.Equal(t, autherrors.UsernameAlreadyExistErr{Name: "other"}, err)
+}
+
+func Test_userService_Update_validator_error(t *testing.T) {
+
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	update := models.UserUpdate{Email: "test"}
+
+	errorMsg := "something is not valid"
+	mockValidator.EXPECT().Validate(update).Return(fmt.Errorf(errorMsg)).Times(1)
+	mockUserStore.EXPECT().Get(ctx, gomock.Any()).Times(0)
+	mockUserStore.EXPECT().Update(ctx, gomock.Any(), gomock.Any()).Times(0)
+
+	s := &userService{
+		userStore: mockUserStore,
+		validator: mockValidator,
+		hasher:    hashers.NewBcryptHasher(bcrypt.MinCost),
+	}
+
+	err := s.Update(ctx, "test", update)
+	require.EqualError(t, err, fmt.Sprintf("validation error: %s", errorMsg))
+}
+
+func Test_userService_Delete_not_found(t *testing.T) {
+
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+
+	mockUserStore.EXPECT().Delete(ctx, "test").Return(false, nil).Times(1)
+
+	s := &userService{
+		userStore: mockUserStore,
+		validator: mockValidator,
+		hasher:    hashers.NewBcryptHasher(bcrypt.MinCost),
+	}
+
+	err := s.Delete(ctx, "test")
+	require.ErrorIs(t, err, autherrors.UserNotFoundErr{Name: "test"})
+}
+
+func Test_userService_List_pages(t *testing.T) {
+
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	users := []models.User{{Username: "alice1"}, {Username: "alice2"}, {Username: "alice3"}}
+
+	mockUserStore.EXPECT().List(ctx, "alice", "", 3).Return(users, nil).Times(1)
+	mockUserStore.EXPECT().List(ctx, "alice", "alice2", 3).Return(users[2:], nil).Times(1)
+
+	s := &userService{
+		userStore: mockUserStore,
+		validator: mockValidator,
+		hasher:    hashers.NewBcryptHasher(bcrypt.MinCost),
+	}
+
+	page, next, err := s.List(ctx, "alice", "", 2)
+	require.NoError(t, err)
+	require.Equal(t, users[:2], page)
+	require.NotEmpty(t, next)
+
+	page, next, err = s.List(ctx, "alice", next, 2)
+	require.NoError(t, err)
+	require.Equal(t, users[2:], page)
+	require.Empty(t, next)
+}
+
+func Test_userService_List_page_size(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageSize int
+		want     int
+	}{
+		{"default", 0, defaultPageSize + 1},
+		{"max", maxPageSize + 1, maxPageSize + 1},
+		{"requested", 10, 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teardownTest := setupTest(t)
+			defer teardownTest(t)
+
+			ctx := context.Background()
+			mockUserStore.EXPECT().List(ctx, "", "", tt.want).Return(nil, nil).Times(1)
+
+			s := &userService{
+				userStore: mockUserStore,
+				validator: mockValidator,
+			}
+
+			_, _, err := s.List(ctx, "", "", tt.pageSize)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_userService_List_invalid_page_token(t *testing.T) {
+
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	mockUserStore.EXPECT().List(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	s := &userService{
+		userStore: mockUserStore,
+		validator: mockValidator,
+		hasher:    hashers.NewBcryptHasher(bcrypt.MinCost),
+	}
+
+	_, _, err := s.List(ctx, "", "not a token!", 10)
 	require.Error(t, err)
-	require.EqualError(t, err, fmt.Sprintf("error creating the user: %s", errorMsg))
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// The last username of a page is not a token.
+	_, _, err = s.List(ctx, "", base64.RawURLEncoding.EncodeToString([]byte("alice")), 10)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_userService_List_page_token_of_another_prefix(t *testing.T) {
+
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	users := []models.User{{Username: "alice1"}, {Username: "alice2"}}
+
+	mockUserStore.EXPECT().List(ctx, "alice", "", 2).Return(users, nil).Times(1)
+	mockUserStore.EXPECT().List(ctx, "bob", gomock.Any(), gomock.Any()).Times(0)
+
+	s := &userService{
+		userStore: mockUserStore,
+		validator: mockValidator,
+	}
+
+	_, next, err := s.List(ctx, "alice", "", 1)
+	require.NoError(t, err)
+	require.NotEmpty(t, next)
+
+	_, _, err = s.List(ctx, "bob", next, 1)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_userService_List_max_page_size_pages(t *testing.T) {
+
+	teardownTest := setupTest(t)
+	defer teardownTest(t)
+
+	ctx := context.Background()
+	users := make([]models.User, maxPageSize+2)
+	for i := range users {
+		users[i] = models.User{Username: fmt.Sprintf("user%04d", i)}
+	}
+	last := users[maxPageSize-1].Username
+
+	mockUserStore.EXPECT().List(ctx, "user", "", maxPageSize+1).Return(users[:maxPageSize+1], nil).Times(1)
+	mockUserStore.EXPECT().List(ctx, "user", last, maxPageSize+1).Return(users[maxPageSize:], nil).Times(1)
+
+	s := &userService{
+		userStore: mockUserStore,
+		validator: mockValidator,
+	}
+
+	page, next, err := s.List(ctx, "user", "", 2*maxPageSize)
+	require.NoError(t, err)
+	require.Equal(t, users[:maxPageSize], page)
+	require.NotEmpty(t, next)
+
+	page, next, err = s.List(ctx, "user", next, 2*maxPageSize)
+	require.NoError(t, err)
+	require.Equal(t, users[maxPageSize:], page)
+	require.Empty(t, next)
 }
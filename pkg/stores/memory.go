@@ -0,0 +1,142 @@
+package stores
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type memoryUser struct {
+	ID              int64
+	Tenant          string
+	Username        string
+	PasswordHash    string
+	Email           string
+	TokenGeneration int64
+}
+
+func (u *memoryUser) model() *models.User {
+	return &models.User{
+		Tenant:          u.Tenant,
+		Username:        u.Username,
+		Password:        u.PasswordHash,
+		Email:           u.Email,
+		TokenGeneration: u.TokenGeneration,
+	}
+}
+
+type MemoryUserStore struct {
+	db     *MemoryDatabase
+	tenant string
+}
+
+// NewMemoryUserStore creates a new instance of a UserStore for a MemoryDatabase,
+// scoped to the users of the tenant.
+func NewMemoryUserStore(db *MemoryDatabase, tenant string) UserStore {
+	return &MemoryUserStore{db: db, tenant: tenant}
+}
+
+func (s *MemoryUserStore) Create(_ context.Context, user models.User) error {
+	if user.Username == "" || user.Password == "" {
+		return fmt.Errorf("error creating the user %s: %w", user.Username, errEmpty)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if _, ok := s.db.user(s.tenant, user.Username); ok {
+		return autherrors.UsernameAlreadyExistErr{Name: user.Username}
+	}
+
+	s.db.data.LastUserID++
+	u := &memoryUser{
+		ID:           s.db.data.LastUserID,
+		Tenant:       s.tenant,
+		Username:     user.Username,
+		PasswordHash: user.Password,
+		Email:        user.Email,
+	}
+	s.db.data.Users[u.ID] = u
+	s.db.usernames[tenantName{s.tenant, u.Username}] = u.ID
+
+	return nil
+}
+
+func (s *MemoryUserStore) Get(_ context.Context, username string) (*models.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	u, ok := s.db.user(s.tenant, username)
+	if !ok {
+		return nil, nil
+	}
+
+	return u.model(), nil
+}
+
+func (s *MemoryUserStore) Update(_ context.Context, username string, user models.User) (bool, error) {
+	if user.Username == "" || user.Password == "" {
+		return false, fmt.Errorf("error updating the user %s: %w", username, errEmpty)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	u, ok := s.db.user(s.tenant, username)
+	if !ok {
+		return false, nil
+	}
+	if user.Username != username {
+		if _, exists := s.db.user(s.tenant, user.Username); exists {
+			return false, autherrors.UsernameAlreadyExistErr{Name: user.Username}
+		}
+		delete(s.db.usernames, tenantName{s.tenant, username})
+		s.db.usernames[tenantName{s.tenant, user.Username}] = u.ID
+	}
+
+	u.Username = user.Username
+	u.PasswordHash = user.Password
+	u.Email = user.Email
+	u.TokenGeneration = user.TokenGeneration
+
+	return true, nil
+}
+
+func (s *MemoryUserStore) UpdatePasswordHash(_ context.Context, username, currentHash, newHash string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	u, ok := s.db.user(s.tenant, username)
+	if !ok || u.PasswordHash != currentHash {
+		return false, nil
+	}
+	u.PasswordHash = newHash
+
+	return true, nil
+}
+
+func (s *MemoryUserStore) Delete(_ context.Context, username string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	u, ok := s.db.user(s.tenant, username)
+	if !ok {
+		return false, nil
+	}
+	s.db.deleteUser(u.ID)
+
+	return true, nil
+}
+
+func (s *MemoryUserStore) List(_ context.Context, prefix, after string, limit int) ([]models.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	result := make([]models.User, 0)
+	for _, u := range s.db.data.Users {
+		if u.Tenant == s.tenant && strings.HasPrefix(u.Username, prefix) && u.Username > after {
+			result = append(result, *u.model())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Username < result[j].Username })
+	if limit >= 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
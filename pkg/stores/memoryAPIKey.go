@@ -0,0 +1,122 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type memoryAPIKey struct {
+	Prefix         string
+	Hash           string
+	Tenant         string
+	ServiceAccount string
+	Permissions    []string
+	ExpiresAt      time.Time
+	Revoked        bool
+	CreatedAt      time.Time
+}
+
+func (k *memoryAPIKey) model() models.APIKey {
+	return models.APIKey{
+		Prefix:         k.Prefix,
+		Hash:           k.Hash,
+		Tenant:         k.Tenant,
+		ServiceAccount: k.ServiceAccount,
+		Permissions:    append([]string(nil), k.Permissions...),
+		ExpiresAt:      k.ExpiresAt,
+		Revoked:        k.Revoked,
+		CreatedAt:      k.CreatedAt,
+	}
+}
+
+type MemoryAPIKeyStore struct {
+	db     *MemoryDatabase
+	tenant string
+}
+
+// NewMemoryAPIKeyStore creates a new instance of an APIKeyStore for a MemoryDatabase,
+// scoped to the service accounts of the tenant.
+func NewMemoryAPIKeyStore(db *MemoryDatabase, tenant string) APIKeyStore {
+	return &MemoryAPIKeyStore{db: db, tenant: tenant}
+}
+
+func (s *MemoryAPIKeyStore) Create(_ context.Context, key models.APIKey) error {
+	if key.Prefix == "" || key.Hash == "" || key.ServiceAccount == "" {
+		return fmt.Errorf("error creating the API key for %s: %w", key.ServiceAccount, errEmpty)
+	}
+	permissions, err := sortedSet(key.Permissions)
+	if err != nil {
+		return fmt.Errorf("error adding the permissions to the API key %s: %w", key.Prefix, err)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	for _, k := range s.db.data.APIKeys {
+		if k.Prefix == key.Prefix || k.Hash == key.Hash {
+			return fmt.Errorf("error creating the API key for %s: %w", key.ServiceAccount, errDuplicate)
+		}
+	}
+	var expiresAt time.Time
+	if !key.ExpiresAt.IsZero() {
+		expiresAt = key.ExpiresAt.UTC()
+	}
+	s.db.data.APIKeys[key.Prefix] = &memoryAPIKey{
+		Prefix:         key.Prefix,
+		Hash:           key.Hash,
+		Tenant:         s.tenant,
+		ServiceAccount: key.ServiceAccount,
+		Permissions:    permissions,
+		ExpiresAt:      expiresAt,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	return nil
+}
+
+func (s *MemoryAPIKeyStore) Get(_ context.Context, prefix string) (*models.APIKey, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	k, ok := s.db.data.APIKeys[prefix]
+	if !ok || k.Tenant != s.tenant {
+		return nil, nil
+	}
+	key := k.model()
+
+	return &key, nil
+}
+
+func (s *MemoryAPIKeyStore) List(_ context.Context, serviceAccount string) ([]models.APIKey, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	keys := make([]models.APIKey, 0)
+	for _, k := range s.db.data.APIKeys {
+		if k.Tenant == s.tenant && (serviceAccount == "" || k.ServiceAccount == serviceAccount) {
+			keys = append(keys, k.model())
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].ServiceAccount != keys[j].ServiceAccount {
+			return keys[i].ServiceAccount < keys[j].ServiceAccount
+		}
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].Prefix < keys[j].Prefix
+	})
+
+	return keys, nil
+}
+
+func (s *MemoryAPIKeyStore) Revoke(_ context.Context, prefix string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	k, ok := s.db.data.APIKeys[prefix]
+	if !ok || k.Tenant != s.tenant || k.Revoked {
+		return false, nil
+	}
+	k.Revoked = true
+
+	return true, nil
+}
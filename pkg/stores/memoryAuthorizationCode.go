@@ -0,0 +1,101 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type memoryAuthorizationCode struct {
+	Hash string
+	// Tenant and ClientID are the OAuth client of the code.
+	Tenant        string
+	ClientID      string
+	UserID        int64
+	RedirectURI   string
+	Scope         string
+	Nonce         string
+	CodeChallenge string
+	AuthTime      time.Time
+	ExpiresAt     time.Time
+	Used          bool
+}
+
+type MemoryAuthorizationCodeStore struct {
+	db     *MemoryDatabase
+	tenant string
+}
+
+// NewMemoryAuthorizationCodeStore creates a new instance of an AuthorizationCodeStore for a MemoryDatabase,
+// scoped to the OAuth clients and the users of the tenant.
+func NewMemoryAuthorizationCodeStore(db *MemoryDatabase, tenant string) AuthorizationCodeStore {
+	return &MemoryAuthorizationCodeStore{db: db, tenant: tenant}
+}
+
+func (s *MemoryAuthorizationCodeStore) Create(_ context.Context, code models.AuthorizationCode) error {
+	if code.Hash == "" || code.RedirectURI == "" || code.CodeChallenge == "" {
+		return fmt.Errorf("error creating the authorization code of %s for %s: %w", code.ClientID, code.Username, errEmpty)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if _, ok := s.db.data.OAuthClients[s.tenant][code.ClientID]; !ok {
+		return fmt.Errorf("error creating the authorization code of %s for %s: %w", code.ClientID, code.Username, errUnknownClient)
+	}
+	u, ok := s.db.user(s.tenant, code.Username)
+	if !ok {
+		return fmt.Errorf("error creating the authorization code of %s for %s: %w", code.ClientID, code.Username, errUnknownUser)
+	}
+	if _, exists := s.db.data.AuthorizationCodes[code.Hash]; exists {
+		return fmt.Errorf("error creating the authorization code of %s for %s: %w", code.ClientID, code.Username, errDuplicate)
+	}
+	s.db.data.AuthorizationCodes[code.Hash] = &memoryAuthorizationCode{
+		Hash:          code.Hash,
+		Tenant:        s.tenant,
+		ClientID:      code.ClientID,
+		UserID:        u.ID,
+		RedirectURI:   code.RedirectURI,
+		Scope:         strings.Join(code.Scopes, " "),
+		Nonce:         code.Nonce,
+		CodeChallenge: code.CodeChallenge,
+		AuthTime:      code.AuthTime.UTC(),
+		ExpiresAt:     code.ExpiresAt.UTC(),
+	}
+
+	return nil
+}
+
+func (s *MemoryAuthorizationCodeStore) Get(_ context.Context, hash string) (*models.AuthorizationCode, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	c, ok := s.db.data.AuthorizationCodes[hash]
+	if !ok || c.Tenant != s.tenant {
+		return nil, nil
+	}
+
+	return &models.AuthorizationCode{
+		Hash:          c.Hash,
+		ClientID:      c.ClientID,
+		Username:      s.db.data.Users[c.UserID].Username,
+		RedirectURI:   c.RedirectURI,
+		Scopes:        strings.Fields(c.Scope),
+		Nonce:         c.Nonce,
+		CodeChallenge: c.CodeChallenge,
+		AuthTime:      c.AuthTime,
+		ExpiresAt:     c.ExpiresAt,
+		Used:          c.Used,
+	}, nil
+}
+
+func (s *MemoryAuthorizationCodeStore) Use(_ context.Context, hash string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	c, ok := s.db.data.AuthorizationCodes[hash]
+	if !ok || c.Used {
+		return false, nil
+	}
+	c.Used = true
+
+	return true, nil
+}
@@ -0,0 +1,240 @@
+package stores
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memorySnapshotVersion is the version of the format of the snapshots of a MemoryDatabase.
+const memorySnapshotVersion = 1
+
+var (
+	// errUnknownUser is returned by the memory stores for the records of a user that does not exist.
+	errUnknownUser = errors.New("unknown user")
+	// errUnknownRole is returned by the memory stores for the assignments of a role that does not exist.
+	errUnknownRole = errors.New("unknown role")
+	// errUnknownClient is returned by the memory stores for the records of an OAuth client that does not exist.
+	errUnknownClient = errors.New("unknown OAuth client")
+	// errEmpty is returned by the memory stores for a record missing a required value.
+	errEmpty = errors.New("empty required value")
+	// errDuplicate is returned by the memory stores for a record whose key is already stored.
+	errDuplicate = errors.New("duplicate key")
+)
+
+// MemoryDatabase holds the records of the memory stores of all the tenants. Like the tables of a database,
+// the records of a user are deleted with the user, and the stores see the changes of each other.
+// The records are lost when the process stops, unless they are saved by Snapshot and loaded by LoadMemoryDatabase.
+type MemoryDatabase struct {
+	mu   sync.RWMutex
+	data memoryData
+	// usernames indexes the ids of the users by tenant and username.
+	usernames   map[tenantName]int64
+	revocations *MemoryRevocationStore
+	// path is the snapshot the database was loaded from, saved again by Close.
+	path string
+}
+
+type tenantName struct {
+	tenant string
+	name   string
+}
+
+// memoryData are the records of a MemoryDatabase, as saved by the snapshots.
+// The records of a user refer to the id of the user, which is kept when the user is renamed.
+type memoryData struct {
+	Version             int
+	LastUserID          int64
+	Users               map[int64]*memoryUser
+	RefreshTokens       map[string]*memoryRefreshToken
+	RevokedTokens       map[string]time.Time
+	PasswordResetTokens map[string]*memoryPasswordResetToken
+	// LoginFailures are indexed by tenant and key.
+	LoginFailures   map[string]map[string]*memoryLoginFailures
+	TOTPCredentials map[int64]*memoryTOTPCredential
+	MFAChallenges   map[string]*memoryMFAChallenge
+	// RecoveryCodes are ordered by creation.
+	RecoveryCodes []*memoryRecoveryCode
+	// Roles are the sorted permissions of each role.
+	Roles map[string][]string
+	// UserRoles are the names of the roles of each user.
+	UserRoles map[int64]map[string]bool
+	APIKeys   map[string]*memoryAPIKey
+	// OAuthClients are indexed by tenant and client id.
+	OAuthClients       map[string]map[string]*memoryOAuthClient
+	AuthorizationCodes map[string]*memoryAuthorizationCode
+}
+
+// NewMemoryDatabase creates a new empty MemoryDatabase.
+func NewMemoryDatabase() *MemoryDatabase {
+	db := &MemoryDatabase{revocations: &MemoryRevocationStore{}}
+	db.init(memoryData{Version: memorySnapshotVersion})
+	return db
+}
+
+// LoadMemoryDatabase creates a MemoryDatabase with the records of the JSON snapshot of the path,
+// or an empty one if the snapshot does not exist. The records are saved again to the path by Close.
+func LoadMemoryDatabase(path string) (*MemoryDatabase, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		db := NewMemoryDatabase()
+		db.path = path
+		return db, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading the snapshot %s: %w", path, err)
+	}
+
+	var data memoryData
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("error decoding the snapshot %s: %w", path, err)
+	}
+	if data.Version != memorySnapshotVersion {
+		return nil, fmt.Errorf("unsupported version %d of the snapshot %s", data.Version, path)
+	}
+	db := &MemoryDatabase{revocations: &MemoryRevocationStore{}, path: path}
+	db.init(data)
+
+	return db, nil
+}
+
+// init sets the records of the database, creating the missing maps, and indexes the users.
+func (db *MemoryDatabase) init(data memoryData) {
+	initMap(&data.Users)
+	initMap(&data.RefreshTokens)
+	initMap(&data.RevokedTokens)
+	initMap(&data.PasswordResetTokens)
+	initMap(&data.LoginFailures)
+	initMap(&data.TOTPCredentials)
+	initMap(&data.MFAChallenges)
+	initMap(&data.Roles)
+	initMap(&data.UserRoles)
+	initMap(&data.APIKeys)
+	initMap(&data.OAuthClients)
+	initMap(&data.AuthorizationCodes)
+
+	db.usernames = make(map[tenantName]int64, len(data.Users))
+	for id, u := range data.Users {
+		db.usernames[tenantName{u.Tenant, u.Username}] = id
+	}
+	// The revoked tokens are kept by a MemoryRevocationStore, with its own lock.
+	db.revocations.revoked = data.RevokedTokens
+	data.RevokedTokens = nil
+	db.data = data
+}
+
+func initMap[K comparable, V any](m *map[K]V) {
+	if *m == nil {
+		*m = make(map[K]V)
+	}
+}
+
+// Snapshot saves the records of the database to the JSON file of the path, replaced atomically.
+// The snapshot holds the password hashes of the users, it is only readable by its owner.
+func (db *MemoryDatabase) Snapshot(path string) error {
+	db.mu.RLock()
+	db.revocations.mu.RLock()
+	data := db.data
+	data.RevokedTokens = db.revocations.revoked
+	content, err := json.Marshal(data)
+	db.revocations.mu.RUnlock()
+	db.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("error encoding the snapshot: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("error creating the snapshot %s: %w", path, err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing the snapshot %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing the snapshot %s: %w", path, err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("error replacing the snapshot %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Close saves the records to the snapshot the database was loaded from, if any.
+func (db *MemoryDatabase) Close() error {
+	if db.path == "" {
+		return nil
+	}
+	return db.Snapshot(db.path)
+}
+
+// sortedSet returns the sorted distinct values, or nil if there are none.
+// It returns errEmpty if a value is empty.
+func sortedSet(values []string) ([]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	set := make([]string, 0, len(values))
+	for _, v := range values {
+		if v == "" {
+			return nil, errEmpty
+		}
+		set = append(set, v)
+	}
+	sort.Strings(set)
+	n := 1
+	for _, v := range set[1:] {
+		if v != set[n-1] {
+			set[n] = v
+			n++
+		}
+	}
+
+	return set[:n], nil
+}
+
+// user returns the user of the tenant with the username, db.mu must be held.
+func (db *MemoryDatabase) user(tenant, username string) (*memoryUser, bool) {
+	id, ok := db.usernames[tenantName{tenant, username}]
+	if !ok {
+		return nil, false
+	}
+	return db.data.Users[id], true
+}
+
+// deleteUser deletes the user with the id and its records, db.mu must be held for writing.
+func (db *MemoryDatabase) deleteUser(id int64) {
+	u := db.data.Users[id]
+	delete(db.usernames, tenantName{u.Tenant, u.Username})
+	delete(db.data.Users, id)
+	for hash, t := range db.data.RefreshTokens {
+		if t.UserID == id {
+			delete(db.data.RefreshTokens, hash)
+		}
+	}
+	for hash, t := range db.data.PasswordResetTokens {
+		if t.UserID == id {
+			delete(db.data.PasswordResetTokens, hash)
+		}
+	}
+	delete(db.data.TOTPCredentials, id)
+	for hash, c := range db.data.MFAChallenges {
+		if c.UserID == id {
+			delete(db.data.MFAChallenges, hash)
+		}
+	}
+	db.deleteRecoveryCodes(func(c *memoryRecoveryCode) bool { return c.UserID == id })
+	delete(db.data.UserRoles, id)
+	for hash, c := range db.data.AuthorizationCodes {
+		if c.UserID == id {
+			delete(db.data.AuthorizationCodes, hash)
+		}
+	}
+}
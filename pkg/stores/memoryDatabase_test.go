@@ -0,0 +1,90 @@
+package stores
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemoryDatabase_Snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.json")
+	ctx := context.Background()
+
+	// A missing snapshot loads an empty database.
+	db, err := LoadMemoryDatabase(path)
+	require.NoError(t, err)
+	s := NewMemoryStores(db)
+	require.NoError(t, s.UserStore("default").Create(ctx, models.User{Username: "test", Password: "hash", Email: "test@example.com"}))
+	require.NoError(t, s.UserStore("other").Create(ctx, models.User{Username: "test", Password: "otherhash"}))
+	require.NoError(t, s.RoleStore("default").Define(ctx, models.Role{Name: "admin", Permissions: []string{"users:read"}}))
+	require.NoError(t, s.RoleStore("default").Assign(ctx, "test", "admin"))
+	expiresAt := time.Now().Add(time.Hour)
+	require.NoError(t, s.RefreshTokenStore("default").Create(ctx, models.RefreshToken{Hash: "hash", FamilyID: "family", Username: "test", ExpiresAt: expiresAt}))
+	require.NoError(t, s.RevocationStore().Revoke(ctx, "jti", expiresAt))
+	require.NoError(t, db.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	db, err = LoadMemoryDatabase(path)
+	require.NoError(t, err)
+	s = NewMemoryStores(db)
+	user, err := s.UserStore("default").Get(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, &models.User{Tenant: "default", Username: "test", Password: "hash", Email: "test@example.com"}, user)
+	user, err = s.UserStore("other").Get(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, "otherhash", user.Password)
+	roles, err := s.RoleStore("default").ListByUser(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, []models.Role{{Name: "admin", Permissions: []string{"users:read"}}}, roles)
+	token, err := s.RefreshTokenStore("default").Get(ctx, "hash")
+	require.NoError(t, err)
+	require.Equal(t, "test", token.Username)
+	require.True(t, expiresAt.Equal(token.ExpiresAt))
+	revoked, err := s.RevocationStore().IsRevoked(ctx, "jti")
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// The loaded users keep their unique usernames, and the new users get new ids.
+	require.ErrorIs(t, s.UserStore("default").Create(ctx, models.User{Username: "test", Password: "hash"}), autherrors.UsernameAlreadyExistErr{Name: "test"})
+	require.NoError(t, s.UserStore("default").Create(ctx, models.User{Username: "new", Password: "hash"}))
+	roles, err = s.RoleStore("default").ListByUser(ctx, "new")
+	require.NoError(t, err)
+	require.Empty(t, roles)
+}
+
+func TestLoadMemoryDatabase_invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot string
+	}{
+		{"Not JSON", "users"},
+		{"Unknown version", `{"Version": 2}`},
+		{"No version", `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "auth.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.snapshot), 0600))
+			_, err := LoadMemoryDatabase(path)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestMemoryDatabase_Close(t *testing.T) {
+	// A database without a snapshot is not saved.
+	require.NoError(t, NewMemoryDatabase().Close())
+
+	path := filepath.Join(t.TempDir(), "missing", "auth.json")
+	db, err := LoadMemoryDatabase(path)
+	require.NoError(t, err)
+	require.Error(t, db.Close())
+}
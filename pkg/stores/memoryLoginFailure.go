@@ -0,0 +1,65 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+type memoryLoginFailures struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+type MemoryLoginFailureStore struct {
+	db     *MemoryDatabase
+	tenant string
+}
+
+// NewMemoryLoginFailureStore creates a new instance of a LoginFailureStore for a MemoryDatabase,
+// scoped to the users of the tenant.
+func NewMemoryLoginFailureStore(db *MemoryDatabase, tenant string) LoginFailureStore {
+	return &MemoryLoginFailureStore{db: db, tenant: tenant}
+}
+
+func (s *MemoryLoginFailureStore) Get(_ context.Context, key string) (*models.LoginFailures, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	f, ok := s.db.data.LoginFailures[s.tenant][key]
+	if !ok {
+		return nil, nil
+	}
+
+	return &models.LoginFailures{Key: key, Failures: f.Failures, LastFailure: f.LastFailure}, nil
+}
+
+func (s *MemoryLoginFailureStore) Add(_ context.Context, key string, failedAt, since time.Time) (*models.LoginFailures, error) {
+	if key == "" {
+		return nil, fmt.Errorf("error adding a login failure %s: %w", key, errEmpty)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	failures, ok := s.db.data.LoginFailures[s.tenant]
+	if !ok {
+		failures = make(map[string]*memoryLoginFailures)
+		s.db.data.LoginFailures[s.tenant] = failures
+	}
+	f, ok := failures[key]
+	if !ok || f.LastFailure.Before(since) {
+		f = &memoryLoginFailures{}
+		failures[key] = f
+	}
+	f.Failures++
+	f.LastFailure = failedAt.UTC()
+
+	return &models.LoginFailures{Key: key, Failures: f.Failures, LastFailure: f.LastFailure}, nil
+}
+
+func (s *MemoryLoginFailureStore) Reset(_ context.Context, key string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	delete(s.db.data.LoginFailures[s.tenant], key)
+
+	return nil
+}
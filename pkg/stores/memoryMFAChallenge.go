@@ -0,0 +1,94 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+type memoryMFAChallenge struct {
+	Hash      string
+	UserID    int64
+	ExpiresAt time.Time
+	Used      bool
+	Attempts  int
+}
+
+type MemoryMFAChallengeStore struct {
+	db     *MemoryDatabase
+	tenant string
+}
+
+// NewMemoryMFAChallengeStore creates a new instance of an MFAChallengeStore for a MemoryDatabase,
+// scoped to the users of the tenant.
+func NewMemoryMFAChallengeStore(db *MemoryDatabase, tenant string) MFAChallengeStore {
+	return &MemoryMFAChallengeStore{db: db, tenant: tenant}
+}
+
+func (s *MemoryMFAChallengeStore) Create(_ context.Context, challenge models.MFAChallenge) error {
+	if challenge.Hash == "" {
+		return fmt.Errorf("error creating the MFA challenge for %s: %w", challenge.Username, errEmpty)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	u, ok := s.db.user(s.tenant, challenge.Username)
+	if !ok {
+		return fmt.Errorf("error creating the MFA challenge for %s: %w", challenge.Username, errUnknownUser)
+	}
+	if _, exists := s.db.data.MFAChallenges[challenge.Hash]; exists {
+		return fmt.Errorf("error creating the MFA challenge for %s: %w", challenge.Username, errDuplicate)
+	}
+	s.db.data.MFAChallenges[challenge.Hash] = &memoryMFAChallenge{
+		Hash:      challenge.Hash,
+		UserID:    u.ID,
+		ExpiresAt: challenge.ExpiresAt.UTC(),
+	}
+
+	return nil
+}
+
+func (s *MemoryMFAChallengeStore) Get(_ context.Context, hash string) (*models.MFAChallenge, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	c, ok := s.db.data.MFAChallenges[hash]
+	if !ok {
+		return nil, nil
+	}
+	u := s.db.data.Users[c.UserID]
+	if u.Tenant != s.tenant {
+		return nil, nil
+	}
+
+	return &models.MFAChallenge{
+		Hash:      c.Hash,
+		Username:  u.Username,
+		ExpiresAt: c.ExpiresAt,
+		Used:      c.Used,
+		Attempts:  c.Attempts,
+	}, nil
+}
+
+func (s *MemoryMFAChallengeStore) Attempt(_ context.Context, hash string, maxAttempts int) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	c, ok := s.db.data.MFAChallenges[hash]
+	if !ok || c.Used || c.Attempts >= maxAttempts {
+		return false, nil
+	}
+	c.Attempts++
+
+	return true, nil
+}
+
+func (s *MemoryMFAChallengeStore) Use(_ context.Context, hash string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	c, ok := s.db.data.MFAChallenges[hash]
+	if !ok || c.Used {
+		return false, nil
+	}
+	c.Used = true
+
+	return true, nil
+}
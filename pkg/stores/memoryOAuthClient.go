@@ -0,0 +1,102 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+type memoryOAuthClient struct {
+	ID           string
+	SecretHash   string
+	Scopes       []string
+	Audiences    []string
+	RedirectURIs []string
+	CreatedAt    time.Time
+}
+
+type MemoryOAuthClientStore struct {
+	db     *MemoryDatabase
+	tenant string
+}
+
+// NewMemoryOAuthClientStore creates a new instance of an OAuthClientStore for a MemoryDatabase,
+// scoped to the OAuth clients of the tenant.
+func NewMemoryOAuthClientStore(db *MemoryDatabase, tenant string) OAuthClientStore {
+	return &MemoryOAuthClientStore{db: db, tenant: tenant}
+}
+
+func (s *MemoryOAuthClientStore) Create(_ context.Context, client models.OAuthClient) error {
+	if client.ID == "" || client.SecretHash == "" {
+		return fmt.Errorf("error creating the OAuth client %s: %w", client.ID, errEmpty)
+	}
+	scopes, err := sortedSet(client.Scopes)
+	if err != nil {
+		return fmt.Errorf("error adding the scopes to the OAuth client %s: %w", client.ID, err)
+	}
+	audiences, err := sortedSet(client.Audiences)
+	if err != nil {
+		return fmt.Errorf("error adding the audiences to the OAuth client %s: %w", client.ID, err)
+	}
+	redirectURIs, err := sortedSet(client.RedirectURIs)
+	if err != nil {
+		return fmt.Errorf("error adding the redirect URIs to the OAuth client %s: %w", client.ID, err)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	clients, ok := s.db.data.OAuthClients[s.tenant]
+	if !ok {
+		clients = make(map[string]*memoryOAuthClient)
+		s.db.data.OAuthClients[s.tenant] = clients
+	}
+	if _, exists := clients[client.ID]; exists {
+		return fmt.Errorf("error creating the OAuth client %s: %w", client.ID, errDuplicate)
+	}
+	clients[client.ID] = &memoryOAuthClient{
+		ID:           client.ID,
+		SecretHash:   client.SecretHash,
+		Scopes:       scopes,
+		Audiences:    audiences,
+		RedirectURIs: redirectURIs,
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	return nil
+}
+
+func (s *MemoryOAuthClientStore) Get(_ context.Context, id string) (*models.OAuthClient, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	c, ok := s.db.data.OAuthClients[s.tenant][id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &models.OAuthClient{
+		ID:           c.ID,
+		SecretHash:   c.SecretHash,
+		Tenant:       s.tenant,
+		Scopes:       append([]string(nil), c.Scopes...),
+		Audiences:    append([]string(nil), c.Audiences...),
+		RedirectURIs: append([]string(nil), c.RedirectURIs...),
+		CreatedAt:    c.CreatedAt,
+	}, nil
+}
+
+func (s *MemoryOAuthClientStore) Delete(_ context.Context, id string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if _, ok := s.db.data.OAuthClients[s.tenant][id]; !ok {
+		return false, nil
+	}
+	delete(s.db.data.OAuthClients[s.tenant], id)
+	// The authorization codes of the client are deleted with it.
+	for hash, c := range s.db.data.AuthorizationCodes {
+		if c.Tenant == s.tenant && c.ClientID == id {
+			delete(s.db.data.AuthorizationCodes, hash)
+		}
+	}
+
+	return true, nil
+}
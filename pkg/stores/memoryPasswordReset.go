@@ -0,0 +1,85 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+type memoryPasswordResetToken struct {
+	Hash      string
+	UserID    int64
+	ExpiresAt time.Time
+	Used      bool
+}
+
+type MemoryPasswordResetStore struct {
+	db     *MemoryDatabase
+	tenant string
+}
+
+// NewMemoryPasswordResetStore creates a new instance of a PasswordResetStore for a MemoryDatabase,
+// scoped to the users of the tenant.
+func NewMemoryPasswordResetStore(db *MemoryDatabase, tenant string) PasswordResetStore {
+	return &MemoryPasswordResetStore{db: db, tenant: tenant}
+}
+
+func (s *MemoryPasswordResetStore) Create(_ context.Context, token models.PasswordResetToken) error {
+	if token.Hash == "" {
+		return fmt.Errorf("error creating the password reset token for %s: %w", token.Username, errEmpty)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	u, ok := s.db.user(s.tenant, token.Username)
+	if !ok {
+		return fmt.Errorf("error creating the password reset token for %s: %w", token.Username, errUnknownUser)
+	}
+	if _, exists := s.db.data.PasswordResetTokens[token.Hash]; exists {
+		return fmt.Errorf("error creating the password reset token for %s: %w", token.Username, errDuplicate)
+	}
+	for _, t := range s.db.data.PasswordResetTokens {
+		if t.UserID == u.ID {
+			t.Used = true
+		}
+	}
+	s.db.data.PasswordResetTokens[token.Hash] = &memoryPasswordResetToken{
+		Hash:      token.Hash,
+		UserID:    u.ID,
+		ExpiresAt: token.ExpiresAt.UTC(),
+	}
+
+	return nil
+}
+
+func (s *MemoryPasswordResetStore) Get(_ context.Context, hash string) (*models.PasswordResetToken, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	t, ok := s.db.data.PasswordResetTokens[hash]
+	if !ok {
+		return nil, nil
+	}
+	u := s.db.data.Users[t.UserID]
+	if u.Tenant != s.tenant {
+		return nil, nil
+	}
+
+	return &models.PasswordResetToken{
+		Hash:      t.Hash,
+		Username:  u.Username,
+		ExpiresAt: t.ExpiresAt,
+		Used:      t.Used,
+	}, nil
+}
+
+func (s *MemoryPasswordResetStore) Use(_ context.Context, hash string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	t, ok := s.db.data.PasswordResetTokens[hash]
+	if !ok || t.Used {
+		return false, nil
+	}
+	t.Used = true
+
+	return true, nil
+}
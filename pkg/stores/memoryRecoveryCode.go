@@ -0,0 +1,118 @@
+package stores
+
+import (
+	"context"
+	"fmt"
+)
+
+type memoryRecoveryCode struct {
+	UserID  int64
+	BatchID string
+	Hash    string
+	Used    bool
+}
+
+type MemoryRecoveryCodeStore struct {
+	db     *MemoryDatabase
+	tenant string
+}
+
+// NewMemoryRecoveryCodeStore creates a new instance of a RecoveryCodeStore for a MemoryDatabase,
+// scoped to the users of the tenant.
+func NewMemoryRecoveryCodeStore(db *MemoryDatabase, tenant string) RecoveryCodeStore {
+	return &MemoryRecoveryCodeStore{db: db, tenant: tenant}
+}
+
+func (s *MemoryRecoveryCodeStore) Replace(_ context.Context, username, batchID string, hashes []string) error {
+	if batchID == "" {
+		return fmt.Errorf("error creating the recovery codes for %s: %w", username, errEmpty)
+	}
+	for _, hash := range hashes {
+		if hash == "" {
+			return fmt.Errorf("error creating the recovery codes for %s: %w", username, errEmpty)
+		}
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	u, ok := s.db.user(s.tenant, username)
+	if !ok {
+		return fmt.Errorf("error creating the recovery codes for %s: %w", username, errUnknownUser)
+	}
+	s.db.deleteRecoveryCodes(func(c *memoryRecoveryCode) bool { return c.UserID == u.ID && c.BatchID != batchID })
+	for _, hash := range hashes {
+		s.db.data.RecoveryCodes = append(s.db.data.RecoveryCodes, &memoryRecoveryCode{
+			UserID:  u.ID,
+			BatchID: batchID,
+			Hash:    hash,
+		})
+	}
+
+	return nil
+}
+
+func (s *MemoryRecoveryCodeStore) ListUnused(_ context.Context, username string) ([]string, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	var hashes []string
+	for _, c := range s.codes(username) {
+		if !c.Used {
+			hashes = append(hashes, c.Hash)
+		}
+	}
+
+	return hashes, nil
+}
+
+func (s *MemoryRecoveryCodeStore) Use(_ context.Context, username, hash string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	for _, c := range s.codes(username) {
+		if c.Hash == hash && !c.Used {
+			c.Used = true
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *MemoryRecoveryCodeStore) CountUnused(_ context.Context, username string) (int, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	n := 0
+	for _, c := range s.codes(username) {
+		if !c.Used {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// codes returns the recovery codes of the user with the username, in the order of creation.
+// s.db.mu must be held.
+func (s *MemoryRecoveryCodeStore) codes(username string) []*memoryRecoveryCode {
+	u, ok := s.db.user(s.tenant, username)
+	if !ok {
+		return nil
+	}
+	var codes []*memoryRecoveryCode
+	for _, c := range s.db.data.RecoveryCodes {
+		if c.UserID == u.ID {
+			codes = append(codes, c)
+		}
+	}
+
+	return codes
+}
+
+// deleteRecoveryCodes deletes the recovery codes matched by the function, db.mu must be held for writing.
+func (db *MemoryDatabase) deleteRecoveryCodes(match func(c *memoryRecoveryCode) bool) {
+	kept := db.data.RecoveryCodes[:0]
+	for _, c := range db.data.RecoveryCodes {
+		if !match(c) {
+			kept = append(kept, c)
+		}
+	}
+	db.data.RecoveryCodes = kept
+}
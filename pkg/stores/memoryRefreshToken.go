@@ -0,0 +1,113 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+type memoryRefreshToken struct {
+	Hash      string
+	FamilyID  string
+	UserID    int64
+	ExpiresAt time.Time
+	Used      bool
+	Revoked   bool
+}
+
+type MemoryRefreshTokenStore struct {
+	db     *MemoryDatabase
+	tenant string
+}
+
+// NewMemoryRefreshTokenStore creates a new instance of a RefreshTokenStore for a MemoryDatabase,
+// scoped to the users of the tenant.
+func NewMemoryRefreshTokenStore(db *MemoryDatabase, tenant string) RefreshTokenStore {
+	return &MemoryRefreshTokenStore{db: db, tenant: tenant}
+}
+
+func (s *MemoryRefreshTokenStore) Create(_ context.Context, token models.RefreshToken) error {
+	if token.Hash == "" || token.FamilyID == "" {
+		return fmt.Errorf("error creating the refresh token for %s: %w", token.Username, errEmpty)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	u, ok := s.db.user(s.tenant, token.Username)
+	if !ok {
+		return fmt.Errorf("error creating the refresh token for %s: %w", token.Username, errUnknownUser)
+	}
+	if _, exists := s.db.data.RefreshTokens[token.Hash]; exists {
+		return fmt.Errorf("error creating the refresh token for %s: %w", token.Username, errDuplicate)
+	}
+	s.db.data.RefreshTokens[token.Hash] = &memoryRefreshToken{
+		Hash:      token.Hash,
+		FamilyID:  token.FamilyID,
+		UserID:    u.ID,
+		ExpiresAt: token.ExpiresAt.UTC(),
+	}
+
+	return nil
+}
+
+func (s *MemoryRefreshTokenStore) Get(_ context.Context, hash string) (*models.RefreshToken, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	t, ok := s.db.data.RefreshTokens[hash]
+	if !ok {
+		return nil, nil
+	}
+	u := s.db.data.Users[t.UserID]
+	if u.Tenant != s.tenant {
+		return nil, nil
+	}
+
+	return &models.RefreshToken{
+		Hash:      t.Hash,
+		FamilyID:  t.FamilyID,
+		Username:  u.Username,
+		ExpiresAt: t.ExpiresAt,
+		Used:      t.Used,
+		Revoked:   t.Revoked,
+	}, nil
+}
+
+func (s *MemoryRefreshTokenStore) Use(_ context.Context, hash string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	t, ok := s.db.data.RefreshTokens[hash]
+	if !ok || t.Used || t.Revoked {
+		return false, nil
+	}
+	t.Used = true
+
+	return true, nil
+}
+
+func (s *MemoryRefreshTokenStore) RevokeFamily(_ context.Context, familyID string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	for _, t := range s.db.data.RefreshTokens {
+		if t.FamilyID == familyID {
+			t.Revoked = true
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryRefreshTokenStore) RevokeUser(_ context.Context, username string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	u, ok := s.db.user(s.tenant, username)
+	if !ok {
+		return nil
+	}
+	for _, t := range s.db.data.RefreshTokens {
+		if t.UserID == u.ID {
+			t.Revoked = true
+		}
+	}
+
+	return nil
+}
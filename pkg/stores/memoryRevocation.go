@@ -0,0 +1,51 @@
+package stores
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRevocationStore is a RevocationStore keeping the revoked tokens in memory.
+// The revocations are lost on restart and are not shared between instances.
+type MemoryRevocationStore struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+// NewMemoryRevocationStore creates a new instance of an in-memory RevocationStore.
+func NewMemoryRevocationStore() RevocationStore {
+	return &MemoryRevocationStore{revoked: make(map[string]time.Time)}
+}
+
+func (s *MemoryRevocationStore) Revoke(_ context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.revoked[jti]; !ok {
+		s.revoked[jti] = expiresAt
+	}
+
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(_ context.Context, jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.revoked[jti]
+
+	return ok, nil
+}
+
+func (s *MemoryRevocationStore) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for jti, expiresAt := range s.revoked {
+		if expiresAt.Before(before) {
+			delete(s.revoked, jti)
+			n++
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,119 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"sort"
+)
+
+type MemoryRoleStore struct {
+	db     *MemoryDatabase
+	tenant string
+}
+
+// NewMemoryRoleStore creates a new instance of a RoleStore for a MemoryDatabase,
+// scoped to the users of the tenant.
+func NewMemoryRoleStore(db *MemoryDatabase, tenant string) RoleStore {
+	return &MemoryRoleStore{db: db, tenant: tenant}
+}
+
+func (s *MemoryRoleStore) Define(_ context.Context, role models.Role) error {
+	if role.Name == "" {
+		return fmt.Errorf("error creating the role %s: %w", role.Name, errEmpty)
+	}
+	permissions, err := sortedSet(role.Permissions)
+	if err != nil {
+		return fmt.Errorf("error adding the permissions to the role %s: %w", role.Name, err)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.data.Roles[role.Name] = permissions
+
+	return nil
+}
+
+func (s *MemoryRoleStore) Get(_ context.Context, name string) (*models.Role, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	if _, ok := s.db.data.Roles[name]; !ok {
+		return nil, nil
+	}
+	role := s.role(name)
+
+	return &role, nil
+}
+
+func (s *MemoryRoleStore) List(_ context.Context) ([]models.Role, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	names := make([]string, 0, len(s.db.data.Roles))
+	for name := range s.db.data.Roles {
+		names = append(names, name)
+	}
+
+	return s.roles(names), nil
+}
+
+func (s *MemoryRoleStore) Assign(_ context.Context, username, role string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	u, ok := s.db.user(s.tenant, username)
+	if !ok {
+		return fmt.Errorf("error assigning the role %s to %s: %w", role, username, errUnknownUser)
+	}
+	if _, ok := s.db.data.Roles[role]; !ok {
+		return fmt.Errorf("error assigning the role %s to %s: %w", role, username, errUnknownRole)
+	}
+	roles, ok := s.db.data.UserRoles[u.ID]
+	if !ok {
+		roles = make(map[string]bool)
+		s.db.data.UserRoles[u.ID] = roles
+	}
+	roles[role] = true
+
+	return nil
+}
+
+func (s *MemoryRoleStore) Revoke(_ context.Context, username, role string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	u, ok := s.db.user(s.tenant, username)
+	if !ok || !s.db.data.UserRoles[u.ID][role] {
+		return false, nil
+	}
+	delete(s.db.data.UserRoles[u.ID], role)
+
+	return true, nil
+}
+
+func (s *MemoryRoleStore) ListByUser(_ context.Context, username string) ([]models.Role, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	u, ok := s.db.user(s.tenant, username)
+	if !ok {
+		return []models.Role{}, nil
+	}
+	names := make([]string, 0, len(s.db.data.UserRoles[u.ID]))
+	for name := range s.db.data.UserRoles[u.ID] {
+		names = append(names, name)
+	}
+
+	return s.roles(names), nil
+}
+
+// roles returns the roles with the names and their permissions, ordered by name. s.db.mu must be held.
+func (s *MemoryRoleStore) roles(names []string) []models.Role {
+	sort.Strings(names)
+	roles := make([]models.Role, 0, len(names))
+	for _, name := range names {
+		roles = append(roles, s.role(name))
+	}
+
+	return roles
+}
+
+// role returns the role with the name and a copy of its permissions, s.db.mu must be held.
+func (s *MemoryRoleStore) role(name string) models.Role {
+	return models.Role{Name: name, Permissions: append([]string(nil), s.db.data.Roles[name]...)}
+}
@@ -0,0 +1,79 @@
+package stores
+
+import (
+	"auth/pkg/config"
+	"io"
+)
+
+func init() {
+	// The memory database needs no server, it suits the tests and the demos.
+	// With a path, the records are loaded from its JSON snapshot and saved to it when closed.
+	Register("memory", func(configuration config.Database) (Stores, io.Closer, error) {
+		if configuration.Path == "" {
+			db := NewMemoryDatabase()
+			return NewMemoryStores(db), db, nil
+		}
+		db, err := LoadMemoryDatabase(configuration.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewMemoryStores(db), db, nil
+	})
+}
+
+type MemoryStores struct {
+	db *MemoryDatabase
+}
+
+// NewMemoryStores creates a new instance of Stores for the MemoryDatabase db.
+func NewMemoryStores(db *MemoryDatabase) Stores {
+	return &MemoryStores{db: db}
+}
+
+func (s *MemoryStores) UserStore(tenant string) UserStore {
+	return NewMemoryUserStore(s.db, tenant)
+}
+
+func (s *MemoryStores) RefreshTokenStore(tenant string) RefreshTokenStore {
+	return NewMemoryRefreshTokenStore(s.db, tenant)
+}
+
+func (s *MemoryStores) RevocationStore() RevocationStore {
+	return s.db.revocations
+}
+
+func (s *MemoryStores) PasswordResetStore(tenant string) PasswordResetStore {
+	return NewMemoryPasswordResetStore(s.db, tenant)
+}
+
+func (s *MemoryStores) LoginFailureStore(tenant string) LoginFailureStore {
+	return NewMemoryLoginFailureStore(s.db, tenant)
+}
+
+func (s *MemoryStores) TOTPStore(tenant string) TOTPStore {
+	return NewMemoryTOTPStore(s.db, tenant)
+}
+
+func (s *MemoryStores) MFAChallengeStore(tenant string) MFAChallengeStore {
+	return NewMemoryMFAChallengeStore(s.db, tenant)
+}
+
+func (s *MemoryStores) RecoveryCodeStore(tenant string) RecoveryCodeStore {
+	return NewMemoryRecoveryCodeStore(s.db, tenant)
+}
+
+func (s *MemoryStores) RoleStore(tenant string) RoleStore {
+	return NewMemoryRoleStore(s.db, tenant)
+}
+
+func (s *MemoryStores) APIKeyStore(tenant string) APIKeyStore {
+	return NewMemoryAPIKeyStore(s.db, tenant)
+}
+
+func (s *MemoryStores) OAuthClientStore(tenant string) OAuthClientStore {
+	return NewMemoryOAuthClientStore(s.db, tenant)
+}
+
+func (s *MemoryStores) AuthorizationCodeStore(tenant string) AuthorizationCodeStore {
+	return NewMemoryAuthorizationCodeStore(s.db, tenant)
+}
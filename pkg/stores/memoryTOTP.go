@@ -0,0 +1,93 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"fmt"
+)
+
+type memoryTOTPCredential struct {
+	Secret      string
+	Confirmed   bool
+	LastCounter int64
+}
+
+type MemoryTOTPStore struct {
+	db     *MemoryDatabase
+	tenant string
+}
+
+// NewMemoryTOTPStore creates a new instance of a TOTPStore for a MemoryDatabase,
+// scoped to the users of the tenant.
+func NewMemoryTOTPStore(db *MemoryDatabase, tenant string) TOTPStore {
+	return &MemoryTOTPStore{db: db, tenant: tenant}
+}
+
+func (s *MemoryTOTPStore) Enroll(_ context.Context, username, secret string) (bool, error) {
+	if secret == "" {
+		return false, fmt.Errorf("error enrolling the TOTP credential of %s: %w", username, errEmpty)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	u, ok := s.db.user(s.tenant, username)
+	if !ok {
+		return false, fmt.Errorf("error enrolling the TOTP credential of %s: %w", username, errUnknownUser)
+	}
+	if c, exists := s.db.data.TOTPCredentials[u.ID]; exists && c.Confirmed {
+		return false, nil
+	}
+	s.db.data.TOTPCredentials[u.ID] = &memoryTOTPCredential{Secret: secret}
+
+	return true, nil
+}
+
+func (s *MemoryTOTPStore) Get(_ context.Context, username string) (*models.TOTPCredential, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	c, ok := s.credential(username)
+	if !ok {
+		return nil, nil
+	}
+
+	return &models.TOTPCredential{
+		Username:    username,
+		Secret:      c.Secret,
+		Confirmed:   c.Confirmed,
+		LastCounter: c.LastCounter,
+	}, nil
+}
+
+func (s *MemoryTOTPStore) Confirm(_ context.Context, username string, counter int64) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	c, ok := s.credential(username)
+	if !ok || c.Confirmed || c.LastCounter >= counter {
+		return false, nil
+	}
+	c.Confirmed = true
+	c.LastCounter = counter
+
+	return true, nil
+}
+
+func (s *MemoryTOTPStore) UseCounter(_ context.Context, username string, counter int64) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	c, ok := s.credential(username)
+	if !ok || !c.Confirmed || c.LastCounter >= counter {
+		return false, nil
+	}
+	c.LastCounter = counter
+
+	return true, nil
+}
+
+// credential returns the TOTP credential of the user with the username, s.db.mu must be held.
+func (s *MemoryTOTPStore) credential(username string) (*memoryTOTPCredential, bool) {
+	u, ok := s.db.user(s.tenant, username)
+	if !ok {
+		return nil, false
+	}
+	c, ok := s.db.data.TOTPCredentials[u.ID]
+	return c, ok
+}
@@ -0,0 +1,103 @@
+package stores
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+func setupMemory(t testing.TB) func(t testing.TB) {
+	s := NewMemoryStores(NewMemoryDatabase())
+	userStore = s.UserStore("default")
+	refreshTokenStore = s.RefreshTokenStore("default")
+	revocationStore = s.RevocationStore()
+	passwordResetStore = s.PasswordResetStore("default")
+	loginFailureStore = s.LoginFailureStore("default")
+	totpStore = s.TOTPStore("default")
+	mfaChallengeStore = s.MFAChallengeStore("default")
+	recoveryCodeStore = s.RecoveryCodeStore("default")
+	roleStore = s.RoleStore("default")
+	apiKeyStore = s.APIKeyStore("default")
+	oauthClientStore = s.OAuthClientStore("default")
+	authorizationCodeStore = s.AuthorizationCodeStore("default")
+	otherUserStore = s.UserStore("other")
+	otherRefreshTokenStore = s.RefreshTokenStore("other")
+	otherAPIKeyStore = s.APIKeyStore("other")
+	otherOAuthClientStore = s.OAuthClientStore("other")
+	otherAuthorizationCodeStore = s.AuthorizationCodeStore("other")
+
+	return func(t testing.TB) {}
+}
+
+func TestMemoryStores(t *testing.T) {
+	for _, tc := range storeTests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, setupMemory)
+		})
+	}
+}
+
+func TestMemoryUserStore_Create_concurrently(t *testing.T) {
+	s := NewMemoryUserStore(NewMemoryDatabase(), "default")
+	ctx := context.Background()
+
+	const users, requests = 5, 10
+	errs := make(chan error, users*requests)
+	var wg sync.WaitGroup
+	for i := 0; i < users; i++ {
+		user := models.User{Username: fmt.Sprintf("user%d", i), Password: "hash"}
+		for j := 0; j < requests; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- s.Create(ctx, user)
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	// Each user is created once, the other requests get a duplicate error.
+	created := 0
+	for err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		require.ErrorAs(t, err, &autherrors.UsernameAlreadyExistErr{})
+	}
+	require.Equal(t, users, created)
+	list, err := s.List(ctx, "", "", users+1)
+	require.NoError(t, err)
+	require.Len(t, list, users)
+}
+
+func TestMemoryUserStore_Update_records(t *testing.T) {
+	teardown := setupMemory(t)
+	defer teardown(t)
+	ctx := context.Background()
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "hash"}))
+	require.NoError(t, roleStore.Define(ctx, models.Role{Name: "admin"}))
+	require.NoError(t, roleStore.Assign(ctx, "test", "admin"))
+
+	// The records of a renamed user follow the user.
+	found, err := userStore.Update(ctx, "test", models.User{Username: "renamed", Password: "hash"})
+	require.NoError(t, err)
+	require.True(t, found)
+	roles, err := roleStore.ListByUser(ctx, "renamed")
+	require.NoError(t, err)
+	require.Equal(t, []models.Role{{Name: "admin"}}, roles)
+	roles, err = roleStore.ListByUser(ctx, "test")
+	require.NoError(t, err)
+	require.Empty(t, roles)
+
+	// A user created with the previous username has none of the records.
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "hash"}))
+	roles, err = roleStore.ListByUser(ctx, "test")
+	require.NoError(t, err)
+	require.Empty(t, roles)
+}
@@ -0,0 +1,371 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// versionTable records the applied migrations.
+const versionTable = "schema_migrations"
+
+// legacyVersionTable and legacyVersion are the version of the schemas created before the migrations,
+// which match the first migration.
+const (
+	legacyVersionTable = "version"
+	legacyVersion      = "0.1"
+)
+
+var (
+	// ErrUnknownVersion is returned when the schema has a migration unknown to the service.
+	ErrUnknownVersion = errors.New("unknown schema version")
+	// ErrNewerVersion is returned when the schema was migrated by a newer version of the service.
+	ErrNewerVersion = errors.New("schema version newer than the service")
+)
+
+var fileRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Dialect describes how the migrations are applied to a database.
+type Dialect struct {
+	// Migrations holds the files <version>_<name>.up.sql and <version>_<name>.down.sql,
+	// numbered from 1 without gaps.
+	Migrations fs.FS
+	// Placeholder returns the placeholder of the nth parameter of a query.
+	Placeholder func(n int) string
+	// TableExists counts the tables named by its parameter.
+	TableExists string
+	// Lock locks out the other migrators of the database until unlock is called, it is optional.
+	Lock func(ctx context.Context, conn *sql.Conn) (unlock func() error, err error)
+}
+
+// Migration changes the schema to its version with the Up statements, the Down statements revert it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// AppliedMigration is a migration recorded by the schema.
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// Status is the version of the schema, with the applied and the pending migrations.
+type Status struct {
+	Version int
+	Applied []AppliedMigration
+	Pending []Migration
+}
+
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Migrator applies the migrations of a dialect to a database, each in its own transaction.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+	logger     *zap.Logger
+}
+
+// New creates a new instance of a Migrator of the db, it returns an error if the migrations of the dialect are invalid.
+func New(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := load(dialect.Migrations)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+		logger:     zap.L().Named("Migrator"),
+	}, nil
+}
+
+// load reads the migrations sorted by version.
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error reading the migrations: %w", err)
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("error reading the migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d named %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %d missing", i+1)
+		}
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d requires an up and a down file", m.Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Migrations returns the migrations sorted by version.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Status returns the version of the schema, it returns ErrUnknownVersion or ErrNewerVersion
+// if the schema does not match the migrations.
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	applied, _, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	version, err := m.check(applied)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Status{
+		Version: version,
+		Applied: applied,
+		Pending: m.migrations[version:],
+	}, nil
+}
+
+// Up applies the pending migrations and returns them. The schema is not changed if its version is unknown or newer.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var pending []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, version int) error {
+		pending = m.migrations[version:]
+		for _, migration := range pending {
+			err := m.apply(ctx, conn, migration.Up, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, m.insertVersion(), migration.Version, migration.Name, time.Now().UTC())
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("error applying the migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			m.logger.Info("migration applied", zap.Int("version", migration.Version), zap.String("name", migration.Name))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// Down reverts the last applied migration and returns it, nil if no migration is applied.
+// The schema is not changed if its version is unknown or newer.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+	err := m.locked(ctx, func(conn *sql.Conn, version int) error {
+		if version == 0 {
+			return nil
+		}
+		migration := m.migrations[version-1]
+		err := m.apply(ctx, conn, migration.Down, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = %s", versionTable, m.dialect.Placeholder(1)), migration.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error reverting the migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		m.logger.Info("migration reverted", zap.Int("version", migration.Version), zap.String("name", migration.Name))
+		reverted = &migration
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+// locked calls fn with the version of the schema, while the other migrators are locked out.
+// The version table is created, and the legacy schemas are recorded as migrated by the first migration.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, version int) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error connecting to the database: %w", err)
+	}
+	defer conn.Close()
+	if m.dialect.Lock != nil {
+		unlock, err := m.dialect.Lock(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("error locking the migrations: %w", err)
+		}
+		defer func() {
+			if err := unlock(); err != nil {
+				m.logger.Error("error unlocking the migrations", zap.Error(err))
+			}
+		}()
+	}
+
+	applied, legacy, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	version, err := m.check(applied)
+	if err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (version bigint PRIMARY KEY, name text NOT NULL, applied_at timestamp NOT NULL)",
+		versionTable,
+	))
+	if err != nil {
+		return fmt.Errorf("error creating the version table: %w", err)
+	}
+	if legacy {
+		err := m.apply(ctx, conn, "DROP TABLE "+legacyVersionTable, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, m.insertVersion(), applied[0].Version, applied[0].Name, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error recording the version of the legacy schema: %w", err)
+		}
+		m.logger.Info("legacy schema recorded", zap.Int("version", version))
+	}
+
+	return fn(conn, version)
+}
+
+// apply runs the statements and records the change of version in a transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, statements string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		return err
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) insertVersion() string {
+	return fmt.Sprintf(
+		"INSERT INTO %s (version, name, applied_at) VALUES (%s, %s, %s)",
+		versionTable,
+		m.dialect.Placeholder(1),
+		m.dialect.Placeholder(2),
+		m.dialect.Placeholder(3),
+	)
+}
+
+// applied returns the applied migrations sorted by version, legacy is true for the schemas created before the migrations.
+func (m *Migrator) applied(ctx context.Context, q querier) (migrations []AppliedMigration, legacy bool, err error) {
+	exists, err := m.tableExists(ctx, q, versionTable)
+	if err != nil {
+		return nil, false, err
+	}
+	if !exists {
+		return m.legacy(ctx, q)
+	}
+
+	rows, err := q.QueryContext(ctx, fmt.Sprintf("SELECT version, name, applied_at FROM %s ORDER BY version", versionTable))
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading the schema version: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.AppliedAt); err != nil {
+			return nil, false, fmt.Errorf("error reading the schema version: %w", err)
+		}
+		migrations = append(migrations, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("error reading the schema version: %w", err)
+	}
+
+	return migrations, false, nil
+}
+
+// legacy returns the first migration if the schema was created before the migrations, nothing if the schema is empty.
+func (m *Migrator) legacy(ctx context.Context, q querier) ([]AppliedMigration, bool, error) {
+	exists, err := m.tableExists(ctx, q, legacyVersionTable)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	var version string
+	err = q.QueryRowContext(ctx, "SELECT version FROM "+legacyVersionTable).Scan(&version)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading the legacy schema version: %w", err)
+	}
+	if version != legacyVersion || len(m.migrations) == 0 {
+		return nil, false, fmt.Errorf("%w: legacy version %s", ErrUnknownVersion, version)
+	}
+
+	return []AppliedMigration{{Version: m.migrations[0].Version, Name: m.migrations[0].Name}}, true, nil
+}
+
+func (m *Migrator) tableExists(ctx context.Context, q querier, table string) (bool, error) {
+	var count int
+	if err := q.QueryRowContext(ctx, m.dialect.TableExists, table).Scan(&count); err != nil {
+		return false, fmt.Errorf("error looking for the table %s: %w", table, err)
+	}
+	return count > 0, nil
+}
+
+// check returns the version of the schema if the applied migrations are the first migrations.
+func (m *Migrator) check(applied []AppliedMigration) (int, error) {
+	for i, a := range applied {
+		if a.Version > len(m.migrations) {
+			return 0, fmt.Errorf("%w: version %d, latest migration %d", ErrNewerVersion, applied[len(applied)-1].Version, len(m.migrations))
+		}
+		if a.Version != i+1 || a.Name != m.migrations[i].Name {
+			return 0, fmt.Errorf("%w: migration %d_%s", ErrUnknownVersion, a.Version, a.Name)
+		}
+	}
+	return len(applied), nil
+}
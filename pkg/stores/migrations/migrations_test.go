@@ -0,0 +1,241 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"testing/fstest"
+)
+
+var testMigrations = fstest.MapFS{
+	"0001_users.up.sql":   {Data: []byte("CREATE TABLE users (name text NOT NULL);")},
+	"0001_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	"0002_email.up.sql":   {Data: []byte("ALTER TABLE users ADD COLUMN email text NOT NULL DEFAULT '';")},
+	"0002_email.down.sql": {Data: []byte("ALTER TABLE users DROP COLUMN email;")},
+	"0003_roles.up.sql":   {Data: []byte("CREATE TABLE roles (name text NOT NULL); CREATE TABLE user_roles (name text NOT NULL);")},
+	"0003_roles.down.sql": {Data: []byte("DROP TABLE user_roles; DROP TABLE roles;")},
+}
+
+func setupMigrator(t *testing.T, fsys fstest.MapFS) (*Migrator, *sql.DB) {
+	db, err := sql.Open("sqlite3", "file::memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	m, err := New(db, testDialect(fsys))
+	require.NoError(t, err)
+	return m, db
+}
+
+func testDialect(fsys fstest.MapFS) Dialect {
+	return Dialect{
+		Migrations:  fsys,
+		Placeholder: func(n int) string { return "?" },
+		TableExists: "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?",
+	}
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	var count int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count))
+	return count > 0
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    int
+		wantErr bool
+	}{
+		{"Valid migrations", testMigrations, 3, false},
+		{"No migrations", fstest.MapFS{}, 0, false},
+		{"Invalid name", fstest.MapFS{"users.up.sql": {}}, 0, true},
+		{"Missing down", fstest.MapFS{"0001_users.up.sql": {Data: []byte("CREATE TABLE users (name text);")}}, 0, true},
+		{"Missing version", fstest.MapFS{
+			"0002_users.up.sql":   {Data: []byte("CREATE TABLE users (name text);")},
+			"0002_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		}, 0, true},
+		{"Different names", fstest.MapFS{
+			"0001_users.up.sql":    {Data: []byte("CREATE TABLE users (name text);")},
+			"0001_people.down.sql": {Data: []byte("DROP TABLE users;")},
+		}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(nil, testDialect(tt.fsys))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, m.Migrations(), tt.want)
+			for i, migration := range m.Migrations() {
+				require.Equal(t, i+1, migration.Version)
+			}
+		})
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	m, db := setupMigrator(t, testMigrations)
+	ctx := context.Background()
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 3)
+	require.True(t, tableExists(t, db, "user_roles"))
+	_, err = db.Exec("INSERT INTO users (name, email) VALUES ('test', 'test@example.com')")
+	require.NoError(t, err)
+
+	// Nothing left to apply.
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	require.Empty(t, applied)
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, status.Version)
+	require.Len(t, status.Applied, 3)
+	require.Equal(t, "roles", status.Applied[2].Name)
+	require.False(t, status.Applied[2].AppliedAt.IsZero())
+	require.Empty(t, status.Pending)
+}
+
+func TestMigrator_Up_failure(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_users.up.sql":   testMigrations["0001_users.up.sql"],
+		"0001_users.down.sql": testMigrations["0001_users.down.sql"],
+		"0002_bad.up.sql":     {Data: []byte("CREATE TABLE bad (name text); INSERT INTO missing VALUES (1);")},
+		"0002_bad.down.sql":   {Data: []byte("DROP TABLE bad;")},
+	}
+	m, db := setupMigrator(t, fsys)
+	ctx := context.Background()
+
+	_, err := m.Up(ctx)
+	require.Error(t, err)
+
+	// The failed migration is rolled back, the previous ones are kept.
+	require.False(t, tableExists(t, db, "bad"))
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, status.Version)
+	require.Len(t, status.Pending, 1)
+}
+
+func TestMigrator_Down(t *testing.T) {
+	m, db := setupMigrator(t, testMigrations)
+	ctx := context.Background()
+
+	// Nothing to revert on an empty schema.
+	reverted, err := m.Down(ctx)
+	require.NoError(t, err)
+	require.Nil(t, reverted)
+
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+	reverted, err = m.Down(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, reverted.Version)
+	require.False(t, tableExists(t, db, "roles"))
+	require.True(t, tableExists(t, db, "users"))
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, status.Version)
+	require.Len(t, status.Pending, 1)
+	require.Equal(t, "roles", status.Pending[0].Name)
+
+	// The reverted migration is applied again.
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	require.True(t, tableExists(t, db, "roles"))
+}
+
+func TestMigrator_Status_empty(t *testing.T) {
+	m, _ := setupMigrator(t, testMigrations)
+
+	status, err := m.Status(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, status.Version)
+	require.Empty(t, status.Applied)
+	require.Len(t, status.Pending, 3)
+}
+
+func TestMigrator_newer_version(t *testing.T) {
+	m, db := setupMigrator(t, testMigrations)
+	ctx := context.Background()
+	_, err := m.Up(ctx)
+	require.NoError(t, err)
+
+	// An older service only knows the first migrations.
+	old, err := New(db, testDialect(fstest.MapFS{
+		"0001_users.up.sql":   testMigrations["0001_users.up.sql"],
+		"0001_users.down.sql": testMigrations["0001_users.down.sql"],
+	}))
+	require.NoError(t, err)
+	_, err = old.Status(ctx)
+	require.ErrorIs(t, err, ErrNewerVersion)
+	_, err = old.Up(ctx)
+	require.ErrorIs(t, err, ErrNewerVersion)
+	_, err = old.Down(ctx)
+	require.ErrorIs(t, err, ErrNewerVersion)
+	require.True(t, tableExists(t, db, "roles"))
+}
+
+func TestMigrator_unknown_version(t *testing.T) {
+	m, db := setupMigrator(t, testMigrations)
+	ctx := context.Background()
+	_, err := m.Up(ctx)
+	require.NoError(t, err)
+	_, err = db.Exec("UPDATE schema_migrations SET name = 'other' WHERE version = 2")
+	require.NoError(t, err)
+
+	_, err = m.Status(ctx)
+	require.ErrorIs(t, err, ErrUnknownVersion)
+	_, err = m.Up(ctx)
+	require.ErrorIs(t, err, ErrUnknownVersion)
+	_, err = m.Down(ctx)
+	require.ErrorIs(t, err, ErrUnknownVersion)
+}
+
+func TestMigrator_legacy_schema(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		wantErr error
+	}{
+		{"Legacy version", legacyVersion, nil},
+		{"Unknown legacy version", "0.2", ErrUnknownVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, db := setupMigrator(t, testMigrations)
+			ctx := context.Background()
+			_, err := db.Exec("CREATE TABLE users (name text NOT NULL); CREATE TABLE version (version text NOT NULL); INSERT INTO version VALUES (?)", tt.version)
+			require.NoError(t, err)
+
+			status, err := m.Status(ctx)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				_, err = m.Up(ctx)
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, 1, status.Version)
+
+			// The legacy schema is recorded as the first migration, the next ones are applied.
+			applied, err := m.Up(ctx)
+			require.NoError(t, err)
+			require.Len(t, applied, 2)
+			require.False(t, tableExists(t, db, "version"))
+			status, err = m.Status(ctx)
+			require.NoError(t, err)
+			require.Equal(t, 3, status.Version)
+		})
+	}
+}
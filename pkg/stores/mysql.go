@@ -0,0 +1,141 @@
+package stores
+
+import (
+	autherrors "auth/pkg/errors"
+	"auth/pkg/models"
+	"auth/pkg/stores/mysql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+type MysqlUserStore struct {
+	querier mysql.Querier
+	tenant  string
+}
+
+// NewMysqlUserStore creates a new instance of a UserStore for a MySQL database,
+// scoped to the users of the tenant.
+func NewMysqlUserStore(q mysql.Querier, tenant string) UserStore {
+	return &MysqlUserStore{querier: q, tenant: tenant}
+}
+
+func (s *MysqlUserStore) Create(ctx context.Context, user models.User) error {
+	_, err := s.querier.CreateUser(ctx, mysql.CreateUserParams{
+		Tenant:       s.tenant,
+		Username:     user.Username,
+		PasswordHash: user.Password,
+		Email:        user.Email,
+	})
+	if isMysqlUniqueViolation(err) {
+		return autherrors.UsernameAlreadyExistErr{Name: user.Username}
+	}
+	if err != nil {
+		return fmt.Errorf("error creating the user %s: %w", user.Username, err)
+	}
+
+	return nil
+}
+
+func (s *MysqlUserStore) Get(ctx context.Context, username string) (*models.User, error) {
+	u, err := s.querier.GetUser(ctx, mysql.GetUserParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the user %s: %w", username, err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.User{
+		Tenant:          u.Tenant,
+		Username:        u.Username,
+		Password:        u.PasswordHash,
+		Email:           u.Email,
+		TokenGeneration: u.TokenGeneration,
+	}, nil
+}
+
+func (s *MysqlUserStore) Update(ctx context.Context, username string, user models.User) (bool, error) {
+	n, err := s.querier.UpdateUser(ctx, mysql.UpdateUserParams{
+		NewUsername:     user.Username,
+		PasswordHash:    user.Password,
+		Email:           user.Email,
+		TokenGeneration: user.TokenGeneration,
+		Tenant:          s.tenant,
+		Username:        username,
+	})
+	if isMysqlUniqueViolation(err) {
+		return false, autherrors.UsernameAlreadyExistErr{Name: user.Username}
+	}
+	if err != nil {
+		return false, fmt.Errorf("error updating the user %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *MysqlUserStore) UpdatePasswordHash(ctx context.Context, username, currentHash, newHash string) (bool, error) {
+	n, err := s.querier.UpdatePasswordHash(ctx, mysql.UpdatePasswordHashParams{
+		NewHash:     newHash,
+		Tenant:      s.tenant,
+		Username:    username,
+		CurrentHash: currentHash,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error updating the password hash of the user %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *MysqlUserStore) Delete(ctx context.Context, username string) (bool, error) {
+	n, err := s.querier.DeleteUser(ctx, mysql.DeleteUserParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error deleting the user %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *MysqlUserStore) List(ctx context.Context, prefix, after string, limit int) ([]models.User, error) {
+	users, err := s.querier.ListUsers(ctx, mysql.ListUsersParams{
+		Tenant:   s.tenant,
+		Prefix:   prefix,
+		After:    after,
+		PageSize: int64(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the users: %w", err)
+	}
+
+	result := make([]models.User, 0, len(users))
+	for _, u := range users {
+		result = append(result, models.User{
+			Tenant:          u.Tenant,
+			Username:        u.Username,
+			Password:        u.PasswordHash,
+			Email:           u.Email,
+			TokenGeneration: u.TokenGeneration,
+		})
+	}
+
+	return result, nil
+}
+
+// mysqlDuplicateEntry is the MySQL error number of the unique constraint violations.
+const mysqlDuplicateEntry = 1062
+
+// isMysqlUniqueViolation returns true if err is a duplicate entry error of MySQL.
+func isMysqlUniqueViolation(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
@@ -0,0 +1,42 @@
+package mysql
+
+import (
+	"auth/pkg/stores/migrations"
+	"auth/sql/mysql"
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+)
+
+// migrationLockName is the name of the user lock held by the migrations.
+const migrationLockName = "auth_migrations"
+
+// NewMigrator creates a new instance of a Migrator of the MySQL schema of the db.
+// The concurrent migrators are serialized by a user lock.
+// MySQL commits the schema changes of a migration as they are applied, a failed migration
+// may leave the statements that preceded the failure.
+func NewMigrator(db *sql.DB) (*migrations.Migrator, error) {
+	fsys, err := fs.Sub(mysql.Migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return migrations.New(db, migrations.Dialect{
+		Migrations:  fsys,
+		Placeholder: func(n int) string { return "?" },
+		TableExists: "SELECT count(*) FROM information_schema.tables WHERE table_schema = database() AND table_name = ?",
+		Lock: func(ctx context.Context, conn *sql.Conn) (func() error, error) {
+			var locked sql.NullInt64
+			if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", migrationLockName).Scan(&locked); err != nil {
+				return nil, err
+			}
+			if locked.Int64 != 1 {
+				return nil, errors.New("error acquiring the migration lock")
+			}
+			return func() error {
+				_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLockName)
+				return err
+			}, nil
+		},
+	})
+}
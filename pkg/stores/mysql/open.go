@@ -0,0 +1,102 @@
+package mysql
+
+import (
+	"auth/pkg/config"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// tlsConfigName is the name of the TLS configuration registered for the require and verify-full SslMode.
+const tlsConfigName = "auth"
+
+// Open connects to the MySQL database of the configuration.
+// The SslMode takes the values of PostgreSQL: disable, require or verify-full, but require also verifies
+// the certificate of the server like verify-full. The certificates are the RootCert, SslCert and SslKey files.
+// The schema is created and updated by the migrations of NewMigrator, on a connection of OpenMigrations.
+func Open(configuration config.Database) (*sql.DB, error) {
+	return open(configuration, false)
+}
+
+// OpenMigrations connects to the MySQL database of the configuration like Open, with several statements allowed
+// per query for the migrations. The connection is only used by the migrations, it would let an injected query
+// run more statements.
+func OpenMigrations(configuration config.Database) (*sql.DB, error) {
+	return open(configuration, true)
+}
+
+func open(configuration config.Database, multiStatements bool) (*sql.DB, error) {
+	tlsConfig, err := registerTLSConfig(configuration)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	mysqlConfig := mysql.NewConfig()
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = net.JoinHostPort(configuration.Host, strconv.Itoa(configuration.Port))
+	mysqlConfig.User = configuration.UserName
+	mysqlConfig.Passwd = configuration.Password
+	mysqlConfig.DBName = configuration.DbName
+	mysqlConfig.TLSConfig = tlsConfig
+	mysqlConfig.ParseTime = true
+	mysqlConfig.Loc = time.UTC
+	// The migrations hold several statements.
+	mysqlConfig.MultiStatements = multiStatements
+	// The updates of a row with its current values are found like with the other databases.
+	mysqlConfig.ClientFoundRows = true
+
+	db, err := sql.Open("mysql", mysqlConfig.FormatDSN())
+
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connection to database not alive: %w", err)
+	}
+
+	return db, nil
+}
+
+// registerTLSConfig returns the TLS setting of the driver for the SslMode of the configuration.
+func registerTLSConfig(configuration config.Database) (string, error) {
+	switch configuration.SslMode {
+	case "", "disable":
+		return "false", nil
+	case "require", "verify-full":
+	default:
+		return "", fmt.Errorf("unsupported sslMode %q", configuration.SslMode)
+	}
+
+	tlsConfig := &tls.Config{ServerName: configuration.Host, MinVersion: tls.VersionTLS12}
+	if configuration.RootCert != "" {
+		pem, err := os.ReadFile(configuration.RootCert)
+		if err != nil {
+			return "", fmt.Errorf("error reading the root certificate: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return "", fmt.Errorf("no certificate in the root certificate %s", configuration.RootCert)
+		}
+	}
+	if configuration.SslCert != "" {
+		cert, err := tls.LoadX509KeyPair(configuration.SslCert, configuration.SslKey)
+		if err != nil {
+			return "", fmt.Errorf("error loading the client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if err := mysql.RegisterTLSConfig(tlsConfigName, tlsConfig); err != nil {
+		return "", err
+	}
+
+	return tlsConfigName, nil
+}
@@ -0,0 +1,144 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/mysql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type MysqlAPIKeyStore struct {
+	db      mysql.DBTX
+	querier mysql.Querier
+	tenant  string
+}
+
+// NewMysqlAPIKeyStore creates a new instance of an APIKeyStore for the MySQL database or transaction db,
+// scoped to the service accounts of the tenant.
+func NewMysqlAPIKeyStore(db mysql.DBTX, tenant string) APIKeyStore {
+	return &MysqlAPIKeyStore{db: db, querier: mysql.New(db), tenant: tenant}
+}
+
+func (s *MysqlAPIKeyStore) Create(ctx context.Context, key models.APIKey) error {
+	// The service account, the key and its permissions are created together or not at all.
+	return withTx(ctx, s.db, func(tx mysql.DBTX) error {
+		q := mysql.New(tx)
+		err := q.CreateServiceAccount(ctx, mysql.CreateServiceAccountParams{
+			Tenant: s.tenant,
+			Name:   key.ServiceAccount,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating the service account %s: %w", key.ServiceAccount, err)
+		}
+		err = q.CreateAPIKey(ctx, mysql.CreateAPIKeyParams{
+			Prefix:         key.Prefix,
+			KeyHash:        key.Hash,
+			Tenant:         s.tenant,
+			ServiceAccount: key.ServiceAccount,
+			ExpiresAt:      sql.NullTime{Time: key.ExpiresAt.UTC(), Valid: !key.ExpiresAt.IsZero()},
+		})
+		if err != nil {
+			return fmt.Errorf("error creating the API key for %s: %w", key.ServiceAccount, err)
+		}
+		for _, permission := range key.Permissions {
+			err := q.AddAPIKeyPermission(ctx, mysql.AddAPIKeyPermissionParams{
+				Prefix:     key.Prefix,
+				Permission: permission,
+			})
+			if err != nil {
+				return fmt.Errorf("error adding the permission %s to the API key %s: %w", permission, key.Prefix, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *MysqlAPIKeyStore) Get(ctx context.Context, prefix string) (*models.APIKey, error) {
+	k, err := s.querier.GetAPIKey(ctx, mysql.GetAPIKeyParams{
+		Prefix: prefix,
+		Tenant: s.tenant,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the API key %s: %w", prefix, err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return s.apiKey(ctx, k)
+}
+
+func (s *MysqlAPIKeyStore) List(ctx context.Context, serviceAccount string) ([]models.APIKey, error) {
+	var rows []mysql.GetAPIKeyRow
+	if serviceAccount == "" {
+		keys, err := s.querier.ListAPIKeys(ctx, s.tenant)
+		if err != nil {
+			return nil, fmt.Errorf("error listing the API keys: %w", err)
+		}
+		for _, k := range keys {
+			rows = append(rows, mysql.GetAPIKeyRow(k))
+		}
+	} else {
+		keys, err := s.querier.ListServiceAccountAPIKeys(ctx, mysql.ListServiceAccountAPIKeysParams{
+			Tenant: s.tenant,
+			Name:   serviceAccount,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing the API keys of %s: %w", serviceAccount, err)
+		}
+		for _, k := range keys {
+			rows = append(rows, mysql.GetAPIKeyRow(k))
+		}
+	}
+
+	apiKeys := make([]models.APIKey, 0, len(rows))
+	for _, row := range rows {
+		k, err := s.apiKey(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, *k)
+	}
+
+	return apiKeys, nil
+}
+
+func (s *MysqlAPIKeyStore) Revoke(ctx context.Context, prefix string) (bool, error) {
+	n, err := s.querier.RevokeAPIKey(ctx, mysql.RevokeAPIKeyParams{
+		Prefix: prefix,
+		Tenant: s.tenant,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error revoking the API key %s: %w", prefix, err)
+	}
+
+	return n == 1, nil
+}
+
+// apiKey returns the API key of the row with its permissions.
+func (s *MysqlAPIKeyStore) apiKey(ctx context.Context, k mysql.GetAPIKeyRow) (*models.APIKey, error) {
+	permissions, err := s.querier.ListAPIKeyPermissions(ctx, k.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the permissions of the API key %s: %w", k.Prefix, err)
+	}
+	var expiresAt time.Time
+	if k.ExpiresAt.Valid {
+		expiresAt = k.ExpiresAt.Time
+	}
+
+	return &models.APIKey{
+		Prefix:         k.Prefix,
+		Hash:           k.KeyHash,
+		Tenant:         s.tenant,
+		ServiceAccount: k.Name,
+		Permissions:    permissions,
+		ExpiresAt:      expiresAt,
+		Revoked:        k.Revoked,
+		CreatedAt:      k.CreatedAt,
+	}, nil
+}
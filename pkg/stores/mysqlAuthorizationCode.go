@@ -0,0 +1,78 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/mysql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type MysqlAuthorizationCodeStore struct {
+	querier mysql.Querier
+	tenant  string
+}
+
+// NewMysqlAuthorizationCodeStore creates a new instance of an AuthorizationCodeStore for a MySQL database,
+// scoped to the OAuth clients and the users of the tenant.
+func NewMysqlAuthorizationCodeStore(q mysql.Querier, tenant string) AuthorizationCodeStore {
+	return &MysqlAuthorizationCodeStore{querier: q, tenant: tenant}
+}
+
+func (s *MysqlAuthorizationCodeStore) Create(ctx context.Context, code models.AuthorizationCode) error {
+	err := s.querier.CreateAuthorizationCode(ctx, mysql.CreateAuthorizationCodeParams{
+		CodeHash:      code.Hash,
+		Tenant:        s.tenant,
+		ClientID:      code.ClientID,
+		Username:      code.Username,
+		RedirectUri:   code.RedirectURI,
+		Scope:         strings.Join(code.Scopes, " "),
+		Nonce:         code.Nonce,
+		CodeChallenge: code.CodeChallenge,
+		AuthTime:      code.AuthTime.UTC(),
+		ExpiresAt:     code.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating the authorization code of %s for %s: %w", code.ClientID, code.Username, err)
+	}
+
+	return nil
+}
+
+func (s *MysqlAuthorizationCodeStore) Get(ctx context.Context, hash string) (*models.AuthorizationCode, error) {
+	c, err := s.querier.GetAuthorizationCode(ctx, mysql.GetAuthorizationCodeParams{
+		CodeHash: hash,
+		Tenant:   s.tenant,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the authorization code: %w", err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.AuthorizationCode{
+		Hash:          c.CodeHash,
+		ClientID:      c.ClientID,
+		Username:      c.Username,
+		RedirectURI:   c.RedirectUri,
+		Scopes:        strings.Fields(c.Scope),
+		Nonce:         c.Nonce,
+		CodeChallenge: c.CodeChallenge,
+		AuthTime:      c.AuthTime,
+		ExpiresAt:     c.ExpiresAt,
+		Used:          c.Used,
+	}, nil
+}
+
+func (s *MysqlAuthorizationCodeStore) Use(ctx context.Context, hash string) (bool, error) {
+	n, err := s.querier.UseAuthorizationCode(ctx, hash)
+	if err != nil {
+		return false, fmt.Errorf("error using the authorization code: %w", err)
+	}
+
+	return n == 1, nil
+}
@@ -0,0 +1,69 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/mysql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type MysqlLoginFailureStore struct {
+	querier mysql.Querier
+	tenant  string
+}
+
+// NewMysqlLoginFailureStore creates a new instance of a LoginFailureStore for a MySQL database,
+// scoped to the users of the tenant.
+func NewMysqlLoginFailureStore(q mysql.Querier, tenant string) LoginFailureStore {
+	return &MysqlLoginFailureStore{querier: q, tenant: tenant}
+}
+
+func (s *MysqlLoginFailureStore) Get(ctx context.Context, key string) (*models.LoginFailures, error) {
+	f, err := s.querier.GetLoginFailures(ctx, mysql.GetLoginFailuresParams{
+		Tenant:     s.tenant,
+		AttemptKey: key,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the login failures %s: %w", key, err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.LoginFailures{
+		Key:         f.AttemptKey,
+		Failures:    int(f.Failures),
+		LastFailure: f.LastFailure,
+	}, nil
+}
+
+func (s *MysqlLoginFailureStore) Add(ctx context.Context, key string, failedAt, since time.Time) (*models.LoginFailures, error) {
+	err := s.querier.AddLoginFailure(ctx, mysql.AddLoginFailureParams{
+		Tenant:     s.tenant,
+		AttemptKey: key,
+		FailedAt:   failedAt.UTC(),
+		Since:      since.UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error adding a login failure %s: %w", key, err)
+	}
+
+	// MySQL does not return the upserted row.
+	return s.Get(ctx, key)
+}
+
+func (s *MysqlLoginFailureStore) Reset(ctx context.Context, key string) error {
+	err := s.querier.DeleteLoginFailures(ctx, mysql.DeleteLoginFailuresParams{
+		Tenant:     s.tenant,
+		AttemptKey: key,
+	})
+	if err != nil {
+		return fmt.Errorf("error resetting the login failures %s: %w", key, err)
+	}
+
+	return nil
+}
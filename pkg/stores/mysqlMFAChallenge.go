@@ -0,0 +1,78 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/mysql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type MysqlMFAChallengeStore struct {
+	querier mysql.Querier
+	tenant  string
+}
+
+// NewMysqlMFAChallengeStore creates a new instance of an MFAChallengeStore for a MySQL database,
+// scoped to the users of the tenant.
+func NewMysqlMFAChallengeStore(q mysql.Querier, tenant string) MFAChallengeStore {
+	return &MysqlMFAChallengeStore{querier: q, tenant: tenant}
+}
+
+func (s *MysqlMFAChallengeStore) Create(ctx context.Context, challenge models.MFAChallenge) error {
+	err := s.querier.CreateMFAChallenge(ctx, mysql.CreateMFAChallengeParams{
+		TokenHash: challenge.Hash,
+		Tenant:    s.tenant,
+		Username:  challenge.Username,
+		ExpiresAt: challenge.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating the MFA challenge for %s: %w", challenge.Username, err)
+	}
+
+	return nil
+}
+
+func (s *MysqlMFAChallengeStore) Get(ctx context.Context, hash string) (*models.MFAChallenge, error) {
+	c, err := s.querier.GetMFAChallenge(ctx, mysql.GetMFAChallengeParams{
+		Tenant:    s.tenant,
+		TokenHash: hash,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the MFA challenge: %w", err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.MFAChallenge{
+		Hash:      c.TokenHash,
+		Username:  c.Username,
+		ExpiresAt: c.ExpiresAt,
+		Used:      c.Used,
+		Attempts:  int(c.Attempts),
+	}, nil
+}
+
+func (s *MysqlMFAChallengeStore) Attempt(ctx context.Context, hash string, maxAttempts int) (bool, error) {
+	n, err := s.querier.AttemptMFAChallenge(ctx, mysql.AttemptMFAChallengeParams{
+		TokenHash:   hash,
+		MaxAttempts: int64(maxAttempts),
+	})
+	if err != nil {
+		return false, fmt.Errorf("error attempting the MFA challenge: %w", err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *MysqlMFAChallengeStore) Use(ctx context.Context, hash string) (bool, error) {
+	n, err := s.querier.UseMFAChallenge(ctx, hash)
+	if err != nil {
+		return false, fmt.Errorf("error using the MFA challenge: %w", err)
+	}
+
+	return n == 1, nil
+}
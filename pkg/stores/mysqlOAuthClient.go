@@ -0,0 +1,121 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/mysql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type MysqlOAuthClientStore struct {
+	querier mysql.Querier
+	tenant  string
+}
+
+// NewMysqlOAuthClientStore creates a new instance of an OAuthClientStore for a MySQL database,
+// scoped to the OAuth clients of the tenant.
+func NewMysqlOAuthClientStore(q mysql.Querier, tenant string) OAuthClientStore {
+	return &MysqlOAuthClientStore{querier: q, tenant: tenant}
+}
+
+func (s *MysqlOAuthClientStore) Create(ctx context.Context, client models.OAuthClient) error {
+	err := s.querier.CreateOAuthClient(ctx, mysql.CreateOAuthClientParams{
+		Tenant:     s.tenant,
+		ClientID:   client.ID,
+		SecretHash: client.SecretHash,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating the OAuth client %s: %w", client.ID, err)
+	}
+	for _, scope := range client.Scopes {
+		err := s.querier.AddOAuthClientScope(ctx, mysql.AddOAuthClientScopeParams{
+			Tenant:   s.tenant,
+			ClientID: client.ID,
+			Scope:    scope,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding the scope %s to the OAuth client %s: %w", scope, client.ID, err)
+		}
+	}
+	for _, audience := range client.Audiences {
+		err := s.querier.AddOAuthClientAudience(ctx, mysql.AddOAuthClientAudienceParams{
+			Tenant:   s.tenant,
+			ClientID: client.ID,
+			Audience: audience,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding the audience %s to the OAuth client %s: %w", audience, client.ID, err)
+		}
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		err := s.querier.AddOAuthClientRedirectURI(ctx, mysql.AddOAuthClientRedirectURIParams{
+			Tenant:      s.tenant,
+			ClientID:    client.ID,
+			RedirectUri: redirectURI,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding the redirect URI %s to the OAuth client %s: %w", redirectURI, client.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *MysqlOAuthClientStore) Get(ctx context.Context, id string) (*models.OAuthClient, error) {
+	c, err := s.querier.GetOAuthClient(ctx, mysql.GetOAuthClientParams{
+		Tenant:   s.tenant,
+		ClientID: id,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the OAuth client %s: %w", id, err)
+		} else {
+			return nil, nil
+		}
+	}
+	scopes, err := s.querier.ListOAuthClientScopes(ctx, mysql.ListOAuthClientScopesParams{
+		Tenant:   s.tenant,
+		ClientID: id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the scopes of the OAuth client %s: %w", id, err)
+	}
+	audiences, err := s.querier.ListOAuthClientAudiences(ctx, mysql.ListOAuthClientAudiencesParams{
+		Tenant:   s.tenant,
+		ClientID: id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the audiences of the OAuth client %s: %w", id, err)
+	}
+	redirectURIs, err := s.querier.ListOAuthClientRedirectURIs(ctx, mysql.ListOAuthClientRedirectURIsParams{
+		Tenant:   s.tenant,
+		ClientID: id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the redirect URIs of the OAuth client %s: %w", id, err)
+	}
+
+	return &models.OAuthClient{
+		ID:           c.ClientID,
+		SecretHash:   c.SecretHash,
+		Tenant:       s.tenant,
+		Scopes:       scopes,
+		Audiences:    audiences,
+		RedirectURIs: redirectURIs,
+		CreatedAt:    c.CreatedAt,
+	}, nil
+}
+
+func (s *MysqlOAuthClientStore) Delete(ctx context.Context, id string) (bool, error) {
+	n, err := s.querier.DeleteOAuthClient(ctx, mysql.DeleteOAuthClientParams{
+		Tenant:   s.tenant,
+		ClientID: id,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error deleting the OAuth client %s: %w", id, err)
+	}
+
+	return n == 1, nil
+}
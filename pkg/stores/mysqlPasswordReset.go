@@ -0,0 +1,76 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/mysql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type MysqlPasswordResetStore struct {
+	db      mysql.DBTX
+	querier mysql.Querier
+	tenant  string
+}
+
+// NewMysqlPasswordResetStore creates a new instance of a PasswordResetStore for the MySQL database or transaction db,
+// scoped to the users of the tenant.
+func NewMysqlPasswordResetStore(db mysql.DBTX, tenant string) PasswordResetStore {
+	return &MysqlPasswordResetStore{db: db, querier: mysql.New(db), tenant: tenant}
+}
+
+func (s *MysqlPasswordResetStore) Create(ctx context.Context, token models.PasswordResetToken) error {
+	return withTx(ctx, s.db, func(tx mysql.DBTX) error {
+		q := mysql.New(tx)
+		err := q.UseUserPasswordResetTokens(ctx, mysql.UseUserPasswordResetTokensParams{
+			Tenant:   s.tenant,
+			Username: token.Username,
+		})
+		if err != nil {
+			return fmt.Errorf("error invalidating the password reset tokens of %s: %w", token.Username, err)
+		}
+		err = q.CreatePasswordResetToken(ctx, mysql.CreatePasswordResetTokenParams{
+			TokenHash: token.Hash,
+			Tenant:    s.tenant,
+			Username:  token.Username,
+			ExpiresAt: token.ExpiresAt.UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("error creating the password reset token for %s: %w", token.Username, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *MysqlPasswordResetStore) Get(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	t, err := s.querier.GetPasswordResetToken(ctx, mysql.GetPasswordResetTokenParams{
+		Tenant:    s.tenant,
+		TokenHash: hash,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the password reset token: %w", err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.PasswordResetToken{
+		Hash:      t.TokenHash,
+		Username:  t.Username,
+		ExpiresAt: t.ExpiresAt,
+		Used:      t.Used,
+	}, nil
+}
+
+func (s *MysqlPasswordResetStore) Use(ctx context.Context, hash string) (bool, error) {
+	n, err := s.querier.UsePasswordResetToken(ctx, hash)
+	if err != nil {
+		return false, fmt.Errorf("error using the password reset token: %w", err)
+	}
+
+	return n == 1, nil
+}
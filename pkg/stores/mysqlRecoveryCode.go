@@ -0,0 +1,84 @@
+package stores
+
+import (
+	"auth/pkg/stores/mysql"
+	"context"
+	"fmt"
+)
+
+type MysqlRecoveryCodeStore struct {
+	db      mysql.DBTX
+	querier mysql.Querier
+	tenant  string
+}
+
+// NewMysqlRecoveryCodeStore creates a new instance of a RecoveryCodeStore for the MySQL database or transaction db,
+// scoped to the users of the tenant.
+func NewMysqlRecoveryCodeStore(db mysql.DBTX, tenant string) RecoveryCodeStore {
+	return &MysqlRecoveryCodeStore{db: db, querier: mysql.New(db), tenant: tenant}
+}
+
+func (s *MysqlRecoveryCodeStore) Replace(ctx context.Context, username, batchID string, hashes []string) error {
+	// The previous codes are only deleted with all the new ones stored, a failure doesn't leave the user without codes.
+	return withTx(ctx, s.db, func(tx mysql.DBTX) error {
+		q := mysql.New(tx)
+		for _, hash := range hashes {
+			err := q.CreateRecoveryCode(ctx, mysql.CreateRecoveryCodeParams{
+				Tenant:   s.tenant,
+				Username: username,
+				BatchID:  batchID,
+				CodeHash: hash,
+			})
+			if err != nil {
+				return fmt.Errorf("error creating the recovery codes for %s: %w", username, err)
+			}
+		}
+		err := q.DeleteOtherRecoveryCodes(ctx, mysql.DeleteOtherRecoveryCodesParams{
+			Tenant:   s.tenant,
+			Username: username,
+			BatchID:  batchID,
+		})
+		if err != nil {
+			return fmt.Errorf("error deleting the previous recovery codes of %s: %w", username, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *MysqlRecoveryCodeStore) ListUnused(ctx context.Context, username string) ([]string, error) {
+	hashes, err := s.querier.ListUnusedRecoveryCodes(ctx, mysql.ListUnusedRecoveryCodesParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the recovery codes of %s: %w", username, err)
+	}
+
+	return hashes, nil
+}
+
+func (s *MysqlRecoveryCodeStore) Use(ctx context.Context, username, hash string) (bool, error) {
+	n, err := s.querier.UseRecoveryCode(ctx, mysql.UseRecoveryCodeParams{
+		Tenant:   s.tenant,
+		Username: username,
+		CodeHash: hash,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error using the recovery code of %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *MysqlRecoveryCodeStore) CountUnused(ctx context.Context, username string) (int, error) {
+	n, err := s.querier.CountUnusedRecoveryCodes(ctx, mysql.CountUnusedRecoveryCodesParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error counting the recovery codes of %s: %w", username, err)
+	}
+
+	return int(n), nil
+}
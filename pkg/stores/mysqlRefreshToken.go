@@ -0,0 +1,89 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/mysql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type MysqlRefreshTokenStore struct {
+	querier mysql.Querier
+	tenant  string
+}
+
+// NewMysqlRefreshTokenStore creates a new instance of a RefreshTokenStore for a MySQL database,
+// scoped to the users of the tenant.
+func NewMysqlRefreshTokenStore(q mysql.Querier, tenant string) RefreshTokenStore {
+	return &MysqlRefreshTokenStore{querier: q, tenant: tenant}
+}
+
+func (s *MysqlRefreshTokenStore) Create(ctx context.Context, token models.RefreshToken) error {
+	err := s.querier.CreateRefreshToken(ctx, mysql.CreateRefreshTokenParams{
+		TokenHash: token.Hash,
+		FamilyID:  token.FamilyID,
+		Tenant:    s.tenant,
+		Username:  token.Username,
+		ExpiresAt: token.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating the refresh token for %s: %w", token.Username, err)
+	}
+
+	return nil
+}
+
+func (s *MysqlRefreshTokenStore) Get(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	t, err := s.querier.GetRefreshToken(ctx, mysql.GetRefreshTokenParams{
+		Tenant:    s.tenant,
+		TokenHash: hash,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the refresh token: %w", err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.RefreshToken{
+		Hash:      t.TokenHash,
+		FamilyID:  t.FamilyID,
+		Username:  t.Username,
+		ExpiresAt: t.ExpiresAt,
+		Used:      t.Used,
+		Revoked:   t.Revoked,
+	}, nil
+}
+
+func (s *MysqlRefreshTokenStore) Use(ctx context.Context, hash string) (bool, error) {
+	n, err := s.querier.UseRefreshToken(ctx, hash)
+	if err != nil {
+		return false, fmt.Errorf("error using the refresh token: %w", err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *MysqlRefreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	err := s.querier.RevokeRefreshTokenFamily(ctx, familyID)
+	if err != nil {
+		return fmt.Errorf("error revoking the refresh token family %s: %w", familyID, err)
+	}
+
+	return nil
+}
+
+func (s *MysqlRefreshTokenStore) RevokeUser(ctx context.Context, username string) error {
+	err := s.querier.RevokeUserRefreshTokens(ctx, mysql.RevokeUserRefreshTokensParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return fmt.Errorf("error revoking the refresh tokens of %s: %w", username, err)
+	}
+
+	return nil
+}
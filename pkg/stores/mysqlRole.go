@@ -0,0 +1,125 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/mysql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type MysqlRoleStore struct {
+	querier mysql.Querier
+	tenant  string
+}
+
+// NewMysqlRoleStore creates a new instance of a RoleStore for a MySQL database,
+// scoped to the users of the tenant.
+func NewMysqlRoleStore(q mysql.Querier, tenant string) RoleStore {
+	return &MysqlRoleStore{querier: q, tenant: tenant}
+}
+
+func (s *MysqlRoleStore) Define(ctx context.Context, role models.Role) error {
+	if err := s.querier.CreateRole(ctx, role.Name); err != nil {
+		return fmt.Errorf("error creating the role %s: %w", role.Name, err)
+	}
+	if err := s.querier.DeleteRolePermissions(ctx, role.Name); err != nil {
+		return fmt.Errorf("error deleting the permissions of the role %s: %w", role.Name, err)
+	}
+	for _, permission := range role.Permissions {
+		err := s.querier.AddRolePermission(ctx, mysql.AddRolePermissionParams{
+			Role:       role.Name,
+			Permission: permission,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding the permission %s to the role %s: %w", permission, role.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *MysqlRoleStore) Get(ctx context.Context, name string) (*models.Role, error) {
+	_, err := s.querier.GetRole(ctx, name)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the role %s: %w", name, err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return s.role(ctx, name)
+}
+
+func (s *MysqlRoleStore) List(ctx context.Context) ([]models.Role, error) {
+	names, err := s.querier.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the roles: %w", err)
+	}
+
+	return s.roles(ctx, names)
+}
+
+func (s *MysqlRoleStore) Assign(ctx context.Context, username, role string) error {
+	err := s.querier.AssignRole(ctx, mysql.AssignRoleParams{
+		Tenant:   s.tenant,
+		Username: username,
+		Role:     role,
+	})
+	if err != nil {
+		return fmt.Errorf("error assigning the role %s to %s: %w", role, username, err)
+	}
+
+	return nil
+}
+
+func (s *MysqlRoleStore) Revoke(ctx context.Context, username, role string) (bool, error) {
+	n, err := s.querier.RevokeRole(ctx, mysql.RevokeRoleParams{
+		Tenant:   s.tenant,
+		Username: username,
+		Role:     role,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error revoking the role %s of %s: %w", role, username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *MysqlRoleStore) ListByUser(ctx context.Context, username string) ([]models.Role, error) {
+	names, err := s.querier.ListUserRoles(ctx, mysql.ListUserRolesParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the roles of %s: %w", username, err)
+	}
+
+	return s.roles(ctx, names)
+}
+
+// roles returns the roles with the names and their permissions.
+func (s *MysqlRoleStore) roles(ctx context.Context, names []string) ([]models.Role, error) {
+	roles := make([]models.Role, 0, len(names))
+	for _, name := range names {
+		role, err := s.role(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+
+	return roles, nil
+}
+
+// role returns the role with the name and its permissions.
+func (s *MysqlRoleStore) role(ctx context.Context, name string) (*models.Role, error) {
+	permissions, err := s.querier.ListRolePermissions(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the permissions of the role %s: %w", name, err)
+	}
+
+	return &models.Role{Name: name, Permissions: permissions}, nil
+}
@@ -0,0 +1,83 @@
+package stores
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/stores/mysql"
+	"io"
+)
+
+func init() {
+	Register("mysql", func(configuration config.Database) (Stores, io.Closer, error) {
+		// The migrations run on their own connection, the only one allowing several statements per query.
+		migrationDB, err := openMigrated(configuration, mysql.OpenMigrations, mysql.NewMigrator)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := migrationDB.Close(); err != nil {
+			return nil, nil, err
+		}
+		db, err := mysql.Open(configuration)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewMysqlStores(db), db, nil
+	})
+	RegisterMigrations("mysql", openMigrator(mysql.OpenMigrations, mysql.NewMigrator))
+}
+
+type MysqlStores struct {
+	db mysql.DBTX
+}
+
+// NewMysqlStores creates a new instance of Stores for the MySQL database or transaction db.
+func NewMysqlStores(db mysql.DBTX) Stores {
+	return &MysqlStores{db: db}
+}
+
+func (s *MysqlStores) UserStore(tenant string) UserStore {
+	return NewMysqlUserStore(mysql.New(s.db), tenant)
+}
+
+func (s *MysqlStores) RefreshTokenStore(tenant string) RefreshTokenStore {
+	return NewMysqlRefreshTokenStore(mysql.New(s.db), tenant)
+}
+
+func (s *MysqlStores) RevocationStore() RevocationStore {
+	return NewMysqlRevocationStore(mysql.New(s.db))
+}
+
+func (s *MysqlStores) PasswordResetStore(tenant string) PasswordResetStore {
+	return NewMysqlPasswordResetStore(s.db, tenant)
+}
+
+func (s *MysqlStores) LoginFailureStore(tenant string) LoginFailureStore {
+	return NewMysqlLoginFailureStore(mysql.New(s.db), tenant)
+}
+
+func (s *MysqlStores) TOTPStore(tenant string) TOTPStore {
+	return NewMysqlTOTPStore(mysql.New(s.db), tenant)
+}
+
+func (s *MysqlStores) MFAChallengeStore(tenant string) MFAChallengeStore {
+	return NewMysqlMFAChallengeStore(mysql.New(s.db), tenant)
+}
+
+func (s *MysqlStores) RecoveryCodeStore(tenant string) RecoveryCodeStore {
+	return NewMysqlRecoveryCodeStore(s.db, tenant)
+}
+
+func (s *MysqlStores) RoleStore(tenant string) RoleStore {
+	return NewMysqlRoleStore(mysql.New(s.db), tenant)
+}
+
+func (s *MysqlStores) APIKeyStore(tenant string) APIKeyStore {
+	return NewMysqlAPIKeyStore(s.db, tenant)
+}
+
+func (s *MysqlStores) OAuthClientStore(tenant string) OAuthClientStore {
+	return NewMysqlOAuthClientStore(mysql.New(s.db), tenant)
+}
+
+func (s *MysqlStores) AuthorizationCodeStore(tenant string) AuthorizationCodeStore {
+	return NewMysqlAuthorizationCodeStore(mysql.New(s.db), tenant)
+}
@@ -0,0 +1,92 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/mysql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type MysqlTOTPStore struct {
+	querier mysql.Querier
+	tenant  string
+}
+
+// NewMysqlTOTPStore creates a new instance of a TOTPStore for a MySQL database,
+// scoped to the users of the tenant.
+func NewMysqlTOTPStore(q mysql.Querier, tenant string) TOTPStore {
+	return &MysqlTOTPStore{querier: q, tenant: tenant}
+}
+
+func (s *MysqlTOTPStore) Enroll(ctx context.Context, username, secret string) (bool, error) {
+	// MySQL has no conditional upsert, the unconfirmed credential is replaced
+	// and the confirmed one rejects the new credential.
+	err := s.querier.DeleteUnconfirmedTOTPCredential(ctx, mysql.DeleteUnconfirmedTOTPCredentialParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err == nil {
+		err = s.querier.CreateTOTPCredential(ctx, mysql.CreateTOTPCredentialParams{
+			Tenant:   s.tenant,
+			Username: username,
+			Secret:   secret,
+		})
+	}
+	if isMysqlUniqueViolation(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error enrolling the TOTP credential of %s: %w", username, err)
+	}
+
+	return true, nil
+}
+
+func (s *MysqlTOTPStore) Get(ctx context.Context, username string) (*models.TOTPCredential, error) {
+	c, err := s.querier.GetTOTPCredential(ctx, mysql.GetTOTPCredentialParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the TOTP credential of %s: %w", username, err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.TOTPCredential{
+		Username:    c.Username,
+		Secret:      c.Secret,
+		Confirmed:   c.Confirmed,
+		LastCounter: c.LastCounter,
+	}, nil
+}
+
+func (s *MysqlTOTPStore) Confirm(ctx context.Context, username string, counter int64) (bool, error) {
+	n, err := s.querier.ConfirmTOTPCredential(ctx, mysql.ConfirmTOTPCredentialParams{
+		Counter:  counter,
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error confirming the TOTP credential of %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *MysqlTOTPStore) UseCounter(ctx context.Context, username string, counter int64) (bool, error) {
+	n, err := s.querier.UseTOTPCounter(ctx, mysql.UseTOTPCounterParams{
+		Counter:  counter,
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error using the TOTP code of %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
@@ -0,0 +1,72 @@
+//go:build mysql_test
+
+package stores
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/stores/mysql"
+	"context"
+	"testing"
+)
+
+// mysqlDatabase is the test database started by make tests-mysql.
+var mysqlDatabase = config.Database{
+	Host:     "localhost",
+	Port:     3307,
+	UserName: "auth_user",
+	Password: "autPassw@ord",
+	DbName:   "auth",
+}
+
+func setupMysql(t testing.TB) func(t testing.TB) {
+	migrationDB, err := mysql.OpenMigrations(mysqlDatabase)
+	if err != nil {
+		t.Fatalf("an error %v was not expected when opening a test database connection", err)
+	}
+	migrator, err := mysql.NewMigrator(migrationDB)
+	if err != nil {
+		t.Fatalf("an error %v was not expected when loading the migrations", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("an error %v was not expected when migrating the test database", err)
+	}
+	migrationDB.Close()
+	database, err := mysql.Open(mysqlDatabase)
+	if err != nil {
+		t.Fatalf("an error %v was not expected when opening a test database connection", err)
+	}
+	tx, err := database.Begin()
+	if err != nil {
+		t.Fatalf("an error %v was not expected when opening a stub database transaction", err)
+	}
+	userStore = NewMysqlUserStore(mysql.New(tx), "default")
+	refreshTokenStore = NewMysqlRefreshTokenStore(mysql.New(tx), "default")
+	revocationStore = NewMysqlRevocationStore(mysql.New(tx))
+	passwordResetStore = NewMysqlPasswordResetStore(tx, "default")
+	loginFailureStore = NewMysqlLoginFailureStore(mysql.New(tx), "default")
+	totpStore = NewMysqlTOTPStore(mysql.New(tx), "default")
+	mfaChallengeStore = NewMysqlMFAChallengeStore(mysql.New(tx), "default")
+	recoveryCodeStore = NewMysqlRecoveryCodeStore(tx, "default")
+	roleStore = NewMysqlRoleStore(mysql.New(tx), "default")
+	apiKeyStore = NewMysqlAPIKeyStore(tx, "default")
+	oauthClientStore = NewMysqlOAuthClientStore(mysql.New(tx), "default")
+	authorizationCodeStore = NewMysqlAuthorizationCodeStore(mysql.New(tx), "default")
+	otherUserStore = NewMysqlUserStore(mysql.New(tx), "other")
+	otherRefreshTokenStore = NewMysqlRefreshTokenStore(mysql.New(tx), "other")
+	otherAPIKeyStore = NewMysqlAPIKeyStore(tx, "other")
+	otherOAuthClientStore = NewMysqlOAuthClientStore(mysql.New(tx), "other")
+	otherAuthorizationCodeStore = NewMysqlAuthorizationCodeStore(mysql.New(tx), "other")
+
+	return func(t testing.TB) {
+		tx.Rollback()
+		database.Close()
+	}
+}
+
+func TestMysqlStores(t *testing.T) {
+	for _, tc := range storeTests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, setupMysql)
+		})
+	}
+}
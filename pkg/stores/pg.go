@@ -1,25 +1,37 @@
 package stores
 
 import (
+	autherrors "auth/pkg/errors"
 	"auth/pkg/models"
 	"auth/pkg/stores/pg"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/lib/pq"
 )
 
 type PgUserStore struct {
 	querier pg.Querier
+	tenant  string
 }
 
-// NewPgUserStore creates a new instance of a UserStore for a PostgreSQL database.
-func NewPgUserStore(q pg.Querier) UserStore {
-	return &PgUserStore{querier: q}
+// NewPgUserStore creates a new instance of a UserStore for a PostgreSQL database,
+// scoped to the users of the tenant.
+func NewPgUserStore(q pg.Querier, tenant string) UserStore {
+	return &PgUserStore{querier: q, tenant: tenant}
 }
 
 func (s *PgUserStore) Create(ctx context.Context, user models.User) error {
-	_, err := s.querier.CreateUser(ctx, pg.CreateUserParams{Username: user.Username, PasswordHash: user.Password})
+	_, err := s.querier.CreateUser(ctx, pg.CreateUserParams{
+		Tenant:       s.tenant,
+		Username:     user.Username,
+		PasswordHash: user.Password,
+		Email:        user.Email,
+	})
+	if isPgUniqueViolation(err) {
+		return autherrors.UsernameAlreadyExistErr{Name: user.Username}
+	}
 	if err != nil {
 		return fmt.Errorf("error creating the user %s: %w", user.Username, err)
 	}
@@ -28,7 +40,10 @@ func (s *PgUserStore) Create(ctx context.Context, user models.User) error {
 }
 
 func (s *PgUserStore) Get(ctx context.Context, username string) (*models.User, error) {
-	u, err := s.querier.GetUser(ctx, username)
+	u, err := s.querier.GetUser(ctx, pg.GetUserParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
 	if err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("error getting the user %s: %w", username, err)
@@ -37,5 +52,90 @@ func (s *PgUserStore) Get(ctx context.Context, username string) (*models.User, e
 		}
 	}
 
-	return &models.User{Username: u.Username, Password: u.PasswordHash}, nil
+	return &models.User{
+		Tenant:          u.Tenant,
+		Username:        u.Username,
+		Password:        u.PasswordHash,
+		Email:           u.Email,
+		TokenGeneration: u.TokenGeneration,
+	}, nil
+}
+
+func (s *PgUserStore) Update(ctx context.Context, username string, user models.User) (bool, error) {
+	n, err := s.querier.UpdateUser(ctx, pg.UpdateUserParams{
+		NewUsername:     user.Username,
+		PasswordHash:    user.Password,
+		Email:           user.Email,
+		TokenGeneration: user.TokenGeneration,
+		Tenant:          s.tenant,
+		Username:        username,
+	})
+	if isPgUniqueViolation(err) {
+		return false, autherrors.UsernameAlreadyExistErr{Name: user.Username}
+	}
+	if err != nil {
+		return false, fmt.Errorf("error updating the user %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *PgUserStore) UpdatePasswordHash(ctx context.Context, username, currentHash, newHash string) (bool, error) {
+	n, err := s.querier.UpdatePasswordHash(ctx, pg.UpdatePasswordHashParams{
+		NewHash:     newHash,
+		Tenant:      s.tenant,
+		Username:    username,
+		CurrentHash: currentHash,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error updating the password hash of the user %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *PgUserStore) Delete(ctx context.Context, username string) (bool, error) {
+	n, err := s.querier.DeleteUser(ctx, pg.DeleteUserParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error deleting the user %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *PgUserStore) List(ctx context.Context, prefix, after string, limit int) ([]models.User, error) {
+	users, err := s.querier.ListUsers(ctx, pg.ListUsersParams{
+		Tenant:   s.tenant,
+		Prefix:   prefix,
+		After:    after,
+		PageSize: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the users: %w", err)
+	}
+
+	result := make([]models.User, 0, len(users))
+	for _, u := range users {
+		result = append(result, models.User{
+			Tenant:          u.Tenant,
+			Username:        u.Username,
+			Password:        u.PasswordHash,
+			Email:           u.Email,
+			TokenGeneration: u.TokenGeneration,
+		})
+	}
+
+	return result, nil
+}
+
+// pgUniqueViolation is the PostgreSQL error code of the unique constraint violations.
+const pgUniqueViolation = "23505"
+
+// isPgUniqueViolation returns true if err is a unique constraint violation of PostgreSQL.
+func isPgUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation
 }
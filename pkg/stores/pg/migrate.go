@@ -0,0 +1,36 @@
+package pg
+
+import (
+	"auth/pkg/stores/migrations"
+	"auth/sql/postgresql"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+)
+
+// migrationLockID is the key of the advisory lock held by the migrations.
+const migrationLockID = 0x61757468
+
+// NewMigrator creates a new instance of a Migrator of the PostgreSQL schema of the db.
+// The concurrent migrators are serialized by an advisory lock.
+func NewMigrator(db *sql.DB) (*migrations.Migrator, error) {
+	fsys, err := fs.Sub(postgresql.Migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return migrations.New(db, migrations.Dialect{
+		Migrations:  fsys,
+		Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+		TableExists: "SELECT count(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1",
+		Lock: func(ctx context.Context, conn *sql.Conn) (func() error, error) {
+			if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+				return nil, err
+			}
+			return func() error {
+				_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+				return err
+			}, nil
+		},
+	})
+}
@@ -7,6 +7,8 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// Open connects to the PostgreSQL database of the configuration.
+// The schema is created and updated by the migrations of NewMigrator.
 func Open(configuration config.Database) (*sql.DB, error) {
 
 	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s sslrootcert=%s sslkey=%s sslcert=%s",
@@ -32,10 +34,5 @@ func Open(configuration config.Database) (*sql.DB, error) {
 		return nil, fmt.Errorf("connection to database not alive: %w", err)
 	}
 
-	_, err = db.Query("select * from version;")
-	if err != nil {
-		return nil, fmt.Errorf("db schema not present: %w", err)
-	}
-
 	return db, nil
 }
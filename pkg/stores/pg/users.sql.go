@@ -11,29 +11,183 @@ import (
 )
 
 const createUser = `-- name: CreateUser :execresult
-INSERT INTO users (username, password_hash)
-VALUES ($1, $2)
+INSERT INTO users (tenant, username, password_hash, email)
+VALUES ($1, $2, $3, $4)
 `
 
 type CreateUserParams struct {
+	Tenant       string
 	Username     string
 	PasswordHash string
+	Email        string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (sql.Result, error) {
-	return q.db.ExecContext(ctx, createUser, arg.Username, arg.PasswordHash)
+	return q.db.ExecContext(ctx, createUser,
+		arg.Tenant,
+		arg.Username,
+		arg.PasswordHash,
+		arg.Email,
+	)
+}
+
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE
+FROM users
+WHERE tenant = $1
+  AND username = $2
+`
+
+type DeleteUserParams struct {
+	Tenant   string
+	Username string
+}
+
+func (q *Queries) DeleteUser(ctx context.Context, arg DeleteUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, arg.Tenant, arg.Username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, username, password_hash
+SELECT id, username, password_hash, email, token_generation, tenant
 FROM users
-WHERE username = $1
+WHERE tenant = $1
+  AND username = $2
 LIMIT 1
 `
 
-func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUser, username)
+type GetUserParams struct {
+	Tenant   string
+	Username string
+}
+
+func (q *Queries) GetUser(ctx context.Context, arg GetUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, arg.Tenant, arg.Username)
 	var i User
-	err := row.Scan(&i.ID, &i.Username, &i.PasswordHash)
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.Email,
+		&i.TokenGeneration,
+		&i.Tenant,
+	)
 	return i, err
 }
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, username, password_hash, email, token_generation, tenant
+FROM users
+WHERE tenant = $1
+  AND substr(username, 1, length($2)) = $2
+  AND username > $3
+ORDER BY username
+LIMIT $4
+`
+
+type ListUsersParams struct {
+	Tenant   string
+	Prefix   string
+	After    string
+	PageSize int32
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers,
+		arg.Tenant,
+		arg.Prefix,
+		arg.After,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.PasswordHash,
+			&i.Email,
+			&i.TokenGeneration,
+			&i.Tenant,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updatePasswordHash = `-- name: UpdatePasswordHash :execrows
+UPDATE users
+SET password_hash = $1
+WHERE tenant = $2
+  AND username = $3
+  AND password_hash = $4
+`
+
+type UpdatePasswordHashParams struct {
+	NewHash     string
+	Tenant      string
+	Username    string
+	CurrentHash string
+}
+
+func (q *Queries) UpdatePasswordHash(ctx context.Context, arg UpdatePasswordHashParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updatePasswordHash,
+		arg.NewHash,
+		arg.Tenant,
+		arg.Username,
+		arg.CurrentHash,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateUser = `-- name: UpdateUser :execrows
+UPDATE users
+SET username         = $1,
+    password_hash    = $2,
+    email            = $3,
+    token_generation = $4
+WHERE tenant = $5
+  AND username = $6
+`
+
+type UpdateUserParams struct {
+	NewUsername     string
+	PasswordHash    string
+	Email           string
+	TokenGeneration int64
+	Tenant          string
+	Username        string
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUser,
+		arg.NewUsername,
+		arg.PasswordHash,
+		arg.Email,
+		arg.TokenGeneration,
+		arg.Tenant,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
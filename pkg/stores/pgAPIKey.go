@@ -0,0 +1,144 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/pg"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type PgAPIKeyStore struct {
+	db      pg.DBTX
+	querier pg.Querier
+	tenant  string
+}
+
+// NewPgAPIKeyStore creates a new instance of an APIKeyStore for the PostgreSQL database or transaction db,
+// scoped to the service accounts of the tenant.
+func NewPgAPIKeyStore(db pg.DBTX, tenant string) APIKeyStore {
+	return &PgAPIKeyStore{db: db, querier: pg.New(db), tenant: tenant}
+}
+
+func (s *PgAPIKeyStore) Create(ctx context.Context, key models.APIKey) error {
+	// The service account, the key and its permissions are created together or not at all.
+	return withTx(ctx, s.db, func(tx pg.DBTX) error {
+		q := pg.New(tx)
+		err := q.CreateServiceAccount(ctx, pg.CreateServiceAccountParams{
+			Tenant: s.tenant,
+			Name:   key.ServiceAccount,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating the service account %s: %w", key.ServiceAccount, err)
+		}
+		err = q.CreateAPIKey(ctx, pg.CreateAPIKeyParams{
+			Prefix:         key.Prefix,
+			KeyHash:        key.Hash,
+			Tenant:         s.tenant,
+			ServiceAccount: key.ServiceAccount,
+			ExpiresAt:      sql.NullTime{Time: key.ExpiresAt.UTC(), Valid: !key.ExpiresAt.IsZero()},
+		})
+		if err != nil {
+			return fmt.Errorf("error creating the API key for %s: %w", key.ServiceAccount, err)
+		}
+		for _, permission := range key.Permissions {
+			err := q.AddAPIKeyPermission(ctx, pg.AddAPIKeyPermissionParams{
+				Prefix:     key.Prefix,
+				Permission: permission,
+			})
+			if err != nil {
+				return fmt.Errorf("error adding the permission %s to the API key %s: %w", permission, key.Prefix, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *PgAPIKeyStore) Get(ctx context.Context, prefix string) (*models.APIKey, error) {
+	k, err := s.querier.GetAPIKey(ctx, pg.GetAPIKeyParams{
+		Prefix: prefix,
+		Tenant: s.tenant,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the API key %s: %w", prefix, err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return s.apiKey(ctx, k)
+}
+
+func (s *PgAPIKeyStore) List(ctx context.Context, serviceAccount string) ([]models.APIKey, error) {
+	var rows []pg.GetAPIKeyRow
+	if serviceAccount == "" {
+		keys, err := s.querier.ListAPIKeys(ctx, s.tenant)
+		if err != nil {
+			return nil, fmt.Errorf("error listing the API keys: %w", err)
+		}
+		for _, k := range keys {
+			rows = append(rows, pg.GetAPIKeyRow(k))
+		}
+	} else {
+		keys, err := s.querier.ListServiceAccountAPIKeys(ctx, pg.ListServiceAccountAPIKeysParams{
+			Tenant: s.tenant,
+			Name:   serviceAccount,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing the API keys of %s: %w", serviceAccount, err)
+		}
+		for _, k := range keys {
+			rows = append(rows, pg.GetAPIKeyRow(k))
+		}
+	}
+
+	apiKeys := make([]models.APIKey, 0, len(rows))
+	for _, row := range rows {
+		k, err := s.apiKey(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, *k)
+	}
+
+	return apiKeys, nil
+}
+
+func (s *PgAPIKeyStore) Revoke(ctx context.Context, prefix string) (bool, error) {
+	n, err := s.querier.RevokeAPIKey(ctx, pg.RevokeAPIKeyParams{
+		Prefix: prefix,
+		Tenant: s.tenant,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error revoking the API key %s: %w", prefix, err)
+	}
+
+	return n == 1, nil
+}
+
+// apiKey returns the API key of the row with its permissions.
+func (s *PgAPIKeyStore) apiKey(ctx context.Context, k pg.GetAPIKeyRow) (*models.APIKey, error) {
+	permissions, err := s.querier.ListAPIKeyPermissions(ctx, k.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the permissions of the API key %s: %w", k.Prefix, err)
+	}
+	var expiresAt time.Time
+	if k.ExpiresAt.Valid {
+		expiresAt = k.ExpiresAt.Time
+	}
+
+	return &models.APIKey{
+		Prefix:         k.Prefix,
+		Hash:           k.KeyHash,
+		Tenant:         s.tenant,
+		ServiceAccount: k.Name,
+		Permissions:    permissions,
+		ExpiresAt:      expiresAt,
+		Revoked:        k.Revoked,
+		CreatedAt:      k.CreatedAt,
+	}, nil
+}
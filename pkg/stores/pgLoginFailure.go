@@ -0,0 +1,72 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/pg"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type PgLoginFailureStore struct {
+	querier pg.Querier
+	tenant  string
+}
+
+// NewPgLoginFailureStore creates a new instance of a LoginFailureStore for a PostgreSQL database,
+// scoped to the users of the tenant.
+func NewPgLoginFailureStore(q pg.Querier, tenant string) LoginFailureStore {
+	return &PgLoginFailureStore{querier: q, tenant: tenant}
+}
+
+func (s *PgLoginFailureStore) Get(ctx context.Context, key string) (*models.LoginFailures, error) {
+	f, err := s.querier.GetLoginFailures(ctx, pg.GetLoginFailuresParams{
+		Tenant:     s.tenant,
+		AttemptKey: key,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the login failures %s: %w", key, err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.LoginFailures{
+		Key:         f.AttemptKey,
+		Failures:    int(f.Failures),
+		LastFailure: f.LastFailure,
+	}, nil
+}
+
+func (s *PgLoginFailureStore) Add(ctx context.Context, key string, failedAt, since time.Time) (*models.LoginFailures, error) {
+	f, err := s.querier.AddLoginFailure(ctx, pg.AddLoginFailureParams{
+		Tenant:     s.tenant,
+		AttemptKey: key,
+		FailedAt:   failedAt.UTC(),
+		Since:      since.UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error adding a login failure %s: %w", key, err)
+	}
+
+	return &models.LoginFailures{
+		Key:         f.AttemptKey,
+		Failures:    int(f.Failures),
+		LastFailure: f.LastFailure,
+	}, nil
+}
+
+func (s *PgLoginFailureStore) Reset(ctx context.Context, key string) error {
+	err := s.querier.DeleteLoginFailures(ctx, pg.DeleteLoginFailuresParams{
+		Tenant:     s.tenant,
+		AttemptKey: key,
+	})
+	if err != nil {
+		return fmt.Errorf("error resetting the login failures %s: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/pg"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type PgMFAChallengeStore struct {
+	querier pg.Querier
+	tenant  string
+}
+
+// NewPgMFAChallengeStore creates a new instance of an MFAChallengeStore for a PostgreSQL database,
+// scoped to the users of the tenant.
+func NewPgMFAChallengeStore(q pg.Querier, tenant string) MFAChallengeStore {
+	return &PgMFAChallengeStore{querier: q, tenant: tenant}
+}
+
+func (s *PgMFAChallengeStore) Create(ctx context.Context, challenge models.MFAChallenge) error {
+	err := s.querier.CreateMFAChallenge(ctx, pg.CreateMFAChallengeParams{
+		TokenHash: challenge.Hash,
+		Tenant:    s.tenant,
+		Username:  challenge.Username,
+		ExpiresAt: challenge.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating the MFA challenge for %s: %w", challenge.Username, err)
+	}
+
+	return nil
+}
+
+func (s *PgMFAChallengeStore) Get(ctx context.Context, hash string) (*models.MFAChallenge, error) {
+	c, err := s.querier.GetMFAChallenge(ctx, pg.GetMFAChallengeParams{
+		Tenant:    s.tenant,
+		TokenHash: hash,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the MFA challenge: %w", err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.MFAChallenge{
+		Hash:      c.TokenHash,
+		Username:  c.Username,
+		ExpiresAt: c.ExpiresAt,
+		Used:      c.Used,
+		Attempts:  int(c.Attempts),
+	}, nil
+}
+
+func (s *PgMFAChallengeStore) Attempt(ctx context.Context, hash string, maxAttempts int) (bool, error) {
+	n, err := s.querier.AttemptMFAChallenge(ctx, pg.AttemptMFAChallengeParams{
+		TokenHash:   hash,
+		MaxAttempts: int32(maxAttempts),
+	})
+	if err != nil {
+		return false, fmt.Errorf("error attempting the MFA challenge: %w", err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *PgMFAChallengeStore) Use(ctx context.Context, hash string) (bool, error) {
+	n, err := s.querier.UseMFAChallenge(ctx, hash)
+	if err != nil {
+		return false, fmt.Errorf("error using the MFA challenge: %w", err)
+	}
+
+	return n == 1, nil
+}
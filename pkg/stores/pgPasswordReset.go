@@ -0,0 +1,76 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/pg"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type PgPasswordResetStore struct {
+	db      pg.DBTX
+	querier pg.Querier
+	tenant  string
+}
+
+// NewPgPasswordResetStore creates a new instance of a PasswordResetStore for the PostgreSQL database or transaction db,
+// scoped to the users of the tenant.
+func NewPgPasswordResetStore(db pg.DBTX, tenant string) PasswordResetStore {
+	return &PgPasswordResetStore{db: db, querier: pg.New(db), tenant: tenant}
+}
+
+func (s *PgPasswordResetStore) Create(ctx context.Context, token models.PasswordResetToken) error {
+	return withTx(ctx, s.db, func(tx pg.DBTX) error {
+		q := pg.New(tx)
+		err := q.UseUserPasswordResetTokens(ctx, pg.UseUserPasswordResetTokensParams{
+			Tenant:   s.tenant,
+			Username: token.Username,
+		})
+		if err != nil {
+			return fmt.Errorf("error invalidating the password reset tokens of %s: %w", token.Username, err)
+		}
+		err = q.CreatePasswordResetToken(ctx, pg.CreatePasswordResetTokenParams{
+			TokenHash: token.Hash,
+			Tenant:    s.tenant,
+			Username:  token.Username,
+			ExpiresAt: token.ExpiresAt.UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("error creating the password reset token for %s: %w", token.Username, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *PgPasswordResetStore) Get(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	t, err := s.querier.GetPasswordResetToken(ctx, pg.GetPasswordResetTokenParams{
+		Tenant:    s.tenant,
+		TokenHash: hash,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the password reset token: %w", err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.PasswordResetToken{
+		Hash:      t.TokenHash,
+		Username:  t.Username,
+		ExpiresAt: t.ExpiresAt,
+		Used:      t.Used,
+	}, nil
+}
+
+func (s *PgPasswordResetStore) Use(ctx context.Context, hash string) (bool, error) {
+	n, err := s.querier.UsePasswordResetToken(ctx, hash)
+	if err != nil {
+		return false, fmt.Errorf("error using the password reset token: %w", err)
+	}
+
+	return n == 1, nil
+}
@@ -0,0 +1,84 @@
+package stores
+
+import (
+	"auth/pkg/stores/pg"
+	"context"
+	"fmt"
+)
+
+type PgRecoveryCodeStore struct {
+	db      pg.DBTX
+	querier pg.Querier
+	tenant  string
+}
+
+// NewPgRecoveryCodeStore creates a new instance of a RecoveryCodeStore for the PostgreSQL database or transaction db,
+// scoped to the users of the tenant.
+func NewPgRecoveryCodeStore(db pg.DBTX, tenant string) RecoveryCodeStore {
+	return &PgRecoveryCodeStore{db: db, querier: pg.New(db), tenant: tenant}
+}
+
+func (s *PgRecoveryCodeStore) Replace(ctx context.Context, username, batchID string, hashes []string) error {
+	// The previous codes are only deleted with all the new ones stored, a failure doesn't leave the user without codes.
+	return withTx(ctx, s.db, func(tx pg.DBTX) error {
+		q := pg.New(tx)
+		for _, hash := range hashes {
+			err := q.CreateRecoveryCode(ctx, pg.CreateRecoveryCodeParams{
+				Tenant:   s.tenant,
+				Username: username,
+				BatchID:  batchID,
+				CodeHash: hash,
+			})
+			if err != nil {
+				return fmt.Errorf("error creating the recovery codes for %s: %w", username, err)
+			}
+		}
+		err := q.DeleteOtherRecoveryCodes(ctx, pg.DeleteOtherRecoveryCodesParams{
+			Tenant:   s.tenant,
+			Username: username,
+			BatchID:  batchID,
+		})
+		if err != nil {
+			return fmt.Errorf("error deleting the previous recovery codes of %s: %w", username, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *PgRecoveryCodeStore) ListUnused(ctx context.Context, username string) ([]string, error) {
+	hashes, err := s.querier.ListUnusedRecoveryCodes(ctx, pg.ListUnusedRecoveryCodesParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the recovery codes of %s: %w", username, err)
+	}
+
+	return hashes, nil
+}
+
+func (s *PgRecoveryCodeStore) Use(ctx context.Context, username, hash string) (bool, error) {
+	n, err := s.querier.UseRecoveryCode(ctx, pg.UseRecoveryCodeParams{
+		Tenant:   s.tenant,
+		Username: username,
+		CodeHash: hash,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error using the recovery code of %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *PgRecoveryCodeStore) CountUnused(ctx context.Context, username string) (int, error) {
+	n, err := s.querier.CountUnusedRecoveryCodes(ctx, pg.CountUnusedRecoveryCodesParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error counting the recovery codes of %s: %w", username, err)
+	}
+
+	return int(n), nil
+}
@@ -0,0 +1,89 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/pg"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type PgRefreshTokenStore struct {
+	querier pg.Querier
+	tenant  string
+}
+
+// NewPgRefreshTokenStore creates a new instance of a RefreshTokenStore for a PostgreSQL database,
+// scoped to the users of the tenant.
+func NewPgRefreshTokenStore(q pg.Querier, tenant string) RefreshTokenStore {
+	return &PgRefreshTokenStore{querier: q, tenant: tenant}
+}
+
+func (s *PgRefreshTokenStore) Create(ctx context.Context, token models.RefreshToken) error {
+	err := s.querier.CreateRefreshToken(ctx, pg.CreateRefreshTokenParams{
+		TokenHash: token.Hash,
+		FamilyID:  token.FamilyID,
+		Tenant:    s.tenant,
+		Username:  token.Username,
+		ExpiresAt: token.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating the refresh token for %s: %w", token.Username, err)
+	}
+
+	return nil
+}
+
+func (s *PgRefreshTokenStore) Get(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	t, err := s.querier.GetRefreshToken(ctx, pg.GetRefreshTokenParams{
+		Tenant:    s.tenant,
+		TokenHash: hash,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the refresh token: %w", err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.RefreshToken{
+		Hash:      t.TokenHash,
+		FamilyID:  t.FamilyID,
+		Username:  t.Username,
+		ExpiresAt: t.ExpiresAt,
+		Used:      t.Used,
+		Revoked:   t.Revoked,
+	}, nil
+}
+
+func (s *PgRefreshTokenStore) Use(ctx context.Context, hash string) (bool, error) {
+	n, err := s.querier.UseRefreshToken(ctx, hash)
+	if err != nil {
+		return false, fmt.Errorf("error using the refresh token: %w", err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *PgRefreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	err := s.querier.RevokeRefreshTokenFamily(ctx, familyID)
+	if err != nil {
+		return fmt.Errorf("error revoking the refresh token family %s: %w", familyID, err)
+	}
+
+	return nil
+}
+
+func (s *PgRefreshTokenStore) RevokeUser(ctx context.Context, username string) error {
+	err := s.querier.RevokeUserRefreshTokens(ctx, pg.RevokeUserRefreshTokensParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return fmt.Errorf("error revoking the refresh tokens of %s: %w", username, err)
+	}
+
+	return nil
+}
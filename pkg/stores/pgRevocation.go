@@ -0,0 +1,47 @@
+package stores
+
+import (
+	"auth/pkg/stores/pg"
+	"context"
+	"fmt"
+	"time"
+)
+
+type PgRevocationStore struct {
+	querier pg.Querier
+}
+
+// NewPgRevocationStore creates a new instance of a RevocationStore for a PostgreSQL database.
+func NewPgRevocationStore(q pg.Querier) RevocationStore {
+	return &PgRevocationStore{querier: q}
+}
+
+func (s *PgRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	err := s.querier.RevokeToken(ctx, pg.RevokeTokenParams{
+		Jti:       jti,
+		ExpiresAt: expiresAt.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error revoking the token %s: %w", jti, err)
+	}
+
+	return nil
+}
+
+func (s *PgRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := s.querier.CountRevokedTokens(ctx, jti)
+	if err != nil {
+		return false, fmt.Errorf("error checking the revocation of the token %s: %w", jti, err)
+	}
+
+	return n > 0, nil
+}
+
+func (s *PgRevocationStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	n, err := s.querier.DeleteExpiredRevokedTokens(ctx, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error deleting the expired revoked tokens: %w", err)
+	}
+
+	return n, nil
+}
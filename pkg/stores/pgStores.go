@@ -0,0 +1,75 @@
+package stores
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/stores/pg"
+	"io"
+)
+
+func init() {
+	Register("postgres", func(configuration config.Database) (Stores, io.Closer, error) {
+		db, err := openMigrated(configuration, pg.Open, pg.NewMigrator)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewPgStores(db), db, nil
+	})
+	RegisterMigrations("postgres", openMigrator(pg.Open, pg.NewMigrator))
+}
+
+type PgStores struct {
+	db pg.DBTX
+}
+
+// NewPgStores creates a new instance of Stores for the PostgreSQL database or transaction db.
+func NewPgStores(db pg.DBTX) Stores {
+	return &PgStores{db: db}
+}
+
+func (s *PgStores) UserStore(tenant string) UserStore {
+	return NewPgUserStore(pg.New(s.db), tenant)
+}
+
+func (s *PgStores) RefreshTokenStore(tenant string) RefreshTokenStore {
+	return NewPgRefreshTokenStore(pg.New(s.db), tenant)
+}
+
+func (s *PgStores) RevocationStore() RevocationStore {
+	return NewPgRevocationStore(pg.New(s.db))
+}
+
+func (s *PgStores) PasswordResetStore(tenant string) PasswordResetStore {
+	return NewPgPasswordResetStore(s.db, tenant)
+}
+
+func (s *PgStores) LoginFailureStore(tenant string) LoginFailureStore {
+	return NewPgLoginFailureStore(pg.New(s.db), tenant)
+}
+
+func (s *PgStores) TOTPStore(tenant string) TOTPStore {
+	return NewPgTOTPStore(pg.New(s.db), tenant)
+}
+
+func (s *PgStores) MFAChallengeStore(tenant string) MFAChallengeStore {
+	return NewPgMFAChallengeStore(pg.New(s.db), tenant)
+}
+
+func (s *PgStores) RecoveryCodeStore(tenant string) RecoveryCodeStore {
+	return NewPgRecoveryCodeStore(s.db, tenant)
+}
+
+func (s *PgStores) RoleStore(tenant string) RoleStore {
+	return NewPgRoleStore(pg.New(s.db), tenant)
+}
+
+func (s *PgStores) APIKeyStore(tenant string) APIKeyStore {
+	return NewPgAPIKeyStore(s.db, tenant)
+}
+
+func (s *PgStores) OAuthClientStore(tenant string) OAuthClientStore {
+	return NewPgOAuthClientStore(pg.New(s.db), tenant)
+}
+
+func (s *PgStores) AuthorizationCodeStore(tenant string) AuthorizationCodeStore {
+	return NewPgAuthorizationCodeStore(pg.New(s.db), tenant)
+}
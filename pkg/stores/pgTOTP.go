@@ -0,0 +1,81 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/pg"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type PgTOTPStore struct {
+	querier pg.Querier
+	tenant  string
+}
+
+// NewPgTOTPStore creates a new instance of a TOTPStore for a PostgreSQL database,
+// scoped to the users of the tenant.
+func NewPgTOTPStore(q pg.Querier, tenant string) TOTPStore {
+	return &PgTOTPStore{querier: q, tenant: tenant}
+}
+
+func (s *PgTOTPStore) Enroll(ctx context.Context, username, secret string) (bool, error) {
+	n, err := s.querier.EnrollTOTPCredential(ctx, pg.EnrollTOTPCredentialParams{
+		Tenant:   s.tenant,
+		Username: username,
+		Secret:   secret,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error enrolling the TOTP credential of %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *PgTOTPStore) Get(ctx context.Context, username string) (*models.TOTPCredential, error) {
+	c, err := s.querier.GetTOTPCredential(ctx, pg.GetTOTPCredentialParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the TOTP credential of %s: %w", username, err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.TOTPCredential{
+		Username:    c.Username,
+		Secret:      c.Secret,
+		Confirmed:   c.Confirmed,
+		LastCounter: c.LastCounter,
+	}, nil
+}
+
+func (s *PgTOTPStore) Confirm(ctx context.Context, username string, counter int64) (bool, error) {
+	n, err := s.querier.ConfirmTOTPCredential(ctx, pg.ConfirmTOTPCredentialParams{
+		Counter:  counter,
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error confirming the TOTP credential of %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *PgTOTPStore) UseCounter(ctx context.Context, username string, counter int64) (bool, error) {
+	n, err := s.querier.UseTOTPCounter(ctx, pg.UseTOTPCounterParams{
+		Counter:  counter,
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error using the TOTP code of %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
@@ -5,6 +5,7 @@ package stores
 import (
 	"auth/pkg/config"
 	"auth/pkg/stores/pg"
+	"context"
 	"testing"
 )
 
@@ -20,11 +21,34 @@ func setupPg(t testing.TB) func(t testing.TB) {
 	if err != nil {
 		t.Fatalf("an error %v was not expected when opening a test database connection", err)
 	}
+	migrator, err := pg.NewMigrator(database)
+	if err != nil {
+		t.Fatalf("an error %v was not expected when loading the migrations", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("an error %v was not expected when migrating the test database", err)
+	}
 	tx, err := database.Begin()
 	if err != nil {
 		t.Fatalf("an error %v was not expected when opening a stub database transaction", err)
 	}
-	userStore = NewPgUserStore(pg.New(tx))
+	userStore = NewPgUserStore(pg.New(tx), "default")
+	refreshTokenStore = NewPgRefreshTokenStore(pg.New(tx), "default")
+	revocationStore = NewPgRevocationStore(pg.New(tx))
+	passwordResetStore = NewPgPasswordResetStore(tx, "default")
+	loginFailureStore = NewPgLoginFailureStore(pg.New(tx), "default")
+	totpStore = NewPgTOTPStore(pg.New(tx), "default")
+	mfaChallengeStore = NewPgMFAChallengeStore(pg.New(tx), "default")
+	recoveryCodeStore = NewPgRecoveryCodeStore(tx, "default")
+	roleStore = NewPgRoleStore(pg.New(tx), "default")
+	apiKeyStore = NewPgAPIKeyStore(tx, "default")
+	oauthClientStore = NewPgOAuthClientStore(pg.New(tx), "default")
+	authorizationCodeStore = NewPgAuthorizationCodeStore(pg.New(tx), "default")
+	otherUserStore = NewPgUserStore(pg.New(tx), "other")
+	otherRefreshTokenStore = NewPgRefreshTokenStore(pg.New(tx), "other")
+	otherAPIKeyStore = NewPgAPIKeyStore(tx, "other")
+	otherOAuthClientStore = NewPgOAuthClientStore(pg.New(tx), "other")
+	otherAuthorizationCodeStore = NewPgAuthorizationCodeStore(pg.New(tx), "other")
 
 	return func(t testing.TB) {
 		tx.Rollback()
@@ -32,10 +56,10 @@ func setupPg(t testing.TB) func(t testing.TB) {
 	}
 }
 
-func TestPgUserStore_Create(t *testing.T) {
-	testCreateUser(t, setupPg)
-}
-
-func TestPgUserStore_Get(t *testing.T) {
-	testGetUser(t, setupPg)
+func TestPgStores(t *testing.T) {
+	for _, tc := range storeTests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, setupPg)
+		})
+	}
 }
@@ -0,0 +1,158 @@
+package stores
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/stores/migrations"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Stores creates the stores of a database scoped to a tenant, except the RevocationStore
+// whose token ids are unique across the tenants.
+type Stores interface {
+	UserStore(tenant string) UserStore
+	RefreshTokenStore(tenant string) RefreshTokenStore
+	RevocationStore() RevocationStore
+	PasswordResetStore(tenant string) PasswordResetStore
+	LoginFailureStore(tenant string) LoginFailureStore
+	TOTPStore(tenant string) TOTPStore
+	MFAChallengeStore(tenant string) MFAChallengeStore
+	RecoveryCodeStore(tenant string) RecoveryCodeStore
+	RoleStore(tenant string) RoleStore
+	APIKeyStore(tenant string) APIKeyStore
+	OAuthClientStore(tenant string) OAuthClientStore
+	AuthorizationCodeStore(tenant string) AuthorizationCodeStore
+}
+
+// Driver opens the Stores of the config.Database, the io.Closer closes the database.
+type Driver func(configuration config.Database) (Stores, io.Closer, error)
+
+// MigrationDriver opens the Migrator of the schema of the config.Database, the io.Closer closes the database.
+type MigrationDriver func(configuration config.Database) (*migrations.Migrator, io.Closer, error)
+
+var (
+	driversMu        sync.RWMutex
+	drivers          = make(map[string]Driver)
+	migrationDrivers = make(map[string]MigrationDriver)
+)
+
+// Register makes the driver available to Open for the config.Database of the type.
+// The sqlite, postgres, mysql and memory drivers are registered by this package.
+// It panics if the driver is nil or if a driver is already registered for the type.
+func Register(databaseType string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if driver == nil {
+		panic("stores: Register driver is nil")
+	}
+	if _, dup := drivers[databaseType]; dup {
+		panic("stores: Register called twice for driver " + databaseType)
+	}
+	drivers[databaseType] = driver
+}
+
+// Drivers returns the sorted types of the registered drivers.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	types := make([]string, 0, len(drivers))
+	for databaseType := range drivers {
+		types = append(types, databaseType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Open opens the Stores of the config.Database with the driver registered for its type.
+func Open(configuration config.Database) (Stores, io.Closer, error) {
+	driversMu.RLock()
+	driver, ok := drivers[configuration.Type]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown database type %q, expected one of %s", configuration.Type, strings.Join(Drivers(), ", "))
+	}
+
+	s, closer, err := driver(configuration)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening the %s database: %w", configuration.Type, err)
+	}
+
+	return s, closer, nil
+}
+
+// RegisterMigrations makes the migration driver available to OpenMigrator for the config.Database of the type.
+// It panics if the driver is nil or if a migration driver is already registered for the type.
+func RegisterMigrations(databaseType string, driver MigrationDriver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if driver == nil {
+		panic("stores: RegisterMigrations driver is nil")
+	}
+	if _, dup := migrationDrivers[databaseType]; dup {
+		panic("stores: RegisterMigrations called twice for driver " + databaseType)
+	}
+	migrationDrivers[databaseType] = driver
+}
+
+// OpenMigrator opens the Migrator of the config.Database with the migration driver registered for its type.
+func OpenMigrator(configuration config.Database) (*migrations.Migrator, io.Closer, error) {
+	driversMu.RLock()
+	driver, ok := migrationDrivers[configuration.Type]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("database type %q has no migrations", configuration.Type)
+	}
+
+	m, closer, err := driver(configuration)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening the %s database: %w", configuration.Type, err)
+	}
+
+	return m, closer, nil
+}
+
+// openMigrator returns a MigrationDriver of the database opened by open, whose Migrator is created by newMigrator.
+func openMigrator(
+	open func(configuration config.Database) (*sql.DB, error),
+	newMigrator func(db *sql.DB) (*migrations.Migrator, error),
+) MigrationDriver {
+	return func(configuration config.Database) (*migrations.Migrator, io.Closer, error) {
+		db, err := open(configuration)
+		if err != nil {
+			return nil, nil, err
+		}
+		m, err := newMigrator(db)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return m, db, nil
+	}
+}
+
+// openMigrated opens the database with open and applies its pending migrations, created by newMigrator.
+// It refuses a schema whose version is unknown or newer than the migrations.
+func openMigrated(
+	configuration config.Database,
+	open func(configuration config.Database) (*sql.DB, error),
+	newMigrator func(db *sql.DB) (*migrations.Migrator, error),
+) (*sql.DB, error) {
+	db, err := open(configuration)
+	if err != nil {
+		return nil, err
+	}
+	m, err := newMigrator(db)
+	if err == nil {
+		_, err = m.Up(context.Background())
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,89 @@
+package stores
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/models"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+func TestOpen(t *testing.T) {
+	errDriver := errors.New("driver error")
+	Register("test", func(configuration config.Database) (Stores, io.Closer, error) {
+		return NewSqliteStores(nil), nopCloser{}, nil
+	})
+	Register("test-error", func(configuration config.Database) (Stores, io.Closer, error) {
+		return nil, nil, errDriver
+	})
+	defer func() {
+		driversMu.Lock()
+		delete(drivers, "test")
+		delete(drivers, "test-error")
+		driversMu.Unlock()
+	}()
+
+	tests := []struct {
+		name         string
+		databaseType string
+		wantErr      bool
+		target       error
+	}{
+		{"Registered driver", "test", false, nil},
+		{"Memory", "memory", false, nil},
+		{"Driver error", "test-error", true, errDriver},
+		{"Unknown type", "unknown", true, nil},
+		{"No type", "", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, closer, err := Open(config.Database{Type: tt.databaseType})
+			if tt.wantErr {
+				require.Error(t, err)
+				if tt.target != nil {
+					require.ErrorIs(t, err, tt.target)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, s)
+			require.NoError(t, closer.Close())
+		})
+	}
+}
+
+func TestOpen_memory_shared(t *testing.T) {
+	s, closer, err := Open(config.Database{Type: "memory"})
+	require.NoError(t, err)
+	defer closer.Close()
+	ctx := context.Background()
+
+	// The stores opened from the same database see the same users, whatever the connection.
+	require.NoError(t, s.UserStore("default").Create(ctx, models.User{Username: "test", Password: "hash"}))
+	user, err := s.UserStore("default").Get(ctx, "test")
+	require.NoError(t, err)
+	require.NotNil(t, user)
+
+	// Each memory database is separate.
+	other, otherCloser, err := Open(config.Database{Type: "memory"})
+	require.NoError(t, err)
+	defer otherCloser.Close()
+	user, err = other.UserStore("default").Get(ctx, "test")
+	require.NoError(t, err)
+	require.Nil(t, user)
+}
+
+func TestRegister(t *testing.T) {
+	driver := func(configuration config.Database) (Stores, io.Closer, error) {
+		return nil, nil, nil
+	}
+	require.Panics(t, func() { Register("test-nil", nil) })
+	require.Panics(t, func() { Register("sqlite", driver) })
+	require.Equal(t, []string{"memory", "mysql", "postgres", "sqlite"}, Drivers())
+}
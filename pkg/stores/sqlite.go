@@ -1,25 +1,37 @@
 package stores
 
 import (
+	autherrors "auth/pkg/errors"
 	"auth/pkg/models"
 	"auth/pkg/stores/sqlite"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/mattn/go-sqlite3"
 )
 
 type SqliteUserStore struct {
 	querier sqlite.Querier
+	tenant  string
 }
 
-// NewSqliteUserStore creates a new instance of a UserStore for a SQLite database.
-func NewSqliteUserStore(q sqlite.Querier) UserStore {
-	return &SqliteUserStore{querier: q}
+// NewSqliteUserStore creates a new instance of a UserStore for a SQLite database,
+// scoped to the users of the tenant.
+func NewSqliteUserStore(q sqlite.Querier, tenant string) UserStore {
+	return &SqliteUserStore{querier: q, tenant: tenant}
 }
 
 func (s *SqliteUserStore) Create(ctx context.Context, user models.User) error {
-	_, err := s.querier.CreateUser(ctx, sqlite.CreateUserParams{Username: user.Username, PasswordHash: user.Password})
+	_, err := s.querier.CreateUser(ctx, sqlite.CreateUserParams{
+		Tenant:       s.tenant,
+		Username:     user.Username,
+		PasswordHash: user.Password,
+		Email:        user.Email,
+	})
+	if isSqliteUniqueViolation(err) {
+		return autherrors.UsernameAlreadyExistErr{Name: user.Username}
+	}
 	if err != nil {
 		return fmt.Errorf("error creating the user %s: %w", user.Username, err)
 	}
@@ -28,7 +40,10 @@ func (s *SqliteUserStore) Create(ctx context.Context, user models.User) error {
 }
 
 func (s *SqliteUserStore) Get(ctx context.Context, username string) (*models.User, error) {
-	u, err := s.querier.GetUser(ctx, username)
+	u, err := s.querier.GetUser(ctx, sqlite.GetUserParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
 	if err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("error getting the user %s: %w", username, err)
@@ -37,5 +52,87 @@ func (s *SqliteUserStore) Get(ctx context.Context, username string) (*models.Use
 		}
 	}
 
-	return &models.User{Username: u.Username, Password: u.PasswordHash}, nil
+	return &models.User{
+		Tenant:          u.Tenant,
+		Username:        u.Username,
+		Password:        u.PasswordHash,
+		Email:           u.Email,
+		TokenGeneration: u.TokenGeneration,
+	}, nil
+}
+
+func (s *SqliteUserStore) Update(ctx context.Context, username string, user models.User) (bool, error) {
+	n, err := s.querier.UpdateUser(ctx, sqlite.UpdateUserParams{
+		NewUsername:     user.Username,
+		PasswordHash:    user.Password,
+		Email:           user.Email,
+		TokenGeneration: user.TokenGeneration,
+		Tenant:          s.tenant,
+		Username:        username,
+	})
+	if isSqliteUniqueViolation(err) {
+		return false, autherrors.UsernameAlreadyExistErr{Name: user.Username}
+	}
+	if err != nil {
+		return false, fmt.Errorf("error updating the user %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *SqliteUserStore) UpdatePasswordHash(ctx context.Context, username, currentHash, newHash string) (bool, error) {
+	n, err := s.querier.UpdatePasswordHash(ctx, sqlite.UpdatePasswordHashParams{
+		NewHash:     newHash,
+		Tenant:      s.tenant,
+		Username:    username,
+		CurrentHash: currentHash,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error updating the password hash of the user %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *SqliteUserStore) Delete(ctx context.Context, username string) (bool, error) {
+	n, err := s.querier.DeleteUser(ctx, sqlite.DeleteUserParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error deleting the user %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *SqliteUserStore) List(ctx context.Context, prefix, after string, limit int) ([]models.User, error) {
+	users, err := s.querier.ListUsers(ctx, sqlite.ListUsersParams{
+		Tenant:   s.tenant,
+		Prefix:   prefix,
+		After:    after,
+		PageSize: int64(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the users: %w", err)
+	}
+
+	result := make([]models.User, 0, len(users))
+	for _, u := range users {
+		result = append(result, models.User{
+			Tenant:          u.Tenant,
+			Username:        u.Username,
+			Password:        u.PasswordHash,
+			Email:           u.Email,
+			TokenGeneration: u.TokenGeneration,
+		})
+	}
+
+	return result, nil
+}
+
+// isSqliteUniqueViolation returns true if err is a "UNIQUE constraint failed" error of SQLite.
+func isSqliteUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
 }
@@ -0,0 +1,22 @@
+package sqlite
+
+import (
+	"auth/pkg/stores/migrations"
+	"auth/sql/sqlite"
+	"database/sql"
+	"io/fs"
+)
+
+// NewMigrator creates a new instance of a Migrator of the SQLite schema of the db.
+// The concurrent migrators are serialized by the lock of the database file taken by each migration.
+func NewMigrator(db *sql.DB) (*migrations.Migrator, error) {
+	fsys, err := fs.Sub(sqlite.Migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return migrations.New(db, migrations.Dialect{
+		Migrations:  fsys,
+		Placeholder: func(n int) string { return "?" },
+		TableExists: "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?",
+	})
+}
@@ -4,14 +4,158 @@
 
 package sqlite
 
-import ()
+import (
+	"database/sql"
+	"time"
+)
+
+type ApiKey struct {
+	ID               int64
+	Prefix           string
+	KeyHash          string
+	ServiceAccountID int64
+	ExpiresAt        sql.NullTime
+	Revoked          bool
+	CreatedAt        time.Time
+}
+
+type ApiKeyPermission struct {
+	ID         int64
+	ApiKeyID   int64
+	Permission string
+}
+
+type AuthorizationCode struct {
+	ID            int64
+	CodeHash      string
+	OauthClientID int64
+	UserID        int64
+	RedirectUri   string
+	Scope         string
+	Nonce         string
+	CodeChallenge string
+	AuthTime      time.Time
+	ExpiresAt     time.Time
+	Used          bool
+	CreatedAt     time.Time
+}
+
+type LoginFailure struct {
+	AttemptKey  string
+	Failures    int64
+	LastFailure time.Time
+	Tenant      string
+}
+
+type MfaChallenge struct {
+	ID        int64
+	TokenHash string
+	UserID    int64
+	ExpiresAt time.Time
+	Used      bool
+	Attempts  int64
+	CreatedAt time.Time
+}
+
+type OauthClient struct {
+	ID         int64
+	ClientID   string
+	SecretHash string
+	Tenant     string
+	CreatedAt  time.Time
+}
+
+type OauthClientAudience struct {
+	ID            int64
+	OauthClientID int64
+	Audience      string
+}
+
+type OauthClientRedirectUri struct {
+	ID            int64
+	OauthClientID int64
+	RedirectUri   string
+}
+
+type OauthClientScope struct {
+	ID            int64
+	OauthClientID int64
+	Scope         string
+}
+
+type PasswordResetToken struct {
+	ID        int64
+	TokenHash string
+	UserID    int64
+	ExpiresAt time.Time
+	Used      bool
+	CreatedAt time.Time
+}
+
+type RecoveryCode struct {
+	ID        int64
+	UserID    int64
+	BatchID   string
+	CodeHash  string
+	Used      bool
+	CreatedAt time.Time
+}
+
+type RefreshToken struct {
+	ID        int64
+	TokenHash string
+	FamilyID  string
+	UserID    int64
+	ExpiresAt time.Time
+	Used      bool
+	Revoked   bool
+	CreatedAt time.Time
+}
+
+type RevokedToken struct {
+	Jti       string
+	ExpiresAt time.Time
+}
+
+type Role struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+}
+
+type RolePermission struct {
+	ID         int64
+	RoleID     int64
+	Permission string
+}
+
+type ServiceAccount struct {
+	ID        int64
+	Name      string
+	Tenant    string
+	CreatedAt time.Time
+}
+
+type TotpCredential struct {
+	ID          int64
+	UserID      int64
+	Secret      string
+	Confirmed   bool
+	LastCounter int64
+	CreatedAt   time.Time
+}
 
 type User struct {
-	ID           int64
-	Username     string
-	PasswordHash string
+	ID              int64
+	Username        string
+	PasswordHash    string
+	Email           string
+	TokenGeneration int64
+	Tenant          string
 }
 
-type Version struct {
-	Version string
+type UserRole struct {
+	UserID    int64
+	RoleID    int64
+	CreatedAt time.Time
 }
@@ -1,42 +1,40 @@
 package sqlite
 
 import (
-	"auth/sql/sqlite"
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	_ "github.com/mattn/go-sqlite3"
-	"os"
 )
 
+// Open opens the SQLite database file of the dbPath, created if it does not exist.
+// The schema is created and updated by the migrations of NewMigrator.
 func Open(dbPath string) (*sql.DB, error) {
-	newDb := false
-	if _, err := os.Stat(dbPath); errors.Is(err, os.ErrNotExist) {
-		newDb = true
-	}
 	database, err := sql.Open("sqlite3", fmt.Sprintf("%s?_foreign_keys=on", dbPath))
 	if err != nil {
 		return nil, err
 	}
 
-	if newDb {
-		if _, err := database.ExecContext(context.Background(), sqlite.Schema); err != nil {
-			return nil, err
-		}
-	}
-
 	return database, nil
 }
 
+// OpenInMemory opens a new in-memory database with the schema of the migrations.
 func OpenInMemory() (*sql.DB, error) {
 
 	database, err := sql.Open("sqlite3", fmt.Sprintf("file::memory:?_foreign_keys=on"))
 	if err != nil {
 		return nil, err
 	}
+	// Each connection to file::memory: opens its own database.
+	database.SetMaxOpenConns(1)
 
-	if _, err := database.ExecContext(context.Background(), sqlite.Schema); err != nil {
+	migrator, err := NewMigrator(database)
+	if err != nil {
+		database.Close()
+		return nil, err
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		database.Close()
 		return nil, err
 	}
 
@@ -7,11 +7,73 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	AddAPIKeyPermission(ctx context.Context, arg AddAPIKeyPermissionParams) error
+	AddLoginFailure(ctx context.Context, arg AddLoginFailureParams) (AddLoginFailureRow, error)
+	AddOAuthClientAudience(ctx context.Context, arg AddOAuthClientAudienceParams) error
+	AddOAuthClientRedirectURI(ctx context.Context, arg AddOAuthClientRedirectURIParams) error
+	AddOAuthClientScope(ctx context.Context, arg AddOAuthClientScopeParams) error
+	AddRolePermission(ctx context.Context, arg AddRolePermissionParams) error
+	AssignRole(ctx context.Context, arg AssignRoleParams) error
+	AttemptMFAChallenge(ctx context.Context, arg AttemptMFAChallengeParams) (int64, error)
+	ConfirmTOTPCredential(ctx context.Context, arg ConfirmTOTPCredentialParams) (int64, error)
+	CountRevokedTokens(ctx context.Context, jti string) (int64, error)
+	CountUnusedRecoveryCodes(ctx context.Context, arg CountUnusedRecoveryCodesParams) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) error
+	CreateAuthorizationCode(ctx context.Context, arg CreateAuthorizationCodeParams) error
+	CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) error
+	CreateOAuthClient(ctx context.Context, arg CreateOAuthClientParams) error
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) error
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error
+	CreateRole(ctx context.Context, name string) error
+	CreateServiceAccount(ctx context.Context, arg CreateServiceAccountParams) error
 	CreateUser(ctx context.Context, arg CreateUserParams) (sql.Result, error)
-	GetUser(ctx context.Context, username string) (User, error)
+	DeleteExpiredRevokedTokens(ctx context.Context, expiresAt time.Time) (int64, error)
+	DeleteLoginFailures(ctx context.Context, arg DeleteLoginFailuresParams) error
+	DeleteOAuthClient(ctx context.Context, arg DeleteOAuthClientParams) (int64, error)
+	DeleteOtherRecoveryCodes(ctx context.Context, arg DeleteOtherRecoveryCodesParams) error
+	DeleteRolePermissions(ctx context.Context, role string) error
+	DeleteUser(ctx context.Context, arg DeleteUserParams) (int64, error)
+	EnrollTOTPCredential(ctx context.Context, arg EnrollTOTPCredentialParams) (int64, error)
+	GetAPIKey(ctx context.Context, arg GetAPIKeyParams) (GetAPIKeyRow, error)
+	GetAuthorizationCode(ctx context.Context, arg GetAuthorizationCodeParams) (GetAuthorizationCodeRow, error)
+	GetLoginFailures(ctx context.Context, arg GetLoginFailuresParams) (GetLoginFailuresRow, error)
+	GetMFAChallenge(ctx context.Context, arg GetMFAChallengeParams) (GetMFAChallengeRow, error)
+	GetOAuthClient(ctx context.Context, arg GetOAuthClientParams) (GetOAuthClientRow, error)
+	GetPasswordResetToken(ctx context.Context, arg GetPasswordResetTokenParams) (GetPasswordResetTokenRow, error)
+	GetRefreshToken(ctx context.Context, arg GetRefreshTokenParams) (GetRefreshTokenRow, error)
+	GetRole(ctx context.Context, role string) (string, error)
+	GetTOTPCredential(ctx context.Context, arg GetTOTPCredentialParams) (GetTOTPCredentialRow, error)
+	GetUser(ctx context.Context, arg GetUserParams) (User, error)
+	ListAPIKeyPermissions(ctx context.Context, prefix string) ([]string, error)
+	ListAPIKeys(ctx context.Context, tenant string) ([]ListAPIKeysRow, error)
+	ListOAuthClientAudiences(ctx context.Context, arg ListOAuthClientAudiencesParams) ([]string, error)
+	ListOAuthClientRedirectURIs(ctx context.Context, arg ListOAuthClientRedirectURIsParams) ([]string, error)
+	ListOAuthClientScopes(ctx context.Context, arg ListOAuthClientScopesParams) ([]string, error)
+	ListRolePermissions(ctx context.Context, role string) ([]string, error)
+	ListRoles(ctx context.Context) ([]string, error)
+	ListServiceAccountAPIKeys(ctx context.Context, arg ListServiceAccountAPIKeysParams) ([]ListServiceAccountAPIKeysRow, error)
+	ListUnusedRecoveryCodes(ctx context.Context, arg ListUnusedRecoveryCodesParams) ([]string, error)
+	ListUserRoles(ctx context.Context, arg ListUserRolesParams) ([]string, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (int64, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeRole(ctx context.Context, arg RevokeRoleParams) (int64, error)
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	RevokeUserRefreshTokens(ctx context.Context, arg RevokeUserRefreshTokensParams) error
+	UpdatePasswordHash(ctx context.Context, arg UpdatePasswordHashParams) (int64, error)
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (int64, error)
+	UseAuthorizationCode(ctx context.Context, codeHash string) (int64, error)
+	UseMFAChallenge(ctx context.Context, tokenHash string) (int64, error)
+	UsePasswordResetToken(ctx context.Context, tokenHash string) (int64, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+	UseRefreshToken(ctx context.Context, tokenHash string) (int64, error)
+	UseTOTPCounter(ctx context.Context, arg UseTOTPCounterParams) (int64, error)
+	UseUserPasswordResetTokens(ctx context.Context, arg UseUserPasswordResetTokensParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,113 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/sqlite"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testAPIKeys(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	key := models.APIKey{
+		Prefix:         "ak_1",
+		Hash:           "hash1",
+		ServiceAccount: "billing",
+		Permissions:    []string{"users:read", "invoices:write"},
+		ExpiresAt:      expiresAt,
+	}
+	require.NoError(t, apiKeyStore.Create(ctx, key))
+	require.NoError(t, apiKeyStore.Create(ctx, models.APIKey{Prefix: "ak_2", Hash: "hash2", ServiceAccount: "billing"}))
+	require.NoError(t, apiKeyStore.Create(ctx, models.APIKey{Prefix: "ak_3", Hash: "hash3", ServiceAccount: "reports"}))
+	require.Error(t, apiKeyStore.Create(ctx, models.APIKey{Prefix: "ak_1", Hash: "hash4", ServiceAccount: "billing"}))
+
+	got, err := apiKeyStore.Get(ctx, "ak_1")
+	require.NoError(t, err)
+	require.Equal(t, "ak_1", got.Prefix)
+	require.Equal(t, "hash1", got.Hash)
+	require.Equal(t, "default", got.Tenant)
+	require.Equal(t, "billing", got.ServiceAccount)
+	require.Equal(t, []string{"invoices:write", "users:read"}, got.Permissions)
+	require.True(t, expiresAt.Equal(got.ExpiresAt))
+	require.False(t, got.Revoked)
+	require.False(t, got.CreatedAt.IsZero())
+
+	got, err = apiKeyStore.Get(ctx, "ak_2")
+	require.NoError(t, err)
+	require.True(t, got.ExpiresAt.IsZero())
+	require.Empty(t, got.Permissions)
+
+	got, err = apiKeyStore.Get(ctx, "unknown")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	keys, err := apiKeyStore.List(ctx, "billing")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	keys, err = apiKeyStore.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	require.Equal(t, "reports", keys[2].ServiceAccount)
+	keys, err = apiKeyStore.List(ctx, "unknown")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	revoked, err := apiKeyStore.Revoke(ctx, "ak_1")
+	require.NoError(t, err)
+	require.True(t, revoked)
+	revoked, err = apiKeyStore.Revoke(ctx, "ak_1")
+	require.NoError(t, err)
+	require.False(t, revoked)
+	revoked, err = apiKeyStore.Revoke(ctx, "unknown")
+	require.NoError(t, err)
+	require.False(t, revoked)
+	got, err = apiKeyStore.Get(ctx, "ak_1")
+	require.NoError(t, err)
+	require.True(t, got.Revoked)
+
+	// The keys of the service accounts of another tenant are not visible.
+	require.NoError(t, otherAPIKeyStore.Create(ctx, models.APIKey{Prefix: "ak_5", Hash: "hash5", ServiceAccount: "billing"}))
+	got, err = otherAPIKeyStore.Get(ctx, "ak_2")
+	require.NoError(t, err)
+	require.Nil(t, got)
+	keys, err = otherAPIKeyStore.List(ctx, "billing")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, "other", keys[0].Tenant)
+	revoked, err = otherAPIKeyStore.Revoke(ctx, "ak_2")
+	require.NoError(t, err)
+	require.False(t, revoked)
+	keys, err = apiKeyStore.List(ctx, "billing")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+}
+
+func TestSqliteAPIKeyStore_Create_rollback(t *testing.T) {
+	database, err := sqlite.OpenInMemory()
+	require.NoError(t, err)
+	defer database.Close()
+	ctx := context.Background()
+	store := NewSqliteAPIKeyStore(database, "default")
+
+	// The empty permission fails after the service account and the key are inserted.
+	err = store.Create(ctx, models.APIKey{Prefix: "ak_1", Hash: "hash1", ServiceAccount: "billing", Permissions: []string{"users:read", ""}})
+	require.Error(t, err)
+
+	got, err := store.Get(ctx, "ak_1")
+	require.NoError(t, err)
+	require.Nil(t, got)
+	var count int
+	require.NoError(t, database.QueryRowContext(ctx, "SELECT count(*) FROM service_accounts").Scan(&count))
+	require.Equal(t, 0, count)
+
+	require.NoError(t, store.Create(ctx, models.APIKey{Prefix: "ak_1", Hash: "hash1", ServiceAccount: "billing", Permissions: []string{"users:read"}}))
+	got, err = store.Get(ctx, "ak_1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"users:read"}, got.Permissions)
+}
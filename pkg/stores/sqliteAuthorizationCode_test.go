@@ -0,0 +1,65 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testAuthorizationCodes(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "fsdjak"}))
+	require.NoError(t, oauthClientStore.Create(ctx, models.OAuthClient{ID: "web", SecretHash: "hash"}))
+
+	authTime := time.Now().Truncate(time.Second)
+	expiresAt := authTime.Add(time.Minute)
+	code := models.AuthorizationCode{
+		Hash:          "hash1",
+		ClientID:      "web",
+		Username:      "test",
+		RedirectURI:   "https://web.example.com/callback",
+		Scopes:        []string{"openid", "email"},
+		Nonce:         "nonce",
+		CodeChallenge: "challenge",
+		AuthTime:      authTime,
+		ExpiresAt:     expiresAt,
+	}
+	require.NoError(t, authorizationCodeStore.Create(ctx, code))
+	unknownUser := code
+	unknownUser.Hash, unknownUser.Username = "hash2", "unknown"
+	require.Error(t, authorizationCodeStore.Create(ctx, unknownUser))
+	unknownClient := code
+	unknownClient.Hash, unknownClient.ClientID = "hash3", "unknown"
+	require.Error(t, authorizationCodeStore.Create(ctx, unknownClient))
+
+	got, err := authorizationCodeStore.Get(ctx, "hash1")
+	require.NoError(t, err)
+	require.True(t, authTime.Equal(got.AuthTime))
+	require.True(t, expiresAt.Equal(got.ExpiresAt))
+	got.AuthTime, got.ExpiresAt = code.AuthTime, code.ExpiresAt
+	require.Equal(t, code, *got)
+
+	got, err = authorizationCodeStore.Get(ctx, "unknown")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	// The codes of another tenant are not visible.
+	got, err = otherAuthorizationCodeStore.Get(ctx, "hash1")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	used, err := authorizationCodeStore.Use(ctx, "hash1")
+	require.NoError(t, err)
+	require.True(t, used)
+	used, err = authorizationCodeStore.Use(ctx, "hash1")
+	require.NoError(t, err)
+	require.False(t, used)
+	got, err = authorizationCodeStore.Get(ctx, "hash1")
+	require.NoError(t, err)
+	require.True(t, got.Used)
+}
@@ -0,0 +1,49 @@
+package stores
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testLoginFailures(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+
+	got, err := loginFailureStore.Get(ctx, "user:test")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	start := time.Now().Truncate(time.Second)
+	for i := 1; i <= 3; i++ {
+		failedAt := start.Add(time.Duration(i) * time.Second)
+		got, err = loginFailureStore.Add(ctx, "user:test", failedAt, start)
+		require.NoError(t, err)
+		require.Equal(t, i, got.Failures)
+		require.True(t, failedAt.Equal(got.LastFailure))
+	}
+	got, err = loginFailureStore.Get(ctx, "user:test")
+	require.NoError(t, err)
+	require.Equal(t, "user:test", got.Key)
+	require.Equal(t, 3, got.Failures)
+
+	// The failures before the time since are forgotten.
+	got, err = loginFailureStore.Add(ctx, "user:test", start.Add(time.Minute), start.Add(30*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, 1, got.Failures)
+
+	// The keys are counted separately.
+	got, err = loginFailureStore.Add(ctx, "ip:127.0.0.1", start, start)
+	require.NoError(t, err)
+	require.Equal(t, 1, got.Failures)
+
+	require.NoError(t, loginFailureStore.Reset(ctx, "user:test"))
+	got, err = loginFailureStore.Get(ctx, "user:test")
+	require.NoError(t, err)
+	require.Nil(t, got)
+	got, err = loginFailureStore.Get(ctx, "ip:127.0.0.1")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+}
@@ -0,0 +1,78 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/sqlite"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type SqliteMFAChallengeStore struct {
+	querier sqlite.Querier
+	tenant  string
+}
+
+// NewSqliteMFAChallengeStore creates a new instance of an MFAChallengeStore for a SQLite database,
+// scoped to the users of the tenant.
+func NewSqliteMFAChallengeStore(q sqlite.Querier, tenant string) MFAChallengeStore {
+	return &SqliteMFAChallengeStore{querier: q, tenant: tenant}
+}
+
+func (s *SqliteMFAChallengeStore) Create(ctx context.Context, challenge models.MFAChallenge) error {
+	err := s.querier.CreateMFAChallenge(ctx, sqlite.CreateMFAChallengeParams{
+		TokenHash: challenge.Hash,
+		Tenant:    s.tenant,
+		Username:  challenge.Username,
+		ExpiresAt: challenge.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating the MFA challenge for %s: %w", challenge.Username, err)
+	}
+
+	return nil
+}
+
+func (s *SqliteMFAChallengeStore) Get(ctx context.Context, hash string) (*models.MFAChallenge, error) {
+	c, err := s.querier.GetMFAChallenge(ctx, sqlite.GetMFAChallengeParams{
+		Tenant:    s.tenant,
+		TokenHash: hash,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the MFA challenge: %w", err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.MFAChallenge{
+		Hash:      c.TokenHash,
+		Username:  c.Username,
+		ExpiresAt: c.ExpiresAt,
+		Used:      c.Used,
+		Attempts:  int(c.Attempts),
+	}, nil
+}
+
+func (s *SqliteMFAChallengeStore) Attempt(ctx context.Context, hash string, maxAttempts int) (bool, error) {
+	n, err := s.querier.AttemptMFAChallenge(ctx, sqlite.AttemptMFAChallengeParams{
+		TokenHash:   hash,
+		MaxAttempts: int64(maxAttempts),
+	})
+	if err != nil {
+		return false, fmt.Errorf("error attempting the MFA challenge: %w", err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *SqliteMFAChallengeStore) Use(ctx context.Context, hash string) (bool, error) {
+	n, err := s.querier.UseMFAChallenge(ctx, hash)
+	if err != nil {
+		return false, fmt.Errorf("error using the MFA challenge: %w", err)
+	}
+
+	return n == 1, nil
+}
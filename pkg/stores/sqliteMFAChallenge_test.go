@@ -0,0 +1,59 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testMFAChallenges(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "fsdjak"}))
+
+	expiresAt := time.Now().Add(time.Minute).Truncate(time.Second)
+	challenge := models.MFAChallenge{Hash: "hash1", Username: "test", ExpiresAt: expiresAt}
+	require.NoError(t, mfaChallengeStore.Create(ctx, challenge))
+	require.Error(t, mfaChallengeStore.Create(ctx, models.MFAChallenge{Hash: "hash2", Username: "unknown", ExpiresAt: expiresAt}))
+
+	got, err := mfaChallengeStore.Get(ctx, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, challenge.Hash, got.Hash)
+	require.Equal(t, challenge.Username, got.Username)
+	require.True(t, expiresAt.Equal(got.ExpiresAt))
+	require.False(t, got.Used)
+	require.Equal(t, 0, got.Attempts)
+
+	got, err = mfaChallengeStore.Get(ctx, "unknown")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	for i := 0; i < 2; i++ {
+		attempted, err := mfaChallengeStore.Attempt(ctx, "hash1", 2)
+		require.NoError(t, err)
+		require.True(t, attempted)
+	}
+	attempted, err := mfaChallengeStore.Attempt(ctx, "hash1", 2)
+	require.NoError(t, err)
+	require.False(t, attempted)
+	got, err = mfaChallengeStore.Get(ctx, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, 2, got.Attempts)
+
+	used, err := mfaChallengeStore.Use(ctx, "hash1")
+	require.NoError(t, err)
+	require.True(t, used)
+	used, err = mfaChallengeStore.Use(ctx, "hash1")
+	require.NoError(t, err)
+	require.False(t, used)
+	attempted, err = mfaChallengeStore.Attempt(ctx, "hash1", 5)
+	require.NoError(t, err)
+	require.False(t, attempted)
+	got, err = mfaChallengeStore.Get(ctx, "hash1")
+	require.NoError(t, err)
+	require.True(t, got.Used)
+}
@@ -0,0 +1,121 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/sqlite"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type SqliteOAuthClientStore struct {
+	querier sqlite.Querier
+	tenant  string
+}
+
+// NewSqliteOAuthClientStore creates a new instance of an OAuthClientStore for a SQLite database,
+// scoped to the OAuth clients of the tenant.
+func NewSqliteOAuthClientStore(q sqlite.Querier, tenant string) OAuthClientStore {
+	return &SqliteOAuthClientStore{querier: q, tenant: tenant}
+}
+
+func (s *SqliteOAuthClientStore) Create(ctx context.Context, client models.OAuthClient) error {
+	err := s.querier.CreateOAuthClient(ctx, sqlite.CreateOAuthClientParams{
+		Tenant:     s.tenant,
+		ClientID:   client.ID,
+		SecretHash: client.SecretHash,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating the OAuth client %s: %w", client.ID, err)
+	}
+	for _, scope := range client.Scopes {
+		err := s.querier.AddOAuthClientScope(ctx, sqlite.AddOAuthClientScopeParams{
+			Tenant:   s.tenant,
+			ClientID: client.ID,
+			Scope:    scope,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding the scope %s to the OAuth client %s: %w", scope, client.ID, err)
+		}
+	}
+	for _, audience := range client.Audiences {
+		err := s.querier.AddOAuthClientAudience(ctx, sqlite.AddOAuthClientAudienceParams{
+			Tenant:   s.tenant,
+			ClientID: client.ID,
+			Audience: audience,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding the audience %s to the OAuth client %s: %w", audience, client.ID, err)
+		}
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		err := s.querier.AddOAuthClientRedirectURI(ctx, sqlite.AddOAuthClientRedirectURIParams{
+			Tenant:      s.tenant,
+			ClientID:    client.ID,
+			RedirectUri: redirectURI,
+		})
+		if err != nil {
+			return fmt.Errorf("error adding the redirect URI %s to the OAuth client %s: %w", redirectURI, client.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *SqliteOAuthClientStore) Get(ctx context.Context, id string) (*models.OAuthClient, error) {
+	c, err := s.querier.GetOAuthClient(ctx, sqlite.GetOAuthClientParams{
+		Tenant:   s.tenant,
+		ClientID: id,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the OAuth client %s: %w", id, err)
+		} else {
+			return nil, nil
+		}
+	}
+	scopes, err := s.querier.ListOAuthClientScopes(ctx, sqlite.ListOAuthClientScopesParams{
+		Tenant:   s.tenant,
+		ClientID: id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the scopes of the OAuth client %s: %w", id, err)
+	}
+	audiences, err := s.querier.ListOAuthClientAudiences(ctx, sqlite.ListOAuthClientAudiencesParams{
+		Tenant:   s.tenant,
+		ClientID: id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the audiences of the OAuth client %s: %w", id, err)
+	}
+	redirectURIs, err := s.querier.ListOAuthClientRedirectURIs(ctx, sqlite.ListOAuthClientRedirectURIsParams{
+		Tenant:   s.tenant,
+		ClientID: id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the redirect URIs of the OAuth client %s: %w", id, err)
+	}
+
+	return &models.OAuthClient{
+		ID:           c.ClientID,
+		SecretHash:   c.SecretHash,
+		Tenant:       s.tenant,
+		Scopes:       scopes,
+		Audiences:    audiences,
+		RedirectURIs: redirectURIs,
+		CreatedAt:    c.CreatedAt,
+	}, nil
+}
+
+func (s *SqliteOAuthClientStore) Delete(ctx context.Context, id string) (bool, error) {
+	n, err := s.querier.DeleteOAuthClient(ctx, sqlite.DeleteOAuthClientParams{
+		Tenant:   s.tenant,
+		ClientID: id,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error deleting the OAuth client %s: %w", id, err)
+	}
+
+	return n == 1, nil
+}
@@ -0,0 +1,79 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func testOAuthClients(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+
+	client := models.OAuthClient{
+		ID:           "billing",
+		SecretHash:   "hash1",
+		Scopes:       []string{"users:read", "invoices:write"},
+		Audiences:    []string{"https://billing.example.com"},
+		RedirectURIs: []string{"https://billing.example.com/callback", "http://localhost:8080/callback"},
+	}
+	require.NoError(t, oauthClientStore.Create(ctx, client))
+	require.NoError(t, oauthClientStore.Create(ctx, models.OAuthClient{ID: "reports", SecretHash: "hash2"}))
+	require.Error(t, oauthClientStore.Create(ctx, models.OAuthClient{ID: "billing", SecretHash: "hash3"}))
+
+	got, err := oauthClientStore.Get(ctx, "billing")
+	require.NoError(t, err)
+	require.Equal(t, "billing", got.ID)
+	require.Equal(t, "hash1", got.SecretHash)
+	require.Equal(t, "default", got.Tenant)
+	require.Equal(t, []string{"invoices:write", "users:read"}, got.Scopes)
+	require.Equal(t, []string{"https://billing.example.com"}, got.Audiences)
+	require.Equal(t, []string{"http://localhost:8080/callback", "https://billing.example.com/callback"}, got.RedirectURIs)
+	require.False(t, got.CreatedAt.IsZero())
+
+	got, err = oauthClientStore.Get(ctx, "reports")
+	require.NoError(t, err)
+	require.Empty(t, got.Scopes)
+	require.Empty(t, got.Audiences)
+	require.Empty(t, got.RedirectURIs)
+
+	got, err = oauthClientStore.Get(ctx, "unknown")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	// The clients of another tenant are not visible.
+	require.NoError(t, otherOAuthClientStore.Create(ctx, models.OAuthClient{ID: "billing", SecretHash: "hash4", Scopes: []string{"users:write"}}))
+	got, err = otherOAuthClientStore.Get(ctx, "reports")
+	require.NoError(t, err)
+	require.Nil(t, got)
+	got, err = otherOAuthClientStore.Get(ctx, "billing")
+	require.NoError(t, err)
+	require.Equal(t, "hash4", got.SecretHash)
+	require.Equal(t, []string{"users:write"}, got.Scopes)
+	deleted, err := otherOAuthClientStore.Delete(ctx, "reports")
+	require.NoError(t, err)
+	require.False(t, deleted)
+
+	deleted, err = oauthClientStore.Delete(ctx, "billing")
+	require.NoError(t, err)
+	require.True(t, deleted)
+	deleted, err = oauthClientStore.Delete(ctx, "billing")
+	require.NoError(t, err)
+	require.False(t, deleted)
+	got, err = oauthClientStore.Get(ctx, "billing")
+	require.NoError(t, err)
+	require.Nil(t, got)
+	got, err = otherOAuthClientStore.Get(ctx, "billing")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+
+	// The scopes and audiences of a deleted client are deleted with it.
+	require.NoError(t, oauthClientStore.Create(ctx, models.OAuthClient{ID: "billing", SecretHash: "hash5"}))
+	got, err = oauthClientStore.Get(ctx, "billing")
+	require.NoError(t, err)
+	require.Empty(t, got.Scopes)
+	require.Empty(t, got.Audiences)
+	require.Empty(t, got.RedirectURIs)
+}
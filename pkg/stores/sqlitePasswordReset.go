@@ -0,0 +1,76 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/sqlite"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type SqlitePasswordResetStore struct {
+	db      sqlite.DBTX
+	querier sqlite.Querier
+	tenant  string
+}
+
+// NewSqlitePasswordResetStore creates a new instance of a PasswordResetStore for the SQLite database or transaction db,
+// scoped to the users of the tenant.
+func NewSqlitePasswordResetStore(db sqlite.DBTX, tenant string) PasswordResetStore {
+	return &SqlitePasswordResetStore{db: db, querier: sqlite.New(db), tenant: tenant}
+}
+
+func (s *SqlitePasswordResetStore) Create(ctx context.Context, token models.PasswordResetToken) error {
+	return withTx(ctx, s.db, func(tx sqlite.DBTX) error {
+		q := sqlite.New(tx)
+		err := q.UseUserPasswordResetTokens(ctx, sqlite.UseUserPasswordResetTokensParams{
+			Tenant:   s.tenant,
+			Username: token.Username,
+		})
+		if err != nil {
+			return fmt.Errorf("error invalidating the password reset tokens of %s: %w", token.Username, err)
+		}
+		err = q.CreatePasswordResetToken(ctx, sqlite.CreatePasswordResetTokenParams{
+			TokenHash: token.Hash,
+			Tenant:    s.tenant,
+			Username:  token.Username,
+			ExpiresAt: token.ExpiresAt.UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("error creating the password reset token for %s: %w", token.Username, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *SqlitePasswordResetStore) Get(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	t, err := s.querier.GetPasswordResetToken(ctx, sqlite.GetPasswordResetTokenParams{
+		Tenant:    s.tenant,
+		TokenHash: hash,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the password reset token: %w", err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.PasswordResetToken{
+		Hash:      t.TokenHash,
+		Username:  t.Username,
+		ExpiresAt: t.ExpiresAt,
+		Used:      t.Used,
+	}, nil
+}
+
+func (s *SqlitePasswordResetStore) Use(ctx context.Context, hash string) (bool, error) {
+	n, err := s.querier.UsePasswordResetToken(ctx, hash)
+	if err != nil {
+		return false, fmt.Errorf("error using the password reset token: %w", err)
+	}
+
+	return n == 1, nil
+}
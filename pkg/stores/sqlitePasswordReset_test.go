@@ -0,0 +1,58 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testPasswordResets(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "fsdjak"}))
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	token := models.PasswordResetToken{Hash: "hash1", Username: "test", ExpiresAt: expiresAt}
+	require.NoError(t, passwordResetStore.Create(ctx, token))
+	require.Error(t, passwordResetStore.Create(ctx, models.PasswordResetToken{Hash: "hash2", Username: "unknown", ExpiresAt: expiresAt}))
+
+	got, err := passwordResetStore.Get(ctx, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, token.Hash, got.Hash)
+	require.Equal(t, token.Username, got.Username)
+	require.True(t, expiresAt.Equal(got.ExpiresAt))
+	require.False(t, got.Used)
+
+	got, err = passwordResetStore.Get(ctx, "unknown")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	used, err := passwordResetStore.Use(ctx, "hash1")
+	require.NoError(t, err)
+	require.True(t, used)
+	used, err = passwordResetStore.Use(ctx, "hash1")
+	require.NoError(t, err)
+	require.False(t, used)
+	got, err = passwordResetStore.Get(ctx, "hash1")
+	require.NoError(t, err)
+	require.True(t, got.Used)
+
+	// A new token invalidates the previous tokens of the user, but not the tokens of the other users.
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "other", Password: "fsdjak"}))
+	require.NoError(t, passwordResetStore.Create(ctx, models.PasswordResetToken{Hash: "hash3", Username: "test", ExpiresAt: expiresAt}))
+	require.NoError(t, passwordResetStore.Create(ctx, models.PasswordResetToken{Hash: "hash4", Username: "other", ExpiresAt: expiresAt}))
+	require.NoError(t, passwordResetStore.Create(ctx, models.PasswordResetToken{Hash: "hash5", Username: "test", ExpiresAt: expiresAt}))
+	got, err = passwordResetStore.Get(ctx, "hash3")
+	require.NoError(t, err)
+	require.True(t, got.Used)
+	got, err = passwordResetStore.Get(ctx, "hash4")
+	require.NoError(t, err)
+	require.False(t, got.Used)
+	got, err = passwordResetStore.Get(ctx, "hash5")
+	require.NoError(t, err)
+	require.False(t, got.Used)
+}
@@ -0,0 +1,84 @@
+package stores
+
+import (
+	"auth/pkg/stores/sqlite"
+	"context"
+	"fmt"
+)
+
+type SqliteRecoveryCodeStore struct {
+	db      sqlite.DBTX
+	querier sqlite.Querier
+	tenant  string
+}
+
+// NewSqliteRecoveryCodeStore creates a new instance of a RecoveryCodeStore for the SQLite database or transaction db,
+// scoped to the users of the tenant.
+func NewSqliteRecoveryCodeStore(db sqlite.DBTX, tenant string) RecoveryCodeStore {
+	return &SqliteRecoveryCodeStore{db: db, querier: sqlite.New(db), tenant: tenant}
+}
+
+func (s *SqliteRecoveryCodeStore) Replace(ctx context.Context, username, batchID string, hashes []string) error {
+	// The previous codes are only deleted with all the new ones stored, a failure doesn't leave the user without codes.
+	return withTx(ctx, s.db, func(tx sqlite.DBTX) error {
+		q := sqlite.New(tx)
+		for _, hash := range hashes {
+			err := q.CreateRecoveryCode(ctx, sqlite.CreateRecoveryCodeParams{
+				Tenant:   s.tenant,
+				Username: username,
+				BatchID:  batchID,
+				CodeHash: hash,
+			})
+			if err != nil {
+				return fmt.Errorf("error creating the recovery codes for %s: %w", username, err)
+			}
+		}
+		err := q.DeleteOtherRecoveryCodes(ctx, sqlite.DeleteOtherRecoveryCodesParams{
+			Tenant:   s.tenant,
+			Username: username,
+			BatchID:  batchID,
+		})
+		if err != nil {
+			return fmt.Errorf("error deleting the previous recovery codes of %s: %w", username, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *SqliteRecoveryCodeStore) ListUnused(ctx context.Context, username string) ([]string, error) {
+	hashes, err := s.querier.ListUnusedRecoveryCodes(ctx, sqlite.ListUnusedRecoveryCodesParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the recovery codes of %s: %w", username, err)
+	}
+
+	return hashes, nil
+}
+
+func (s *SqliteRecoveryCodeStore) Use(ctx context.Context, username, hash string) (bool, error) {
+	n, err := s.querier.UseRecoveryCode(ctx, sqlite.UseRecoveryCodeParams{
+		Tenant:   s.tenant,
+		Username: username,
+		CodeHash: hash,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error using the recovery code of %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *SqliteRecoveryCodeStore) CountUnused(ctx context.Context, username string) (int, error) {
+	n, err := s.querier.CountUnusedRecoveryCodes(ctx, sqlite.CountUnusedRecoveryCodesParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error counting the recovery codes of %s: %w", username, err)
+	}
+
+	return int(n), nil
+}
@@ -0,0 +1,79 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/sqlite"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func testRecoveryCodes(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "fsdjak"}))
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "other", Password: "fsdjak"}))
+	require.Error(t, recoveryCodeStore.Replace(ctx, "unknown", "batch1", []string{"hash"}))
+
+	hashes, err := recoveryCodeStore.ListUnused(ctx, "test")
+	require.NoError(t, err)
+	require.Empty(t, hashes)
+
+	require.NoError(t, recoveryCodeStore.Replace(ctx, "test", "batch1", []string{"hash1", "hash2", "hash3"}))
+	require.NoError(t, recoveryCodeStore.Replace(ctx, "other", "batch1", []string{"other1"}))
+	hashes, err = recoveryCodeStore.ListUnused(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2", "hash3"}, hashes)
+
+	used, err := recoveryCodeStore.Use(ctx, "test", "hash2")
+	require.NoError(t, err)
+	require.True(t, used)
+	used, err = recoveryCodeStore.Use(ctx, "test", "hash2")
+	require.NoError(t, err)
+	require.False(t, used)
+	// A code of another user is not used.
+	used, err = recoveryCodeStore.Use(ctx, "test", "other1")
+	require.NoError(t, err)
+	require.False(t, used)
+
+	hashes, err = recoveryCodeStore.ListUnused(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash3"}, hashes)
+	count, err := recoveryCodeStore.CountUnused(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	// A new batch replaces the previous one.
+	require.NoError(t, recoveryCodeStore.Replace(ctx, "test", "batch2", []string{"hash4", "hash5"}))
+	hashes, err = recoveryCodeStore.ListUnused(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash4", "hash5"}, hashes)
+	used, err = recoveryCodeStore.Use(ctx, "test", "hash1")
+	require.NoError(t, err)
+	require.False(t, used)
+	count, err = recoveryCodeStore.CountUnused(ctx, "other")
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	count, err = recoveryCodeStore.CountUnused(ctx, "unknown")
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+}
+
+func TestSqliteRecoveryCodeStore_Replace_rollback(t *testing.T) {
+	database, err := sqlite.OpenInMemory()
+	require.NoError(t, err)
+	defer database.Close()
+	ctx := context.Background()
+	require.NoError(t, NewSqliteUserStore(sqlite.New(database), "default").Create(ctx, models.User{Username: "test", Password: "fsdjak"}))
+	store := NewSqliteRecoveryCodeStore(database, "default")
+	require.NoError(t, store.Replace(ctx, "test", "batch1", []string{"hash1", "hash2"}))
+
+	// The empty hash fails after the first code of the new batch is inserted.
+	require.Error(t, store.Replace(ctx, "test", "batch2", []string{"hash3", ""}))
+
+	hashes, err := store.ListUnused(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash1", "hash2"}, hashes)
+}
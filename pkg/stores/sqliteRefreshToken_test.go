@@ -0,0 +1,65 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testRefreshTokens(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "fsdjak"}))
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	token := models.RefreshToken{Hash: "hash1", FamilyID: "family", Username: "test", ExpiresAt: expiresAt}
+	require.NoError(t, refreshTokenStore.Create(ctx, token))
+	require.NoError(t, refreshTokenStore.Create(ctx, models.RefreshToken{Hash: "hash2", FamilyID: "family", Username: "test", ExpiresAt: expiresAt}))
+	require.Error(t, refreshTokenStore.Create(ctx, models.RefreshToken{Hash: "hash3", FamilyID: "family", Username: "unknown", ExpiresAt: expiresAt}))
+
+	got, err := refreshTokenStore.Get(ctx, "hash1")
+	require.NoError(t, err)
+	require.Equal(t, token.Hash, got.Hash)
+	require.Equal(t, token.FamilyID, got.FamilyID)
+	require.Equal(t, token.Username, got.Username)
+	require.True(t, expiresAt.Equal(got.ExpiresAt))
+	require.False(t, got.Used)
+	require.False(t, got.Revoked)
+
+	got, err = refreshTokenStore.Get(ctx, "unknown")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	used, err := refreshTokenStore.Use(ctx, "hash1")
+	require.NoError(t, err)
+	require.True(t, used)
+	used, err = refreshTokenStore.Use(ctx, "hash1")
+	require.NoError(t, err)
+	require.False(t, used)
+	got, err = refreshTokenStore.Get(ctx, "hash1")
+	require.NoError(t, err)
+	require.True(t, got.Used)
+
+	require.NoError(t, refreshTokenStore.RevokeFamily(ctx, "family"))
+	got, err = refreshTokenStore.Get(ctx, "hash2")
+	require.NoError(t, err)
+	require.True(t, got.Revoked)
+	used, err = refreshTokenStore.Use(ctx, "hash2")
+	require.NoError(t, err)
+	require.False(t, used)
+
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "other", Password: "fsdjak"}))
+	require.NoError(t, refreshTokenStore.Create(ctx, models.RefreshToken{Hash: "hash4", FamilyID: "family2", Username: "test", ExpiresAt: expiresAt}))
+	require.NoError(t, refreshTokenStore.Create(ctx, models.RefreshToken{Hash: "hash5", FamilyID: "family3", Username: "other", ExpiresAt: expiresAt}))
+	require.NoError(t, refreshTokenStore.RevokeUser(ctx, "test"))
+	got, err = refreshTokenStore.Get(ctx, "hash4")
+	require.NoError(t, err)
+	require.True(t, got.Revoked)
+	got, err = refreshTokenStore.Get(ctx, "hash5")
+	require.NoError(t, err)
+	require.False(t, got.Revoked)
+}
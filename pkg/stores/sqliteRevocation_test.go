@@ -0,0 +1,38 @@
+package stores
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testRevocations(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	require.NoError(t, revocationStore.Revoke(ctx, "expired", now.Add(-time.Minute)))
+	require.NoError(t, revocationStore.Revoke(ctx, "active", now.Add(time.Hour)))
+	// Revoking a token twice is not an error.
+	require.NoError(t, revocationStore.Revoke(ctx, "active", now.Add(time.Hour)))
+
+	revoked, err := revocationStore.IsRevoked(ctx, "active")
+	require.NoError(t, err)
+	require.True(t, revoked)
+	revoked, err = revocationStore.IsRevoked(ctx, "unknown")
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	n, err := revocationStore.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	revoked, err = revocationStore.IsRevoked(ctx, "expired")
+	require.NoError(t, err)
+	require.False(t, revoked)
+	revoked, err = revocationStore.IsRevoked(ctx, "active")
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
@@ -0,0 +1,71 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func testRoles(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "fsdjak"}))
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "other", Password: "fsdjak"}))
+
+	role, err := roleStore.Get(ctx, "admin")
+	require.NoError(t, err)
+	require.Nil(t, role)
+
+	require.NoError(t, roleStore.Define(ctx, models.Role{Name: "admin", Permissions: []string{"users:write", "users:read"}}))
+	require.NoError(t, roleStore.Define(ctx, models.Role{Name: "viewer", Permissions: []string{"users:read"}}))
+	require.NoError(t, roleStore.Define(ctx, models.Role{Name: "guest"}))
+	role, err = roleStore.Get(ctx, "admin")
+	require.NoError(t, err)
+	require.Equal(t, &models.Role{Name: "admin", Permissions: []string{"users:read", "users:write"}}, role)
+
+	// Defining a role again replaces its permissions.
+	require.NoError(t, roleStore.Define(ctx, models.Role{Name: "admin", Permissions: []string{"users:read", "users:delete"}}))
+	roles, err := roleStore.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []models.Role{
+		{Name: "admin", Permissions: []string{"users:delete", "users:read"}},
+		{Name: "guest"},
+		{Name: "viewer", Permissions: []string{"users:read"}},
+	}, roles)
+
+	roles, err = roleStore.ListByUser(ctx, "test")
+	require.NoError(t, err)
+	require.Empty(t, roles)
+
+	require.NoError(t, roleStore.Assign(ctx, "test", "viewer"))
+	require.NoError(t, roleStore.Assign(ctx, "test", "admin"))
+	require.NoError(t, roleStore.Assign(ctx, "test", "admin"))
+	require.NoError(t, roleStore.Assign(ctx, "other", "guest"))
+	require.Error(t, roleStore.Assign(ctx, "unknown", "admin"))
+	require.Error(t, roleStore.Assign(ctx, "test", "unknown"))
+	roles, err = roleStore.ListByUser(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, []models.Role{
+		{Name: "admin", Permissions: []string{"users:delete", "users:read"}},
+		{Name: "viewer", Permissions: []string{"users:read"}},
+	}, roles)
+
+	revoked, err := roleStore.Revoke(ctx, "test", "admin")
+	require.NoError(t, err)
+	require.True(t, revoked)
+	revoked, err = roleStore.Revoke(ctx, "test", "admin")
+	require.NoError(t, err)
+	require.False(t, revoked)
+	revoked, err = roleStore.Revoke(ctx, "test", "guest")
+	require.NoError(t, err)
+	require.False(t, revoked)
+	roles, err = roleStore.ListByUser(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, []models.Role{{Name: "viewer", Permissions: []string{"users:read"}}}, roles)
+	roles, err = roleStore.ListByUser(ctx, "other")
+	require.NoError(t, err)
+	require.Equal(t, []models.Role{{Name: "guest"}}, roles)
+}
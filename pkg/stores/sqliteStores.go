@@ -0,0 +1,79 @@
+package stores
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/stores/sqlite"
+	"database/sql"
+	"io"
+)
+
+func init() {
+	open := func(configuration config.Database) (*sql.DB, error) {
+		return sqlite.Open(configuration.Path)
+	}
+	Register("sqlite", func(configuration config.Database) (Stores, io.Closer, error) {
+		db, err := openMigrated(configuration, open, sqlite.NewMigrator)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewSqliteStores(db), db, nil
+	})
+	RegisterMigrations("sqlite", openMigrator(open, sqlite.NewMigrator))
+}
+
+type SqliteStores struct {
+	db sqlite.DBTX
+}
+
+// NewSqliteStores creates a new instance of Stores for the SQLite database or transaction db.
+func NewSqliteStores(db sqlite.DBTX) Stores {
+	return &SqliteStores{db: db}
+}
+
+func (s *SqliteStores) UserStore(tenant string) UserStore {
+	return NewSqliteUserStore(sqlite.New(s.db), tenant)
+}
+
+func (s *SqliteStores) RefreshTokenStore(tenant string) RefreshTokenStore {
+	return NewSqliteRefreshTokenStore(sqlite.New(s.db), tenant)
+}
+
+func (s *SqliteStores) RevocationStore() RevocationStore {
+	return NewSqliteRevocationStore(sqlite.New(s.db))
+}
+
+func (s *SqliteStores) PasswordResetStore(tenant string) PasswordResetStore {
+	return NewSqlitePasswordResetStore(s.db, tenant)
+}
+
+func (s *SqliteStores) LoginFailureStore(tenant string) LoginFailureStore {
+	return NewSqliteLoginFailureStore(sqlite.New(s.db), tenant)
+}
+
+func (s *SqliteStores) TOTPStore(tenant string) TOTPStore {
+	return NewSqliteTOTPStore(sqlite.New(s.db), tenant)
+}
+
+func (s *SqliteStores) MFAChallengeStore(tenant string) MFAChallengeStore {
+	return NewSqliteMFAChallengeStore(sqlite.New(s.db), tenant)
+}
+
+func (s *SqliteStores) RecoveryCodeStore(tenant string) RecoveryCodeStore {
+	return NewSqliteRecoveryCodeStore(s.db, tenant)
+}
+
+func (s *SqliteStores) RoleStore(tenant string) RoleStore {
+	return NewSqliteRoleStore(sqlite.New(s.db), tenant)
+}
+
+func (s *SqliteStores) APIKeyStore(tenant string) APIKeyStore {
+	return NewSqliteAPIKeyStore(s.db, tenant)
+}
+
+func (s *SqliteStores) OAuthClientStore(tenant string) OAuthClientStore {
+	return NewSqliteOAuthClientStore(sqlite.New(s.db), tenant)
+}
+
+func (s *SqliteStores) AuthorizationCodeStore(tenant string) AuthorizationCodeStore {
+	return NewSqliteAuthorizationCodeStore(sqlite.New(s.db), tenant)
+}
@@ -0,0 +1,81 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"auth/pkg/stores/sqlite"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type SqliteTOTPStore struct {
+	querier sqlite.Querier
+	tenant  string
+}
+
+// NewSqliteTOTPStore creates a new instance of a TOTPStore for a SQLite database,
+// scoped to the users of the tenant.
+func NewSqliteTOTPStore(q sqlite.Querier, tenant string) TOTPStore {
+	return &SqliteTOTPStore{querier: q, tenant: tenant}
+}
+
+func (s *SqliteTOTPStore) Enroll(ctx context.Context, username, secret string) (bool, error) {
+	n, err := s.querier.EnrollTOTPCredential(ctx, sqlite.EnrollTOTPCredentialParams{
+		Tenant:   s.tenant,
+		Username: username,
+		Secret:   secret,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error enrolling the TOTP credential of %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *SqliteTOTPStore) Get(ctx context.Context, username string) (*models.TOTPCredential, error) {
+	c, err := s.querier.GetTOTPCredential(ctx, sqlite.GetTOTPCredentialParams{
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("error getting the TOTP credential of %s: %w", username, err)
+		} else {
+			return nil, nil
+		}
+	}
+
+	return &models.TOTPCredential{
+		Username:    c.Username,
+		Secret:      c.Secret,
+		Confirmed:   c.Confirmed,
+		LastCounter: c.LastCounter,
+	}, nil
+}
+
+func (s *SqliteTOTPStore) Confirm(ctx context.Context, username string, counter int64) (bool, error) {
+	n, err := s.querier.ConfirmTOTPCredential(ctx, sqlite.ConfirmTOTPCredentialParams{
+		Counter:  counter,
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error confirming the TOTP credential of %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
+
+func (s *SqliteTOTPStore) UseCounter(ctx context.Context, username string, counter int64) (bool, error) {
+	n, err := s.querier.UseTOTPCounter(ctx, sqlite.UseTOTPCounterParams{
+		Counter:  counter,
+		Tenant:   s.tenant,
+		Username: username,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error using the TOTP code of %s: %w", username, err)
+	}
+
+	return n == 1, nil
+}
@@ -0,0 +1,68 @@
+package stores
+
+import (
+	"auth/pkg/models"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func testTOTP(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "fsdjak"}))
+	_, err := totpStore.Enroll(ctx, "unknown", "secret")
+	require.Error(t, err)
+
+	got, err := totpStore.Get(ctx, "test")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	enrolled, err := totpStore.Enroll(ctx, "test", "secret1")
+	require.NoError(t, err)
+	require.True(t, enrolled)
+	// An unconfirmed credential is replaced by a new enrollment.
+	enrolled, err = totpStore.Enroll(ctx, "test", "secret2")
+	require.NoError(t, err)
+	require.True(t, enrolled)
+	got, err = totpStore.Get(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, &models.TOTPCredential{Username: "test", Secret: "secret2"}, got)
+
+	// The codes are only accepted once the credential is confirmed.
+	used, err := totpStore.UseCounter(ctx, "test", 10)
+	require.NoError(t, err)
+	require.False(t, used)
+
+	confirmed, err := totpStore.Confirm(ctx, "test", 10)
+	require.NoError(t, err)
+	require.True(t, confirmed)
+	confirmed, err = totpStore.Confirm(ctx, "test", 11)
+	require.NoError(t, err)
+	require.False(t, confirmed)
+	got, err = totpStore.Get(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, &models.TOTPCredential{Username: "test", Secret: "secret2", Confirmed: true, LastCounter: 10}, got)
+
+	// A confirmed credential is not replaced.
+	enrolled, err = totpStore.Enroll(ctx, "test", "secret3")
+	require.NoError(t, err)
+	require.False(t, enrolled)
+
+	// A time step can only be used once.
+	used, err = totpStore.UseCounter(ctx, "test", 10)
+	require.NoError(t, err)
+	require.False(t, used)
+	used, err = totpStore.UseCounter(ctx, "test", 11)
+	require.NoError(t, err)
+	require.True(t, used)
+	used, err = totpStore.UseCounter(ctx, "test", 11)
+	require.NoError(t, err)
+	require.False(t, used)
+	got, err = totpStore.Get(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, int64(11), got.LastCounter)
+	require.Equal(t, "secret2", got.Secret)
+}
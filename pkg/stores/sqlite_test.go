@@ -1,17 +1,39 @@
 package stores
 
 import (
+	"auth/pkg/config"
+	autherrors "auth/pkg/errors"
 	"auth/pkg/models"
 	"auth/pkg/stores/sqlite"
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 )
 
 var (
-	userStore UserStore
+	userStore              UserStore
+	refreshTokenStore      RefreshTokenStore
+	revocationStore        RevocationStore
+	passwordResetStore     PasswordResetStore
+	loginFailureStore      LoginFailureStore
+	totpStore              TOTPStore
+	mfaChallengeStore      MFAChallengeStore
+	recoveryCodeStore      RecoveryCodeStore
+	roleStore              RoleStore
+	apiKeyStore            APIKeyStore
+	oauthClientStore       OAuthClientStore
+	authorizationCodeStore AuthorizationCodeStore
+	// The stores of another tenant sharing the database.
+	otherUserStore              UserStore
+	otherRefreshTokenStore      RefreshTokenStore
+	otherAPIKeyStore            APIKeyStore
+	otherOAuthClientStore       OAuthClientStore
+	otherAuthorizationCodeStore AuthorizationCodeStore
 )
 
 func setupSqlite(t testing.TB) func(t testing.TB) {
@@ -23,7 +45,23 @@ func setupSqlite(t testing.TB) func(t testing.TB) {
 	if err != nil {
 		t.Fatalf("an error %v was not expected when opening a stub database transaction", err)
 	}
-	userStore = NewSqliteUserStore(sqlite.New(tx))
+	userStore = NewSqliteUserStore(sqlite.New(tx), "default")
+	refreshTokenStore = NewSqliteRefreshTokenStore(sqlite.New(tx), "default")
+	revocationStore = NewSqliteRevocationStore(sqlite.New(tx))
+	passwordResetStore = NewSqlitePasswordResetStore(tx, "default")
+	loginFailureStore = NewSqliteLoginFailureStore(sqlite.New(tx), "default")
+	totpStore = NewSqliteTOTPStore(sqlite.New(tx), "default")
+	mfaChallengeStore = NewSqliteMFAChallengeStore(sqlite.New(tx), "default")
+	recoveryCodeStore = NewSqliteRecoveryCodeStore(tx, "default")
+	roleStore = NewSqliteRoleStore(sqlite.New(tx), "default")
+	apiKeyStore = NewSqliteAPIKeyStore(tx, "default")
+	oauthClientStore = NewSqliteOAuthClientStore(sqlite.New(tx), "default")
+	authorizationCodeStore = NewSqliteAuthorizationCodeStore(sqlite.New(tx), "default")
+	otherUserStore = NewSqliteUserStore(sqlite.New(tx), "other")
+	otherRefreshTokenStore = NewSqliteRefreshTokenStore(sqlite.New(tx), "other")
+	otherAPIKeyStore = NewSqliteAPIKeyStore(tx, "other")
+	otherOAuthClientStore = NewSqliteOAuthClientStore(sqlite.New(tx), "other")
+	otherAuthorizationCodeStore = NewSqliteAuthorizationCodeStore(sqlite.New(tx), "other")
 
 	return func(t testing.TB) {
 		tx.Rollback()
@@ -39,12 +77,109 @@ func openSqliteDb() (*sql.DB, error) {
 	return database, nil
 }
 
-func TestSqliteUserStore_Create(t *testing.T) {
-	testCreateUser(t, setupSqlite)
+func TestSqliteStores(t *testing.T) {
+	for _, tc := range storeTests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, setupSqlite)
+		})
+	}
 }
 
-func TestSqliteUserStore_Get(t *testing.T) {
-	testGetUser(t, setupSqlite)
+func TestSqliteMigrations(t *testing.T) {
+	database, err := openSqliteDb()
+	require.NoError(t, err)
+	defer database.Close()
+	ctx := context.Background()
+	migrator, err := sqlite.NewMigrator(database)
+	require.NoError(t, err)
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, len(migrator.Migrations()), status.Version)
+	require.Empty(t, status.Pending)
+
+	// Each migration is reverted, then applied again.
+	for i := len(migrator.Migrations()); i > 0; i-- {
+		reverted, err := migrator.Down(ctx)
+		require.NoError(t, err)
+		require.Equal(t, i, reverted.Version)
+	}
+	var tables int
+	require.NoError(t, database.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'users'").Scan(&tables))
+	require.Zero(t, tables)
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, len(migrator.Migrations()))
+
+	s := NewSqliteUserStore(sqlite.New(database), "default")
+	require.NoError(t, s.Create(ctx, models.User{Username: "test", Password: "hash"}))
+}
+
+// legacySqliteSchema is the schema created before the migrations.
+const legacySqliteSchema = `
+CREATE TABLE users
+(
+    id            INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    username      text NOT NULL CHECK(username <> ''),
+    password_hash text NOT NULL CHECK(password_hash <> ''),
+    UNIQUE(username)
+);
+
+CREATE INDEX username_idx ON users (username);
+
+CREATE TABLE version
+(
+    version text NOT NULL DEFAULT '0.0.0'
+);
+
+INSERT into version
+VALUES ('0.1');`
+
+func TestSqliteMigrations_legacy_schema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.db")
+	database, err := sqlite.Open(path)
+	require.NoError(t, err)
+	_, err = database.Exec(legacySqliteSchema)
+	require.NoError(t, err)
+	_, err = database.Exec("INSERT INTO users (username, password_hash) VALUES ('test', 'hash'), ('other', 'otherhash')")
+	require.NoError(t, err)
+	require.NoError(t, database.Close())
+
+	s, closer, err := Open(config.Database{Type: "sqlite", Path: path})
+	require.NoError(t, err)
+	defer closer.Close()
+	ctx := context.Background()
+
+	// The users are kept in the default tenant, with the columns added since.
+	user, err := s.UserStore("default").Get(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, &models.User{Tenant: "default", Username: "test", Password: "hash"}, user)
+	require.NoError(t, s.UserStore("other").Create(ctx, models.User{Username: "test", Password: "hash"}))
+	require.ErrorIs(t, s.UserStore("default").Create(ctx, models.User{Username: "other", Password: "hash"}), autherrors.UsernameAlreadyExistErr{Name: "other"})
+	found, err := s.UserStore("default").Update(ctx, "test", models.User{Username: "test", Password: "hash", Email: "test@example.com", TokenGeneration: 1})
+	require.NoError(t, err)
+	require.True(t, found)
+
+	// The tables added since are created.
+	require.NoError(t, s.RefreshTokenStore("default").Create(ctx, models.RefreshToken{Hash: "hash", FamilyID: "family", Username: "test", ExpiresAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, s.RevocationStore().Revoke(ctx, "jti", time.Now().Add(time.Hour)))
+	_, err = s.LoginFailureStore("default").Add(ctx, "test", time.Now(), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	enrolled, err := s.TOTPStore("default").Enroll(ctx, "test", "secret")
+	require.NoError(t, err)
+	require.True(t, enrolled)
+	require.NoError(t, s.RoleStore("default").Define(ctx, models.Role{Name: "admin", Permissions: []string{"users:read"}}))
+	require.NoError(t, s.RoleStore("default").Assign(ctx, "test", "admin"))
+	require.NoError(t, s.OAuthClientStore("default").Create(ctx, models.OAuthClient{ID: "client", SecretHash: "hash", Scopes: []string{"read"}}))
+
+	// The legacy version is replaced by the migrations.
+	migrator, closer, err := OpenMigrator(config.Database{Type: "sqlite", Path: path})
+	require.NoError(t, err)
+	defer closer.Close()
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, len(migrator.Migrations()), status.Version)
+	require.Empty(t, status.Pending)
 }
 
 func testCreateUser(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
@@ -53,9 +188,9 @@ func testCreateUser(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
 		user    models.User
 		wantErr bool
 	}{
-		{"Valid user", models.User{"test", "sdfafdfasdfds"}, false},
-		{"No username", models.User{"", "sdfafdfasdfds"}, true},
-		{"No password", models.User{"tesr", ""}, true},
+		{"Valid user", models.User{Username: "test", Password: "sdfafdfasdfds"}, false},
+		{"No username", models.User{Username: "", Password: "sdfafdfasdfds"}, true},
+		{"No password", models.User{Username: "tesr", Password: ""}, true},
 		{"Nothing", models.User{}, true},
 	}
 	for _, tt := range tests {
@@ -70,8 +205,18 @@ func testCreateUser(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
 	}
 }
 
+func testCreateExistingUser(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "hash"}))
+
+	err := userStore.Create(ctx, models.User{Username: "test", Password: "otherhash"})
+	require.ErrorIs(t, err, autherrors.UsernameAlreadyExistErr{Name: "test"})
+}
+
 func testGetUser(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
-	user := models.User{"test", "fsdjak"}
+	user := models.User{Tenant: "default", Username: "test", Password: "fsdjak", Email: "test@example.com"}
 	tests := []struct {
 		name         string
 		existingUser models.User
@@ -99,3 +244,156 @@ func testGetUser(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
 		})
 	}
 }
+
+func testUpdateUser(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "hash"}))
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "other", Password: "hash"}))
+
+	updated := models.User{Username: "renamed", Password: "newhash", Email: "renamed@example.com", TokenGeneration: 2}
+	found, err := userStore.Update(ctx, "test", updated)
+	require.NoError(t, err)
+	require.True(t, found)
+	got, err := userStore.Get(ctx, "renamed")
+	require.NoError(t, err)
+	updated.Tenant = "default"
+	require.Equal(t, &updated, got)
+	got, err = userStore.Get(ctx, "test")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	found, err = userStore.Update(ctx, "unknown", models.User{Username: "unknown", Password: "hash"})
+	require.NoError(t, err)
+	require.False(t, found)
+
+	_, err = userStore.Update(ctx, "renamed", models.User{Username: "other", Password: "hash"})
+	require.ErrorIs(t, err, autherrors.UsernameAlreadyExistErr{Name: "other"})
+}
+
+func testUpdatePasswordHash(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "hash"}))
+
+	updated, err := userStore.UpdatePasswordHash(ctx, "test", "hash", "newhash")
+	require.NoError(t, err)
+	require.True(t, updated)
+	got, err := userStore.Get(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, "newhash", got.Password)
+
+	// The hash is not replaced if it changed since it was read.
+	updated, err = userStore.UpdatePasswordHash(ctx, "test", "hash", "otherhash")
+	require.NoError(t, err)
+	require.False(t, updated)
+	got, err = userStore.Get(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, "newhash", got.Password)
+
+	updated, err = userStore.UpdatePasswordHash(ctx, "unknown", "hash", "newhash")
+	require.NoError(t, err)
+	require.False(t, updated)
+}
+
+func testDeleteUser(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "hash"}))
+	require.NoError(t, refreshTokenStore.Create(ctx, models.RefreshToken{Hash: "hash", FamilyID: "family", Username: "test", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	found, err := userStore.Delete(ctx, "test")
+	require.NoError(t, err)
+	require.True(t, found)
+	got, err := userStore.Get(ctx, "test")
+	require.NoError(t, err)
+	require.Nil(t, got)
+	// The refresh tokens of the user are deleted with the user.
+	rt, err := refreshTokenStore.Get(ctx, "hash")
+	require.NoError(t, err)
+	require.Nil(t, rt)
+
+	found, err = userStore.Delete(ctx, "test")
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func testListUsers(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+	for _, username := range []string{"bob", "alice2", "carol", "alice1", "al%"} {
+		require.NoError(t, userStore.Create(ctx, models.User{Username: username, Password: "hash"}))
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		after  string
+		limit  int
+		want   []string
+	}{
+		{"all", "", "", 10, []string{"al%", "alice1", "alice2", "bob", "carol"}},
+		{"first page", "", "", 2, []string{"al%", "alice1"}},
+		{"next page", "", "alice1", 2, []string{"alice2", "bob"}},
+		{"prefix", "alice", "", 10, []string{"alice1", "alice2"}},
+		{"prefix next page", "alice", "alice1", 10, []string{"alice2"}},
+		{"prefix is not a pattern", "al%", "", 10, []string{"al%"}},
+		{"prefix is case sensitive", "Alice", "", 10, []string{}},
+		{"no more users", "", "carol", 10, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := userStore.List(ctx, tt.prefix, tt.after, tt.limit)
+			require.NoError(t, err)
+			got := make([]string, 0, len(users))
+			for _, u := range users {
+				got = append(got, u.Username)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func testTenants(t *testing.T, setup func(t testing.TB) func(t testing.TB)) {
+	teardown := setup(t)
+	defer teardown(t)
+	ctx := context.Background()
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "test", Password: "hash"}))
+	// The same username is available in each tenant.
+	require.NoError(t, otherUserStore.Create(ctx, models.User{Username: "test", Password: "otherhash"}))
+
+	got, err := userStore.Get(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, "default", got.Tenant)
+	require.Equal(t, "hash", got.Password)
+	got, err = otherUserStore.Get(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, "other", got.Tenant)
+	require.Equal(t, "otherhash", got.Password)
+
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "alice", Password: "hash"}))
+	users, err := otherUserStore.List(ctx, "", "", 10)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, "test", users[0].Username)
+
+	// The refresh tokens are only found in the tenant of their user.
+	require.NoError(t, refreshTokenStore.Create(ctx, models.RefreshToken{Hash: "hash", FamilyID: "family", Username: "test", ExpiresAt: time.Now().Add(time.Hour)}))
+	rt, err := otherRefreshTokenStore.Get(ctx, "hash")
+	require.NoError(t, err)
+	require.Nil(t, rt)
+	rt, err = refreshTokenStore.Get(ctx, "hash")
+	require.NoError(t, err)
+	require.NotNil(t, rt)
+
+	found, err := otherUserStore.Delete(ctx, "test")
+	require.NoError(t, err)
+	require.True(t, found)
+	got, err = userStore.Get(ctx, "test")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+}
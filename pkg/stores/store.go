@@ -3,6 +3,7 @@ package stores
 import (
 	"auth/pkg/models"
 	"context"
+	"time"
 )
 
 type UserStore interface {
@@ -10,4 +11,138 @@ type UserStore interface {
 	Create(ctx context.Context, user models.User) error
 	//Get a user with the username from the store.
 	Get(ctx context.Context, username string) (*models.User, error)
+	//Update the user with the username from models.User, it returns false if the user does not exist.
+	Update(ctx context.Context, username string, user models.User) (bool, error)
+	//UpdatePasswordHash replaces the password hash of the user with the username if it is still currentHash,
+	//it returns false if the user does not exist or if its password hash changed.
+	UpdatePasswordHash(ctx context.Context, username, currentHash, newHash string) (bool, error)
+	//Delete the user with the username, it returns false if the user does not exist.
+	Delete(ctx context.Context, username string) (bool, error)
+	//List returns at most limit users whose username starts with the prefix, ordered by username,
+	//starting after the username after.
+	List(ctx context.Context, prefix, after string, limit int) ([]models.User, error)
+}
+
+type RefreshTokenStore interface {
+	//Create a refresh token from models.RefreshToken and store it.
+	Create(ctx context.Context, token models.RefreshToken) error
+	//Get the refresh token with the hash from the store.
+	Get(ctx context.Context, hash string) (*models.RefreshToken, error)
+	//Use marks the refresh token with the hash as used, it returns false if the token was already used or revoked.
+	Use(ctx context.Context, hash string) (bool, error)
+	//RevokeFamily revokes all the refresh tokens of the family.
+	RevokeFamily(ctx context.Context, familyID string) error
+	//RevokeUser revokes all the refresh tokens of the user.
+	RevokeUser(ctx context.Context, username string) error
+}
+
+type RevocationStore interface {
+	//Revoke stores the jti of a token revoked until its expiration.
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	//IsRevoked returns true if the token with the jti is revoked.
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	//DeleteExpired deletes the revoked tokens expired before the time and returns the number of deleted tokens.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type PasswordResetStore interface {
+	//Create a password reset token from models.PasswordResetToken and store it, the previous tokens of the user are used.
+	Create(ctx context.Context, token models.PasswordResetToken) error
+	//Get the password reset token with the hash from the store.
+	Get(ctx context.Context, hash string) (*models.PasswordResetToken, error)
+	//Use marks the password reset token with the hash as used, it returns false if the token was already used.
+	Use(ctx context.Context, hash string) (bool, error)
+}
+
+type LoginFailureStore interface {
+	//Get the failed login attempts with the key from the store.
+	Get(ctx context.Context, key string) (*models.LoginFailures, error)
+	//Add records a failed login attempt with the key at the time failedAt and returns the recorded attempts,
+	//the failures before the time since are forgotten.
+	Add(ctx context.Context, key string, failedAt, since time.Time) (*models.LoginFailures, error)
+	//Reset forgets the failed login attempts with the key.
+	Reset(ctx context.Context, key string) error
+}
+
+type TOTPStore interface {
+	//Enroll stores the encrypted secret of an unconfirmed TOTP credential of the user with the username,
+	//replacing a previous unconfirmed one. It returns false if the user already has a confirmed credential.
+	Enroll(ctx context.Context, username, secret string) (bool, error)
+	//Get the TOTP credential of the user with the username from the store.
+	Get(ctx context.Context, username string) (*models.TOTPCredential, error)
+	//Confirm activates the TOTP credential of the user with a code of the time step counter,
+	//it returns false if the credential is already confirmed or the time step was already used.
+	Confirm(ctx context.Context, username string, counter int64) (bool, error)
+	//UseCounter records the time step counter of a code accepted for the user,
+	//it returns false if a code of this or a later time step was already accepted.
+	UseCounter(ctx context.Context, username string, counter int64) (bool, error)
+}
+
+type MFAChallengeStore interface {
+	//Create an MFA challenge from models.MFAChallenge and store it.
+	Create(ctx context.Context, challenge models.MFAChallenge) error
+	//Get the MFA challenge with the hash from the store.
+	Get(ctx context.Context, hash string) (*models.MFAChallenge, error)
+	//Attempt counts an attempt to answer the MFA challenge with the hash,
+	//it returns false if the challenge was used or already attempted maxAttempts times.
+	Attempt(ctx context.Context, hash string, maxAttempts int) (bool, error)
+	//Use marks the MFA challenge with the hash as used, it returns false if the challenge was already used.
+	Use(ctx context.Context, hash string) (bool, error)
+}
+
+type RecoveryCodeStore interface {
+	//Replace stores the hashes of a new batch of recovery codes of the user with the username,
+	//then deletes the codes of the previous batches.
+	Replace(ctx context.Context, username, batchID string, hashes []string) error
+	//ListUnused returns the hashes of the unused recovery codes of the user with the username.
+	ListUnused(ctx context.Context, username string) ([]string, error)
+	//Use marks the recovery code with the hash of the user as used, it returns false if the code was already used.
+	Use(ctx context.Context, username, hash string) (bool, error)
+	//CountUnused returns the number of unused recovery codes of the user with the username.
+	CountUnused(ctx context.Context, username string) (int, error)
+}
+
+type RoleStore interface {
+	//Define creates the role from models.Role, or replaces the permissions of the role if it exists.
+	Define(ctx context.Context, role models.Role) error
+	//Get the role with the name from the store.
+	Get(ctx context.Context, name string) (*models.Role, error)
+	//List returns all the roles, ordered by name.
+	List(ctx context.Context) ([]models.Role, error)
+	//Assign the role with the name to the user with the username, assigning a role twice has no effect.
+	Assign(ctx context.Context, username, role string) error
+	//Revoke the role with the name from the user with the username, it returns false if the user didn't have the role.
+	Revoke(ctx context.Context, username, role string) (bool, error)
+	//ListByUser returns the roles assigned to the user with the username, ordered by name.
+	ListByUser(ctx context.Context, username string) ([]models.Role, error)
+}
+
+type APIKeyStore interface {
+	//Create an API key from models.APIKey and store it, creating its service account if needed.
+	Create(ctx context.Context, key models.APIKey) error
+	//Get the API key with the prefix from the store.
+	Get(ctx context.Context, prefix string) (*models.APIKey, error)
+	//List returns the API keys of the service account, or of all the service accounts if it is empty,
+	//ordered by service account and creation time.
+	List(ctx context.Context, serviceAccount string) ([]models.APIKey, error)
+	//Revoke the API key with the prefix, it returns false if the key doesn't exist or is already revoked.
+	Revoke(ctx context.Context, prefix string) (bool, error)
+}
+
+type OAuthClientStore interface {
+	//Create an OAuth client from models.OAuthClient and store it with its scopes, audiences and redirect URIs.
+	Create(ctx context.Context, client models.OAuthClient) error
+	//Get the OAuth client with the id from the store.
+	Get(ctx context.Context, id string) (*models.OAuthClient, error)
+	//Delete the OAuth client with the id, it returns false if the client doesn't exist.
+	Delete(ctx context.Context, id string) (bool, error)
+}
+
+type AuthorizationCodeStore interface {
+	//Create an authorization code from models.AuthorizationCode and store it.
+	Create(ctx context.Context, code models.AuthorizationCode) error
+	//Get the authorization code with the hash from the store.
+	Get(ctx context.Context, hash string) (*models.AuthorizationCode, error)
+	//Use marks the authorization code with the hash as used, it returns false if the code was already used.
+	Use(ctx context.Context, hash string) (bool, error)
 }
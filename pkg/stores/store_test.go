@@ -0,0 +1,31 @@
+package stores
+
+import (
+	"testing"
+)
+
+// storeTests are the tests of the stores shared by the databases, run with the setup of the stores of a database.
+var storeTests = []struct {
+	name string
+	test func(t *testing.T, setup func(t testing.TB) func(t testing.TB))
+}{
+	{"UserStore_Create", testCreateUser},
+	{"UserStore_Create_existing", testCreateExistingUser},
+	{"UserStore_Get", testGetUser},
+	{"UserStore_Update", testUpdateUser},
+	{"UserStore_UpdatePasswordHash", testUpdatePasswordHash},
+	{"UserStore_Delete", testDeleteUser},
+	{"UserStore_List", testListUsers},
+	{"UserStore_Tenants", testTenants},
+	{"RefreshTokenStore", testRefreshTokens},
+	{"RevocationStore", testRevocations},
+	{"PasswordResetStore", testPasswordResets},
+	{"LoginFailureStore", testLoginFailures},
+	{"TOTPStore", testTOTP},
+	{"MFAChallengeStore", testMFAChallenges},
+	{"RecoveryCodeStore", testRecoveryCodes},
+	{"RoleStore", testRoles},
+	{"APIKeyStore", testAPIKeys},
+	{"OAuthClientStore", testOAuthClients},
+	{"AuthorizationCodeStore", testAuthorizationCodes},
+}
@@ -0,0 +1,34 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// txBeginner is a database beginning transactions, like *sql.DB.
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// withTx runs fn in a transaction of db committed if fn succeeds, and rolled back otherwise.
+// A db which can't begin a transaction, like *sql.Tx, is already one and fn runs in it.
+func withTx[DBTX any](ctx context.Context, db DBTX, fn func(tx DBTX) error) error {
+	beginner, ok := any(db).(txBeginner)
+	if !ok {
+		return fn(db)
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning a transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err := fn(any(tx).(DBTX)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing a transaction: %w", err)
+	}
+
+	return nil
+}
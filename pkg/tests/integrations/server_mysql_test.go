@@ -0,0 +1,202 @@
+//go:build mysql_test
+// +build mysql_test
+
+package integrations
+
+import (
+	"auth/pkg/config"
+	"auth/pkg/stores"
+	"auth/pkg/stores/mysql"
+	"context"
+	_ "github.com/mattn/go-sqlite3"
+	"testing"
+)
+
+// mysqlDatabase is the test database started by make tests-mysql.
+var mysqlDatabase = config.Database{
+	Type:     "mysql",
+	Host:     "localhost",
+	Port:     3307,
+	UserName: "auth_user",
+	Password: "autPassw@ord",
+	DbName:   "auth",
+}
+
+// openMysqlDb opens the stores of a transaction of the test database, rolled back by the teardown.
+func openMysqlDb() (stores.Stores, func(), error) {
+	migrationDB, err := mysql.OpenMigrations(mysqlDatabase)
+	if err != nil {
+		return nil, nil, err
+	}
+	migrator, err := mysql.NewMigrator(migrationDB)
+	if err == nil {
+		_, err = migrator.Up(context.Background())
+	}
+	migrationDB.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	database, err := mysql.Open(mysqlDatabase)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err := database.Begin()
+	if err != nil {
+		database.Close()
+		return nil, nil, err
+	}
+
+	tearDown := func() {
+		tx.Rollback()
+		database.Close()
+	}
+
+	return stores.NewMysqlStores(tx), tearDown, nil
+}
+
+// openMysqlStores opens the stores of the test database without a transaction, so the requests run concurrently.
+// The tests write to their own tenant and delete their users.
+func openMysqlStores() (stores.Stores, func(), error) {
+	s, closer, err := stores.Open(mysqlDatabase)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s, func() { closer.Close() }, nil
+}
+
+func Test_mysql_Server_Create(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerCreate(t)
+}
+
+func Test_mysql_Server_Create_SameUsername(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerCreateSameUser(t)
+}
+
+func Test_mysql_Server_Create_concurrently(t *testing.T) {
+	testServerCreateConcurrently(t, openMysqlStores)
+}
+
+func Test_mysql_Server_Auth_Success(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerAuthSuccess(t)
+}
+
+func Test_mysql_Server_Auth_Failure(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerAuthFailure(t)
+}
+
+func Test_mysql_Server_Introspect(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerIntrospect(t)
+}
+
+func Test_mysql_Server_RefreshToken(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerRefreshToken(t)
+}
+
+func Test_mysql_Server_Logout(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerLogout(t)
+}
+
+func Test_mysql_Server_UserLifecycle(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerUserLifecycle(t)
+}
+
+func Test_mysql_Server_ListUsers_pages(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerListUsersPages(t)
+}
+
+func Test_mysql_Server_ChangePassword(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerChangePassword(t)
+}
+
+func Test_mysql_Server_PasswordReset(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerPasswordReset(t)
+}
+
+func Test_mysql_Server_Rehash(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerRehash(t)
+}
+
+func Test_mysql_Server_Lockout(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerLockout(t)
+}
+
+func Test_mysql_Server_MFA(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerMFA(t)
+}
+
+func Test_mysql_Server_RecoveryCodes(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerRecoveryCodes(t)
+}
+
+func Test_mysql_Server_Roles(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerRoles(t)
+}
+
+func Test_mysql_Server_Authorization(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerAuthorization(t)
+}
+
+func Test_mysql_Server_Realms(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerRealms(t)
+}
+
+func Test_mysql_Server_APIKeys(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerAPIKeys(t)
+}
+
+func Test_mysql_Server_OAuth(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerOAuth(t)
+}
+
+func Test_mysql_Server_OAuth_IPLockout(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerOAuthIPLockout(t)
+}
+
+func Test_mysql_Server_OIDC(t *testing.T) {
+	teardown := setup(t, openMysqlDb)
+	defer teardown(t)
+	testServerOIDC(t)
+}
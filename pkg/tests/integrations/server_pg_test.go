@@ -7,33 +7,59 @@ import (
 	"auth/pkg/config"
 	"auth/pkg/stores"
 	"auth/pkg/stores/pg"
-	"database/sql"
+	"context"
 	_ "github.com/mattn/go-sqlite3"
 	"testing"
 )
 
-func openPgDb() (*sql.DB, stores.UserStore, func(), error) {
-	database, err := pg.Open(config.Database{
-		Host:     "localhost",
-		Port:     5433,
-		UserName: "auth_user",
-		Password: "autPassw@ord",
-		DbName:   "auth",
-		SslMode:  "disable",
-	})
+// pgDatabase is the test database started by make tests-pg.
+var pgDatabase = config.Database{
+	Type:     "postgres",
+	Host:     "localhost",
+	Port:     5433,
+	UserName: "auth_user",
+	Password: "autPassw@ord",
+	DbName:   "auth",
+	SslMode:  "disable",
+}
+
+// openPgDb opens the stores of a transaction of the test database, rolled back by the teardown.
+func openPgDb() (stores.Stores, func(), error) {
+	database, err := pg.Open(pgDatabase)
+	if err != nil {
+		return nil, nil, err
+	}
+	migrator, err := pg.NewMigrator(database)
+	if err == nil {
+		_, err = migrator.Up(context.Background())
+	}
 	if err != nil {
-		return nil, nil, nil, err
+		database.Close()
+		return nil, nil, err
 	}
 	tx, err := database.Begin()
 	if err != nil {
-		return nil, nil, nil, err
+		database.Close()
+		return nil, nil, err
 	}
 
 	tearDown := func() {
 		tx.Rollback()
+		database.Close()
 	}
 
-	return database, stores.NewPgUserStore(pg.New(tx)), tearDown, nil
+	return stores.NewPgStores(tx), tearDown, nil
+}
+
+// openPgStores opens the stores of the test database without a transaction, so the requests run concurrently.
+// The tests write to their own tenant and delete their users.
+func openPgStores() (stores.Stores, func(), error) {
+	s, closer, err := stores.Open(pgDatabase)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s, func() { closer.Close() }, nil
 }
 
 func Test_pg_Server_Create(t *testing.T) {
@@ -48,6 +74,10 @@ func Test_pg_Server_Create_SameUsername(t *testing.T) {
 	testServerCreateSameUser(t)
 }
 
+func Test_pg_Server_Create_concurrently(t *testing.T) {
+	testServerCreateConcurrently(t, openPgStores)
+}
+
 func Test_pg_Server_Auth_Success(t *testing.T) {
 	teardown := setup(t, openPgDb)
 	defer teardown(t)
@@ -59,3 +89,111 @@ func Test_pg_Server_Auth_Failure(t *testing.T) {
 	defer teardown(t)
 	testServerAuthFailure(t)
 }
+
+func Test_pg_Server_Introspect(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerIntrospect(t)
+}
+
+func Test_pg_Server_RefreshToken(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerRefreshToken(t)
+}
+
+func Test_pg_Server_Logout(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerLogout(t)
+}
+
+func Test_pg_Server_UserLifecycle(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerUserLifecycle(t)
+}
+
+func Test_pg_Server_ListUsers_pages(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerListUsersPages(t)
+}
+
+func Test_pg_Server_ChangePassword(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerChangePassword(t)
+}
+
+func Test_pg_Server_PasswordReset(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerPasswordReset(t)
+}
+
+func Test_pg_Server_Rehash(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerRehash(t)
+}
+
+func Test_pg_Server_Lockout(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerLockout(t)
+}
+
+func Test_pg_Server_MFA(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerMFA(t)
+}
+
+func Test_pg_Server_RecoveryCodes(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerRecoveryCodes(t)
+}
+
+func Test_pg_Server_Roles(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerRoles(t)
+}
+
+func Test_pg_Server_Authorization(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerAuthorization(t)
+}
+
+func Test_pg_Server_Realms(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerRealms(t)
+}
+
+func Test_pg_Server_APIKeys(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerAPIKeys(t)
+}
+
+func Test_pg_Server_OAuth(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerOAuth(t)
+}
+
+func Test_pg_Server_OAuth_IPLockout(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerOAuthIPLockout(t)
+}
+
+func Test_pg_Server_OIDC(t *testing.T) {
+	teardown := setup(t, openPgDb)
+	defer teardown(t)
+	testServerOIDC(t)
+}
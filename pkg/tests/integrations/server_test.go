@@ -2,100 +2,285 @@ package integrations
 
 import (
 	"auth/pkg/config"
+	"auth/pkg/hashers"
 	"auth/pkg/jwt"
+	"auth/pkg/models"
 	"auth/pkg/pb"
+	"auth/pkg/secrets"
 	"auth/pkg/server"
 	"auth/pkg/services"
 	"auth/pkg/stores"
-	"auth/pkg/stores/sqlite"
+	"auth/pkg/totp"
 	"auth/pkg/validators"
 	"context"
-	"database/sql"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"github.com/go-playground/validator/v10"
+	gojwt "github.com/golang-jwt/jwt/v4"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 var (
-	grpcServer  *server.AuthServer
-	userService services.UserService
-	authService services.AuthService
-	userStore   stores.UserStore
+	grpcServer        *server.AuthServer
+	authorizedServer  *grpc.Server
+	httpHandler       http.Handler
+	userService       services.UserService
+	authService       services.AuthService
+	userStore         stores.UserStore
+	refreshTokenStore stores.RefreshTokenStore
+	revocationStore   stores.RevocationStore
+	resetTokens       channelNotifier
+	totpStore         stores.TOTPStore
+	// realmStores are the stores of the database of the test, realmHasher hashes the passwords of the realms.
+	realmStores stores.Stores
+	realmHasher hashers.PasswordHasher
 )
 
-func inMemoryUserStore() (*sql.DB, stores.UserStore, func(), error) {
-	database, err := sqlite.OpenInMemory()
+// channelNotifier sends the password reset tokens to the channel instead of notifying the users.
+type channelNotifier chan string
+
+func (n channelNotifier) NotifyPasswordReset(_ context.Context, _ models.User, token string, _ time.Time) error {
+	n <- token
+	return nil
+}
+
+// inMemoryUserStore opens the stores of a new memory database, as configured with the memory database type.
+func inMemoryUserStore() (stores.Stores, func(), error) {
+	s, closer, err := stores.Open(config.Database{Type: "memory"})
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("an error %v was not expected when opening a stub database connection", err)
+		return nil, nil, err
 	}
-	tx, err := database.Begin()
+
+	return s, func() { closer.Close() }, nil
+}
+
+// sqliteStores opens the stores of a new SQLite database file, as configured with the sqlite database type.
+// The teardown removes the file.
+func sqliteStores() (stores.Stores, func(), error) {
+	dir, err := os.MkdirTemp("", "auth-integration")
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, err
 	}
-
-	tearDown := func() {
-		tx.Rollback()
+	s, closer, err := stores.Open(config.Database{Type: "sqlite", Path: filepath.Join(dir, "auth.db")})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
 	}
 
-	return database, stores.NewSqliteUserStore(sqlite.New(tx)), tearDown, nil
+	return s, func() {
+		closer.Close()
+		os.RemoveAll(dir)
+	}, nil
 }
 
-func setup(t testing.TB, storeFn func() (*sql.DB, stores.UserStore, func(), error)) func(t testing.TB) {
-
-	var err error
-	var database *sql.DB
-	var tearDown func()
-	database, userStore, tearDown, err = storeFn()
+func setup(t testing.TB, storeFn func() (stores.Stores, func(), error)) func(t testing.TB) {
+	s, tearDown, err := storeFn()
 	if err != nil {
 		t.Fatalf("an error %v was not expected when opening a stub database connection", err)
 	}
+	userStore = s.UserStore(config.DefaultRealm)
+	refreshTokenStore = s.RefreshTokenStore(config.DefaultRealm)
+	revocationStore = s.RevocationStore()
+	totpStore = s.TOTPStore(config.DefaultRealm)
 
-	userValidator := &validators.UserValidator{
-		StructValidator:   validator.New(),
-		PasswordValidator: validators.NewPasswordValidator(config.Password{}),
+	passwordHasher, err := hashers.NewPasswordHasher(config.Hasher{
+		Algorithm: "argon2id",
+		Argon2id:  config.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1},
+	})
+	if err != nil {
+		t.Fatalf("an error %v was not expected when creating the password hasher", err)
 	}
-	userService = services.NewUserService(userStore, userValidator, 10)
-	jwtGenerator := jwt.NewTokenGenerator(config.Token{
+	realmStores, realmHasher = s, passwordHasher
+	resetTokens = make(channelNotifier, 1)
+	realm := newRealm(t, s, config.DefaultRealm, passwordHasher, config.Password{}, config.Token{
 		SigningMethod: "HS256",
 		SignedKey:     "sdfsadfa",
 		Audience:      "audience",
 		Issuer:        "issuer",
 		ExpDuration:   10,
 	})
-	authService = services.NewJwtAuthService(userStore, jwtGenerator)
+	userService, authService = realm.UserService, realm.AuthService
+
+	err = realm.RoleService.DefineRoles(context.Background(), []models.Role{
+		{Name: "admin", Permissions: []string{"users:read", "users:write"}},
+		{Name: "viewer", Permissions: []string{"users:read"}},
+	})
+	if err != nil {
+		t.Fatalf("an error %v was not expected when defining the roles", err)
+	}
+
+	// The acme realm requires longer passwords and signs its tokens with another key.
+	acme := newRealm(t, s, "acme", passwordHasher, config.Password{MinLength: 10}, config.Token{
+		SigningMethod: "HS256",
+		SignedKey:     "acmesecret",
+		Audience:      "audience",
+		Issuer:        "acme",
+		ExpDuration:   10,
+	})
 
-	grpcServer = server.NewAuthServer(userService, authService)
+	grpcServer = server.NewAuthServer(realm.UserService, realm.AuthService, realm.PasswordResetService, realm.RoleService, realm.APIKeyService, realm.OAuthService)
+	realms := map[string]server.Realm{config.DefaultRealm: realm, "acme": acme}
+	authorizedServer, err = server.NewGrpcServer(
+		config.TLS{},
+		realms,
+		server.DefaultPolicies("admin"),
+	)
+	if err != nil {
+		t.Fatalf("an error %v was not expected when creating the gRPC server", err)
+	}
+	httpHandler = server.NewHTTPHandler(realms)
 
 	return func(t testing.TB) {
 		tearDown()
-		database.Close()
 	}
 }
 
-func Test_Server_Create(t *testing.T) {
-	teardown := setup(t, inMemoryUserStore)
-	defer teardown(t)
-	testServerCreate(t)
-}
+// newRealm creates the services of a realm with the stores of its tenant, its password policy and token settings.
+func newRealm(t testing.TB, s stores.Stores, tenant string, passwordHasher hashers.PasswordHasher, password config.Password, tokenConfig config.Token) server.Realm {
+	userValidator := &validators.UserValidator{
+		StructValidator:   validator.New(),
+		PasswordValidator: validators.NewPasswordValidator(password),
+	}
+	keyRing, err := jwt.NewKeyRing(tokenConfig)
+	if err != nil {
+		t.Fatalf("an error %v was not expected when loading the token keys", err)
+	}
+	jwtGenerator := jwt.NewTokenGenerator(tokenConfig, keyRing)
+	jwtVerifier := jwt.NewTokenVerifier(tokenConfig, keyRing)
+	cipher, err := secrets.NewCipher([]byte(strings.Repeat("k", secrets.KeySize)))
+	if err != nil {
+		t.Fatalf("an error %v was not expected when creating the MFA cipher", err)
+	}
+	userStore := s.UserStore(tenant)
+	refreshTokenStore := s.RefreshTokenStore(tenant)
+	roleStore := s.RoleStore(tenant)
+	apiKeyStore := s.APIKeyStore(tenant)
+	oauthClientStore := s.OAuthClientStore(tenant)
+	mfaService := services.NewMFAService(s.TOTPStore(tenant), s.MFAChallengeStore(tenant), cipher, config.MFA{
+		Issuer:               "auth",
+		ChallengeExpDuration: 5,
+		MaxAttempts:          5,
+		Skew:                 1,
+	})
 
-func Test_Server_Create_SameUsername(t *testing.T) {
-	teardown := setup(t, inMemoryUserStore)
-	defer teardown(t)
-	testServerCreateSameUser(t)
+	authService := services.NewJwtAuthService(
+		userStore,
+		refreshTokenStore,
+		s.RevocationStore(),
+		jwtGenerator,
+		jwtVerifier,
+		keyRing,
+		userValidator.PasswordValidator,
+		passwordHasher,
+		services.NewLoginThrottler(s.LoginFailureStore(tenant), config.Throttle{Window: 15, MaxFailures: 3, IPMaxFailures: 10, Lockout: 15}),
+		mfaService,
+		s.RecoveryCodeStore(tenant),
+		roleStore,
+		apiKeyStore,
+		oauthClientStore,
+		time.Hour,
+	)
+
+	return server.Realm{
+		UserService: services.NewUserService(userStore, userValidator, passwordHasher),
+		AuthService: authService,
+		PasswordResetService: services.NewPasswordResetService(
+			userStore,
+			s.PasswordResetStore(tenant),
+			refreshTokenStore,
+			services.NewPasswordResetThrottler(s.LoginFailureStore(tenant), config.Throttle{Window: 15, MaxFailures: 3, IPMaxFailures: 10, Lockout: 15}),
+			resetTokens,
+			userValidator.PasswordValidator,
+			passwordHasher,
+			time.Hour,
+		),
+		RoleService:   services.NewRoleService(userStore, roleStore),
+		APIKeyService: services.NewAPIKeyService(apiKeyStore),
+		OAuthService:  services.NewOAuthService(oauthClientStore, authService, jwtGenerator, tokenConfig),
+		OIDCService:   services.NewOIDCService(oauthClientStore, s.AuthorizationCodeStore(tenant), userStore, authService, jwtGenerator, tokenConfig),
+	}
 }
 
-func Test_Server_Auth_Success(t *testing.T) {
-	teardown := setup(t, inMemoryUserStore)
-	defer teardown(t)
-	testServerAuthSuccess(t)
+// serverTests are run against the databases of Test_Server, each test with a new database.
+var serverTests = []struct {
+	name string
+	test func(t *testing.T)
+}{
+	{"Create", testServerCreate},
+	{"Create_SameUsername", testServerCreateSameUser},
+	{"Auth_Success", testServerAuthSuccess},
+	{"Auth_Failure", testServerAuthFailure},
+	{"Introspect", testServerIntrospect},
+	{"RefreshToken", testServerRefreshToken},
+	{"Logout", testServerLogout},
+	{"UserLifecycle", testServerUserLifecycle},
+	{"ListUsers_pages", testServerListUsersPages},
+	{"PasswordReset", testServerPasswordReset},
+	{"Rehash", testServerRehash},
+	{"Lockout", testServerLockout},
+	{"ChangePassword", testServerChangePassword},
+	{"MFA", testServerMFA},
+	{"RecoveryCodes", testServerRecoveryCodes},
+	{"Roles", testServerRoles},
+	{"Authorization", testServerAuthorization},
+	{"Realms", testServerRealms},
+	{"APIKeys", testServerAPIKeys},
+	{"OAuth", testServerOAuth},
+	{"OAuth_IPLockout", testServerOAuthIPLockout},
+	{"OIDC", testServerOIDC},
 }
 
-func Test_Server_Auth_Failure(t *testing.T) {
-	teardown := setup(t, inMemoryUserStore)
-	defer teardown(t)
-	testServerAuthFailure(t)
+func Test_Server(t *testing.T) {
+	for _, db := range []struct {
+		name    string
+		storeFn func() (stores.Stores, func(), error)
+	}{
+		{"sqlite", sqliteStores},
+		{"memory", inMemoryUserStore},
+	} {
+		t.Run(db.name, func(t *testing.T) {
+			for _, tt := range serverTests {
+				t.Run(tt.name, func(t *testing.T) {
+					teardown := setup(t, db.storeFn)
+					defer teardown(t)
+					tt.test(t)
+				})
+			}
+			t.Run("Create_concurrently", func(t *testing.T) {
+				testServerCreateConcurrently(t, db.storeFn)
+			})
+		})
+	}
 }
 
 func testServerCreate(t *testing.T) {
@@ -138,6 +323,956 @@ func testServerAuthFailure(t *testing.T) {
 	require.Empty(t, response)
 }
 
+func testServerIntrospect(t *testing.T) {
+	username := "test5"
+	password := "password"
+	createUser(t, username, password)
+
+	auth, err := grpcServer.Authenticate(context.Background(), &pb.AuthenticateRequest{
+		Username: username,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	response, err := grpcServer.Introspect(context.Background(), &pb.IntrospectRequest{Token: auth.Token})
+	require.NoError(t, err)
+	require.True(t, response.Active)
+	require.Equal(t, username, response.Subject)
+	require.Equal(t, []string{"audience"}, response.Audience)
+	require.Equal(t, "issuer", response.Issuer)
+	require.NotZero(t, response.ExpiresAt)
+
+	response, err = grpcServer.Introspect(context.Background(), &pb.IntrospectRequest{Token: auth.Token + "x"})
+	require.NoError(t, err)
+	require.False(t, response.Active)
+}
+
+func testServerLogout(t *testing.T) {
+	username := "test7"
+	password := "password"
+	createUser(t, username, password)
+
+	auth, err := grpcServer.Authenticate(context.Background(), &pb.AuthenticateRequest{
+		Username: username,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	response, err := grpcServer.Logout(context.Background(), &pb.LogoutRequest{Token: auth.Token, RefreshToken: auth.RefreshToken})
+	require.NoError(t, err)
+	require.True(t, response.Success)
+
+	introspection, err := grpcServer.Introspect(context.Background(), &pb.IntrospectRequest{Token: auth.Token})
+	require.NoError(t, err)
+	require.False(t, introspection.Active)
+	_, err = grpcServer.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: auth.RefreshToken})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid refresh token")
+	_, err = grpcServer.Logout(context.Background(), &pb.LogoutRequest{Token: auth.Token})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = token revoked")
+}
+
+func testServerChangePassword(t *testing.T) {
+	ctx := context.Background()
+	username := "test8"
+	password := "password"
+	createUser(t, username, password)
+
+	auth, err := grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.NoError(t, err)
+
+	_, err = grpcServer.ChangePassword(ctx, &pb.ChangePasswordRequest{Token: auth.Token, CurrentPassword: "wrong", NewPassword: "newpassword"})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = authentication failed")
+
+	response, err := grpcServer.ChangePassword(ctx, &pb.ChangePasswordRequest{Token: auth.Token, CurrentPassword: password, NewPassword: "newpassword"})
+	require.NoError(t, err)
+	require.True(t, response.Success)
+
+	// The tokens issued before the change are invalidated.
+	introspection, err := grpcServer.Introspect(ctx, &pb.IntrospectRequest{Token: auth.Token})
+	require.NoError(t, err)
+	require.False(t, introspection.Active)
+	_, err = grpcServer.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: auth.RefreshToken})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid refresh token")
+
+	_, err = grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.Error(t, err)
+	auth, err = grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: "newpassword"})
+	require.NoError(t, err)
+	introspection, err = grpcServer.Introspect(ctx, &pb.IntrospectRequest{Token: auth.Token})
+	require.NoError(t, err)
+	require.True(t, introspection.Active)
+}
+
+func testServerPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	username := "test9"
+	password := "password"
+	createUser(t, username, password)
+
+	auth, err := grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.NoError(t, err)
+
+	// The response does not reveal whether the user exists.
+	response, err := grpcServer.RequestPasswordReset(ctx, &pb.RequestPasswordResetRequest{Username: "unknown"})
+	require.NoError(t, err)
+	require.True(t, response.Success)
+	// The requests of a username are limited, whether the user exists or not.
+	for i := 0; i < 2; i++ {
+		_, err = grpcServer.RequestPasswordReset(ctx, &pb.RequestPasswordResetRequest{Username: "unknown"})
+		require.NoError(t, err)
+	}
+	_, err = grpcServer.RequestPasswordReset(ctx, &pb.RequestPasswordResetRequest{Username: "unknown"})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	response, err = grpcServer.RequestPasswordReset(ctx, &pb.RequestPasswordResetRequest{Username: username})
+	require.NoError(t, err)
+	require.True(t, response.Success)
+
+	var token string
+	select {
+	case token = <-resetTokens:
+	case <-time.After(time.Second):
+		t.Fatal("the password reset token was not sent")
+	}
+
+	_, err = grpcServer.ResetPassword(ctx, &pb.ResetPasswordRequest{Token: "wrong", NewPassword: "newpassword"})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid password reset token")
+
+	reset, err := grpcServer.ResetPassword(ctx, &pb.ResetPasswordRequest{Token: token, NewPassword: "newpassword"})
+	require.NoError(t, err)
+	require.True(t, reset.Success)
+
+	// The reset token can only be used once.
+	_, err = grpcServer.ResetPassword(ctx, &pb.ResetPasswordRequest{Token: token, NewPassword: "otherpassword"})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid password reset token")
+
+	// The tokens issued before the reset are invalidated.
+	introspection, err := grpcServer.Introspect(ctx, &pb.IntrospectRequest{Token: auth.Token})
+	require.NoError(t, err)
+	require.False(t, introspection.Active)
+	_, err = grpcServer.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: auth.RefreshToken})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid refresh token")
+
+	_, err = grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.Error(t, err)
+	_, err = grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: "newpassword"})
+	require.NoError(t, err)
+}
+
+func testServerRehash(t *testing.T) {
+	ctx := context.Background()
+	username := "test10"
+	password := "password"
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.NoError(t, userStore.Create(ctx, models.User{Username: username, Password: string(legacyHash)}))
+
+	_, err = grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.NoError(t, err)
+
+	// The bcrypt hash is replaced by an argon2id hash of the same password.
+	u, err := userStore.Get(ctx, username)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(u.Password, "$argon2id$"), u.Password)
+	_, err = grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.NoError(t, err)
+}
+
+func testServerLockout(t *testing.T) {
+	ctx := context.Background()
+	username := "test11"
+	password := "password"
+	createUser(t, username, password)
+
+	for _, name := range []string{username, "unknown"} {
+		for i := 0; i < 3; i++ {
+			_, err := grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: name, Password: "wrong"})
+			require.EqualError(t, err, "rpc error: code = Unauthenticated desc = authentication failed")
+		}
+		// The lockout doesn't reveal whether the user exists.
+		_, err := grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: name, Password: password})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	}
+
+	response, err := grpcServer.UnlockUser(ctx, &pb.UnlockUserRequest{Username: username})
+	require.NoError(t, err)
+	require.True(t, response.Success)
+	_, err = grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.NoError(t, err)
+}
+
+func testServerMFA(t *testing.T) {
+	ctx := context.Background()
+	username := "test12"
+	password := "password"
+	createUser(t, username, password)
+
+	auth, err := grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.NoError(t, err)
+	require.Empty(t, auth.MfaToken)
+
+	enrollment, err := grpcServer.EnrollTOTP(ctx, &pb.EnrollTOTPRequest{Token: auth.Token})
+	require.NoError(t, err)
+	require.Contains(t, enrollment.Uri, "otpauth://totp/auth:"+username)
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(enrollment.Secret)
+	require.NoError(t, err)
+	// The secret is encrypted at rest.
+	credential, err := totpStore.Get(ctx, username)
+	require.NoError(t, err)
+	require.NotContains(t, credential.Secret, enrollment.Secret)
+
+	// The authentication doesn't require the second factor until the secret is confirmed.
+	auth, err = grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.NoError(t, err)
+	require.Empty(t, auth.MfaToken)
+
+	counter := totp.Counter(time.Now())
+	_, err = grpcServer.ConfirmTOTP(ctx, &pb.ConfirmTOTPRequest{Token: auth.Token, Code: totp.Code(secret, counter+5, totp.Digits)})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid MFA code")
+	confirmed, err := grpcServer.ConfirmTOTP(ctx, &pb.ConfirmTOTPRequest{Token: auth.Token, Code: totp.Code(secret, counter, totp.Digits)})
+	require.NoError(t, err)
+	require.True(t, confirmed.Success)
+	_, err = grpcServer.EnrollTOTP(ctx, &pb.EnrollTOTPRequest{Token: auth.Token})
+	require.EqualError(t, err, "rpc error: code = FailedPrecondition desc = TOTP already enabled")
+
+	auth, err = grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.NoError(t, err)
+	require.NotEmpty(t, auth.MfaToken)
+	require.Empty(t, auth.Token)
+	require.Empty(t, auth.RefreshToken)
+
+	// The code of the confirmation can't be used again.
+	_, err = grpcServer.VerifyMFA(ctx, &pb.VerifyMFARequest{MfaToken: auth.MfaToken, Code: totp.Code(secret, counter, totp.Digits)})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid MFA code")
+
+	code := totp.Code(secret, counter+1, totp.Digits)
+	verified, err := grpcServer.VerifyMFA(ctx, &pb.VerifyMFARequest{MfaToken: auth.MfaToken, Code: code})
+	require.NoError(t, err)
+	require.NotEmpty(t, verified.Token)
+	require.NotEmpty(t, verified.RefreshToken)
+	introspect, err := grpcServer.Introspect(ctx, &pb.IntrospectRequest{Token: verified.Token})
+	require.NoError(t, err)
+	require.True(t, introspect.Active)
+
+	// The MFA token can only be redeemed once and the code only used once.
+	_, err = grpcServer.VerifyMFA(ctx, &pb.VerifyMFARequest{MfaToken: auth.MfaToken, Code: code})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid MFA token")
+	auth, err = grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.NoError(t, err)
+	_, err = grpcServer.VerifyMFA(ctx, &pb.VerifyMFARequest{MfaToken: auth.MfaToken, Code: code})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid MFA code")
+}
+
+func testServerRecoveryCodes(t *testing.T) {
+	ctx := context.Background()
+	username := "test13"
+	password := "password"
+	createUser(t, username, password)
+
+	auth, err := grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.NoError(t, err)
+	oldCodes, err := grpcServer.GenerateRecoveryCodes(ctx, &pb.GenerateRecoveryCodesRequest{Token: auth.Token})
+	require.NoError(t, err)
+	require.Len(t, oldCodes.Codes, 10)
+	generated, err := grpcServer.GenerateRecoveryCodes(ctx, &pb.GenerateRecoveryCodesRequest{Token: auth.Token})
+	require.NoError(t, err)
+	require.Len(t, generated.Codes, 10)
+	count, err := grpcServer.CountRecoveryCodes(ctx, &pb.CountRecoveryCodesRequest{Token: auth.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(10), count.Remaining)
+
+	// The regeneration invalidates the previous batch.
+	_, err = grpcServer.AuthenticateWithRecoveryCode(ctx, &pb.AuthenticateWithRecoveryCodeRequest{Username: username, Code: oldCodes.Codes[0]})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = authentication failed")
+
+	recovered, err := grpcServer.AuthenticateWithRecoveryCode(ctx, &pb.AuthenticateWithRecoveryCodeRequest{Username: username, Code: generated.Codes[0]})
+	require.NoError(t, err)
+	introspect, err := grpcServer.Introspect(ctx, &pb.IntrospectRequest{Token: recovered.Token})
+	require.NoError(t, err)
+	require.True(t, introspect.Active)
+	require.True(t, introspect.PasswordChange)
+	count, err = grpcServer.CountRecoveryCodes(ctx, &pb.CountRecoveryCodesRequest{Token: auth.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(9), count.Remaining)
+
+	// Each code can only be used once.
+	_, err = grpcServer.AuthenticateWithRecoveryCode(ctx, &pb.AuthenticateWithRecoveryCodeRequest{Username: username, Code: generated.Codes[0]})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = authentication failed")
+
+	// The recovered token only allows to change the password.
+	_, err = grpcServer.GenerateRecoveryCodes(ctx, &pb.GenerateRecoveryCodesRequest{Token: recovered.Token})
+	require.EqualError(t, err, "rpc error: code = PermissionDenied desc = password change required")
+	changed, err := grpcServer.ChangePassword(ctx, &pb.ChangePasswordRequest{Token: recovered.Token, NewPassword: "newpassword"})
+	require.NoError(t, err)
+	require.True(t, changed.Success)
+	_, err = grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: "newpassword"})
+	require.NoError(t, err)
+}
+
+func testServerRoles(t *testing.T) {
+	ctx := context.Background()
+	username := "test14"
+	password := "password"
+	createUser(t, username, password)
+
+	all, err := grpcServer.ListRoles(ctx, &pb.ListRolesRequest{})
+	require.NoError(t, err)
+	require.Len(t, all.Roles, 2)
+	require.Equal(t, "admin", all.Roles[0].Name)
+	require.Equal(t, []string{"users:read", "users:write"}, all.Roles[0].Permissions)
+
+	_, err = grpcServer.AssignRole(ctx, &pb.AssignRoleRequest{Username: username, Role: "owner"})
+	require.EqualError(t, err, "rpc error: code = NotFound desc = role owner not found")
+	_, err = grpcServer.AssignRole(ctx, &pb.AssignRoleRequest{Username: "unknown", Role: "admin"})
+	require.EqualError(t, err, "rpc error: code = NotFound desc = user unknown not found")
+	for _, role := range []string{"admin", "viewer"} {
+		assigned, err := grpcServer.AssignRole(ctx, &pb.AssignRoleRequest{Username: username, Role: role})
+		require.NoError(t, err)
+		require.True(t, assigned.Success)
+	}
+	roles, err := grpcServer.ListRoles(ctx, &pb.ListRolesRequest{Username: username})
+	require.NoError(t, err)
+	require.Len(t, roles.Roles, 2)
+
+	auth, err := grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: password})
+	require.NoError(t, err)
+	introspect, err := grpcServer.Introspect(ctx, &pb.IntrospectRequest{Token: auth.Token})
+	require.NoError(t, err)
+	require.Equal(t, []string{"admin", "viewer"}, introspect.Roles)
+	require.Equal(t, []string{"users:read", "users:write"}, introspect.Scopes)
+
+	// The refreshed tokens carry the roles of the user at the time of the refresh.
+	revoked, err := grpcServer.RevokeRole(ctx, &pb.RevokeRoleRequest{Username: username, Role: "admin"})
+	require.NoError(t, err)
+	require.True(t, revoked.Success)
+	_, err = grpcServer.RevokeRole(ctx, &pb.RevokeRoleRequest{Username: username, Role: "admin"})
+	require.EqualError(t, err, "rpc error: code = NotFound desc = role admin not assigned to user test14")
+	refreshed, err := grpcServer.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: auth.RefreshToken})
+	require.NoError(t, err)
+	introspect, err = grpcServer.Introspect(ctx, &pb.IntrospectRequest{Token: refreshed.Token})
+	require.NoError(t, err)
+	require.Equal(t, []string{"viewer"}, introspect.Roles)
+	require.Equal(t, []string{"users:read"}, introspect.Scopes)
+}
+
+// dialAuthorizedServer serves the authorizedServer in memory and returns a client of it.
+func dialAuthorizedServer(t *testing.T) pb.AuthClient {
+	listener := bufconn.Listen(1024 * 1024)
+	go authorizedServer.Serve(listener)
+	t.Cleanup(authorizedServer.Stop)
+
+	conn, err := grpc.Dial(
+		"bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewAuthClient(conn)
+}
+
+func testServerAuthorization(t *testing.T) {
+	ctx := context.Background()
+	client := dialAuthorizedServer(t)
+	admin, user, password := "admin15", "test15", "password"
+	for _, username := range []string{admin, user} {
+		_, err := client.CreateUser(ctx, &pb.CreateUserRequest{Username: username, Password: password})
+		require.NoError(t, err)
+	}
+	_, err := grpcServer.AssignRole(ctx, &pb.AssignRoleRequest{Username: admin, Role: "admin"})
+	require.NoError(t, err)
+
+	adminAuth, err := client.Authenticate(ctx, &pb.AuthenticateRequest{Username: admin, Password: password})
+	require.NoError(t, err)
+	userAuth, err := client.Authenticate(ctx, &pb.AuthenticateRequest{Username: user, Password: password})
+	require.NoError(t, err)
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+
+	_, err = client.ListUsers(ctx, &pb.ListUsersRequest{})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = missing bearer token")
+	_, err = client.ListUsers(withToken("invalid"), &pb.ListUsersRequest{})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid token")
+	_, err = client.AssignRole(withToken(userAuth.Token), &pb.AssignRoleRequest{Username: user, Role: "admin"})
+	require.EqualError(t, err, "rpc error: code = PermissionDenied desc = permission denied")
+
+	users, err := client.ListUsers(withToken(adminAuth.Token), &pb.ListUsersRequest{Prefix: "test15"})
+	require.NoError(t, err)
+	require.Len(t, users.Users, 1)
+	_, err = client.AssignRole(withToken(adminAuth.Token), &pb.AssignRoleRequest{Username: user, Role: "viewer"})
+	require.NoError(t, err)
+
+	// The token of the logged out admin is no longer accepted.
+	_, err = client.Logout(ctx, &pb.LogoutRequest{Token: adminAuth.Token})
+	require.NoError(t, err)
+	_, err = client.ListUsers(withToken(adminAuth.Token), &pb.ListUsersRequest{})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid token")
+}
+
+func testServerRealms(t *testing.T) {
+	ctx := context.Background()
+	client := dialAuthorizedServer(t)
+	inRealm := func(ctx context.Context, realm string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, server.RealmMetadataKey, realm)
+	}
+	acme := inRealm(ctx, "acme")
+	username := "test16"
+
+	// The same username is created in each realm, with the password policy of the realm.
+	_, err := client.CreateUser(ctx, &pb.CreateUserRequest{Username: username, Password: "password"})
+	require.NoError(t, err)
+	_, err = client.CreateUser(acme, &pb.CreateUserRequest{Username: username, Password: "password"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.CreateUser(acme, &pb.CreateUserRequest{Username: username, Password: "acmepassword"})
+	require.NoError(t, err)
+	_, err = client.CreateUser(inRealm(ctx, "unknown"), &pb.CreateUserRequest{Username: username, Password: "password"})
+	require.EqualError(t, err, "rpc error: code = InvalidArgument desc = realm unknown not found")
+
+	// Each user authenticates with its own password.
+	_, err = client.Authenticate(acme, &pb.AuthenticateRequest{Username: username, Password: "password"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	defaultAuth, err := client.Authenticate(ctx, &pb.AuthenticateRequest{Username: username, Password: "password"})
+	require.NoError(t, err)
+	acmeAuth, err := client.Authenticate(acme, &pb.AuthenticateRequest{Username: username, Password: "acmepassword"})
+	require.NoError(t, err)
+
+	// The tokens carry the tenant and are only active in their realm.
+	introspected, err := client.Introspect(acme, &pb.IntrospectRequest{Token: acmeAuth.Token})
+	require.NoError(t, err)
+	require.True(t, introspected.Active)
+	require.Equal(t, "acme", introspected.Tenant)
+	require.Equal(t, "acme", introspected.Issuer)
+	introspected, err = client.Introspect(ctx, &pb.IntrospectRequest{Token: acmeAuth.Token})
+	require.NoError(t, err)
+	require.False(t, introspected.Active)
+	introspected, err = client.Introspect(acme, &pb.IntrospectRequest{Token: defaultAuth.Token})
+	require.NoError(t, err)
+	require.False(t, introspected.Active)
+	_, err = client.RefreshToken(acme, &pb.RefreshTokenRequest{RefreshToken: defaultAuth.RefreshToken})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid refresh token")
+
+	// Deleting the user of a realm leaves the user of the other realm.
+	_, err = grpcServer.DeleteUser(ctx, &pb.DeleteUserRequest{Username: username})
+	require.NoError(t, err)
+	_, err = client.Authenticate(acme, &pb.AuthenticateRequest{Username: username, Password: "acmepassword"})
+	require.NoError(t, err)
+}
+
+func testServerAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	client := dialAuthorizedServer(t)
+	admin, password := "admin17", "password"
+	_, err := client.CreateUser(ctx, &pb.CreateUserRequest{Username: admin, Password: password})
+	require.NoError(t, err)
+	_, err = grpcServer.AssignRole(ctx, &pb.AssignRoleRequest{Username: admin, Role: "admin"})
+	require.NoError(t, err)
+	adminAuth, err := client.Authenticate(ctx, &pb.AuthenticateRequest{Username: admin, Password: password})
+	require.NoError(t, err)
+	asAdmin := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+adminAuth.Token)
+
+	// The API keys are administered by the admins.
+	_, err = client.CreateAPIKey(ctx, &pb.CreateAPIKeyRequest{ServiceAccount: "billing"})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = missing bearer token")
+	created, err := client.CreateAPIKey(asAdmin, &pb.CreateAPIKeyRequest{
+		ServiceAccount: "billing",
+		Permissions:    []string{"invoices:write", "users:read"},
+		ExpiresAt:      time.Now().Add(time.Hour).Unix(),
+	})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(created.Key, created.ApiKey.Prefix+"_"))
+	keys, err := client.ListAPIKeys(asAdmin, &pb.ListAPIKeysRequest{ServiceAccount: "billing"})
+	require.NoError(t, err)
+	require.Len(t, keys.ApiKeys, 1)
+	require.Equal(t, created.ApiKey.Prefix, keys.ApiKeys[0].Prefix)
+
+	// The API key is exchanged for a token of the service account carrying the permissions of the key.
+	_, err = client.ExchangeAPIKey(ctx, &pb.ExchangeAPIKeyRequest{Key: created.Key + "x"})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid API key")
+	exchanged, err := client.ExchangeAPIKey(ctx, &pb.ExchangeAPIKeyRequest{Key: created.Key})
+	require.NoError(t, err)
+	introspected, err := client.Introspect(ctx, &pb.IntrospectRequest{Token: exchanged.Token})
+	require.NoError(t, err)
+	require.True(t, introspected.Active)
+	require.Equal(t, "billing", introspected.Subject)
+	require.Equal(t, "service", introspected.ClientType)
+	require.Equal(t, []string{"invoices:write", "users:read"}, introspected.Scopes)
+
+	// The token of a service account doesn't act as a user.
+	_, err = client.ChangePassword(ctx, &pb.ChangePasswordRequest{Token: exchanged.Token, NewPassword: "newpassword"})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid token")
+
+	// Revoking the key deactivates the tokens exchanged for it.
+	_, err = client.RevokeAPIKey(asAdmin, &pb.RevokeAPIKeyRequest{Prefix: created.ApiKey.Prefix})
+	require.NoError(t, err)
+	introspected, err = client.Introspect(ctx, &pb.IntrospectRequest{Token: exchanged.Token})
+	require.NoError(t, err)
+	require.False(t, introspected.Active)
+	_, err = client.ExchangeAPIKey(ctx, &pb.ExchangeAPIKeyRequest{Key: created.Key})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid API key")
+	_, err = client.RevokeAPIKey(asAdmin, &pb.RevokeAPIKeyRequest{Prefix: created.ApiKey.Prefix})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// postToken posts the form to the OAuth token endpoint and decodes the JSON response.
+func postToken(t *testing.T, path string, form url.Values) (int, map[string]interface{}) {
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	httpHandler.ServeHTTP(recorder, request)
+
+	var response map[string]interface{}
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+	return recorder.Code, response
+}
+
+func testServerOAuth(t *testing.T) {
+	ctx := context.Background()
+	client := dialAuthorizedServer(t)
+	admin, password := "admin19", "password"
+	_, err := client.CreateUser(ctx, &pb.CreateUserRequest{Username: admin, Password: password})
+	require.NoError(t, err)
+	_, err = grpcServer.AssignRole(ctx, &pb.AssignRoleRequest{Username: admin, Role: "admin"})
+	require.NoError(t, err)
+	adminAuth, err := client.Authenticate(ctx, &pb.AuthenticateRequest{Username: admin, Password: password})
+	require.NoError(t, err)
+	asAdmin := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+adminAuth.Token)
+
+	// The OAuth clients are registered by the admins.
+	_, err = client.CreateOAuthClient(ctx, &pb.CreateOAuthClientRequest{ClientId: "billing"})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = missing bearer token")
+	created, err := client.CreateOAuthClient(asAdmin, &pb.CreateOAuthClientRequest{
+		ClientId:  "billing",
+		Scopes:    []string{"invoices:read", "invoices:write"},
+		Audiences: []string{"https://billing.example.com"},
+	})
+	require.NoError(t, err)
+	_, err = client.CreateOAuthClient(asAdmin, &pb.CreateOAuthClientRequest{ClientId: "billing"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// The client_credentials grant issues a token to the client with the requested scopes and audience.
+	code, response := postToken(t, "/oauth/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"billing"},
+		"client_secret": {created.ClientSecret},
+		"scope":         {"invoices:read"},
+		"audience":      {"https://billing.example.com"},
+	})
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "Bearer", response["token_type"])
+	require.Equal(t, float64(600), response["expires_in"])
+	require.Equal(t, "invoices:read", response["scope"])
+	introspected, err := client.Introspect(ctx, &pb.IntrospectRequest{Token: response["access_token"].(string)})
+	require.NoError(t, err)
+	require.True(t, introspected.Active)
+	require.Equal(t, "billing", introspected.Subject)
+	require.Equal(t, "service", introspected.ClientType)
+	require.Equal(t, []string{"invoices:read"}, introspected.Scopes)
+	clientToken := response["access_token"].(string)
+
+	code, response = postToken(t, "/oauth/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"billing"},
+		"client_secret": {created.ClientSecret},
+		"scope":         {"users:write"},
+	})
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, "invalid_scope", response["error"])
+	code, response = postToken(t, "/oauth/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"billing"},
+		"client_secret": {"wrong"},
+	})
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.Equal(t, "invalid_client", response["error"])
+
+	// The password grant issues the tokens of the user to the client.
+	code, response = postToken(t, "/oauth/token", url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {"billing"},
+		"client_secret": {created.ClientSecret},
+		"username":      {admin},
+		"password":      {password},
+	})
+	require.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, response["refresh_token"])
+	introspected, err = client.Introspect(ctx, &pb.IntrospectRequest{Token: response["access_token"].(string)})
+	require.NoError(t, err)
+	require.True(t, introspected.Active)
+	require.Equal(t, admin, introspected.Subject)
+	require.Empty(t, introspected.ClientType)
+	code, response = postToken(t, "/oauth/token", url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {"billing"},
+		"client_secret": {created.ClientSecret},
+		"username":      {admin},
+		"password":      {"wrong"},
+	})
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, "invalid_grant", response["error"])
+
+	// The clients of a realm are unknown to the other realms.
+	code, response = postToken(t, "/realms/acme/oauth/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"billing"},
+		"client_secret": {created.ClientSecret},
+	})
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.Equal(t, "invalid_client", response["error"])
+
+	// A deleted client gets no more tokens, and its tokens are inactive.
+	_, err = client.DeleteOAuthClient(asAdmin, &pb.DeleteOAuthClientRequest{ClientId: "billing"})
+	require.NoError(t, err)
+	code, _ = postToken(t, "/oauth/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"billing"},
+		"client_secret": {created.ClientSecret},
+	})
+	require.Equal(t, http.StatusUnauthorized, code)
+	introspected, err = client.Introspect(ctx, &pb.IntrospectRequest{Token: clientToken})
+	require.NoError(t, err)
+	require.False(t, introspected.Active)
+}
+
+// testServerOAuthIPLockout fails the password grant of many usernames from an address,
+// which locks the logins from the address out of the password grant.
+func testServerOAuthIPLockout(t *testing.T) {
+	ctx := context.Background()
+	username, password := "test21", "password"
+	createUser(t, username, password)
+	secret, err := grpcServer.CreateOAuthClient(ctx, &pb.CreateOAuthClientRequest{ClientId: "web"})
+	require.NoError(t, err)
+	passwordGrant := func(remoteAddr, username, password string) (int, map[string]interface{}) {
+		request := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(url.Values{
+			"grant_type":    {"password"},
+			"client_id":     {"web"},
+			"client_secret": {secret.ClientSecret},
+			"username":      {username},
+			"password":      {password},
+		}.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		httpHandler.ServeHTTP(recorder, request)
+
+		var response map[string]interface{}
+		require.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		return recorder.Code, response
+	}
+
+	// Each username stays under its own limit, the address reaches its limit.
+	for i := 0; i < 10; i++ {
+		code, response := passwordGrant("10.0.0.1:5000", fmt.Sprintf("guess%d", i), "wrong")
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, "invalid_grant", response["error"])
+	}
+	code, response := passwordGrant("10.0.0.1:6000", username, password)
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, "invalid_grant", response["error"])
+
+	// The other addresses are not locked out.
+	code, response = passwordGrant("10.0.0.2:5000", username, password)
+	require.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, response["access_token"])
+}
+
+// newOIDCServer starts an HTTP server of the default realm, whose issuer is the URL of the server
+// and which signs the tokens with an ES256 key, so the ID tokens can be verified with the published keys.
+func newOIDCServer(t *testing.T) (*httptest.Server, server.Realm) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "private.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+	ts := httptest.NewUnstartedServer(nil)
+	realm := newRealm(t, realmStores, config.DefaultRealm, realmHasher, config.Password{}, config.Token{
+		SigningMethod:  "ES256",
+		PrivateKeyFile: keyFile,
+		Audience:       "audience",
+		Issuer:         "http://" + ts.Listener.Addr().String(),
+		ExpDuration:    10,
+	})
+	ts.Config.Handler = server.NewHTTPHandler(map[string]server.Realm{config.DefaultRealm: realm})
+	ts.Start()
+	return ts, realm
+}
+
+// idTokenKey returns the public key of the JSON Web Key Set with the id.
+func idTokenKey(t *testing.T, jwks jwt.JSONWebKeySet, kid string) *ecdsa.PublicKey {
+	for _, k := range jwks.Keys {
+		if k.Kid == kid {
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			require.NoError(t, err)
+			y, err := base64.RawURLEncoding.DecodeString(k.Y)
+			require.NoError(t, err)
+			return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	t.Fatalf("key %s not found", kid)
+	return nil
+}
+
+// newRequest returns a client request of the method to the URL.
+func newRequest(t *testing.T, method, url string, body io.Reader) *http.Request {
+	request, err := http.NewRequest(method, url, body)
+	require.NoError(t, err)
+	return request
+}
+
+// getJSON sends the request with the client and decodes the JSON response into v.
+func getJSON(t *testing.T, client *http.Client, request *http.Request, v interface{}) int {
+	response, err := client.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.NoError(t, json.NewDecoder(response.Body).Decode(v))
+	return response.StatusCode
+}
+
+func testServerOIDC(t *testing.T) {
+	ctx := context.Background()
+	ts, realm := newOIDCServer(t)
+	defer ts.Close()
+	// The browser keeps the cookies of the provider and stops at the redirect to the web app.
+	browser := ts.Client()
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	browser.Jar = jar
+	browser.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	username, password := "test20", "password"
+	createUser(t, username, password)
+	user, err := userStore.Get(ctx, username)
+	require.NoError(t, err)
+	user.Email = "test20@example.com"
+	_, err = userStore.Update(ctx, username, *user)
+	require.NoError(t, err)
+	redirectURI := "https://app.example.com/callback"
+	secret, err := realm.OAuthService.CreateClient(ctx, "webapp", nil, nil, []string{redirectURI})
+	require.NoError(t, err)
+
+	// The web app discovers the endpoints of the provider.
+	var discovery map[string]interface{}
+	require.Equal(t, http.StatusOK, getJSON(t, browser, newRequest(t, http.MethodGet, ts.URL+"/.well-known/openid-configuration", nil), &discovery))
+	require.Equal(t, ts.URL, discovery["issuer"])
+	require.Equal(t, []interface{}{"ES256"}, discovery["id_token_signing_alg_values_supported"])
+	authorizationEndpoint := discovery["authorization_endpoint"].(string)
+	tokenEndpoint := discovery["token_endpoint"].(string)
+
+	// The user is sent to the login page with the PKCE challenge of the web app.
+	verifier := strings.Repeat("v", 64)
+	sum := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"webapp"},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {"openid profile"},
+		"state":                 {"st4te"},
+		"nonce":                 {"n0nce"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+	response, err := browser.Get(authorizationEndpoint + "?" + params.Encode())
+	require.NoError(t, err)
+	page, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Contains(t, string(page), `<form method="post" action="/authorize">`)
+	csrfToken := regexp.MustCompile(`name="csrf_token" value="([^"]+)"`).FindStringSubmatch(string(page))
+	require.Len(t, csrfToken, 2)
+
+	// The login form is posted with the parameters of the request and the CSRF token of the page.
+	login := func(username, password string) (*http.Response, string) {
+		form := url.Values{"username": {username}, "password": {password}, "csrf_token": {csrfToken[1]}}
+		for name, values := range params {
+			form[name] = values
+		}
+		response, err := browser.PostForm(ts.URL+"/authorize", form)
+		require.NoError(t, err)
+		page, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		require.NoError(t, err)
+		return response, string(page)
+	}
+	response, _ = login(username, "wrong")
+	require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	response, _ = login(username, password)
+	require.Equal(t, http.StatusFound, response.StatusCode)
+	location, err := url.Parse(response.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, redirectURI, location.Scheme+"://"+location.Host+location.Path)
+	require.Equal(t, "st4te", location.Query().Get("state"))
+	code := location.Query().Get("code")
+	require.NotEmpty(t, code)
+
+	// The web app exchanges the code with the PKCE verifier for the tokens of the user.
+	exchange := func(verifier string) (int, map[string]interface{}) {
+		request := newRequest(t, http.MethodPost, tokenEndpoint, strings.NewReader(url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {verifier},
+		}.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.SetBasicAuth("webapp", secret)
+		var tokens map[string]interface{}
+		return getJSON(t, browser, request, &tokens), tokens
+	}
+	status, tokens := exchange(strings.Repeat("x", 64))
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "invalid_grant", tokens["error"])
+	status, tokens = exchange(verifier)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, tokens["refresh_token"])
+	require.Equal(t, "openid profile", tokens["scope"])
+
+	// The ID token is signed by a key of the published key set.
+	var jwks jwt.JSONWebKeySet
+	require.Equal(t, http.StatusOK, getJSON(t, browser, newRequest(t, http.MethodGet, discovery["jwks_uri"].(string), nil), &jwks))
+	claims := gojwt.MapClaims{}
+	_, err = gojwt.ParseWithClaims(tokens["id_token"].(string), claims, func(token *gojwt.Token) (interface{}, error) {
+		return idTokenKey(t, jwks, token.Header["kid"].(string)), nil
+	}, gojwt.WithValidMethods([]string{"ES256"}))
+	require.NoError(t, err)
+	require.Equal(t, ts.URL, claims["iss"])
+	require.Equal(t, username, claims["sub"])
+	require.Equal(t, "webapp", claims["aud"])
+	require.Equal(t, "n0nce", claims["nonce"])
+	require.Equal(t, username, claims["preferred_username"])
+	require.NotEmpty(t, claims["auth_time"])
+
+	// The access token gets the claims of the user from the userinfo endpoint.
+	request := newRequest(t, http.MethodGet, discovery["userinfo_endpoint"].(string), nil)
+	request.Header.Set("Authorization", "Bearer "+tokens["access_token"].(string))
+	var userInfo map[string]interface{}
+	require.Equal(t, http.StatusOK, getJSON(t, browser, request, &userInfo))
+	require.Equal(t, username, userInfo["sub"])
+	// The email is not returned without the email scope.
+	require.Equal(t, username, userInfo["preferred_username"])
+	require.NotContains(t, userInfo, "email")
+	introspected, err := realm.AuthService.Introspect(ctx, tokens["access_token"].(string))
+	require.NoError(t, err)
+	require.NotNil(t, introspected)
+
+	// The code can only be exchanged once.
+	status, tokens = exchange(verifier)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "invalid_grant", tokens["error"])
+
+	// The forms posted by the other sites, without the CSRF token of the browser, don't sign the user in.
+	form := url.Values{"username": {username}, "password": {password}}
+	for name, values := range params {
+		form[name] = values
+	}
+	response, err = ts.Client().PostForm(ts.URL+"/authorize", form)
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	// The locked account is shown on the login page.
+	for i := 0; i < 3; i++ {
+		response, _ = login(username, "wrong")
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	}
+	response, lockedPage := login(username, password)
+	require.Equal(t, http.StatusForbidden, response.StatusCode)
+	require.Contains(t, lockedPage, "account temporarily locked until")
+}
+
+func testServerRefreshToken(t *testing.T) {
+	username := "test6"
+	password := "password"
+	createUser(t, username, password)
+
+	auth, err := grpcServer.Authenticate(context.Background(), &pb.AuthenticateRequest{
+		Username: username,
+		Password: password,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, auth.RefreshToken)
+
+	refreshed, err := grpcServer.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: auth.RefreshToken})
+	require.NoError(t, err)
+	require.NotEmpty(t, refreshed.Token)
+	require.NotEqual(t, auth.RefreshToken, refreshed.RefreshToken)
+
+	// Reusing the rotated token revokes the whole family, including the token issued by the rotation.
+	_, err = grpcServer.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: auth.RefreshToken})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid refresh token")
+	_, err = grpcServer.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: refreshed.RefreshToken})
+	require.EqualError(t, err, "rpc error: code = Unauthenticated desc = invalid refresh token")
+}
+
+func testServerUserLifecycle(t *testing.T) {
+	ctx := context.Background()
+	for _, username := range []string{"member1", "member2", "member3", "other"} {
+		createUser(t, username, "password")
+	}
+
+	got, err := grpcServer.GetUser(ctx, &pb.GetUserRequest{Username: "member1"})
+	require.NoError(t, err)
+	require.Equal(t, "member1", got.User.Username)
+
+	_, err = grpcServer.UpdateUser(ctx, &pb.UpdateUserRequest{Username: "member1", NewUsername: "member0", Password: "newpassword"})
+	require.NoError(t, err)
+	_, err = grpcServer.Authenticate(ctx, &pb.AuthenticateRequest{Username: "member0", Password: "newpassword"})
+	require.NoError(t, err)
+	_, err = grpcServer.GetUser(ctx, &pb.GetUserRequest{Username: "member1"})
+	require.EqualError(t, err, "rpc error: code = NotFound desc = user member1 not found")
+
+	var usernames []string
+	request := &pb.ListUsersRequest{Prefix: "member", PageSize: 2}
+	for {
+		page, err := grpcServer.ListUsers(ctx, request)
+		require.NoError(t, err)
+		for _, u := range page.Users {
+			usernames = append(usernames, u.Username)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		request.PageToken = page.NextPageToken
+	}
+	require.Equal(t, []string{"member0", "member2", "member3"}, usernames)
+
+	_, err = grpcServer.DeleteUser(ctx, &pb.DeleteUserRequest{Username: "member0"})
+	require.NoError(t, err)
+	_, err = grpcServer.DeleteUser(ctx, &pb.DeleteUserRequest{Username: "member0"})
+	require.EqualError(t, err, "rpc error: code = NotFound desc = user member0 not found")
+}
+
+func testServerListUsersPages(t *testing.T) {
+	ctx := context.Background()
+	// One more user than the largest page, created in the store to skip the password hashing.
+	const users = 501
+	for i := 0; i < users; i++ {
+		require.NoError(t, userStore.Create(ctx, models.User{Username: fmt.Sprintf("page%04d", i), Password: "hash"}))
+	}
+	require.NoError(t, userStore.Create(ctx, models.User{Username: "pagf", Password: "hash"}))
+
+	// The page size is limited to 500 users.
+	page, err := grpcServer.ListUsers(ctx, &pb.ListUsersRequest{Prefix: "page", PageSize: 1000})
+	require.NoError(t, err)
+	require.Len(t, page.Users, 500)
+	require.Equal(t, "page0499", page.Users[499].Username)
+	require.NotEmpty(t, page.NextPageToken)
+
+	// The token of a page is refused for another prefix.
+	_, err = grpcServer.ListUsers(ctx, &pb.ListUsersRequest{Prefix: "pag", PageSize: 1000, PageToken: page.NextPageToken})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	last, err := grpcServer.ListUsers(ctx, &pb.ListUsersRequest{Prefix: "page", PageSize: 1000, PageToken: page.NextPageToken})
+	require.NoError(t, err)
+	require.Len(t, last.Users, 1)
+	require.Equal(t, "page0500", last.Users[0].Username)
+	require.Empty(t, last.NextPageToken)
+}
+
 func testServerCreateSameUser(t *testing.T) {
 	username := "test2"
 	password := "password"
@@ -152,6 +1287,57 @@ func testServerCreateSameUser(t *testing.T) {
 	require.EqualError(t, err, "rpc error: code = AlreadyExists desc = username test2 already exists")
 }
 
+// testServerCreateConcurrently sends the same CreateUser requests in parallel,
+// each user is created once and the other requests get an AlreadyExists error.
+func testServerCreateConcurrently(t *testing.T, storeFn func() (stores.Stores, func(), error)) {
+	s, tearDown, err := storeFn()
+	require.NoError(t, err)
+	defer tearDown()
+	ctx := context.Background()
+	tenant := fmt.Sprintf("concurrent%d", time.Now().UnixNano())
+	realm := newRealm(t, s, tenant, hashers.NewBcryptHasher(bcrypt.MinCost), config.Password{}, config.Token{
+		SigningMethod: "HS256",
+		SignedKey:     "sdfsadfa",
+		Audience:      "audience",
+		Issuer:        "issuer",
+		ExpDuration:   10,
+	})
+	srv := server.NewAuthServer(realm.UserService, realm.AuthService, realm.PasswordResetService, realm.RoleService, realm.APIKeyService, realm.OAuthService)
+
+	const users, requests = 5, 10
+	var mu sync.Mutex
+	codesByUser := make(map[string][]codes.Code, users)
+	var wg sync.WaitGroup
+	for i := 0; i < users; i++ {
+		username := fmt.Sprintf("user%d", i)
+		defer s.UserStore(tenant).Delete(ctx, username)
+		for j := 0; j < requests; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := srv.CreateUser(ctx, &pb.CreateUserRequest{Username: username, Password: "password"})
+				mu.Lock()
+				codesByUser[username] = append(codesByUser[username], status.Code(err))
+				mu.Unlock()
+			}()
+		}
+	}
+	wg.Wait()
+
+	for username, got := range codesByUser {
+		created := 0
+		for _, code := range got {
+			if code == codes.OK {
+				created++
+				continue
+			}
+			require.Equal(t, codes.AlreadyExists, code, username)
+		}
+		require.Equal(t, 1, created, username)
+	}
+	require.Len(t, codesByUser, users)
+}
+
 func createUser(t *testing.T, username string, password string) {
 	response, err := grpcServer.CreateUser(context.Background(), &pb.CreateUserRequest{
 		Username: username,
@@ -5,6 +5,8 @@
 package tests
 
 import (
+	jwt "auth/pkg/jwt"
+	models "auth/pkg/models"
 	context "context"
 	reflect "reflect"
 
@@ -35,10 +37,10 @@ func (m *MockAuthService) EXPECT() *MockAuthServiceMockRecorder {
 }
 
 // Authenticate mocks base method.
-func (m *MockAuthService) Authenticate(ctx context.Context, username, password string) (string, error) {
+func (m *MockAuthService) Authenticate(ctx context.Context, username, password string) (*models.Tokens, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Authenticate", ctx, username, password)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(*models.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -48,3 +50,240 @@ func (mr *MockAuthServiceMockRecorder) Authenticate(ctx, username, password inte
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAuthService)(nil).Authenticate), ctx, username, password)
 }
+
+// AuthenticateUser mocks base method.
+func (m *MockAuthService) AuthenticateUser(ctx context.Context, username, password string) (*models.User, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateUser", ctx, username, password)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AuthenticateUser indicates an expected call of AuthenticateUser.
+func (mr *MockAuthServiceMockRecorder) AuthenticateUser(ctx, username, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateUser", reflect.TypeOf((*MockAuthService)(nil).AuthenticateUser), ctx, username, password)
+}
+
+// AuthenticateWithRecoveryCode mocks base method.
+func (m *MockAuthService) AuthenticateWithRecoveryCode(ctx context.Context, username, code string) (*models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateWithRecoveryCode", ctx, username, code)
+	ret0, _ := ret[0].(*models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateWithRecoveryCode indicates an expected call of AuthenticateWithRecoveryCode.
+func (mr *MockAuthServiceMockRecorder) AuthenticateWithRecoveryCode(ctx, username, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateWithRecoveryCode", reflect.TypeOf((*MockAuthService)(nil).AuthenticateWithRecoveryCode), ctx, username, code)
+}
+
+// ChangePassword mocks base method.
+func (m *MockAuthService) ChangePassword(ctx context.Context, token, currentPassword, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, token, currentPassword, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAuthServiceMockRecorder) ChangePassword(ctx, token, currentPassword, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAuthService)(nil).ChangePassword), ctx, token, currentPassword, newPassword)
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockAuthService) ConfirmTOTP(ctx context.Context, token, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", ctx, token, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockAuthServiceMockRecorder) ConfirmTOTP(ctx, token, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockAuthService)(nil).ConfirmTOTP), ctx, token, code)
+}
+
+// CountRecoveryCodes mocks base method.
+func (m *MockAuthService) CountRecoveryCodes(ctx context.Context, token string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRecoveryCodes", ctx, token)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRecoveryCodes indicates an expected call of CountRecoveryCodes.
+func (mr *MockAuthServiceMockRecorder) CountRecoveryCodes(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRecoveryCodes", reflect.TypeOf((*MockAuthService)(nil).CountRecoveryCodes), ctx, token)
+}
+
+// EnrollTOTP mocks base method.
+func (m *MockAuthService) EnrollTOTP(ctx context.Context, token string) (*models.TOTPEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTOTP", ctx, token)
+	ret0, _ := ret[0].(*models.TOTPEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTOTP indicates an expected call of EnrollTOTP.
+func (mr *MockAuthServiceMockRecorder) EnrollTOTP(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTOTP", reflect.TypeOf((*MockAuthService)(nil).EnrollTOTP), ctx, token)
+}
+
+// ExchangeAPIKey mocks base method.
+func (m *MockAuthService) ExchangeAPIKey(ctx context.Context, apiKey string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExchangeAPIKey", ctx, apiKey)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExchangeAPIKey indicates an expected call of ExchangeAPIKey.
+func (mr *MockAuthServiceMockRecorder) ExchangeAPIKey(ctx, apiKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangeAPIKey", reflect.TypeOf((*MockAuthService)(nil).ExchangeAPIKey), ctx, apiKey)
+}
+
+// GenerateRecoveryCodes mocks base method.
+func (m *MockAuthService) GenerateRecoveryCodes(ctx context.Context, token string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateRecoveryCodes", ctx, token)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateRecoveryCodes indicates an expected call of GenerateRecoveryCodes.
+func (mr *MockAuthServiceMockRecorder) GenerateRecoveryCodes(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRecoveryCodes", reflect.TypeOf((*MockAuthService)(nil).GenerateRecoveryCodes), ctx, token)
+}
+
+// Introspect mocks base method.
+func (m *MockAuthService) Introspect(ctx context.Context, token string) (*jwt.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Introspect", ctx, token)
+	ret0, _ := ret[0].(*jwt.Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Introspect indicates an expected call of Introspect.
+func (mr *MockAuthServiceMockRecorder) Introspect(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Introspect", reflect.TypeOf((*MockAuthService)(nil).Introspect), ctx, token)
+}
+
+// IssueTokens mocks base method.
+func (m *MockAuthService) IssueTokens(ctx context.Context, username string, scopes []string) (*models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueTokens", ctx, username, scopes)
+	ret0, _ := ret[0].(*models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueTokens indicates an expected call of IssueTokens.
+func (mr *MockAuthServiceMockRecorder) IssueTokens(ctx, username, scopes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueTokens", reflect.TypeOf((*MockAuthService)(nil).IssueTokens), ctx, username, scopes)
+}
+
+// JWKS mocks base method.
+func (m *MockAuthService) JWKS(ctx context.Context) (jwt.JSONWebKeySet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS", ctx)
+	ret0, _ := ret[0].(jwt.JSONWebKeySet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockAuthServiceMockRecorder) JWKS(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockAuthService)(nil).JWKS), ctx)
+}
+
+// Logout mocks base method.
+func (m *MockAuthService) Logout(ctx context.Context, token, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, token, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAuthServiceMockRecorder) Logout(ctx, token, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthService)(nil).Logout), ctx, token, refreshToken)
+}
+
+// Refresh mocks base method.
+func (m *MockAuthService) Refresh(ctx context.Context, refreshToken string) (*models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", ctx, refreshToken)
+	ret0, _ := ret[0].(*models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockAuthServiceMockRecorder) Refresh(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockAuthService)(nil).Refresh), ctx, refreshToken)
+}
+
+// UnlockUser mocks base method.
+func (m *MockAuthService) UnlockUser(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockAuthServiceMockRecorder) UnlockUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockAuthService)(nil).UnlockUser), ctx, username)
+}
+
+// VerifyMFA mocks base method.
+func (m *MockAuthService) VerifyMFA(ctx context.Context, mfaToken, code string) (*models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMFA", ctx, mfaToken, code)
+	ret0, _ := ret[0].(*models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFA indicates an expected call of VerifyMFA.
+func (mr *MockAuthServiceMockRecorder) VerifyMFA(ctx, mfaToken, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFA", reflect.TypeOf((*MockAuthService)(nil).VerifyMFA), ctx, mfaToken, code)
+}
+
+// VerifyMFAUser mocks base method.
+func (m *MockAuthService) VerifyMFAUser(ctx context.Context, mfaToken, code string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMFAUser", ctx, mfaToken, code)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFAUser indicates an expected call of VerifyMFAUser.
+func (mr *MockAuthServiceMockRecorder) VerifyMFAUser(ctx, mfaToken, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFAUser", reflect.TypeOf((*MockAuthService)(nil).VerifyMFAUser), ctx, mfaToken, code)
+}
@@ -5,6 +5,7 @@
 package tests
 
 import (
+	jwt "auth/pkg/jwt"
 	models "auth/pkg/models"
 	reflect "reflect"
 
@@ -35,16 +36,21 @@ func (m *MockTokenGenerator) EXPECT() *MockTokenGeneratorMockRecorder {
 }
 
 // Generate mocks base method.
-func (m *MockTokenGenerator) Generate(user models.User) (string, error) {
+func (m *MockTokenGenerator) Generate(user models.User, options ...jwt.Option) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Generate", user)
+	varargs := []interface{}{user}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Generate", varargs...)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Generate indicates an expected call of Generate.
-func (mr *MockTokenGeneratorMockRecorder) Generate(user interface{}) *gomock.Call {
+func (mr *MockTokenGeneratorMockRecorder) Generate(user interface{}, options ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*MockTokenGenerator)(nil).Generate), user)
+	varargs := append([]interface{}{user}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*MockTokenGenerator)(nil).Generate), varargs...)
 }
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./pkg/notifiers/notifier.go
+
+// Package tests is a generated GoMock package.
+package tests
+
+import (
+	models "auth/pkg/models"
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNotifier is a mock of Notifier interface.
+type MockNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotifierMockRecorder
+}
+
+// MockNotifierMockRecorder is the mock recorder for MockNotifier.
+type MockNotifierMockRecorder struct {
+	mock *MockNotifier
+}
+
+// NewMockNotifier creates a new mock instance.
+func NewMockNotifier(ctrl *gomock.Controller) *MockNotifier {
+	mock := &MockNotifier{ctrl: ctrl}
+	mock.recorder = &MockNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotifier) EXPECT() *MockNotifierMockRecorder {
+	return m.recorder
+}
+
+// NotifyPasswordReset mocks base method.
+func (m *MockNotifier) NotifyPasswordReset(ctx context.Context, user models.User, token string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NotifyPasswordReset", ctx, user, token, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NotifyPasswordReset indicates an expected call of NotifyPasswordReset.
+func (mr *MockNotifierMockRecorder) NotifyPasswordReset(ctx, user, token, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyPasswordReset", reflect.TypeOf((*MockNotifier)(nil).NotifyPasswordReset), ctx, user, token, expiresAt)
+}
@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./pkg/services/oauthService.go
+
+// Package tests is a generated GoMock package.
+package tests
+
+import (
+	models "auth/pkg/models"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOAuthService is a mock of OAuthService interface.
+type MockOAuthService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthServiceMockRecorder
+}
+
+// MockOAuthServiceMockRecorder is the mock recorder for MockOAuthService.
+type MockOAuthServiceMockRecorder struct {
+	mock *MockOAuthService
+}
+
+// NewMockOAuthService creates a new mock instance.
+func NewMockOAuthService(ctrl *gomock.Controller) *MockOAuthService {
+	mock := &MockOAuthService{ctrl: ctrl}
+	mock.recorder = &MockOAuthServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuthService) EXPECT() *MockOAuthServiceMockRecorder {
+	return m.recorder
+}
+
+// ClientCredentials mocks base method.
+func (m *MockOAuthService) ClientCredentials(ctx context.Context, clientID, clientSecret string, scopes []string, audience string) (*models.OAuthToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientCredentials", ctx, clientID, clientSecret, scopes, audience)
+	ret0, _ := ret[0].(*models.OAuthToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientCredentials indicates an expected call of ClientCredentials.
+func (mr *MockOAuthServiceMockRecorder) ClientCredentials(ctx, clientID, clientSecret, scopes, audience interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientCredentials", reflect.TypeOf((*MockOAuthService)(nil).ClientCredentials), ctx, clientID, clientSecret, scopes, audience)
+}
+
+// CreateClient mocks base method.
+func (m *MockOAuthService) CreateClient(ctx context.Context, id string, scopes, audiences, redirectURIs []string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateClient", ctx, id, scopes, audiences, redirectURIs)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateClient indicates an expected call of CreateClient.
+func (mr *MockOAuthServiceMockRecorder) CreateClient(ctx, id, scopes, audiences, redirectURIs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateClient", reflect.TypeOf((*MockOAuthService)(nil).CreateClient), ctx, id, scopes, audiences, redirectURIs)
+}
+
+// DeleteClient mocks base method.
+func (m *MockOAuthService) DeleteClient(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteClient", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteClient indicates an expected call of DeleteClient.
+func (mr *MockOAuthServiceMockRecorder) DeleteClient(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteClient", reflect.TypeOf((*MockOAuthService)(nil).DeleteClient), ctx, id)
+}
+
+// Password mocks base method.
+func (m *MockOAuthService) Password(ctx context.Context, clientID, clientSecret, username, password string) (*models.OAuthToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Password", ctx, clientID, clientSecret, username, password)
+	ret0, _ := ret[0].(*models.OAuthToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Password indicates an expected call of Password.
+func (mr *MockOAuthServiceMockRecorder) Password(ctx, clientID, clientSecret, username, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Password", reflect.TypeOf((*MockOAuthService)(nil).Password), ctx, clientID, clientSecret, username, password)
+}
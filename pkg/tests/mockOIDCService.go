@@ -0,0 +1,125 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./pkg/services/oidcService.go
+
+// Package tests is a generated GoMock package.
+package tests
+
+import (
+	models "auth/pkg/models"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOIDCService is a mock of OIDCService interface.
+type MockOIDCService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOIDCServiceMockRecorder
+}
+
+// MockOIDCServiceMockRecorder is the mock recorder for MockOIDCService.
+type MockOIDCServiceMockRecorder struct {
+	mock *MockOIDCService
+}
+
+// NewMockOIDCService creates a new mock instance.
+func NewMockOIDCService(ctrl *gomock.Controller) *MockOIDCService {
+	mock := &MockOIDCService{ctrl: ctrl}
+	mock.recorder = &MockOIDCServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOIDCService) EXPECT() *MockOIDCServiceMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method.
+func (m *MockOIDCService) Authorize(ctx context.Context, req models.AuthorizationRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockOIDCServiceMockRecorder) Authorize(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockOIDCService)(nil).Authorize), ctx, req)
+}
+
+// ExchangeCode mocks base method.
+func (m *MockOIDCService) ExchangeCode(ctx context.Context, clientID, clientSecret, code, redirectURI, codeVerifier string) (*models.OAuthToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExchangeCode", ctx, clientID, clientSecret, code, redirectURI, codeVerifier)
+	ret0, _ := ret[0].(*models.OAuthToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExchangeCode indicates an expected call of ExchangeCode.
+func (mr *MockOIDCServiceMockRecorder) ExchangeCode(ctx, clientID, clientSecret, code, redirectURI, codeVerifier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangeCode", reflect.TypeOf((*MockOIDCService)(nil).ExchangeCode), ctx, clientID, clientSecret, code, redirectURI, codeVerifier)
+}
+
+// Issuer mocks base method.
+func (m *MockOIDCService) Issuer() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Issuer")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Issuer indicates an expected call of Issuer.
+func (mr *MockOIDCServiceMockRecorder) Issuer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Issuer", reflect.TypeOf((*MockOIDCService)(nil).Issuer))
+}
+
+// Login mocks base method.
+func (m *MockOIDCService) Login(ctx context.Context, req models.AuthorizationRequest, username, password string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, req, username, password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockOIDCServiceMockRecorder) Login(ctx, req, username, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockOIDCService)(nil).Login), ctx, req, username, password)
+}
+
+// LoginMFA mocks base method.
+func (m *MockOIDCService) LoginMFA(ctx context.Context, req models.AuthorizationRequest, mfaToken, totpCode string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginMFA", ctx, req, mfaToken, totpCode)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginMFA indicates an expected call of LoginMFA.
+func (mr *MockOIDCServiceMockRecorder) LoginMFA(ctx, req, mfaToken, totpCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginMFA", reflect.TypeOf((*MockOIDCService)(nil).LoginMFA), ctx, req, mfaToken, totpCode)
+}
+
+// UserInfo mocks base method.
+func (m *MockOIDCService) UserInfo(ctx context.Context, token string) (*models.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserInfo", ctx, token)
+	ret0, _ := ret[0].(*models.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserInfo indicates an expected call of UserInfo.
+func (mr *MockOIDCServiceMockRecorder) UserInfo(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserInfo", reflect.TypeOf((*MockOIDCService)(nil).UserInfo), ctx, token)
+}
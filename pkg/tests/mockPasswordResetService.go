@@ -0,0 +1,77 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./pkg/services/passwordResetService.go
+
+// Package tests is a generated GoMock package.
+package tests
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPasswordResetService is a mock of PasswordResetService interface.
+type MockPasswordResetService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordResetServiceMockRecorder
+}
+
+// MockPasswordResetServiceMockRecorder is the mock recorder for MockPasswordResetService.
+type MockPasswordResetServiceMockRecorder struct {
+	mock *MockPasswordResetService
+}
+
+// NewMockPasswordResetService creates a new mock instance.
+func NewMockPasswordResetService(ctrl *gomock.Controller) *MockPasswordResetService {
+	mock := &MockPasswordResetService{ctrl: ctrl}
+	mock.recorder = &MockPasswordResetServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordResetService) EXPECT() *MockPasswordResetServiceMockRecorder {
+	return m.recorder
+}
+
+// RequestReset mocks base method.
+func (m *MockPasswordResetService) RequestReset(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestReset", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestReset indicates an expected call of RequestReset.
+func (mr *MockPasswordResetServiceMockRecorder) RequestReset(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestReset", reflect.TypeOf((*MockPasswordResetService)(nil).RequestReset), ctx, username)
+}
+
+// Reset mocks base method.
+func (m *MockPasswordResetService) Reset(ctx context.Context, resetToken, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, resetToken, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockPasswordResetServiceMockRecorder) Reset(ctx, resetToken, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockPasswordResetService)(nil).Reset), ctx, resetToken, newPassword)
+}
+
+// Shutdown mocks base method.
+func (m *MockPasswordResetService) Shutdown(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Shutdown", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Shutdown indicates an expected call of Shutdown.
+func (mr *MockPasswordResetServiceMockRecorder) Shutdown(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockPasswordResetService)(nil).Shutdown), ctx)
+}
@@ -0,0 +1,93 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./pkg/services/roleService.go
+
+// Package tests is a generated GoMock package.
+package tests
+
+import (
+	models "auth/pkg/models"
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRoleService is a mock of RoleService interface.
+type MockRoleService struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleServiceMockRecorder
+}
+
+// MockRoleServiceMockRecorder is the mock recorder for MockRoleService.
+type MockRoleServiceMockRecorder struct {
+	mock *MockRoleService
+}
+
+// NewMockRoleService creates a new mock instance.
+func NewMockRoleService(ctrl *gomock.Controller) *MockRoleService {
+	mock := &MockRoleService{ctrl: ctrl}
+	mock.recorder = &MockRoleServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleService) EXPECT() *MockRoleServiceMockRecorder {
+	return m.recorder
+}
+
+// AssignRole mocks base method.
+func (m *MockRoleService) AssignRole(ctx context.Context, username, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRole", ctx, username, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockRoleServiceMockRecorder) AssignRole(ctx, username, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockRoleService)(nil).AssignRole), ctx, username, role)
+}
+
+// DefineRoles mocks base method.
+func (m *MockRoleService) DefineRoles(ctx context.Context, roles []models.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DefineRoles", ctx, roles)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DefineRoles indicates an expected call of DefineRoles.
+func (mr *MockRoleServiceMockRecorder) DefineRoles(ctx, roles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DefineRoles", reflect.TypeOf((*MockRoleService)(nil).DefineRoles), ctx, roles)
+}
+
+// ListRoles mocks base method.
+func (m *MockRoleService) ListRoles(ctx context.Context, username string) ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRoles", ctx, username)
+	ret0, _ := ret[0].([]models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRoles indicates an expected call of ListRoles.
+func (mr *MockRoleServiceMockRecorder) ListRoles(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRoles", reflect.TypeOf((*MockRoleService)(nil).ListRoles), ctx, username)
+}
+
+// RevokeRole mocks base method.
+func (m *MockRoleService) RevokeRole(ctx context.Context, username, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRole", ctx, username, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRole indicates an expected call of RevokeRole.
+func (mr *MockRoleServiceMockRecorder) RevokeRole(ctx, username, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRole", reflect.TypeOf((*MockRoleService)(nil).RevokeRole), ctx, username, role)
+}
@@ -8,6 +8,7 @@ import (
 	models "auth/pkg/models"
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -49,6 +50,21 @@ func (mr *MockUserStoreMockRecorder) Create(ctx, user interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserStore)(nil).Create), ctx, user)
 }
 
+// Delete mocks base method.
+func (m *MockUserStore) Delete(ctx context.Context, username string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, username)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUserStoreMockRecorder) Delete(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUserStore)(nil).Delete), ctx, username)
+}
+
 // Get mocks base method.
 func (m *MockUserStore) Get(ctx context.Context, username string) (*models.User, error) {
 	m.ctrl.T.Helper()
@@ -63,3 +79,918 @@ func (mr *MockUserStoreMockRecorder) Get(ctx, username interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserStore)(nil).Get), ctx, username)
 }
+
+// List mocks base method.
+func (m *MockUserStore) List(ctx context.Context, prefix, after string, limit int) ([]models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, prefix, after, limit)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockUserStoreMockRecorder) List(ctx, prefix, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserStore)(nil).List), ctx, prefix, after, limit)
+}
+
+// Update mocks base method.
+func (m *MockUserStore) Update(ctx context.Context, username string, user models.User) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, username, user)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockUserStoreMockRecorder) Update(ctx, username, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserStore)(nil).Update), ctx, username, user)
+}
+
+// UpdatePasswordHash mocks base method.
+func (m *MockUserStore) UpdatePasswordHash(ctx context.Context, username, currentHash, newHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePasswordHash", ctx, username, currentHash, newHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePasswordHash indicates an expected call of UpdatePasswordHash.
+func (mr *MockUserStoreMockRecorder) UpdatePasswordHash(ctx, username, currentHash, newHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePasswordHash", reflect.TypeOf((*MockUserStore)(nil).UpdatePasswordHash), ctx, username, currentHash, newHash)
+}
+
+// MockRefreshTokenStore is a mock of RefreshTokenStore interface.
+type MockRefreshTokenStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefreshTokenStoreMockRecorder
+}
+
+// MockRefreshTokenStoreMockRecorder is the mock recorder for MockRefreshTokenStore.
+type MockRefreshTokenStoreMockRecorder struct {
+	mock *MockRefreshTokenStore
+}
+
+// NewMockRefreshTokenStore creates a new mock instance.
+func NewMockRefreshTokenStore(ctrl *gomock.Controller) *MockRefreshTokenStore {
+	mock := &MockRefreshTokenStore{ctrl: ctrl}
+	mock.recorder = &MockRefreshTokenStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefreshTokenStore) EXPECT() *MockRefreshTokenStoreMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockRefreshTokenStore) Create(ctx context.Context, token models.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRefreshTokenStoreMockRecorder) Create(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRefreshTokenStore)(nil).Create), ctx, token)
+}
+
+// Get mocks base method.
+func (m *MockRefreshTokenStore) Get(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, hash)
+	ret0, _ := ret[0].(*models.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockRefreshTokenStoreMockRecorder) Get(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRefreshTokenStore)(nil).Get), ctx, hash)
+}
+
+// RevokeFamily mocks base method.
+func (m *MockRefreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeFamily", ctx, familyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeFamily indicates an expected call of RevokeFamily.
+func (mr *MockRefreshTokenStoreMockRecorder) RevokeFamily(ctx, familyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeFamily", reflect.TypeOf((*MockRefreshTokenStore)(nil).RevokeFamily), ctx, familyID)
+}
+
+// RevokeUser mocks base method.
+func (m *MockRefreshTokenStore) RevokeUser(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUser", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUser indicates an expected call of RevokeUser.
+func (mr *MockRefreshTokenStoreMockRecorder) RevokeUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUser", reflect.TypeOf((*MockRefreshTokenStore)(nil).RevokeUser), ctx, username)
+}
+
+// Use mocks base method.
+func (m *MockRefreshTokenStore) Use(ctx context.Context, hash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Use", ctx, hash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockRefreshTokenStoreMockRecorder) Use(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockRefreshTokenStore)(nil).Use), ctx, hash)
+}
+
+// MockRevocationStore is a mock of RevocationStore interface.
+type MockRevocationStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockRevocationStoreMockRecorder
+}
+
+// MockRevocationStoreMockRecorder is the mock recorder for MockRevocationStore.
+type MockRevocationStoreMockRecorder struct {
+	mock *MockRevocationStore
+}
+
+// NewMockRevocationStore creates a new mock instance.
+func NewMockRevocationStore(ctrl *gomock.Controller) *MockRevocationStore {
+	mock := &MockRevocationStore{ctrl: ctrl}
+	mock.recorder = &MockRevocationStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRevocationStore) EXPECT() *MockRevocationStoreMockRecorder {
+	return m.recorder
+}
+
+// DeleteExpired mocks base method.
+func (m *MockRevocationStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockRevocationStoreMockRecorder) DeleteExpired(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockRevocationStore)(nil).DeleteExpired), ctx, before)
+}
+
+// IsRevoked mocks base method.
+func (m *MockRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRevoked", ctx, jti)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRevoked indicates an expected call of IsRevoked.
+func (mr *MockRevocationStoreMockRecorder) IsRevoked(ctx, jti interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRevoked", reflect.TypeOf((*MockRevocationStore)(nil).IsRevoked), ctx, jti)
+}
+
+// Revoke mocks base method.
+func (m *MockRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, jti, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockRevocationStoreMockRecorder) Revoke(ctx, jti, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockRevocationStore)(nil).Revoke), ctx, jti, expiresAt)
+}
+
+// MockPasswordResetStore is a mock of PasswordResetStore interface.
+type MockPasswordResetStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordResetStoreMockRecorder
+}
+
+// MockPasswordResetStoreMockRecorder is the mock recorder for MockPasswordResetStore.
+type MockPasswordResetStoreMockRecorder struct {
+	mock *MockPasswordResetStore
+}
+
+// NewMockPasswordResetStore creates a new mock instance.
+func NewMockPasswordResetStore(ctrl *gomock.Controller) *MockPasswordResetStore {
+	mock := &MockPasswordResetStore{ctrl: ctrl}
+	mock.recorder = &MockPasswordResetStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordResetStore) EXPECT() *MockPasswordResetStoreMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPasswordResetStore) Create(ctx context.Context, token models.PasswordResetToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPasswordResetStoreMockRecorder) Create(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPasswordResetStore)(nil).Create), ctx, token)
+}
+
+// Get mocks base method.
+func (m *MockPasswordResetStore) Get(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, hash)
+	ret0, _ := ret[0].(*models.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockPasswordResetStoreMockRecorder) Get(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPasswordResetStore)(nil).Get), ctx, hash)
+}
+
+// Use mocks base method.
+func (m *MockPasswordResetStore) Use(ctx context.Context, hash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Use", ctx, hash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockPasswordResetStoreMockRecorder) Use(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockPasswordResetStore)(nil).Use), ctx, hash)
+}
+
+// MockLoginFailureStore is a mock of LoginFailureStore interface.
+type MockLoginFailureStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginFailureStoreMockRecorder
+}
+
+// MockLoginFailureStoreMockRecorder is the mock recorder for MockLoginFailureStore.
+type MockLoginFailureStoreMockRecorder struct {
+	mock *MockLoginFailureStore
+}
+
+// NewMockLoginFailureStore creates a new mock instance.
+func NewMockLoginFailureStore(ctrl *gomock.Controller) *MockLoginFailureStore {
+	mock := &MockLoginFailureStore{ctrl: ctrl}
+	mock.recorder = &MockLoginFailureStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginFailureStore) EXPECT() *MockLoginFailureStoreMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockLoginFailureStore) Add(ctx context.Context, key string, failedAt, since time.Time) (*models.LoginFailures, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, key, failedAt, since)
+	ret0, _ := ret[0].(*models.LoginFailures)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockLoginFailureStoreMockRecorder) Add(ctx, key, failedAt, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockLoginFailureStore)(nil).Add), ctx, key, failedAt, since)
+}
+
+// Get mocks base method.
+func (m *MockLoginFailureStore) Get(ctx context.Context, key string) (*models.LoginFailures, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(*models.LoginFailures)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockLoginFailureStoreMockRecorder) Get(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockLoginFailureStore)(nil).Get), ctx, key)
+}
+
+// Reset mocks base method.
+func (m *MockLoginFailureStore) Reset(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockLoginFailureStoreMockRecorder) Reset(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockLoginFailureStore)(nil).Reset), ctx, key)
+}
+
+// MockTOTPStore is a mock of TOTPStore interface.
+type MockTOTPStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockTOTPStoreMockRecorder
+}
+
+// MockTOTPStoreMockRecorder is the mock recorder for MockTOTPStore.
+type MockTOTPStoreMockRecorder struct {
+	mock *MockTOTPStore
+}
+
+// NewMockTOTPStore creates a new mock instance.
+func NewMockTOTPStore(ctrl *gomock.Controller) *MockTOTPStore {
+	mock := &MockTOTPStore{ctrl: ctrl}
+	mock.recorder = &MockTOTPStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTOTPStore) EXPECT() *MockTOTPStoreMockRecorder {
+	return m.recorder
+}
+
+// Confirm mocks base method.
+func (m *MockTOTPStore) Confirm(ctx context.Context, username string, counter int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Confirm", ctx, username, counter)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Confirm indicates an expected call of Confirm.
+func (mr *MockTOTPStoreMockRecorder) Confirm(ctx, username, counter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirm", reflect.TypeOf((*MockTOTPStore)(nil).Confirm), ctx, username, counter)
+}
+
+// Enroll mocks base method.
+func (m *MockTOTPStore) Enroll(ctx context.Context, username, secret string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enroll", ctx, username, secret)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Enroll indicates an expected call of Enroll.
+func (mr *MockTOTPStoreMockRecorder) Enroll(ctx, username, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enroll", reflect.TypeOf((*MockTOTPStore)(nil).Enroll), ctx, username, secret)
+}
+
+// Get mocks base method.
+func (m *MockTOTPStore) Get(ctx context.Context, username string) (*models.TOTPCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, username)
+	ret0, _ := ret[0].(*models.TOTPCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockTOTPStoreMockRecorder) Get(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockTOTPStore)(nil).Get), ctx, username)
+}
+
+// UseCounter mocks base method.
+func (m *MockTOTPStore) UseCounter(ctx context.Context, username string, counter int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseCounter", ctx, username, counter)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseCounter indicates an expected call of UseCounter.
+func (mr *MockTOTPStoreMockRecorder) UseCounter(ctx, username, counter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseCounter", reflect.TypeOf((*MockTOTPStore)(nil).UseCounter), ctx, username, counter)
+}
+
+// MockMFAChallengeStore is a mock of MFAChallengeStore interface.
+type MockMFAChallengeStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFAChallengeStoreMockRecorder
+}
+
+// MockMFAChallengeStoreMockRecorder is the mock recorder for MockMFAChallengeStore.
+type MockMFAChallengeStoreMockRecorder struct {
+	mock *MockMFAChallengeStore
+}
+
+// NewMockMFAChallengeStore creates a new mock instance.
+func NewMockMFAChallengeStore(ctrl *gomock.Controller) *MockMFAChallengeStore {
+	mock := &MockMFAChallengeStore{ctrl: ctrl}
+	mock.recorder = &MockMFAChallengeStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFAChallengeStore) EXPECT() *MockMFAChallengeStoreMockRecorder {
+	return m.recorder
+}
+
+// Attempt mocks base method.
+func (m *MockMFAChallengeStore) Attempt(ctx context.Context, hash string, maxAttempts int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Attempt", ctx, hash, maxAttempts)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Attempt indicates an expected call of Attempt.
+func (mr *MockMFAChallengeStoreMockRecorder) Attempt(ctx, hash, maxAttempts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Attempt", reflect.TypeOf((*MockMFAChallengeStore)(nil).Attempt), ctx, hash, maxAttempts)
+}
+
+// Create mocks base method.
+func (m *MockMFAChallengeStore) Create(ctx context.Context, challenge models.MFAChallenge) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, challenge)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockMFAChallengeStoreMockRecorder) Create(ctx, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMFAChallengeStore)(nil).Create), ctx, challenge)
+}
+
+// Get mocks base method.
+func (m *MockMFAChallengeStore) Get(ctx context.Context, hash string) (*models.MFAChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, hash)
+	ret0, _ := ret[0].(*models.MFAChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockMFAChallengeStoreMockRecorder) Get(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockMFAChallengeStore)(nil).Get), ctx, hash)
+}
+
+// Use mocks base method.
+func (m *MockMFAChallengeStore) Use(ctx context.Context, hash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Use", ctx, hash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockMFAChallengeStoreMockRecorder) Use(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockMFAChallengeStore)(nil).Use), ctx, hash)
+}
+
+// MockRecoveryCodeStore is a mock of RecoveryCodeStore interface.
+type MockRecoveryCodeStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockRecoveryCodeStoreMockRecorder
+}
+
+// MockRecoveryCodeStoreMockRecorder is the mock recorder for MockRecoveryCodeStore.
+type MockRecoveryCodeStoreMockRecorder struct {
+	mock *MockRecoveryCodeStore
+}
+
+// NewMockRecoveryCodeStore creates a new mock instance.
+func NewMockRecoveryCodeStore(ctrl *gomock.Controller) *MockRecoveryCodeStore {
+	mock := &MockRecoveryCodeStore{ctrl: ctrl}
+	mock.recorder = &MockRecoveryCodeStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRecoveryCodeStore) EXPECT() *MockRecoveryCodeStoreMockRecorder {
+	return m.recorder
+}
+
+// CountUnused mocks base method.
+func (m *MockRecoveryCodeStore) CountUnused(ctx context.Context, username string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnused", ctx, username)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnused indicates an expected call of CountUnused.
+func (mr *MockRecoveryCodeStoreMockRecorder) CountUnused(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnused", reflect.TypeOf((*MockRecoveryCodeStore)(nil).CountUnused), ctx, username)
+}
+
+// ListUnused mocks base method.
+func (m *MockRecoveryCodeStore) ListUnused(ctx context.Context, username string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnused", ctx, username)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnused indicates an expected call of ListUnused.
+func (mr *MockRecoveryCodeStoreMockRecorder) ListUnused(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnused", reflect.TypeOf((*MockRecoveryCodeStore)(nil).ListUnused), ctx, username)
+}
+
+// Replace mocks base method.
+func (m *MockRecoveryCodeStore) Replace(ctx context.Context, username, batchID string, hashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replace", ctx, username, batchID, hashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Replace indicates an expected call of Replace.
+func (mr *MockRecoveryCodeStoreMockRecorder) Replace(ctx, username, batchID, hashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replace", reflect.TypeOf((*MockRecoveryCodeStore)(nil).Replace), ctx, username, batchID, hashes)
+}
+
+// Use mocks base method.
+func (m *MockRecoveryCodeStore) Use(ctx context.Context, username, hash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Use", ctx, username, hash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockRecoveryCodeStoreMockRecorder) Use(ctx, username, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockRecoveryCodeStore)(nil).Use), ctx, username, hash)
+}
+
+// MockRoleStore is a mock of RoleStore interface.
+type MockRoleStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleStoreMockRecorder
+}
+
+// MockRoleStoreMockRecorder is the mock recorder for MockRoleStore.
+type MockRoleStoreMockRecorder struct {
+	mock *MockRoleStore
+}
+
+// NewMockRoleStore creates a new mock instance.
+func NewMockRoleStore(ctrl *gomock.Controller) *MockRoleStore {
+	mock := &MockRoleStore{ctrl: ctrl}
+	mock.recorder = &MockRoleStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleStore) EXPECT() *MockRoleStoreMockRecorder {
+	return m.recorder
+}
+
+// Assign mocks base method.
+func (m *MockRoleStore) Assign(ctx context.Context, username, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Assign", ctx, username, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Assign indicates an expected call of Assign.
+func (mr *MockRoleStoreMockRecorder) Assign(ctx, username, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Assign", reflect.TypeOf((*MockRoleStore)(nil).Assign), ctx, username, role)
+}
+
+// Define mocks base method.
+func (m *MockRoleStore) Define(ctx context.Context, role models.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Define", ctx, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Define indicates an expected call of Define.
+func (mr *MockRoleStoreMockRecorder) Define(ctx, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Define", reflect.TypeOf((*MockRoleStore)(nil).Define), ctx, role)
+}
+
+// Get mocks base method.
+func (m *MockRoleStore) Get(ctx context.Context, name string) (*models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, name)
+	ret0, _ := ret[0].(*models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockRoleStoreMockRecorder) Get(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRoleStore)(nil).Get), ctx, name)
+}
+
+// List mocks base method.
+func (m *MockRoleStore) List(ctx context.Context) ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockRoleStoreMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockRoleStore)(nil).List), ctx)
+}
+
+// ListByUser mocks base method.
+func (m *MockRoleStore) ListByUser(ctx context.Context, username string) ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", ctx, username)
+	ret0, _ := ret[0].([]models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockRoleStoreMockRecorder) ListByUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockRoleStore)(nil).ListByUser), ctx, username)
+}
+
+// Revoke mocks base method.
+func (m *MockRoleStore) Revoke(ctx context.Context, username, role string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, username, role)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockRoleStoreMockRecorder) Revoke(ctx, username, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockRoleStore)(nil).Revoke), ctx, username, role)
+}
+
+// MockAPIKeyStore is a mock of APIKeyStore interface.
+type MockAPIKeyStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyStoreMockRecorder
+}
+
+// MockAPIKeyStoreMockRecorder is the mock recorder for MockAPIKeyStore.
+type MockAPIKeyStoreMockRecorder struct {
+	mock *MockAPIKeyStore
+}
+
+// NewMockAPIKeyStore creates a new mock instance.
+func NewMockAPIKeyStore(ctrl *gomock.Controller) *MockAPIKeyStore {
+	mock := &MockAPIKeyStore{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyStore) EXPECT() *MockAPIKeyStoreMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAPIKeyStore) Create(ctx context.Context, key models.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAPIKeyStoreMockRecorder) Create(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAPIKeyStore)(nil).Create), ctx, key)
+}
+
+// Get mocks base method.
+func (m *MockAPIKeyStore) Get(ctx context.Context, prefix string) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, prefix)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockAPIKeyStoreMockRecorder) Get(ctx, prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockAPIKeyStore)(nil).Get), ctx, prefix)
+}
+
+// List mocks base method.
+func (m *MockAPIKeyStore) List(ctx context.Context, serviceAccount string) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, serviceAccount)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAPIKeyStoreMockRecorder) List(ctx, serviceAccount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAPIKeyStore)(nil).List), ctx, serviceAccount)
+}
+
+// Revoke mocks base method.
+func (m *MockAPIKeyStore) Revoke(ctx context.Context, prefix string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, prefix)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockAPIKeyStoreMockRecorder) Revoke(ctx, prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockAPIKeyStore)(nil).Revoke), ctx, prefix)
+}
+
+// MockOAuthClientStore is a mock of OAuthClientStore interface.
+type MockOAuthClientStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthClientStoreMockRecorder
+}
+
+// MockOAuthClientStoreMockRecorder is the mock recorder for MockOAuthClientStore.
+type MockOAuthClientStoreMockRecorder struct {
+	mock *MockOAuthClientStore
+}
+
+// NewMockOAuthClientStore creates a new mock instance.
+func NewMockOAuthClientStore(ctrl *gomock.Controller) *MockOAuthClientStore {
+	mock := &MockOAuthClientStore{ctrl: ctrl}
+	mock.recorder = &MockOAuthClientStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuthClientStore) EXPECT() *MockOAuthClientStoreMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockOAuthClientStore) Create(ctx context.Context, client models.OAuthClient) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOAuthClientStoreMockRecorder) Create(ctx, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOAuthClientStore)(nil).Create), ctx, client)
+}
+
+// Delete mocks base method.
+func (m *MockOAuthClientStore) Delete(ctx context.Context, id string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockOAuthClientStoreMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockOAuthClientStore)(nil).Delete), ctx, id)
+}
+
+// Get mocks base method.
+func (m *MockOAuthClientStore) Get(ctx context.Context, id string) (*models.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*models.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockOAuthClientStoreMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockOAuthClientStore)(nil).Get), ctx, id)
+}
+
+// MockAuthorizationCodeStore is a mock of AuthorizationCodeStore interface.
+type MockAuthorizationCodeStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorizationCodeStoreMockRecorder
+}
+
+// MockAuthorizationCodeStoreMockRecorder is the mock recorder for MockAuthorizationCodeStore.
+type MockAuthorizationCodeStoreMockRecorder struct {
+	mock *MockAuthorizationCodeStore
+}
+
+// NewMockAuthorizationCodeStore creates a new mock instance.
+func NewMockAuthorizationCodeStore(ctrl *gomock.Controller) *MockAuthorizationCodeStore {
+	mock := &MockAuthorizationCodeStore{ctrl: ctrl}
+	mock.recorder = &MockAuthorizationCodeStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthorizationCodeStore) EXPECT() *MockAuthorizationCodeStoreMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAuthorizationCodeStore) Create(ctx context.Context, code models.AuthorizationCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAuthorizationCodeStoreMockRecorder) Create(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAuthorizationCodeStore)(nil).Create), ctx, code)
+}
+
+// Get mocks base method.
+func (m *MockAuthorizationCodeStore) Get(ctx context.Context, hash string) (*models.AuthorizationCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, hash)
+	ret0, _ := ret[0].(*models.AuthorizationCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockAuthorizationCodeStoreMockRecorder) Get(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockAuthorizationCodeStore)(nil).Get), ctx, hash)
+}
+
+// Use mocks base method.
+func (m *MockAuthorizationCodeStore) Use(ctx context.Context, hash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Use", ctx, hash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockAuthorizationCodeStoreMockRecorder) Use(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockAuthorizationCodeStore)(nil).Use), ctx, hash)
+}
@@ -0,0 +1,91 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./pkg/services/throttler.go
+
+// Package tests is a generated GoMock package.
+package tests
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoginThrottler is a mock of LoginThrottler interface.
+type MockLoginThrottler struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginThrottlerMockRecorder
+}
+
+// MockLoginThrottlerMockRecorder is the mock recorder for MockLoginThrottler.
+type MockLoginThrottlerMockRecorder struct {
+	mock *MockLoginThrottler
+}
+
+// NewMockLoginThrottler creates a new mock instance.
+func NewMockLoginThrottler(ctrl *gomock.Controller) *MockLoginThrottler {
+	mock := &MockLoginThrottler{ctrl: ctrl}
+	mock.recorder = &MockLoginThrottlerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginThrottler) EXPECT() *MockLoginThrottlerMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockLoginThrottler) Check(ctx context.Context, username, address string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, username, address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockLoginThrottlerMockRecorder) Check(ctx, username, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockLoginThrottler)(nil).Check), ctx, username, address)
+}
+
+// Fail mocks base method.
+func (m *MockLoginThrottler) Fail(ctx context.Context, username, address string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", ctx, username, address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fail indicates an expected call of Fail.
+func (mr *MockLoginThrottlerMockRecorder) Fail(ctx, username, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*MockLoginThrottler)(nil).Fail), ctx, username, address)
+}
+
+// Succeed mocks base method.
+func (m *MockLoginThrottler) Succeed(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Succeed", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Succeed indicates an expected call of Succeed.
+func (mr *MockLoginThrottlerMockRecorder) Succeed(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Succeed", reflect.TypeOf((*MockLoginThrottler)(nil).Succeed), ctx, username)
+}
+
+// Unlock mocks base method.
+func (m *MockLoginThrottler) Unlock(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockLoginThrottlerMockRecorder) Unlock(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockLoginThrottler)(nil).Unlock), ctx, username)
+}
@@ -0,0 +1,96 @@
+// Package totp implements the time-based one-time passwords of RFC 6238 with HMAC-SHA1,
+// the algorithm supported by all the authenticator apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	// Period is the duration of a time step.
+	Period = 30 * time.Second
+	// Digits is the number of digits of the codes.
+	Digits = 6
+	// SecretSize is the size in bytes of the secrets, the size of the HMAC-SHA1 output recommended by RFC 4226.
+	SecretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret returns a random secret of SecretSize bytes.
+func NewSecret() ([]byte, error) {
+	secret := make([]byte, SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("error generating the TOTP secret: %w", err)
+	}
+	return secret, nil
+}
+
+// EncodeSecret returns the secret in base32 without padding, the encoding entered in the authenticator apps.
+func EncodeSecret(secret []byte) string {
+	return encoding.EncodeToString(secret)
+}
+
+// Counter returns the time step of the time.
+func Counter(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code returns the HOTP code of RFC 4226 of the counter with the number of digits.
+func Code(secret []byte, counter int64, digits int) string {
+	mac := hmac.New(sha1.New, secret)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := int64(binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff)
+	modulo := int64(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
+
+// Validate returns the time step of the code if it is the code of a time step within skew steps of the time,
+// only the time steps after the step after are accepted so a code can't be used twice.
+func Validate(secret []byte, code string, t time.Time, skew int, after int64) (int64, bool) {
+	if len(code) != Digits {
+		return 0, false
+	}
+	current := Counter(t)
+	for counter := current - int64(skew); counter <= current+int64(skew); counter++ {
+		if counter <= after {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(Code(secret, counter, Digits)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// URI returns the otpauth:// key URI of the secret for the account at the issuer, usually displayed as a QR code.
+func URI(issuer, account string, secret []byte) string {
+	query := url.Values{}
+	query.Set("secret", EncodeSecret(secret))
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period/time.Second)))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
@@ -0,0 +1,68 @@
+package totp
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCode_RFC6238(t *testing.T) {
+	// The SHA1 test vectors of the appendix B of RFC 6238.
+	secret := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "94287082"},
+		{unix: 1111111109, code: "07081804"},
+		{unix: 1111111111, code: "14050471"},
+		{unix: 1234567890, code: "89005924"},
+		{unix: 2000000000, code: "69279037"},
+		{unix: 20000000000, code: "65353130"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.code, Code(secret, Counter(time.Unix(tt.unix, 0)), 8), tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	now := time.Now()
+	current := Counter(now)
+
+	counter, ok := Validate(secret, Code(secret, current, Digits), now, 1, 0)
+	require.True(t, ok)
+	require.Equal(t, current, counter)
+
+	// The codes of the adjacent time steps are accepted within the skew.
+	counter, ok = Validate(secret, Code(secret, current-1, Digits), now, 1, 0)
+	require.True(t, ok)
+	require.Equal(t, current-1, counter)
+	_, ok = Validate(secret, Code(secret, current-2, Digits), now, 1, 0)
+	require.False(t, ok)
+	_, ok = Validate(secret, Code(secret, current+1, Digits), now, 0, 0)
+	require.False(t, ok)
+
+	// A code of a time step already used is rejected.
+	_, ok = Validate(secret, Code(secret, current, Digits), now, 1, current)
+	require.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now, 1, 0)
+	require.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	u, err := url.Parse(URI("Auth Service", "alice@example.com", secret))
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", u.Scheme)
+	require.Equal(t, "totp", u.Host)
+	require.Equal(t, "/Auth Service:alice@example.com", u.Path)
+	require.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", u.Query().Get("secret"))
+	require.Equal(t, "Auth Service", u.Query().Get("issuer"))
+	require.Equal(t, "6", u.Query().Get("digits"))
+	require.Equal(t, "30", u.Query().Get("period"))
+}
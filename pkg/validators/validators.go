@@ -27,19 +27,36 @@ func NewUserValidator(structValidator *validator.Validate, pwdValidator Validato
 	}
 }
 
+// Validate validates a models.User, or a models.UserUpdate whose empty fields are not validated.
 func (v *UserValidator) Validate(value any) error {
-	user, ok := value.(models.User)
-	if !ok {
+	var password string
+	switch user := value.(type) {
+	case models.User:
+		password = user.Password
+	case models.UserUpdate:
+		if user.Password == "" {
+			return v.validateStruct(user)
+		}
+		password = user.Password
+	default:
 		return autherror.NewValidationErr(fmt.Errorf("the input value is not a model.User"))
 	}
-	err := v.StructValidator.Struct(user)
+
+	if err := v.validateStruct(value); err != nil {
+		return err
+	}
+	err := v.PasswordValidator.Validate(password)
 	if err != nil {
 		return autherror.NewValidationErr(err)
 	}
-	err = v.PasswordValidator.Validate(user.Password)
+
+	return nil
+}
+
+func (v *UserValidator) validateStruct(value any) error {
+	err := v.StructValidator.Struct(value)
 	if err != nil {
 		return autherror.NewValidationErr(err)
 	}
-
 	return nil
 }
@@ -15,13 +15,19 @@ func TestUserValidator_Validate(t *testing.T) {
 		value   any
 		wantErr bool
 	}{
-		{"Valid input", models.User{"yann", "password"}, false},
-		{"No username", models.User{"", "password"}, true},
-		{"No password", models.User{"yann", ""}, true},
-		{"No username and password", models.User{"", ""}, true},
-		{"Nil input", models.User{"", ""}, true},
+		{"Valid input", models.User{Username: "yann", Password: "password"}, false},
+		{"No username", models.User{Username: "", Password: "password"}, true},
+		{"No password", models.User{Username: "yann", Password: ""}, true},
+		{"No username and password", models.User{Username: "", Password: ""}, true},
+		{"Nil input", models.User{Username: "", Password: ""}, true},
 		{"Bad input", "username", true},
-		{"Bad password", models.User{"yann", "a"}, true},
+		{"Bad password", models.User{Username: "yann", Password: "a"}, true},
+		{"Valid email", models.User{Username: "yann", Password: "password", Email: "yann@example.com"}, false},
+		{"Bad email", models.User{Username: "yann", Password: "password", Email: "yann"}, true},
+		{"Empty update", models.UserUpdate{}, false},
+		{"Valid update", models.UserUpdate{Username: "yann", Password: "password", Email: "yann@example.com"}, false},
+		{"Update bad password", models.UserUpdate{Password: "a"}, true},
+		{"Update bad email", models.UserUpdate{Email: "yann"}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
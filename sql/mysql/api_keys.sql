@@ -0,0 +1,52 @@
+-- name: CreateServiceAccount :exec
+INSERT INTO service_accounts (tenant, name)
+VALUES (sqlc.arg(tenant), sqlc.arg(name))
+ON DUPLICATE KEY UPDATE name = name;
+
+-- name: CreateAPIKey :exec
+INSERT INTO api_keys (prefix, key_hash, service_account_id, expires_at)
+VALUES (sqlc.arg(prefix), sqlc.arg(key_hash),
+        (SELECT id FROM service_accounts WHERE tenant = sqlc.arg(tenant) AND name = sqlc.arg(service_account)),
+        sqlc.arg(expires_at));
+
+-- name: AddAPIKeyPermission :exec
+INSERT INTO api_key_permissions (api_key_id, permission)
+VALUES ((SELECT id FROM api_keys WHERE prefix = sqlc.arg(prefix)), sqlc.arg(permission))
+ON DUPLICATE KEY UPDATE permission = permission;
+
+-- name: GetAPIKey :one
+SELECT k.prefix, k.key_hash, sa.name, k.expires_at, k.revoked, k.created_at
+FROM api_keys k
+         JOIN service_accounts sa ON sa.id = k.service_account_id
+WHERE k.prefix = sqlc.arg(prefix)
+  AND sa.tenant = sqlc.arg(tenant)
+LIMIT 1;
+
+-- name: ListAPIKeys :many
+SELECT k.prefix, k.key_hash, sa.name, k.expires_at, k.revoked, k.created_at
+FROM api_keys k
+         JOIN service_accounts sa ON sa.id = k.service_account_id
+WHERE sa.tenant = sqlc.arg(tenant)
+ORDER BY sa.name, k.created_at, k.prefix;
+
+-- name: ListServiceAccountAPIKeys :many
+SELECT k.prefix, k.key_hash, sa.name, k.expires_at, k.revoked, k.created_at
+FROM api_keys k
+         JOIN service_accounts sa ON sa.id = k.service_account_id
+WHERE sa.tenant = sqlc.arg(tenant)
+  AND sa.name = sqlc.arg(name)
+ORDER BY k.created_at, k.prefix;
+
+-- name: ListAPIKeyPermissions :many
+SELECT p.permission
+FROM api_key_permissions p
+         JOIN api_keys k ON k.id = p.api_key_id
+WHERE k.prefix = sqlc.arg(prefix)
+ORDER BY p.permission;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked = true
+WHERE prefix = sqlc.arg(prefix)
+  AND revoked = false
+  AND service_account_id IN (SELECT id FROM service_accounts WHERE tenant = sqlc.arg(tenant));
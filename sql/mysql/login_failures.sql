@@ -0,0 +1,18 @@
+-- name: GetLoginFailures :one
+SELECT attempt_key, failures, last_failure
+FROM login_failures
+WHERE tenant = sqlc.arg(tenant)
+  AND attempt_key = sqlc.arg(attempt_key)
+LIMIT 1;
+
+-- name: AddLoginFailure :exec
+INSERT INTO login_failures (tenant, attempt_key, failures, last_failure)
+VALUES (sqlc.arg(tenant), sqlc.arg(attempt_key), 1, sqlc.arg(failed_at))
+ON DUPLICATE KEY UPDATE failures     = IF(last_failure < sqlc.arg(since), 1, failures + 1),
+                        last_failure = VALUES(last_failure);
+
+-- name: DeleteLoginFailures :exec
+DELETE
+FROM login_failures
+WHERE tenant = sqlc.arg(tenant)
+  AND attempt_key = sqlc.arg(attempt_key);
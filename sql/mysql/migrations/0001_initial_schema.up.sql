@@ -0,0 +1,9 @@
+CREATE TABLE users
+(
+    id            BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    username      varchar(255) NOT NULL CHECK(username <> ''),
+    password_hash varchar(255) NOT NULL CHECK(password_hash <> ''),
+    CONSTRAINT users_username_key UNIQUE (username)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE INDEX username_idx ON users (username);
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN email            varchar(255) NOT NULL DEFAULT '',
+    ADD COLUMN token_generation BIGINT       NOT NULL DEFAULT 0,
+    ADD COLUMN tenant           varchar(255) NOT NULL DEFAULT 'default' CHECK(tenant <> ''),
+    DROP INDEX users_username_key,
+    ADD CONSTRAINT users_tenant_username_key UNIQUE (tenant, username);
@@ -0,0 +1,35 @@
+CREATE TABLE refresh_tokens
+(
+    id         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    token_hash varchar(255) NOT NULL CHECK(token_hash <> ''),
+    family_id  varchar(255) NOT NULL CHECK(family_id <> ''),
+    user_id    BIGINT       NOT NULL,
+    expires_at DATETIME(6)  NOT NULL,
+    used       BOOLEAN      NOT NULL DEFAULT false,
+    revoked    BOOLEAN      NOT NULL DEFAULT false,
+    created_at DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE(token_hash),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family_id);
+
+CREATE TABLE revoked_tokens
+(
+    jti        varchar(255) NOT NULL PRIMARY KEY CHECK(jti <> ''),
+    expires_at DATETIME(6)  NOT NULL
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+
+CREATE TABLE password_reset_tokens
+(
+    id         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    token_hash varchar(255) NOT NULL CHECK(token_hash <> ''),
+    user_id    BIGINT       NOT NULL,
+    expires_at DATETIME(6)  NOT NULL,
+    used       BOOLEAN      NOT NULL DEFAULT false,
+    created_at DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE(token_hash),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
@@ -0,0 +1,8 @@
+CREATE TABLE login_failures
+(
+    attempt_key  varchar(255) NOT NULL CHECK(attempt_key <> ''),
+    failures     BIGINT       NOT NULL,
+    last_failure DATETIME(6)  NOT NULL,
+    tenant       varchar(255) NOT NULL DEFAULT 'default' CHECK(tenant <> ''),
+    PRIMARY KEY (tenant, attempt_key)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
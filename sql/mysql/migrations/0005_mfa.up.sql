@@ -0,0 +1,37 @@
+CREATE TABLE totp_credentials
+(
+    id           BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id      BIGINT       NOT NULL,
+    secret       varchar(255) NOT NULL CHECK(secret <> ''),
+    confirmed    BOOLEAN      NOT NULL DEFAULT false,
+    last_counter BIGINT       NOT NULL DEFAULT 0,
+    created_at   DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE(user_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE mfa_challenges
+(
+    id         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    token_hash varchar(255) NOT NULL CHECK(token_hash <> ''),
+    user_id    BIGINT       NOT NULL,
+    expires_at DATETIME(6)  NOT NULL,
+    used       BOOLEAN      NOT NULL DEFAULT false,
+    attempts   BIGINT       NOT NULL DEFAULT 0,
+    created_at DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE(token_hash),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE recovery_codes
+(
+    id         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id    BIGINT       NOT NULL,
+    batch_id   varchar(255) NOT NULL CHECK(batch_id <> ''),
+    code_hash  varchar(255) NOT NULL CHECK(code_hash <> ''),
+    used       BOOLEAN      NOT NULL DEFAULT false,
+    created_at DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE INDEX recovery_codes_user_idx ON recovery_codes (user_id);
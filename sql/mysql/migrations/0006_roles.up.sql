@@ -0,0 +1,26 @@
+CREATE TABLE roles
+(
+    id         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name       varchar(255) NOT NULL CHECK(name <> ''),
+    created_at DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE(name)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE role_permissions
+(
+    id         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    role_id    BIGINT       NOT NULL,
+    permission varchar(255) NOT NULL CHECK(permission <> ''),
+    UNIQUE(role_id, permission),
+    FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE user_roles
+(
+    user_id    BIGINT      NOT NULL,
+    role_id    BIGINT      NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (user_id, role_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
@@ -0,0 +1,33 @@
+CREATE TABLE service_accounts
+(
+    id         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name       varchar(255) NOT NULL CHECK(name <> ''),
+    tenant     varchar(255) NOT NULL DEFAULT 'default' CHECK(tenant <> ''),
+    created_at DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE(tenant, name)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE api_keys
+(
+    id                 BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    prefix             varchar(255) NOT NULL CHECK(prefix <> ''),
+    key_hash           varchar(255) NOT NULL CHECK(key_hash <> ''),
+    service_account_id BIGINT       NOT NULL,
+    expires_at         DATETIME(6),
+    revoked            BOOLEAN      NOT NULL DEFAULT false,
+    created_at         DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE(prefix),
+    UNIQUE(key_hash),
+    FOREIGN KEY (service_account_id) REFERENCES service_accounts (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE INDEX api_keys_service_account_idx ON api_keys (service_account_id);
+
+CREATE TABLE api_key_permissions
+(
+    id         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    api_key_id BIGINT       NOT NULL,
+    permission varchar(255) NOT NULL CHECK(permission <> ''),
+    UNIQUE(api_key_id, permission),
+    FOREIGN KEY (api_key_id) REFERENCES api_keys (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
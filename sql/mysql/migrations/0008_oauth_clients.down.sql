@@ -0,0 +1,5 @@
+DROP TABLE authorization_codes;
+DROP TABLE oauth_client_redirect_uris;
+DROP TABLE oauth_client_audiences;
+DROP TABLE oauth_client_scopes;
+DROP TABLE oauth_clients;
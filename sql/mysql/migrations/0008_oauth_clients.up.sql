@@ -0,0 +1,55 @@
+CREATE TABLE oauth_clients
+(
+    id          BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    client_id   varchar(255) NOT NULL CHECK(client_id <> ''),
+    secret_hash varchar(255) NOT NULL CHECK(secret_hash <> ''),
+    tenant      varchar(255) NOT NULL DEFAULT 'default' CHECK(tenant <> ''),
+    created_at  DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE(tenant, client_id)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE oauth_client_scopes
+(
+    id              BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    oauth_client_id BIGINT       NOT NULL,
+    scope           varchar(255) NOT NULL CHECK(scope <> ''),
+    UNIQUE(oauth_client_id, scope),
+    FOREIGN KEY (oauth_client_id) REFERENCES oauth_clients (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE oauth_client_audiences
+(
+    id              BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    oauth_client_id BIGINT       NOT NULL,
+    audience        varchar(255) NOT NULL CHECK(audience <> ''),
+    UNIQUE(oauth_client_id, audience),
+    FOREIGN KEY (oauth_client_id) REFERENCES oauth_clients (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE oauth_client_redirect_uris
+(
+    id              BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    oauth_client_id BIGINT       NOT NULL,
+    redirect_uri    varchar(512) NOT NULL CHECK(redirect_uri <> ''),
+    UNIQUE(oauth_client_id, redirect_uri),
+    FOREIGN KEY (oauth_client_id) REFERENCES oauth_clients (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE authorization_codes
+(
+    id              BIGINT        NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    code_hash       varchar(255)  NOT NULL CHECK(code_hash <> ''),
+    oauth_client_id BIGINT        NOT NULL,
+    user_id         BIGINT        NOT NULL,
+    redirect_uri    varchar(512)  NOT NULL CHECK(redirect_uri <> ''),
+    scope           varchar(1024) NOT NULL DEFAULT '',
+    nonce           varchar(255)  NOT NULL DEFAULT '',
+    code_challenge  varchar(255)  NOT NULL CHECK(code_challenge <> ''),
+    auth_time       DATETIME(6)   NOT NULL,
+    expires_at      DATETIME(6)   NOT NULL,
+    used            BOOLEAN       NOT NULL DEFAULT false,
+    created_at      DATETIME(6)   NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE(code_hash),
+    FOREIGN KEY (oauth_client_id) REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
@@ -0,0 +1,14 @@
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (jti, expires_at)
+VALUES (?, ?)
+ON DUPLICATE KEY UPDATE jti = jti;
+
+-- name: CountRevokedTokens :one
+SELECT COUNT(*)
+FROM revoked_tokens
+WHERE jti = ?;
+
+-- name: DeleteExpiredRevokedTokens :execrows
+DELETE
+FROM revoked_tokens
+WHERE expires_at < ?;
@@ -0,0 +1,8 @@
+package mysql
+
+import "embed"
+
+// Migrations are the numbered up and down migrations of the MySQL schema.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
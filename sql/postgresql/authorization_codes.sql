@@ -0,0 +1,32 @@
+-- name: CreateAuthorizationCode :exec
+INSERT INTO authorization_codes (code_hash, oauth_client_id, user_id, redirect_uri, scope, nonce, code_challenge,
+                                 auth_time, expires_at)
+VALUES (sqlc.arg(code_hash),
+        (SELECT id FROM oauth_clients WHERE tenant = sqlc.arg(tenant) AND client_id = sqlc.arg(client_id)),
+        (SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username)),
+        sqlc.arg(redirect_uri), sqlc.arg(scope), sqlc.arg(nonce), sqlc.arg(code_challenge), sqlc.arg(auth_time),
+        sqlc.arg(expires_at));
+
+-- name: GetAuthorizationCode :one
+SELECT ac.code_hash,
+       c.client_id,
+       u.username,
+       ac.redirect_uri,
+       ac.scope,
+       ac.nonce,
+       ac.code_challenge,
+       ac.auth_time,
+       ac.expires_at,
+       ac.used
+FROM authorization_codes ac
+         JOIN oauth_clients c ON c.id = ac.oauth_client_id
+         JOIN users u ON u.id = ac.user_id
+WHERE ac.code_hash = sqlc.arg(code_hash)
+  AND c.tenant = sqlc.arg(tenant)
+LIMIT 1;
+
+-- name: UseAuthorizationCode :execrows
+UPDATE authorization_codes
+SET used = true
+WHERE code_hash = $1
+  AND used = false;
@@ -6,6 +6,4 @@ psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" <<-E
 	CREATE DATABASE $AUTH_DB;
 	GRANT ALL PRIVILEGES ON DATABASE $AUTH_DB TO $AUTH_USER;
 	ALTER DATABASE  $AUTH_DB OWNER TO $AUTH_USER;
-EOSQL
-
-psql -v ON_ERROR_STOP=1 --username "$AUTH_USER" --dbname "$AUTH_DB" -f /tmp/schema.sql
\ No newline at end of file
+EOSQL
\ No newline at end of file
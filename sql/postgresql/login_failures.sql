@@ -0,0 +1,23 @@
+-- name: GetLoginFailures :one
+SELECT attempt_key, failures, last_failure
+FROM login_failures
+WHERE tenant = sqlc.arg(tenant)
+  AND attempt_key = sqlc.arg(attempt_key)
+LIMIT 1;
+
+-- name: AddLoginFailure :one
+INSERT INTO login_failures (tenant, attempt_key, failures, last_failure)
+VALUES (sqlc.arg(tenant), sqlc.arg(attempt_key), 1, sqlc.arg(failed_at))
+ON CONFLICT (tenant, attempt_key) DO UPDATE
+    SET failures     = CASE
+                           WHEN login_failures.last_failure < sqlc.arg(since) THEN 1
+                           ELSE login_failures.failures + 1
+        END,
+        last_failure = excluded.last_failure
+RETURNING attempt_key, failures, last_failure;
+
+-- name: DeleteLoginFailures :exec
+DELETE
+FROM login_failures
+WHERE tenant = sqlc.arg(tenant)
+  AND attempt_key = sqlc.arg(attempt_key);
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    DROP CONSTRAINT users_tenant_username_key,
+    ADD CONSTRAINT users_username_key UNIQUE (username),
+    DROP COLUMN tenant,
+    DROP COLUMN token_generation,
+    DROP COLUMN email;
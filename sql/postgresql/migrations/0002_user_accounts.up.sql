@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN email            text   NOT NULL DEFAULT '',
+    ADD COLUMN token_generation bigint NOT NULL DEFAULT 0,
+    ADD COLUMN tenant           text   NOT NULL DEFAULT 'default' CHECK (tenant <> ''),
+    DROP CONSTRAINT users_username_key,
+    ADD CONSTRAINT users_tenant_username_key UNIQUE (tenant, username);
@@ -0,0 +1,31 @@
+CREATE TABLE refresh_tokens
+(
+    id         BIGSERIAL PRIMARY KEY,
+    token_hash text        NOT NULL UNIQUE CHECK (token_hash <> ''),
+    family_id  text        NOT NULL CHECK (family_id <> ''),
+    user_id    BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at timestamptz NOT NULL,
+    used       boolean     NOT NULL DEFAULT false,
+    revoked    boolean     NOT NULL DEFAULT false,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family_id);
+
+CREATE TABLE revoked_tokens
+(
+    jti        text PRIMARY KEY CHECK (jti <> ''),
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+
+CREATE TABLE password_reset_tokens
+(
+    id         BIGSERIAL PRIMARY KEY,
+    token_hash text        NOT NULL UNIQUE CHECK (token_hash <> ''),
+    user_id    BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at timestamptz NOT NULL,
+    used       boolean     NOT NULL DEFAULT false,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
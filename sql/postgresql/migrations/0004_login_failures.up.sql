@@ -0,0 +1,8 @@
+CREATE TABLE login_failures
+(
+    attempt_key  text        NOT NULL CHECK (attempt_key <> ''),
+    failures     integer     NOT NULL,
+    last_failure timestamptz NOT NULL,
+    tenant       text        NOT NULL DEFAULT 'default' CHECK (tenant <> ''),
+    PRIMARY KEY (tenant, attempt_key)
+);
@@ -0,0 +1,32 @@
+CREATE TABLE totp_credentials
+(
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT      NOT NULL UNIQUE REFERENCES users (id) ON DELETE CASCADE,
+    secret       text        NOT NULL CHECK (secret <> ''),
+    confirmed    boolean     NOT NULL DEFAULT false,
+    last_counter bigint      NOT NULL DEFAULT 0,
+    created_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE mfa_challenges
+(
+    id         BIGSERIAL PRIMARY KEY,
+    token_hash text        NOT NULL UNIQUE CHECK (token_hash <> ''),
+    user_id    BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at timestamptz NOT NULL,
+    used       boolean     NOT NULL DEFAULT false,
+    attempts   integer     NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE recovery_codes
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    batch_id   text        NOT NULL CHECK (batch_id <> ''),
+    code_hash  text        NOT NULL CHECK (code_hash <> ''),
+    used       boolean     NOT NULL DEFAULT false,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX recovery_codes_user_idx ON recovery_codes (user_id);
@@ -0,0 +1,22 @@
+CREATE TABLE roles
+(
+    id         BIGSERIAL PRIMARY KEY,
+    name       text        NOT NULL UNIQUE CHECK (name <> ''),
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE role_permissions
+(
+    id         BIGSERIAL PRIMARY KEY,
+    role_id    BIGINT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission text   NOT NULL CHECK (permission <> ''),
+    UNIQUE (role_id, permission)
+);
+
+CREATE TABLE user_roles
+(
+    user_id    BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id    BIGINT      NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, role_id)
+);
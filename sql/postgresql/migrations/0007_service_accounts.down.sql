@@ -0,0 +1,3 @@
+DROP TABLE api_key_permissions;
+DROP TABLE api_keys;
+DROP TABLE service_accounts;
@@ -0,0 +1,29 @@
+CREATE TABLE service_accounts
+(
+    id         BIGSERIAL PRIMARY KEY,
+    name       text        NOT NULL CHECK (name <> ''),
+    tenant     text        NOT NULL DEFAULT 'default' CHECK (tenant <> ''),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (tenant, name)
+);
+
+CREATE TABLE api_keys
+(
+    id                 BIGSERIAL PRIMARY KEY,
+    prefix             text        NOT NULL UNIQUE CHECK (prefix <> ''),
+    key_hash           text        NOT NULL UNIQUE CHECK (key_hash <> ''),
+    service_account_id BIGINT      NOT NULL REFERENCES service_accounts (id) ON DELETE CASCADE,
+    expires_at         timestamptz,
+    revoked            boolean     NOT NULL DEFAULT false,
+    created_at         timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX api_keys_service_account_idx ON api_keys (service_account_id);
+
+CREATE TABLE api_key_permissions
+(
+    id         BIGSERIAL PRIMARY KEY,
+    api_key_id BIGINT NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+    permission text   NOT NULL CHECK (permission <> ''),
+    UNIQUE (api_key_id, permission)
+);
@@ -0,0 +1,49 @@
+CREATE TABLE oauth_clients
+(
+    id          BIGSERIAL PRIMARY KEY,
+    client_id   text        NOT NULL CHECK (client_id <> ''),
+    secret_hash text        NOT NULL CHECK (secret_hash <> ''),
+    tenant      text        NOT NULL DEFAULT 'default' CHECK (tenant <> ''),
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (tenant, client_id)
+);
+
+CREATE TABLE oauth_client_scopes
+(
+    id              BIGSERIAL PRIMARY KEY,
+    oauth_client_id BIGINT NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    scope           text   NOT NULL CHECK (scope <> ''),
+    UNIQUE (oauth_client_id, scope)
+);
+
+CREATE TABLE oauth_client_audiences
+(
+    id              BIGSERIAL PRIMARY KEY,
+    oauth_client_id BIGINT NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    audience        text   NOT NULL CHECK (audience <> ''),
+    UNIQUE (oauth_client_id, audience)
+);
+
+CREATE TABLE oauth_client_redirect_uris
+(
+    id              BIGSERIAL PRIMARY KEY,
+    oauth_client_id BIGINT NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    redirect_uri    text   NOT NULL CHECK (redirect_uri <> ''),
+    UNIQUE (oauth_client_id, redirect_uri)
+);
+
+CREATE TABLE authorization_codes
+(
+    id              BIGSERIAL PRIMARY KEY,
+    code_hash       text        NOT NULL UNIQUE CHECK (code_hash <> ''),
+    oauth_client_id BIGINT      NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    user_id         BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    redirect_uri    text        NOT NULL CHECK (redirect_uri <> ''),
+    scope           text        NOT NULL DEFAULT '',
+    nonce           text        NOT NULL DEFAULT '',
+    code_challenge  text        NOT NULL CHECK (code_challenge <> ''),
+    auth_time       timestamptz NOT NULL,
+    expires_at      timestamptz NOT NULL,
+    used            boolean     NOT NULL DEFAULT false,
+    created_at      timestamptz NOT NULL DEFAULT now()
+);
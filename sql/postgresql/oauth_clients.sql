@@ -0,0 +1,55 @@
+-- name: CreateOAuthClient :exec
+INSERT INTO oauth_clients (tenant, client_id, secret_hash)
+VALUES (sqlc.arg(tenant), sqlc.arg(client_id), sqlc.arg(secret_hash));
+
+-- name: AddOAuthClientScope :exec
+INSERT INTO oauth_client_scopes (oauth_client_id, scope)
+VALUES ((SELECT id FROM oauth_clients WHERE tenant = sqlc.arg(tenant) AND client_id = sqlc.arg(client_id)), sqlc.arg(scope))
+ON CONFLICT (oauth_client_id, scope) DO NOTHING;
+
+-- name: AddOAuthClientAudience :exec
+INSERT INTO oauth_client_audiences (oauth_client_id, audience)
+VALUES ((SELECT id FROM oauth_clients WHERE tenant = sqlc.arg(tenant) AND client_id = sqlc.arg(client_id)), sqlc.arg(audience))
+ON CONFLICT (oauth_client_id, audience) DO NOTHING;
+
+-- name: AddOAuthClientRedirectURI :exec
+INSERT INTO oauth_client_redirect_uris (oauth_client_id, redirect_uri)
+VALUES ((SELECT id FROM oauth_clients WHERE tenant = sqlc.arg(tenant) AND client_id = sqlc.arg(client_id)), sqlc.arg(redirect_uri))
+ON CONFLICT (oauth_client_id, redirect_uri) DO NOTHING;
+
+-- name: GetOAuthClient :one
+SELECT client_id, secret_hash, created_at
+FROM oauth_clients
+WHERE tenant = sqlc.arg(tenant)
+  AND client_id = sqlc.arg(client_id)
+LIMIT 1;
+
+-- name: ListOAuthClientScopes :many
+SELECT s.scope
+FROM oauth_client_scopes s
+         JOIN oauth_clients c ON c.id = s.oauth_client_id
+WHERE c.tenant = sqlc.arg(tenant)
+  AND c.client_id = sqlc.arg(client_id)
+ORDER BY s.scope;
+
+-- name: ListOAuthClientAudiences :many
+SELECT a.audience
+FROM oauth_client_audiences a
+         JOIN oauth_clients c ON c.id = a.oauth_client_id
+WHERE c.tenant = sqlc.arg(tenant)
+  AND c.client_id = sqlc.arg(client_id)
+ORDER BY a.audience;
+
+-- name: ListOAuthClientRedirectURIs :many
+SELECT r.redirect_uri
+FROM oauth_client_redirect_uris r
+         JOIN oauth_clients c ON c.id = r.oauth_client_id
+WHERE c.tenant = sqlc.arg(tenant)
+  AND c.client_id = sqlc.arg(client_id)
+ORDER BY r.redirect_uri;
+
+-- name: DeleteOAuthClient :execrows
+DELETE
+FROM oauth_clients
+WHERE tenant = sqlc.arg(tenant)
+  AND client_id = sqlc.arg(client_id);
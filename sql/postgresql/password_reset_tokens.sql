@@ -0,0 +1,23 @@
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (token_hash, user_id, expires_at)
+VALUES (sqlc.arg(token_hash), (SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username)), sqlc.arg(expires_at));
+
+-- name: GetPasswordResetToken :one
+SELECT prt.token_hash, u.username, prt.expires_at, prt.used
+FROM password_reset_tokens prt
+         JOIN users u ON u.id = prt.user_id
+WHERE prt.token_hash = sqlc.arg(token_hash)
+  AND u.tenant = sqlc.arg(tenant)
+LIMIT 1;
+
+-- name: UsePasswordResetToken :execrows
+UPDATE password_reset_tokens
+SET used = true
+WHERE token_hash = $1
+  AND used = false;
+
+-- name: UseUserPasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used = true
+WHERE user_id = (SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username))
+  AND used = false;
@@ -0,0 +1,33 @@
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, batch_id, code_hash)
+VALUES ((SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username)), sqlc.arg(batch_id), sqlc.arg(code_hash));
+
+-- name: DeleteOtherRecoveryCodes :exec
+DELETE
+FROM recovery_codes
+WHERE user_id = (SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username))
+  AND batch_id <> sqlc.arg(batch_id);
+
+-- name: ListUnusedRecoveryCodes :many
+SELECT rc.code_hash
+FROM recovery_codes rc
+         JOIN users u ON u.id = rc.user_id
+WHERE u.tenant = sqlc.arg(tenant)
+  AND u.username = sqlc.arg(username)
+  AND rc.used = false
+ORDER BY rc.id;
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used = true
+WHERE user_id = (SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username))
+  AND code_hash = sqlc.arg(code_hash)
+  AND used = false;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT count(*)
+FROM recovery_codes rc
+         JOIN users u ON u.id = rc.user_id
+WHERE u.tenant = sqlc.arg(tenant)
+  AND u.username = sqlc.arg(username)
+  AND rc.used = false;
@@ -0,0 +1,14 @@
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (jti, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: CountRevokedTokens :one
+SELECT COUNT(*)
+FROM revoked_tokens
+WHERE jti = $1;
+
+-- name: DeleteExpiredRevokedTokens :execrows
+DELETE
+FROM revoked_tokens
+WHERE expires_at < $1;
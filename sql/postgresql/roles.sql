@@ -0,0 +1,52 @@
+-- name: CreateRole :exec
+INSERT INTO roles (name)
+VALUES (sqlc.arg(name))
+ON CONFLICT (name) DO NOTHING;
+
+-- name: GetRole :one
+SELECT name
+FROM roles
+WHERE name = sqlc.arg(role)
+LIMIT 1;
+
+-- name: ListRoles :many
+SELECT name
+FROM roles
+ORDER BY name;
+
+-- name: DeleteRolePermissions :exec
+DELETE
+FROM role_permissions
+WHERE role_id = (SELECT id FROM roles WHERE name = sqlc.arg(role));
+
+-- name: AddRolePermission :exec
+INSERT INTO role_permissions (role_id, permission)
+VALUES ((SELECT id FROM roles WHERE name = sqlc.arg(role)), sqlc.arg(permission))
+ON CONFLICT (role_id, permission) DO NOTHING;
+
+-- name: ListRolePermissions :many
+SELECT rp.permission
+FROM role_permissions rp
+         JOIN roles r ON r.id = rp.role_id
+WHERE r.name = sqlc.arg(role)
+ORDER BY rp.permission;
+
+-- name: AssignRole :exec
+INSERT INTO user_roles (user_id, role_id)
+VALUES ((SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username)), (SELECT id FROM roles WHERE name = sqlc.arg(role)))
+ON CONFLICT (user_id, role_id) DO NOTHING;
+
+-- name: RevokeRole :execrows
+DELETE
+FROM user_roles
+WHERE user_id = (SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username))
+  AND role_id = (SELECT id FROM roles WHERE name = sqlc.arg(role));
+
+-- name: ListUserRoles :many
+SELECT r.name
+FROM user_roles ur
+         JOIN users u ON u.id = ur.user_id
+         JOIN roles r ON r.id = ur.role_id
+WHERE u.tenant = sqlc.arg(tenant)
+  AND u.username = sqlc.arg(username)
+ORDER BY r.name;
@@ -0,0 +1,8 @@
+package postgresql
+
+import "embed"
+
+// Migrations are the numbered up and down migrations of the PostgreSQL schema.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
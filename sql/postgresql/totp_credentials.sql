@@ -0,0 +1,31 @@
+-- name: EnrollTOTPCredential :execrows
+INSERT INTO totp_credentials (user_id, secret)
+VALUES ((SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username)), sqlc.arg(secret))
+ON CONFLICT (user_id) DO UPDATE
+    SET secret       = excluded.secret,
+        last_counter = 0,
+        created_at   = now()
+WHERE totp_credentials.confirmed = false;
+
+-- name: GetTOTPCredential :one
+SELECT u.username, tc.secret, tc.confirmed, tc.last_counter
+FROM totp_credentials tc
+         JOIN users u ON u.id = tc.user_id
+WHERE u.tenant = sqlc.arg(tenant)
+  AND u.username = sqlc.arg(username)
+LIMIT 1;
+
+-- name: ConfirmTOTPCredential :execrows
+UPDATE totp_credentials
+SET confirmed    = true,
+    last_counter = sqlc.arg(counter)
+WHERE user_id = (SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username))
+  AND confirmed = false
+  AND last_counter < sqlc.arg(counter);
+
+-- name: UseTOTPCounter :execrows
+UPDATE totp_credentials
+SET last_counter = sqlc.arg(counter)
+WHERE user_id = (SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username))
+  AND confirmed = true
+  AND last_counter < sqlc.arg(counter);
@@ -1,9 +1,41 @@
 -- name: GetUser :one
 SELECT *
 FROM users
-WHERE username = $1
+WHERE tenant = sqlc.arg(tenant)
+  AND username = sqlc.arg(username)
 LIMIT 1;
 
 -- name: CreateUser :execresult
-INSERT INTO users (username, password_hash)
-VALUES ($1, $2);
\ No newline at end of file
+INSERT INTO users (tenant, username, password_hash, email)
+VALUES ($1, $2, $3, $4);
+
+-- name: UpdateUser :execrows
+UPDATE users
+SET username         = sqlc.arg(new_username),
+    password_hash    = sqlc.arg(password_hash),
+    email            = sqlc.arg(email),
+    token_generation = sqlc.arg(token_generation)
+WHERE tenant = sqlc.arg(tenant)
+  AND username = sqlc.arg(username);
+
+-- name: UpdatePasswordHash :execrows
+UPDATE users
+SET password_hash = sqlc.arg(new_hash)
+WHERE tenant = sqlc.arg(tenant)
+  AND username = sqlc.arg(username)
+  AND password_hash = sqlc.arg(current_hash);
+
+-- name: DeleteUser :execrows
+DELETE
+FROM users
+WHERE tenant = sqlc.arg(tenant)
+  AND username = sqlc.arg(username);
+
+-- name: ListUsers :many
+SELECT *
+FROM users
+WHERE tenant = sqlc.arg(tenant)
+  AND substr(username, 1, length(sqlc.arg(prefix))) = sqlc.arg(prefix)
+  AND username > sqlc.arg(after)
+ORDER BY username
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,24 @@
+-- name: CreateMFAChallenge :exec
+INSERT INTO mfa_challenges (token_hash, user_id, expires_at)
+VALUES (sqlc.arg(token_hash), (SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username)), sqlc.arg(expires_at));
+
+-- name: GetMFAChallenge :one
+SELECT mc.token_hash, u.username, mc.expires_at, mc.used, mc.attempts
+FROM mfa_challenges mc
+         JOIN users u ON u.id = mc.user_id
+WHERE mc.token_hash = sqlc.arg(token_hash)
+  AND u.tenant = sqlc.arg(tenant)
+LIMIT 1;
+
+-- name: AttemptMFAChallenge :execrows
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE token_hash = sqlc.arg(token_hash)
+  AND used = false
+  AND attempts < sqlc.arg(max_attempts);
+
+-- name: UseMFAChallenge :execrows
+UPDATE mfa_challenges
+SET used = true
+WHERE token_hash = ?
+  AND used = false;
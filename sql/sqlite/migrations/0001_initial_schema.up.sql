@@ -7,11 +7,3 @@ CREATE TABLE users
 );
 
 CREATE INDEX username_idx ON users (username);
-
-CREATE TABLE version
-(
-    version text NOT NULL DEFAULT '0.0.0'
-);
-
-INSERT into version
-VALUES ('0.1');
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE users_baseline
+(
+    id            INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    username      text NOT NULL CHECK(username <> ''),
+    password_hash text NOT NULL CHECK(password_hash <> ''),
+    UNIQUE(username)
+);
+
+INSERT INTO users_baseline (id, username, password_hash)
+SELECT id, username, password_hash
+FROM users;
+
+DROP TABLE users;
+
+ALTER TABLE users_baseline RENAME TO users;
+
+CREATE INDEX username_idx ON users (username);
@@ -0,0 +1,21 @@
+-- SQLite cannot drop the unique constraint of the usernames, the users table is rebuilt.
+CREATE TABLE users_accounts
+(
+    id               INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    username         text    NOT NULL CHECK(username <> ''),
+    password_hash    text    NOT NULL CHECK(password_hash <> ''),
+    email            text    NOT NULL DEFAULT '',
+    token_generation INTEGER NOT NULL DEFAULT 0,
+    tenant           text    NOT NULL DEFAULT 'default' CHECK(tenant <> ''),
+    UNIQUE(tenant, username)
+);
+
+INSERT INTO users_accounts (id, username, password_hash)
+SELECT id, username, password_hash
+FROM users;
+
+DROP TABLE users;
+
+ALTER TABLE users_accounts RENAME TO users;
+
+CREATE INDEX username_idx ON users (username);
@@ -0,0 +1,3 @@
+DROP TABLE password_reset_tokens;
+DROP TABLE revoked_tokens;
+DROP TABLE refresh_tokens;
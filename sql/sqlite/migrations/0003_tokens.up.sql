@@ -0,0 +1,33 @@
+CREATE TABLE refresh_tokens
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    token_hash text     NOT NULL CHECK(token_hash <> ''),
+    family_id  text     NOT NULL CHECK(family_id <> ''),
+    user_id    INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at DATETIME NOT NULL,
+    used       BOOLEAN  NOT NULL DEFAULT false,
+    revoked    BOOLEAN  NOT NULL DEFAULT false,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(token_hash)
+);
+
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family_id);
+
+CREATE TABLE revoked_tokens
+(
+    jti        text PRIMARY KEY NOT NULL CHECK(jti <> ''),
+    expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+
+CREATE TABLE password_reset_tokens
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    token_hash text     NOT NULL CHECK(token_hash <> ''),
+    user_id    INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at DATETIME NOT NULL,
+    used       BOOLEAN  NOT NULL DEFAULT false,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(token_hash)
+);
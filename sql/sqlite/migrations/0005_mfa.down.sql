@@ -0,0 +1,3 @@
+DROP TABLE recovery_codes;
+DROP TABLE mfa_challenges;
+DROP TABLE totp_credentials;
@@ -0,0 +1,34 @@
+CREATE TABLE totp_credentials
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    user_id      INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    secret       text     NOT NULL CHECK(secret <> ''),
+    confirmed    BOOLEAN  NOT NULL DEFAULT false,
+    last_counter INTEGER  NOT NULL DEFAULT 0,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id)
+);
+
+CREATE TABLE mfa_challenges
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    token_hash text     NOT NULL CHECK(token_hash <> ''),
+    user_id    INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at DATETIME NOT NULL,
+    used       BOOLEAN  NOT NULL DEFAULT false,
+    attempts   INTEGER  NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(token_hash)
+);
+
+CREATE TABLE recovery_codes
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    user_id    INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    batch_id   text     NOT NULL CHECK(batch_id <> ''),
+    code_hash  text     NOT NULL CHECK(code_hash <> ''),
+    used       BOOLEAN  NOT NULL DEFAULT false,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX recovery_codes_user_idx ON recovery_codes (user_id);
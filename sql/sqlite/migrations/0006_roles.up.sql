@@ -0,0 +1,23 @@
+CREATE TABLE roles
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name       text     NOT NULL CHECK(name <> ''),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(name)
+);
+
+CREATE TABLE role_permissions
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    role_id    INTEGER NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission text    NOT NULL CHECK(permission <> ''),
+    UNIQUE(role_id, permission)
+);
+
+CREATE TABLE user_roles
+(
+    user_id    INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id    INTEGER  NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role_id)
+);
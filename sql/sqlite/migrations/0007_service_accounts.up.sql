@@ -0,0 +1,31 @@
+CREATE TABLE service_accounts
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name       text     NOT NULL CHECK(name <> ''),
+    tenant     text     NOT NULL DEFAULT 'default' CHECK(tenant <> ''),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tenant, name)
+);
+
+CREATE TABLE api_keys
+(
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    prefix             text     NOT NULL CHECK(prefix <> ''),
+    key_hash           text     NOT NULL CHECK(key_hash <> ''),
+    service_account_id INTEGER  NOT NULL REFERENCES service_accounts (id) ON DELETE CASCADE,
+    expires_at         DATETIME,
+    revoked            BOOLEAN  NOT NULL DEFAULT false,
+    created_at         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(prefix),
+    UNIQUE(key_hash)
+);
+
+CREATE INDEX api_keys_service_account_idx ON api_keys (service_account_id);
+
+CREATE TABLE api_key_permissions
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    api_key_id INTEGER NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+    permission text    NOT NULL CHECK(permission <> ''),
+    UNIQUE(api_key_id, permission)
+);
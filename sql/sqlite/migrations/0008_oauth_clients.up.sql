@@ -0,0 +1,50 @@
+CREATE TABLE oauth_clients
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    client_id   text     NOT NULL CHECK(client_id <> ''),
+    secret_hash text     NOT NULL CHECK(secret_hash <> ''),
+    tenant      text     NOT NULL DEFAULT 'default' CHECK(tenant <> ''),
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tenant, client_id)
+);
+
+CREATE TABLE oauth_client_scopes
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    oauth_client_id INTEGER NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    scope           text    NOT NULL CHECK(scope <> ''),
+    UNIQUE(oauth_client_id, scope)
+);
+
+CREATE TABLE oauth_client_audiences
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    oauth_client_id INTEGER NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    audience        text    NOT NULL CHECK(audience <> ''),
+    UNIQUE(oauth_client_id, audience)
+);
+
+CREATE TABLE oauth_client_redirect_uris
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    oauth_client_id INTEGER NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    redirect_uri    text    NOT NULL CHECK(redirect_uri <> ''),
+    UNIQUE(oauth_client_id, redirect_uri)
+);
+
+CREATE TABLE authorization_codes
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    code_hash       text     NOT NULL CHECK(code_hash <> ''),
+    oauth_client_id INTEGER  NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    user_id         INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    redirect_uri    text     NOT NULL CHECK(redirect_uri <> ''),
+    scope           text     NOT NULL DEFAULT '',
+    nonce           text     NOT NULL DEFAULT '',
+    code_challenge  text     NOT NULL CHECK(code_challenge <> ''),
+    auth_time       DATETIME NOT NULL,
+    expires_at      DATETIME NOT NULL,
+    used            BOOLEAN  NOT NULL DEFAULT false,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(code_hash)
+);
@@ -0,0 +1,29 @@
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (token_hash, family_id, user_id, expires_at)
+VALUES (sqlc.arg(token_hash), sqlc.arg(family_id),
+        (SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username)), sqlc.arg(expires_at));
+
+-- name: GetRefreshToken :one
+SELECT rt.token_hash, rt.family_id, u.username, rt.expires_at, rt.used, rt.revoked
+FROM refresh_tokens rt
+         JOIN users u ON u.id = rt.user_id
+WHERE rt.token_hash = sqlc.arg(token_hash)
+  AND u.tenant = sqlc.arg(tenant)
+LIMIT 1;
+
+-- name: UseRefreshToken :execrows
+UPDATE refresh_tokens
+SET used = true
+WHERE token_hash = ?
+  AND used = false
+  AND revoked = false;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked = true
+WHERE family_id = ?;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked = true
+WHERE user_id = (SELECT id FROM users WHERE tenant = sqlc.arg(tenant) AND username = sqlc.arg(username));
@@ -1,6 +1,8 @@
 package sqlite
 
-import _ "embed"
+import "embed"
 
-//go:embed schema.sql
-var Schema string
+// Migrations are the numbered up and down migrations of the SQLite schema.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS